@@ -3,6 +3,7 @@ package configs
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
@@ -16,6 +17,100 @@ type ProgramConfig struct {
 	DBName        string
 	Secret        string
 	RefreshSecret string
+	TokenIssuer   string
+	TokenAudience string
+	Notifier      string
+	NotifierFile  string
+	// UnverifiedPolicy decides what accounts with an unconfirmed HP may do:
+	// "deny" refuses their logins, "allow" treats them like verified accounts.
+	UnverifiedPolicy string
+
+	PasswordMinLength    int
+	PasswordMinClasses   int
+	PasswordHistory      int
+	BreachedPasswordFile string
+
+	// DeletionGraceDays is how long a deleted account can still be restored
+	// before it is purged, PurgeMode is "anonymize" or "delete".
+	DeletionGraceDays int
+	PurgeMode         string
+
+	// SuspiciousLoginPolicy is "notify" to only alert the user about a login
+	// from a new device or place, or "step_up" to also ask for a code sent to
+	// their HP before the login completes.
+	SuspiciousLoginPolicy string
+	GeoIPDatabase         string
+
+	// OIDCSigningKeyFile is a PEM encoded RSA key the ID tokens are signed
+	// with. Without it a key is generated on every start, and ID tokens issued
+	// before a restart can no longer be verified.
+	OIDCSigningKeyFile string
+	// OAuthAuthorizeURL is the frontend page showing the consent screen, the
+	// discovery document sends relying parties there. TokenIssuer is used as
+	// the OIDC issuer and the base of every other endpoint.
+	OAuthAuthorizeURL string
+
+	// ExternalProvidersFile is a JSON list of the OIDC providers users can
+	// log in with, see helper.ExternalProvider.
+	ExternalProvidersFile string
+
+	// SCIMToken is the bearer token the HR directory provisions accounts
+	// with. The SCIM API refuses every request while it is empty.
+	SCIMToken string
+
+	// LDAPConfigFile is the corporate directory staff log in with, see
+	// helper.LDAPConfig. Logins are checked against the users table first.
+	LDAPConfigFile string
+
+	// WebAuthnRPID is the domain passkeys are registered for, and
+	// WebAuthnOrigins the comma separated frontend origins allowed to use
+	// them. Passkeys cannot be registered or used while either is empty.
+	WebAuthnRPID    string
+	WebAuthnRPName  string
+	WebAuthnOrigins []string
+
+	// SessionCookies lets browser clients ask for their tokens as HttpOnly
+	// cookies instead of in the response body. SessionCookieSameSite is
+	// "strict", "lax" or "none". A frontend on another origin has to be
+	// listed in CORSOrigins to send them.
+	SessionCookies        bool
+	SessionCookieDomain   string
+	SessionCookieSameSite string
+	CORSOrigins           []string
+
+	// TrustedProxies are the comma separated addresses or CIDR ranges of the
+	// reverse proxies in front of the server. X-Forwarded-For is only
+	// believed when it was added by one of them, without any the client
+	// address is the peer of the connection.
+	TrustedProxies []string
+
+	// TenantDomain is the base domain organizations get their subdomain
+	// under, e.g. acme.example.com for "example.com". Without it the
+	// organization can only be named by header or token.
+	TenantDomain string
+
+	// InviteOnly closes POST /users, new accounts can then only be created
+	// by accepting an invitation or through provisioning. Signups through
+	// external providers are closed per provider with DisableSignup.
+	InviteOnly bool
+
+	// AvatarStorage is "local" to keep avatars in AvatarDir, served by this
+	// server under /media, or "s3" to keep them in S3Bucket. AvatarBaseURL is
+	// where local avatars are linked, TokenIssuer + "/media" by default.
+	// Uploads larger than AvatarMaxBytes are refused.
+	AvatarStorage  string
+	AvatarDir      string
+	AvatarBaseURL  string
+	AvatarMaxBytes int
+
+	// S3Endpoint is the base URL of the S3 compatible service, S3PublicURL
+	// where clients can read the bucket if not there, e.g. a CDN.
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PublicURL string
 }
 
 func InitConfig() *ProgramConfig {
@@ -75,6 +170,240 @@ func loadConfig() *ProgramConfig {
 		res.RefreshSecret = val
 	}
 
+	if val, found := os.LookupEnv("ISSUER"); found {
+		res.TokenIssuer = val
+	}
+
+	if val, found := os.LookupEnv("AUDIENCE"); found {
+		res.TokenAudience = val
+	}
+
+	res.Notifier = "log"
+	if val, found := os.LookupEnv("NOTIFIER"); found {
+		res.Notifier = val
+	}
+
+	res.NotifierFile = "notifications.log"
+	if val, found := os.LookupEnv("NOTIFIERFILE"); found {
+		res.NotifierFile = val
+	}
+
+	res.UnverifiedPolicy = "deny"
+	if val, found := os.LookupEnv("UNVERIFIEDPOLICY"); found {
+		if val != "deny" && val != "allow" {
+			logrus.Error("Config : invalid unverified policy value,", val)
+			return nil
+		}
+		res.UnverifiedPolicy = val
+	}
+
+	res.PasswordMinLength = 8
+	if val, found := os.LookupEnv("PASSWORDMINLENGTH"); found {
+		length, err := strconv.Atoi(val)
+		if err != nil {
+			logrus.Error("Config : invalid password min length value,", err.Error())
+			return nil
+		}
+		res.PasswordMinLength = length
+	}
+
+	res.PasswordMinClasses = 3
+	if val, found := os.LookupEnv("PASSWORDMINCLASSES"); found {
+		classes, err := strconv.Atoi(val)
+		if err != nil || classes < 0 || classes > 4 {
+			logrus.Error("Config : invalid password min classes value,", val)
+			return nil
+		}
+		res.PasswordMinClasses = classes
+	}
+
+	res.PasswordHistory = 5
+	if val, found := os.LookupEnv("PASSWORDHISTORY"); found {
+		history, err := strconv.Atoi(val)
+		if err != nil {
+			logrus.Error("Config : invalid password history value,", err.Error())
+			return nil
+		}
+		res.PasswordHistory = history
+	}
+
+	if val, found := os.LookupEnv("BREACHEDPASSWORDFILE"); found {
+		res.BreachedPasswordFile = val
+	}
+
+	res.DeletionGraceDays = 30
+	if val, found := os.LookupEnv("DELETIONGRACEDAYS"); found {
+		days, err := strconv.Atoi(val)
+		if err != nil || days < 0 {
+			logrus.Error("Config : invalid deletion grace days value,", val)
+			return nil
+		}
+		res.DeletionGraceDays = days
+	}
+
+	res.PurgeMode = "anonymize"
+	if val, found := os.LookupEnv("PURGEMODE"); found {
+		if val != "anonymize" && val != "delete" {
+			logrus.Error("Config : invalid purge mode value,", val)
+			return nil
+		}
+		res.PurgeMode = val
+	}
+
+	res.SuspiciousLoginPolicy = "notify"
+	if val, found := os.LookupEnv("SUSPICIOUSLOGINPOLICY"); found {
+		if val != "notify" && val != "step_up" {
+			logrus.Error("Config : invalid suspicious login policy value,", val)
+			return nil
+		}
+		res.SuspiciousLoginPolicy = val
+	}
+
+	if val, found := os.LookupEnv("GEOIPDATABASE"); found {
+		res.GeoIPDatabase = val
+	}
+
+	if val, found := os.LookupEnv("OIDCSIGNINGKEY"); found {
+		res.OIDCSigningKeyFile = val
+	}
+
+	res.OAuthAuthorizeURL = res.TokenIssuer + "/oauth/authorize"
+	if val, found := os.LookupEnv("OAUTHAUTHORIZEURL"); found {
+		res.OAuthAuthorizeURL = val
+	}
+
+	if val, found := os.LookupEnv("EXTERNALPROVIDERS"); found {
+		res.ExternalProvidersFile = val
+	}
+
+	if val, found := os.LookupEnv("SCIMTOKEN"); found {
+		res.SCIMToken = val
+	}
+
+	if val, found := os.LookupEnv("LDAPCONFIG"); found {
+		res.LDAPConfigFile = val
+	}
+
+	if val, found := os.LookupEnv("WEBAUTHNRPID"); found {
+		res.WebAuthnRPID = val
+	}
+
+	if val, found := os.LookupEnv("WEBAUTHNRPNAME"); found {
+		res.WebAuthnRPName = val
+	}
+
+	if val, found := os.LookupEnv("WEBAUTHNORIGINS"); found {
+		for _, origin := range strings.Split(val, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				res.WebAuthnOrigins = append(res.WebAuthnOrigins, origin)
+			}
+		}
+	}
+
+	if val, found := os.LookupEnv("SESSIONCOOKIES"); found {
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			logrus.Error("Config : invalid session cookies value,", err.Error())
+			return nil
+		}
+		res.SessionCookies = enabled
+	}
+
+	if val, found := os.LookupEnv("SESSIONCOOKIEDOMAIN"); found {
+		res.SessionCookieDomain = val
+	}
+
+	res.SessionCookieSameSite = "strict"
+	if val, found := os.LookupEnv("SESSIONCOOKIESAMESITE"); found {
+		if val != "strict" && val != "lax" && val != "none" {
+			logrus.Error("Config : invalid session cookie samesite value,", val)
+			return nil
+		}
+		res.SessionCookieSameSite = val
+	}
+
+	if val, found := os.LookupEnv("CORSORIGINS"); found {
+		for _, origin := range strings.Split(val, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				res.CORSOrigins = append(res.CORSOrigins, origin)
+			}
+		}
+	}
+
+	if val, found := os.LookupEnv("TRUSTEDPROXIES"); found {
+		for _, proxy := range strings.Split(val, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				res.TrustedProxies = append(res.TrustedProxies, proxy)
+			}
+		}
+	}
+
+	if val, found := os.LookupEnv("TENANTDOMAIN"); found {
+		res.TenantDomain = strings.TrimPrefix(strings.TrimSpace(val), ".")
+	}
+
+	if val, found := os.LookupEnv("INVITEONLY"); found {
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			logrus.Error("Config : invalid invite only value,", err.Error())
+			return nil
+		}
+		res.InviteOnly = enabled
+	}
+
+	res.AvatarStorage = "local"
+	if val, found := os.LookupEnv("AVATARSTORAGE"); found {
+		if val != "local" && val != "s3" {
+			logrus.Error("Config : invalid avatar storage value,", val)
+			return nil
+		}
+		res.AvatarStorage = val
+	}
+
+	res.AvatarDir = "media"
+	if val, found := os.LookupEnv("AVATARDIR"); found {
+		res.AvatarDir = val
+	}
+
+	res.AvatarBaseURL = res.TokenIssuer + "/media"
+	if val, found := os.LookupEnv("AVATARBASEURL"); found {
+		res.AvatarBaseURL = val
+	}
+
+	res.AvatarMaxBytes = 5 << 20
+	if val, found := os.LookupEnv("AVATARMAXBYTES"); found {
+		size, err := strconv.Atoi(val)
+		if err != nil || size < 1 {
+			logrus.Error("Config : invalid avatar max bytes value,", val)
+			return nil
+		}
+		res.AvatarMaxBytes = size
+	}
+
+	if val, found := os.LookupEnv("S3ENDPOINT"); found {
+		res.S3Endpoint = val
+	}
+
+	if val, found := os.LookupEnv("S3REGION"); found {
+		res.S3Region = val
+	}
+
+	if val, found := os.LookupEnv("S3BUCKET"); found {
+		res.S3Bucket = val
+	}
+
+	if val, found := os.LookupEnv("S3ACCESSKEY"); found {
+		res.S3AccessKey = val
+	}
+
+	if val, found := os.LookupEnv("S3SECRETKEY"); found {
+		res.S3SecretKey = val
+	}
+
+	if val, found := os.LookupEnv("S3PUBLICURL"); found {
+		res.S3PublicURL = val
+	}
+
 	return res
 
 }
@@ -0,0 +1,16 @@
+package data
+
+import "time"
+
+type APIKey struct {
+	ID         string `gorm:"type:varchar(255);primaryKey"`
+	UserID     string `gorm:"type:varchar(255);index"`
+	Name       string `gorm:"type:varchar(100)"`
+	Prefix     string `gorm:"type:varchar(16);uniqueIndex"`
+	SecretHash string `gorm:"type:varchar(64)"`
+	Scopes     string `gorm:"type:varchar(255)"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
@@ -0,0 +1,111 @@
+package data
+
+import (
+	"errors"
+	"strings"
+	"test/features/apikeys"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type APIKeyData struct {
+	gorm *gorm.DB
+}
+
+func New(g *gorm.DB) apikeys.APIKeyDataInterface {
+	return &APIKeyData{
+		gorm: g,
+	}
+}
+
+func (ad *APIKeyData) Insert(newData apikeys.APIKey) (*apikeys.APIKey, error) {
+	var dbData = toModel(newData)
+
+	if err := ad.gorm.Create(dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return &newData, nil
+}
+
+func (ad *APIKeyData) GetByPrefix(prefix string) (*apikeys.APIKey, error) {
+	var dbData = new(APIKey)
+
+	if err := ad.gorm.Where("prefix = ?", prefix).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toAPIKey(dbData), nil
+}
+
+func (ad *APIKeyData) GetByUser(userID string) ([]apikeys.APIKey, error) {
+	var dbData = []APIKey{}
+
+	if err := ad.gorm.Where("user_id = ?", userID).Order("created_at desc").Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]apikeys.APIKey, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toAPIKey(&dbData[i]))
+	}
+
+	return result, nil
+}
+
+func (ad *APIKeyData) Revoke(userID string, keyID string, revokedAt time.Time) error {
+	var qry = ad.gorm.Model(&APIKey{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).Update("revoked_at", revokedAt)
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (ad *APIKeyData) UpdateLastUsed(keyID string, usedAt time.Time) error {
+	return ad.gorm.Model(&APIKey{}).Where("id = ?", keyID).Update("last_used_at", usedAt).Error
+}
+
+func (ad *APIKeyData) EraseUser(userID string) error {
+	return ad.gorm.Where("user_id = ?", userID).Delete(&APIKey{}).Error
+}
+
+func toModel(newData apikeys.APIKey) *APIKey {
+	var dbData = new(APIKey)
+	dbData.ID = newData.ID
+	dbData.UserID = newData.UserID
+	dbData.Name = newData.Name
+	dbData.Prefix = newData.Prefix
+	dbData.SecretHash = newData.SecretHash
+	dbData.Scopes = strings.Join(newData.Scopes, " ")
+	dbData.ExpiresAt = newData.ExpiresAt
+	dbData.LastUsedAt = newData.LastUsedAt
+	dbData.CreatedAt = newData.CreatedAt
+	dbData.RevokedAt = newData.RevokedAt
+
+	return dbData
+}
+
+func toAPIKey(dbData *APIKey) *apikeys.APIKey {
+	var result = new(apikeys.APIKey)
+	result.ID = dbData.ID
+	result.UserID = dbData.UserID
+	result.Name = dbData.Name
+	result.Prefix = dbData.Prefix
+	result.SecretHash = dbData.SecretHash
+	result.Scopes = strings.Fields(dbData.Scopes)
+	result.ExpiresAt = dbData.ExpiresAt
+	result.LastUsedAt = dbData.LastUsedAt
+	result.CreatedAt = dbData.CreatedAt
+	result.RevokedAt = dbData.RevokedAt
+
+	return result
+}
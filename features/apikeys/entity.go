@@ -0,0 +1,46 @@
+package apikeys
+
+import (
+	"context"
+	"test/helper"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type APIKey struct {
+	ID         string
+	UserID     string
+	Name       string
+	Prefix     string
+	SecretHash string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+	// Key is the full secret, only set on the result of Create.
+	Key string
+}
+
+type APIKeyHandlerInterface interface {
+	Create() echo.HandlerFunc
+	List() echo.HandlerFunc
+	Revoke() echo.HandlerFunc
+}
+type APIKeyServiceInterface interface {
+	Create(ctx context.Context, userID string, name string, scopes []string, expiresAt *time.Time) (*APIKey, error)
+	List(ctx context.Context, userID string) ([]APIKey, error)
+	Revoke(ctx context.Context, userID string, keyID string) error
+	Authenticate(key string) (*helper.Principal, error)
+}
+type APIKeyDataInterface interface {
+	Insert(newData APIKey) (*APIKey, error)
+	GetByPrefix(prefix string) (*APIKey, error)
+	GetByUser(userID string) ([]APIKey, error)
+	Revoke(userID string, keyID string, revokedAt time.Time) error
+	UpdateLastUsed(keyID string, usedAt time.Time) error
+	// EraseUser deletes every key of the user, it is the users feature's
+	// erasure hook.
+	EraseUser(userID string) error
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"test/features/apikeys"
+	"test/helper"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type APIKeyHandler struct {
+	s apikeys.APIKeyServiceInterface
+}
+
+func NewHandler(service apikeys.APIKeyServiceInterface) apikeys.APIKeyHandlerInterface {
+	return &APIKeyHandler{
+		s: service,
+	}
+}
+
+func (ah *APIKeyHandler) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(CreateInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := ah.s.Create(c.Request().Context(), principal.UserID, input.Name, input.Scopes, input.ExpiresAt)
+
+		if err != nil {
+			c.Logger().Error("handler: create api key process error:", err.Error())
+			switch {
+			case strings.Contains(err.Error(), "invalid"):
+				return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+			case strings.Contains(err.Error(), "not allowed"):
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("fail", nil))
+			case strings.Contains(err.Error(), "not found"):
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = apiKeyResponse(*result, time.Now())
+		response.Key = result.Key
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", response))
+	}
+}
+
+func (ah *APIKeyHandler) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := ah.s.List(c.Request().Context(), principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: list api keys process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var now = time.Now()
+		var response = make([]APIKeyResponse, 0, len(result))
+		for _, key := range result {
+			response = append(response, *apiKeyResponse(key, now))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (ah *APIKeyHandler) Revoke() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := ah.s.Revoke(c.Request().Context(), principal.UserID, c.Param("id")); err != nil {
+			c.Logger().Error("handler: revoke api key process error:", err.Error())
+			if strings.Contains(err.Error(), "not found") {
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func apiKeyResponse(key apikeys.APIKey, now time.Time) *APIKeyResponse {
+	var response = new(APIKeyResponse)
+	response.ID = key.ID
+	response.Name = key.Name
+	response.Prefix = key.Prefix
+	response.Scopes = key.Scopes
+	response.ExpiresAt = key.ExpiresAt
+	response.LastUsedAt = key.LastUsedAt
+	response.CreatedAt = key.CreatedAt
+	response.RevokedAt = key.RevokedAt
+	response.Active = key.RevokedAt == nil && (key.ExpiresAt == nil || now.Before(*key.ExpiresAt))
+
+	return response
+}
@@ -0,0 +1,9 @@
+package handler
+
+import "time"
+
+type CreateInput struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
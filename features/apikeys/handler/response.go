@@ -0,0 +1,17 @@
+package handler
+
+import "time"
+
+type APIKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Active     bool       `json:"active"`
+	// Key is only returned once, in the response to the create request.
+	Key string `json:"key,omitempty"`
+}
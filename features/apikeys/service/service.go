@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"test/features/apikeys"
+	"test/features/audit"
+	"test/features/users"
+	"test/helper"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	keyPrefix        = "ak"
+	maxNameLength    = 100
+	lastUsedInterval = time.Minute
+)
+
+type APIKeyService struct {
+	d apikeys.APIKeyDataInterface
+	u users.UserDataInterface
+	a audit.AuditServiceInterface
+	g helper.GeneratorInterface
+	c helper.ClockInterface
+}
+
+func New(data apikeys.APIKeyDataInterface, userData users.UserDataInterface, auditor audit.AuditServiceInterface, generator helper.GeneratorInterface, clock helper.ClockInterface) apikeys.APIKeyServiceInterface {
+	return &APIKeyService{
+		d: data,
+		u: userData,
+		a: auditor,
+		g: generator,
+		c: clock,
+	}
+}
+
+// Create returns the new key with its full secret in Key. Only a hash of the
+// secret is stored, the caller has to hand it over to the user right away.
+func (as *APIKeyService) Create(ctx context.Context, userID string, name string, scopes []string, expiresAt *time.Time) (*apikeys.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLength {
+		return nil, errors.New("invalid name")
+	}
+
+	scopes, err := helper.NormalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := as.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !helper.ScopesAllowed(scopes, user.Role == users.RoleAdmin) {
+		return nil, errors.New("scope not allowed")
+	}
+
+	var now = as.c.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, errors.New("invalid expiry")
+	}
+
+	keyID, err := as.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	prefix, secret, err := generateKey()
+	if err != nil {
+		return nil, errors.New("key process failed")
+	}
+
+	var newData = new(apikeys.APIKey)
+	newData.ID = keyID
+	newData.UserID = user.ID
+	newData.Name = name
+	newData.Prefix = prefix
+	newData.SecretHash = helper.HashToken(secret)
+	newData.Scopes = scopes
+	newData.ExpiresAt = expiresAt
+	newData.CreatedAt = now
+
+	result, err := as.d.Insert(*newData)
+	if err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	as.audit(ctx, audit.Event{ActorID: user.ID, Action: "apikey.created", TargetID: user.ID, After: map[string]any{"key_id": keyID, "name": name, "scopes": scopes}})
+
+	result.Key = keyPrefix + "_" + prefix + "_" + secret
+
+	return result, nil
+}
+
+// List includes revoked and expired keys so the user can still see when a key
+// was last used.
+func (as *APIKeyService) List(ctx context.Context, userID string) ([]apikeys.APIKey, error) {
+	result, err := as.d.GetByUser(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (as *APIKeyService) Revoke(ctx context.Context, userID string, keyID string) error {
+	if err := as.d.Revoke(userID, keyID, as.c.Now()); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("update process failed")
+	}
+
+	as.audit(ctx, audit.Event{ActorID: userID, Action: "apikey.revoked", TargetID: userID, After: map[string]any{"key_id": keyID}})
+
+	return nil
+}
+
+// Authenticate resolves a key from the Authorization header to the principal
+// of its owner, limited to the key's scopes.
+func (as *APIKeyService) Authenticate(key string) (*helper.Principal, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return nil, errors.New("invalid api key")
+	}
+
+	result, err := as.d.GetByPrefix(parts[1])
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("invalid api key")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(helper.HashToken(parts[2])), []byte(result.SecretHash)) != 1 {
+		return nil, errors.New("invalid api key")
+	}
+
+	var now = as.c.Now()
+	if result.RevokedAt != nil || (result.ExpiresAt != nil && now.After(*result.ExpiresAt)) {
+		return nil, errors.New("invalid api key")
+	}
+
+	// The key is checked against the account wherever it lives, like a
+	// session.
+	user, err := as.getUser(context.Background(), result.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Erasure deletes the keys as well, this covers a key read before that.
+	if user.AnonymizedAt != nil {
+		return nil, errors.New("invalid api key")
+	}
+
+	if user.DeactivatedAt != nil || user.DeletedAt != nil {
+		return nil, errors.New("account deactivated")
+	}
+
+	if result.LastUsedAt == nil || now.Sub(*result.LastUsedAt) >= lastUsedInterval {
+		if err := as.d.UpdateLastUsed(result.ID, now); err != nil {
+			logrus.Error("service: update api key last used error:", err.Error())
+		}
+	}
+
+	var role = user.Role
+	if role == "" {
+		role = users.RoleUser
+	}
+
+	var principal = new(helper.Principal)
+	principal.UserID = user.ID
+	principal.Roles = []string{role}
+	principal.Scopes = result.Scopes
+	principal.APIKeyID = result.ID
+
+	return principal, nil
+}
+
+func (as *APIKeyService) getUser(ctx context.Context, userID string) (*users.User, error) {
+	result, err := as.u.WithContext(ctx).GetByID(userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (as *APIKeyService) audit(ctx context.Context, event audit.Event) {
+	if err := as.a.Record(ctx, event); err != nil {
+		logrus.Error("service: audit record error:", err.Error())
+	}
+}
+
+// generateKey returns the public prefix the key is looked up by and the
+// secret only its hash is kept of.
+func generateKey() (string, string, error) {
+	var rawPrefix = make([]byte, 6)
+	if _, err := rand.Read(rawPrefix); err != nil {
+		return "", "", err
+	}
+
+	var rawSecret = make([]byte, 32)
+	if _, err := rand.Read(rawSecret); err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(rawPrefix), base64.RawURLEncoding.EncodeToString(rawSecret), nil
+}
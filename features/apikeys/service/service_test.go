@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"test/features/apikeys"
+	"test/features/apikeys/mocks"
+	"test/features/audit"
+	auditMocks "test/features/audit/mocks"
+	"test/features/users"
+	userMocks "test/features/users/mocks"
+	realHelper "test/helper"
+	helper "test/helper/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreate(t *testing.T) {
+	data := mocks.NewAPIKeyDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, auditor, generator, clock)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Role: users.RoleUser}
+
+	t.Run("Success create", func(t *testing.T) {
+		var inserted apikeys.APIKey
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		clock.On("Now").Return(now).Once()
+		generator.On("GenerateUUID").Return("randomKeyID", nil).Once()
+		data.On("Insert", mock.MatchedBy(func(k apikeys.APIKey) bool {
+			inserted = k
+			return k.ID == "randomKeyID" && k.UserID == user.ID && k.Name == "backup job" && k.Prefix != "" && k.SecretHash != ""
+		})).Return(func(k apikeys.APIKey) *apikeys.APIKey { return &k }, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: user.ID, Action: "apikey.created", TargetID: user.ID, After: map[string]any{"key_id": "randomKeyID", "name": "backup job", "scopes": []string{realHelper.ScopePrivacyExport}}}).Return(nil).Once()
+
+		result, err := service.Create(context.Background(), user.ID, " backup job ", []string{realHelper.ScopePrivacyExport, realHelper.ScopePrivacyExport}, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{realHelper.ScopePrivacyExport}, result.Scopes)
+		assert.True(t, strings.HasPrefix(result.Key, "ak_"+inserted.Prefix+"_"))
+		assert.Equal(t, inserted.SecretHash, realHelper.HashToken(strings.TrimPrefix(result.Key, "ak_"+inserted.Prefix+"_")))
+	})
+
+	t.Run("Admin scope for user", func(t *testing.T) {
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+
+		result, err := service.Create(context.Background(), user.ID, "audit export", []string{realHelper.ScopeAuditRead}, nil)
+		assert.EqualError(t, err, "scope not allowed")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Unknown scope", func(t *testing.T) {
+		result, err := service.Create(context.Background(), user.ID, "backup job", []string{"users:delete"}, nil)
+		assert.EqualError(t, err, "invalid scope")
+		assert.Nil(t, result)
+	})
+
+	t.Run("No scope", func(t *testing.T) {
+		result, err := service.Create(context.Background(), user.ID, "backup job", nil, nil)
+		assert.EqualError(t, err, "invalid scope")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Expiry in the past", func(t *testing.T) {
+		past := now.Add(-time.Hour)
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		result, err := service.Create(context.Background(), user.ID, "backup job", []string{realHelper.ScopePrivacyExport}, &past)
+		assert.EqualError(t, err, "invalid expiry")
+		assert.Nil(t, result)
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	data := mocks.NewAPIKeyDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, auditor, generator, clock)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Success revoke", func(t *testing.T) {
+		clock.On("Now").Return(now).Once()
+		data.On("Revoke", "randomUserID", "randomKeyID", now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "randomUserID", Action: "apikey.revoked", TargetID: "randomUserID", After: map[string]any{"key_id": "randomKeyID"}}).Return(nil).Once()
+
+		err := service.Revoke(context.Background(), "randomUserID", "randomKeyID")
+		assert.Nil(t, err)
+	})
+
+	t.Run("Key of another user", func(t *testing.T) {
+		clock.On("Now").Return(now).Once()
+		data.On("Revoke", "randomUserID", "otherKeyID", now).Return(errors.New("data not found")).Once()
+
+		err := service.Revoke(context.Background(), "randomUserID", "otherKeyID")
+		assert.EqualError(t, err, "data not found")
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	data := mocks.NewAPIKeyDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, auditor, generator, clock)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Role: users.RoleAdmin}
+	key := apikeys.APIKey{
+		ID:         "randomKeyID",
+		UserID:     user.ID,
+		Prefix:     "a1b2c3d4e5f6",
+		SecretHash: realHelper.HashToken("randomSecret"),
+		Scopes:     []string{realHelper.ScopeAuditRead},
+		CreatedAt:  now.Add(-time.Hour),
+	}
+
+	t.Run("Success authenticate", func(t *testing.T) {
+		data.On("GetByPrefix", key.Prefix).Return(&key, nil).Once()
+		clock.On("Now").Return(now).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		data.On("UpdateLastUsed", key.ID, now).Return(nil).Once()
+
+		result, err := service.Authenticate("ak_a1b2c3d4e5f6_randomSecret")
+		assert.Nil(t, err)
+		assert.Equal(t, &realHelper.Principal{UserID: user.ID, Roles: []string{users.RoleAdmin}, Scopes: key.Scopes, APIKeyID: key.ID}, result)
+	})
+
+	t.Run("Recently used", func(t *testing.T) {
+		usedAt := now.Add(-time.Second * 10)
+		recent := key
+		recent.LastUsedAt = &usedAt
+		data.On("GetByPrefix", key.Prefix).Return(&recent, nil).Once()
+		clock.On("Now").Return(now).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+
+		result, err := service.Authenticate("ak_a1b2c3d4e5f6_randomSecret")
+		assert.Nil(t, err)
+		assert.Equal(t, key.ID, result.APIKeyID)
+	})
+
+	t.Run("Wrong secret", func(t *testing.T) {
+		data.On("GetByPrefix", key.Prefix).Return(&key, nil).Once()
+
+		result, err := service.Authenticate("ak_a1b2c3d4e5f6_otherSecret")
+		assert.EqualError(t, err, "invalid api key")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Malformed key", func(t *testing.T) {
+		result, err := service.Authenticate("randomSecret")
+		assert.EqualError(t, err, "invalid api key")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Revoked key", func(t *testing.T) {
+		revokedAt := now.Add(-time.Minute)
+		revoked := key
+		revoked.RevokedAt = &revokedAt
+		data.On("GetByPrefix", key.Prefix).Return(&revoked, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		result, err := service.Authenticate("ak_a1b2c3d4e5f6_randomSecret")
+		assert.EqualError(t, err, "invalid api key")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Expired key", func(t *testing.T) {
+		expiresAt := now.Add(-time.Minute)
+		expired := key
+		expired.ExpiresAt = &expiresAt
+		data.On("GetByPrefix", key.Prefix).Return(&expired, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		result, err := service.Authenticate("ak_a1b2c3d4e5f6_randomSecret")
+		assert.EqualError(t, err, "invalid api key")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Deactivated owner", func(t *testing.T) {
+		deactivated := user
+		deactivated.DeactivatedAt = &now
+		data.On("GetByPrefix", key.Prefix).Return(&key, nil).Once()
+		clock.On("Now").Return(now).Once()
+		userData.On("GetByID", user.ID).Return(&deactivated, nil).Once()
+
+		result, err := service.Authenticate("ak_a1b2c3d4e5f6_randomSecret")
+		assert.EqualError(t, err, "account deactivated")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Erased owner", func(t *testing.T) {
+		anonymized := user
+		anonymized.AnonymizedAt = &now
+		data.On("GetByPrefix", key.Prefix).Return(&key, nil).Once()
+		clock.On("Now").Return(now).Once()
+		userData.On("GetByID", user.ID).Return(&anonymized, nil).Once()
+
+		result, err := service.Authenticate("ak_a1b2c3d4e5f6_randomSecret")
+		assert.EqualError(t, err, "invalid api key")
+		assert.Nil(t, result)
+	})
+}
@@ -0,0 +1,19 @@
+package data
+
+import "time"
+
+type AuditEvent struct {
+	ID        string    `gorm:"varchar(255);primaryKey;"`
+	Seq       uint64    `gorm:"uniqueIndex"`
+	ActorID   string    `gorm:"type:varchar(255);index"`
+	Action    string    `gorm:"type:varchar(64);index"`
+	TargetID  string    `gorm:"type:varchar(255);index"`
+	IP        string    `gorm:"type:varchar(64)"`
+	UserAgent string    `gorm:"type:varchar(512)"`
+	RequestID string    `gorm:"type:varchar(64)"`
+	Before    string    `gorm:"type:text"`
+	After     string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"precision:6;index"`
+	PrevHash  string    `gorm:"type:varchar(64)"`
+	Hash      string    `gorm:"type:varchar(64)"`
+}
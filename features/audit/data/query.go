@@ -0,0 +1,149 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"test/features/audit"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AuditData struct {
+	gorm *gorm.DB
+}
+
+func New(g *gorm.DB) audit.AuditDataInterface {
+	return &AuditData{
+		gorm: g,
+	}
+}
+
+// Insert appends the event to the hash chain. The last row stays locked until
+// the new one is written, and the unique seq refuses a concurrent insert that
+// slipped past the lock on an empty table, so the chain never forks.
+func (ad *AuditData) Insert(newData audit.Event) (*audit.Event, error) {
+	err := ad.gorm.Transaction(func(tx *gorm.DB) error {
+		var last = new(AuditEvent)
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("seq DESC").First(last).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		newData.Seq = last.Seq + 1
+		newData.PrevHash = last.Hash
+		newData.Hash = audit.ComputeHash(newData.PrevHash, newData)
+
+		return tx.Create(toModel(newData)).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &newData, nil
+}
+
+func (ad *AuditData) Find(filter audit.Filter) ([]audit.Event, error) {
+	var qry = ad.gorm.Model(&AuditEvent{})
+
+	if filter.ActorID != "" {
+		qry = qry.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetID != "" {
+		qry = qry.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.Action != "" {
+		qry = qry.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		qry = qry.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		qry = qry.Where("created_at < ?", *filter.To)
+	}
+
+	var dbData = []AuditEvent{}
+	if err := qry.Order("seq DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return toEvents(dbData), nil
+}
+
+func (ad *AuditData) GetByUser(userID string) ([]audit.Event, error) {
+	var dbData = []AuditEvent{}
+
+	if err := ad.gorm.Where("actor_id = ? OR target_id = ?", userID, userID).Order("seq").Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return toEvents(dbData), nil
+}
+
+func (ad *AuditData) GetAfter(seq uint64, limit int) ([]audit.Event, error) {
+	var dbData = []AuditEvent{}
+
+	if err := ad.gorm.Where("seq > ?", seq).Order("seq").Limit(limit).Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return toEvents(dbData), nil
+}
+
+func toModel(newData audit.Event) *AuditEvent {
+	var dbData = new(AuditEvent)
+	dbData.ID = newData.ID
+	dbData.Seq = newData.Seq
+	dbData.ActorID = newData.ActorID
+	dbData.Action = newData.Action
+	dbData.TargetID = newData.TargetID
+	dbData.IP = newData.IP
+	dbData.UserAgent = newData.UserAgent
+	dbData.RequestID = newData.RequestID
+	dbData.Before = marshalChanges(newData.Before)
+	dbData.After = marshalChanges(newData.After)
+	dbData.CreatedAt = newData.CreatedAt
+	dbData.PrevHash = newData.PrevHash
+	dbData.Hash = newData.Hash
+
+	return dbData
+}
+
+func toEvent(dbData AuditEvent) audit.Event {
+	var result = audit.Event{
+		ID:        dbData.ID,
+		Seq:       dbData.Seq,
+		ActorID:   dbData.ActorID,
+		Action:    dbData.Action,
+		TargetID:  dbData.TargetID,
+		IP:        dbData.IP,
+		UserAgent: dbData.UserAgent,
+		RequestID: dbData.RequestID,
+		CreatedAt: dbData.CreatedAt,
+		PrevHash:  dbData.PrevHash,
+		Hash:      dbData.Hash,
+	}
+	json.Unmarshal([]byte(dbData.Before), &result.Before)
+	json.Unmarshal([]byte(dbData.After), &result.After)
+
+	return result
+}
+
+func toEvents(dbData []AuditEvent) []audit.Event {
+	var result = make([]audit.Event, 0, len(dbData))
+	for _, event := range dbData {
+		result = append(result, toEvent(event))
+	}
+
+	return result
+}
+
+func marshalChanges(changes map[string]any) string {
+	if changes == nil {
+		return ""
+	}
+
+	raw, _ := json.Marshal(changes)
+	return string(raw)
+}
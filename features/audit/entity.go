@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Event struct {
+	ID        string
+	Seq       uint64
+	ActorID   string
+	Action    string
+	TargetID  string
+	IP        string
+	UserAgent string
+	RequestID string
+	Before    map[string]any
+	After     map[string]any
+	CreatedAt time.Time
+	PrevHash  string
+	Hash      string
+}
+
+type Filter struct {
+	ActorID  string
+	TargetID string
+	Action   string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+type ChainStatus struct {
+	Checked  int
+	Valid    bool
+	BrokenAt uint64
+}
+
+type AuditHandlerInterface interface {
+	List() echo.HandlerFunc
+	Verify() echo.HandlerFunc
+}
+type AuditServiceInterface interface {
+	Record(ctx context.Context, newData Event) error
+	List(filter Filter) ([]Event, error)
+	ListByUser(userID string) ([]Event, error)
+	VerifyChain() (*ChainStatus, error)
+}
+type AuditDataInterface interface {
+	Insert(newData Event) (*Event, error)
+	Find(filter Filter) ([]Event, error)
+	GetByUser(userID string) ([]Event, error)
+	GetAfter(seq uint64, limit int) ([]Event, error)
+}
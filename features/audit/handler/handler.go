@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"test/features/audit"
+	"test/helper"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AuditHandler struct {
+	s audit.AuditServiceInterface
+}
+
+func NewHandler(service audit.AuditServiceInterface) audit.AuditHandlerInterface {
+	return &AuditHandler{
+		s: service,
+	}
+}
+
+func (ah *AuditHandler) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(ListInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		var filter = new(audit.Filter)
+		filter.ActorID = input.ActorID
+		filter.TargetID = input.TargetID
+		filter.Action = input.Action
+		filter.From = input.From
+		filter.To = input.To
+		filter.Limit = input.Limit
+		filter.Offset = input.Offset
+
+		result, err := ah.s.List(*filter)
+
+		if err != nil {
+			c.Logger().Error("handler: list audit events process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = make([]EventResponse, 0, len(result))
+		for _, event := range result {
+			response = append(response, EventResponse{
+				ID:        event.ID,
+				Seq:       event.Seq,
+				ActorID:   event.ActorID,
+				Action:    event.Action,
+				TargetID:  event.TargetID,
+				IP:        event.IP,
+				UserAgent: event.UserAgent,
+				RequestID: event.RequestID,
+				Before:    event.Before,
+				After:     event.After,
+				CreatedAt: event.CreatedAt,
+				Hash:      event.Hash,
+			})
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (ah *AuditHandler) Verify() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := ah.s.VerifyChain()
+
+		if err != nil {
+			c.Logger().Error("handler: verify audit chain process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = new(ChainStatusResponse)
+		response.Checked = result.Checked
+		response.Valid = result.Valid
+		response.BrokenAt = result.BrokenAt
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
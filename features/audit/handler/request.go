@@ -0,0 +1,13 @@
+package handler
+
+import "time"
+
+type ListInput struct {
+	ActorID  string     `query:"actor_id"`
+	TargetID string     `query:"target_id"`
+	Action   string     `query:"action"`
+	From     *time.Time `query:"from"`
+	To       *time.Time `query:"to"`
+	Limit    int        `query:"limit"`
+	Offset   int        `query:"offset"`
+}
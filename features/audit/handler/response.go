@@ -0,0 +1,24 @@
+package handler
+
+import "time"
+
+type EventResponse struct {
+	ID        string         `json:"id"`
+	Seq       uint64         `json:"seq"`
+	ActorID   string         `json:"actor_id,omitempty"`
+	Action    string         `json:"action"`
+	TargetID  string         `json:"target_id,omitempty"`
+	IP        string         `json:"ip,omitempty"`
+	UserAgent string         `json:"user_agent,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+	Before    map[string]any `json:"before,omitempty"`
+	After     map[string]any `json:"after,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	Hash      string         `json:"hash"`
+}
+
+type ChainStatusResponse struct {
+	Checked  int    `json:"checked"`
+	Valid    bool   `json:"valid"`
+	BrokenAt uint64 `json:"broken_at,omitempty"`
+}
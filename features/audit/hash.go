@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// ComputeHash chains an event to the one recorded before it. Every field that
+// describes the event is covered, so editing or deleting a row breaks the hash
+// of every row after it.
+func ComputeHash(prevHash string, event Event) string {
+	before, _ := json.Marshal(event.Before)
+	after, _ := json.Marshal(event.After)
+
+	var h = sha256.New()
+	for _, field := range []string{
+		prevHash,
+		strconv.FormatUint(event.Seq, 10),
+		event.ID,
+		event.ActorID,
+		event.Action,
+		event.TargetID,
+		event.IP,
+		event.UserAgent,
+		event.RequestID,
+		string(before),
+		string(after),
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	} {
+		h.Write([]byte(strconv.Itoa(len(field))))
+		h.Write([]byte{':'})
+		h.Write([]byte(field))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"test/features/audit"
+	"test/helper"
+	"time"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+	verifyBatchSize  = 500
+)
+
+type AuditService struct {
+	d audit.AuditDataInterface
+	g helper.GeneratorInterface
+	c helper.ClockInterface
+}
+
+func New(data audit.AuditDataInterface, generator helper.GeneratorInterface, clock helper.ClockInterface) audit.AuditServiceInterface {
+	return &AuditService{
+		d: data,
+		g: generator,
+		c: clock,
+	}
+}
+
+// Record fills in where the request came from when the caller did not, so
+// services only have to describe what happened.
+func (as *AuditService) Record(ctx context.Context, newData audit.Event) error {
+	if newData.Action == "" {
+		return errors.New("action is required")
+	}
+
+	newID, err := as.g.GenerateUUID()
+	if err != nil {
+		return errors.New("id generator failed")
+	}
+
+	var meta = helper.RequestMetaFromContext(ctx)
+	if newData.IP == "" {
+		newData.IP = meta.IP
+	}
+	if newData.UserAgent == "" {
+		newData.UserAgent = meta.UserAgent
+	}
+	if newData.RequestID == "" {
+		newData.RequestID = meta.RequestID
+	}
+
+	// Whatever an admin does while impersonating is theirs, the user it was
+	// done as is kept next to it.
+	if principal, ok := helper.PrincipalFromContext(ctx); ok && principal.Impersonated() && newData.ActorID == principal.UserID {
+		var after = make(map[string]any, len(newData.After)+1)
+		for key, value := range newData.After {
+			after[key] = value
+		}
+		after["impersonated_user_id"] = principal.UserID
+		newData.ActorID = principal.ActorID
+		newData.After = after
+	}
+
+	newData.ID = newID
+	// Stored with microsecond precision, the hash has to match what is read back.
+	newData.CreatedAt = as.c.Now().Truncate(time.Microsecond)
+
+	if _, err := as.d.Insert(newData); err != nil {
+		return errors.New("insert process failed")
+	}
+
+	return nil
+}
+
+func (as *AuditService) List(filter audit.Filter) ([]audit.Event, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	result, err := as.d.Find(filter)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (as *AuditService) ListByUser(userID string) ([]audit.Event, error) {
+	result, err := as.d.GetByUser(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+// VerifyChain walks the whole log in order and recomputes every hash. The
+// first event that does not match, or whose seq leaves a gap, is reported.
+func (as *AuditService) VerifyChain() (*audit.ChainStatus, error) {
+	var status = &audit.ChainStatus{Valid: true}
+	var lastSeq uint64
+	var lastHash = ""
+
+	for {
+		events, err := as.d.GetAfter(lastSeq, verifyBatchSize)
+		if err != nil {
+			return nil, errors.New("process failed")
+		}
+
+		for _, event := range events {
+			if event.Seq != lastSeq+1 || event.PrevHash != lastHash || audit.ComputeHash(lastHash, event) != event.Hash {
+				status.Valid = false
+				status.BrokenAt = lastSeq + 1
+				return status, nil
+			}
+
+			status.Checked++
+			lastSeq = event.Seq
+			lastHash = event.Hash
+		}
+
+		if len(events) < verifyBatchSize {
+			return status, nil
+		}
+	}
+}
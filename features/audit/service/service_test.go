@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"test/features/audit"
+	"test/features/audit/mocks"
+	realHelper "test/helper"
+	helper "test/helper/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	data := mocks.NewAuditDataInterface(t)
+	service := New(data, generator, clock)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 123456789, time.UTC)
+	newEvent := audit.Event{
+		ActorID:  "randomUserID",
+		Action:   "user.password_changed",
+		TargetID: "randomUserID",
+	}
+
+	t.Run("Success record", func(t *testing.T) {
+		ctx := realHelper.WithRequestMeta(context.Background(), realHelper.RequestMeta{
+			IP:        "10.0.0.1",
+			UserAgent: "curl/8.0",
+			RequestID: "randomRequestID",
+		})
+		generator.On("GenerateUUID").Return("randomUUID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		expected := newEvent
+		expected.ID = "randomUUID"
+		expected.IP = "10.0.0.1"
+		expected.UserAgent = "curl/8.0"
+		expected.RequestID = "randomRequestID"
+		expected.CreatedAt = now.Truncate(time.Microsecond)
+		data.On("Insert", expected).Return(&expected, nil).Once()
+
+		err := service.Record(ctx, newEvent)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Recorded as the impersonating admin", func(t *testing.T) {
+		ctx := realHelper.WithPrincipal(context.Background(), &realHelper.Principal{UserID: "randomUserID", ActorID: "randomAdminID"})
+		generator.On("GenerateUUID").Return("randomUUID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		expected := newEvent
+		expected.ID = "randomUUID"
+		expected.ActorID = "randomAdminID"
+		expected.After = map[string]any{"impersonated_user_id": "randomUserID"}
+		expected.CreatedAt = now.Truncate(time.Microsecond)
+		data.On("Insert", expected).Return(&expected, nil).Once()
+
+		err := service.Record(ctx, newEvent)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Missing action", func(t *testing.T) {
+		err := service.Record(context.Background(), audit.Event{ActorID: "randomUserID"})
+		assert.EqualError(t, err, "action is required")
+	})
+
+	t.Run("Insert failed", func(t *testing.T) {
+		generator.On("GenerateUUID").Return("randomUUID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("Insert", audit.Event{
+			ID:        "randomUUID",
+			ActorID:   newEvent.ActorID,
+			Action:    newEvent.Action,
+			TargetID:  newEvent.TargetID,
+			CreatedAt: now.Truncate(time.Microsecond),
+		}).Return(nil, errors.New("some db error")).Once()
+
+		err := service.Record(context.Background(), newEvent)
+		assert.EqualError(t, err, "insert process failed")
+	})
+}
+
+func TestList(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	data := mocks.NewAuditDataInterface(t)
+	service := New(data, generator, clock)
+	events := []audit.Event{{ID: "randomUUID", ActorID: "randomUserID", Action: "user.login", TargetID: "randomUserID"}}
+
+	t.Run("Default limit", func(t *testing.T) {
+		data.On("Find", audit.Filter{Action: "user.login", Limit: 50}).Return(events, nil).Once()
+
+		result, err := service.List(audit.Filter{Action: "user.login"})
+		assert.Nil(t, err)
+		assert.Equal(t, events, result)
+	})
+
+	t.Run("Limit is capped", func(t *testing.T) {
+		data.On("Find", audit.Filter{Limit: 500}).Return(events, nil).Once()
+
+		result, err := service.List(audit.Filter{Limit: 10000, Offset: -1})
+		assert.Nil(t, err)
+		assert.Equal(t, events, result)
+	})
+
+	t.Run("Query failed", func(t *testing.T) {
+		data.On("Find", audit.Filter{Limit: 50}).Return(nil, errors.New("some db error")).Once()
+
+		result, err := service.List(audit.Filter{})
+		assert.EqualError(t, err, "process failed")
+		assert.Nil(t, result)
+	})
+}
+
+func TestListByUser(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	data := mocks.NewAuditDataInterface(t)
+	service := New(data, generator, clock)
+	events := []audit.Event{{ID: "randomUUID", ActorID: "randomUserID", Action: "user.password_changed", TargetID: "randomUserID"}}
+
+	t.Run("Success list", func(t *testing.T) {
+		data.On("GetByUser", "randomUserID").Return(events, nil).Once()
+
+		result, err := service.ListByUser("randomUserID")
+		assert.Nil(t, err)
+		assert.Equal(t, events, result)
+	})
+
+	t.Run("Query failed", func(t *testing.T) {
+		data.On("GetByUser", "randomUserID").Return(nil, errors.New("some db error")).Once()
+
+		result, err := service.ListByUser("randomUserID")
+		assert.EqualError(t, err, "process failed")
+		assert.Nil(t, result)
+	})
+}
+
+func TestVerifyChain(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	data := mocks.NewAuditDataInterface(t)
+	service := New(data, generator, clock)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	var chain []audit.Event
+	var prevHash = ""
+	for i, action := range []string{"user.registered", "user.login", "user.password_changed"} {
+		event := audit.Event{
+			ID:        "randomUUID" + action,
+			Seq:       uint64(i + 1),
+			ActorID:   "randomUserID",
+			Action:    action,
+			TargetID:  "randomUserID",
+			After:     map[string]any{"step": i},
+			CreatedAt: now.Add(time.Minute * time.Duration(i)),
+			PrevHash:  prevHash,
+		}
+		event.Hash = audit.ComputeHash(prevHash, event)
+		prevHash = event.Hash
+		chain = append(chain, event)
+	}
+
+	t.Run("Intact chain", func(t *testing.T) {
+		data.On("GetAfter", uint64(0), 500).Return(chain, nil).Once()
+
+		result, err := service.VerifyChain()
+		assert.Nil(t, err)
+		assert.Equal(t, &audit.ChainStatus{Checked: 3, Valid: true}, result)
+	})
+
+	t.Run("Edited event", func(t *testing.T) {
+		tampered := append([]audit.Event{}, chain...)
+		tampered[1].ActorID = "otherUserID"
+		data.On("GetAfter", uint64(0), 500).Return(tampered, nil).Once()
+
+		result, err := service.VerifyChain()
+		assert.Nil(t, err)
+		assert.Equal(t, &audit.ChainStatus{Checked: 1, Valid: false, BrokenAt: 2}, result)
+	})
+
+	t.Run("Deleted event", func(t *testing.T) {
+		data.On("GetAfter", uint64(0), 500).Return([]audit.Event{chain[0], chain[2]}, nil).Once()
+
+		result, err := service.VerifyChain()
+		assert.Nil(t, err)
+		assert.Equal(t, &audit.ChainStatus{Checked: 1, Valid: false, BrokenAt: 2}, result)
+	})
+
+	t.Run("Query failed", func(t *testing.T) {
+		data.On("GetAfter", uint64(0), 500).Return(nil, errors.New("some db error")).Once()
+
+		result, err := service.VerifyChain()
+		assert.EqualError(t, err, "process failed")
+		assert.Nil(t, result)
+	})
+}
@@ -0,0 +1,23 @@
+package avatars
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Avatar is the profile picture of a user. Key is where its sizes are stored,
+// see helper.AvatarKeys, URLs links them by size name.
+type Avatar struct {
+	Key  string
+	URLs map[string]string
+}
+
+type AvatarHandlerInterface interface {
+	Upload() echo.HandlerFunc
+	Delete() echo.HandlerFunc
+}
+type AvatarServiceInterface interface {
+	Upload(ctx context.Context, userID string, body []byte) (*Avatar, error)
+	Delete(ctx context.Context, userID string) error
+}
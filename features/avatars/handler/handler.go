@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"test/features/avatars"
+	"test/helper"
+
+	"github.com/labstack/echo/v4"
+)
+
+const formField = "avatar"
+
+type AvatarHandler struct {
+	s avatars.AvatarServiceInterface
+}
+
+func NewHandler(service avatars.AvatarServiceInterface) avatars.AvatarHandlerInterface {
+	return &AvatarHandler{
+		s: service,
+	}
+}
+
+// Upload takes the image as the avatar field of a multipart/form-data body.
+// The Content-Type of the part is ignored, the service sniffs the type.
+func (ah *AvatarHandler) Upload() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		file, err := c.FormFile(formField)
+		if err != nil {
+			c.Logger().Error("handler: read avatar error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			c.Logger().Error("handler: open avatar error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+		defer src.Close()
+
+		body, err := io.ReadAll(src)
+		if err != nil {
+			c.Logger().Error("handler: read avatar error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := ah.s.Upload(c.Request().Context(), principal.UserID, body)
+
+		if err != nil {
+			c.Logger().Error("handler: upload avatar process error:", err.Error())
+			switch {
+			case strings.Contains(err.Error(), "too large"):
+				return c.JSON(http.StatusRequestEntityTooLarge, helper.FormatResponse(err.Error(), nil))
+			case strings.Contains(err.Error(), "unsupported"):
+				return c.JSON(http.StatusUnsupportedMediaType, helper.FormatResponse(err.Error(), nil))
+			case strings.Contains(err.Error(), "invalid"):
+				return c.JSON(http.StatusBadRequest, helper.FormatResponse(err.Error(), nil))
+			case strings.Contains(err.Error(), "not found"):
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = new(AvatarResponse)
+		response.Avatar = result.URLs
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (ah *AvatarHandler) Delete() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := ah.s.Delete(c.Request().Context(), principal.UserID); err != nil {
+			c.Logger().Error("handler: delete avatar process error:", err.Error())
+			if strings.Contains(err.Error(), "not found") {
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
@@ -0,0 +1,6 @@
+package handler
+
+type AvatarResponse struct {
+	// Avatar links the sizes of the avatar by name, see helper.AvatarSizes.
+	Avatar map[string]string `json:"avatar"`
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"test/configs"
+	"test/features/audit"
+	"test/features/avatars"
+	"test/features/users"
+	"test/helper"
+
+	"github.com/sirupsen/logrus"
+)
+
+type AvatarService struct {
+	u        users.UserDataInterface
+	s        helper.StorageInterface
+	a        audit.AuditServiceInterface
+	g        helper.GeneratorInterface
+	maxBytes int
+}
+
+func New(userData users.UserDataInterface, storage helper.StorageInterface, auditor audit.AuditServiceInterface, generator helper.GeneratorInterface, cfg configs.ProgramConfig) avatars.AvatarServiceInterface {
+	return &AvatarService{
+		u:        userData,
+		s:        storage,
+		a:        auditor,
+		g:        generator,
+		maxBytes: cfg.AvatarMaxBytes,
+	}
+}
+
+// Upload replaces the avatar of the user. Every upload is stored under a new
+// key, so clients and caches never get an old image from a URL, the previous
+// avatar is removed once the account links the new one.
+func (as *AvatarService) Upload(ctx context.Context, userID string, body []byte) (*avatars.Avatar, error) {
+	if len(body) == 0 {
+		return nil, errors.New("invalid image")
+	}
+	if len(body) > as.maxBytes {
+		return nil, errors.New("file too large")
+	}
+
+	images, err := helper.ProcessAvatar(body)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := as.u.WithContext(ctx).GetByID(userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	newID, err := as.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	var key = "avatars/" + userID + "/" + newID
+	var keys = helper.AvatarKeys(key)
+	for i, size := range helper.AvatarSizes {
+		if err := as.s.Put(ctx, keys[size.Name], helper.AvatarContentType, images[size.Name]); err != nil {
+			logrus.Error("service: store avatar error:", err.Error())
+			as.remove(ctx, key, helper.AvatarSizes[:i])
+			return nil, errors.New("upload process failed")
+		}
+	}
+
+	if err := as.u.WithContext(ctx).UpdateAvatar(userID, key); err != nil {
+		as.remove(ctx, key, helper.AvatarSizes)
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("update process failed")
+	}
+
+	if user.AvatarKey != "" {
+		as.remove(ctx, user.AvatarKey, helper.AvatarSizes)
+	}
+
+	as.audit(ctx, audit.Event{ActorID: userID, Action: "user.avatar_updated", TargetID: userID, Before: avatarState(user.AvatarKey), After: avatarState(key)})
+
+	return &avatars.Avatar{Key: key, URLs: helper.AvatarURLs(as.s, key)}, nil
+}
+
+func (as *AvatarService) Delete(ctx context.Context, userID string) error {
+	user, err := as.u.WithContext(ctx).GetByID(userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("process failed")
+	}
+
+	if user.AvatarKey == "" {
+		return errors.New("data not found")
+	}
+
+	if err := as.u.WithContext(ctx).UpdateAvatar(userID, ""); err != nil {
+		return errors.New("update process failed")
+	}
+
+	as.remove(ctx, user.AvatarKey, helper.AvatarSizes)
+
+	as.audit(ctx, audit.Event{ActorID: userID, Action: "user.avatar_removed", TargetID: userID, Before: avatarState(user.AvatarKey)})
+
+	return nil
+}
+
+// remove deletes the stored sizes of an avatar. The account no longer links
+// them at this point, failures only leave unreachable files behind and are
+// logged.
+func (as *AvatarService) remove(ctx context.Context, key string, sizes []helper.AvatarSize) {
+	var keys = helper.AvatarKeys(key)
+	for _, size := range sizes {
+		if err := as.s.Delete(ctx, keys[size.Name]); err != nil {
+			logrus.Error("service: remove avatar error:", err.Error())
+		}
+	}
+}
+
+func (as *AvatarService) audit(ctx context.Context, event audit.Event) {
+	if err := as.a.Record(ctx, event); err != nil {
+		logrus.Error("service: audit record error:", err.Error())
+	}
+}
+
+func avatarState(key string) map[string]any {
+	if key == "" {
+		return nil
+	}
+
+	return map[string]any{"avatar_key": key}
+}
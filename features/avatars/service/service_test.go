@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"test/configs"
+	"test/features/audit"
+	auditMocks "test/features/audit/mocks"
+	"test/features/users"
+	userMocks "test/features/users/mocks"
+	realHelper "test/helper"
+	helper "test/helper/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func pngImage(t *testing.T, width int, height int) []byte {
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestUpload(t *testing.T) {
+	userData := userMocks.NewUserDataInterface(t)
+	storage := helper.NewStorageInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	service := New(userData, storage, auditor, generator, configs.ProgramConfig{AvatarMaxBytes: 1 << 20})
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	var body = pngImage(t, 300, 200)
+
+	t.Run("Success upload", func(t *testing.T) {
+		userData.On("GetByID", "userID").Return(&users.User{ID: "userID", AvatarKey: "avatars/userID/oldID"}, nil).Once()
+		generator.On("GenerateUUID").Return("newID", nil).Once()
+		for _, size := range realHelper.AvatarSizes {
+			storage.On("Put", mock.Anything, "avatars/userID/newID-"+size.Name+".jpg", "image/jpeg", mock.Anything).Return(nil).Once()
+			storage.On("URL", "avatars/userID/newID-"+size.Name+".jpg").Return("https://cdn.example.com/avatars/userID/newID-" + size.Name + ".jpg").Once()
+			storage.On("Delete", mock.Anything, "avatars/userID/oldID-"+size.Name+".jpg").Return(nil).Once()
+		}
+		userData.On("UpdateAvatar", "userID", "avatars/userID/newID").Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "userID", Action: "user.avatar_updated", TargetID: "userID", Before: map[string]any{"avatar_key": "avatars/userID/oldID"}, After: map[string]any{"avatar_key": "avatars/userID/newID"}}).Return(nil).Once()
+
+		result, err := service.Upload(context.Background(), "userID", body)
+		assert.Nil(t, err)
+		assert.Equal(t, "avatars/userID/newID", result.Key)
+		assert.Equal(t, "https://cdn.example.com/avatars/userID/newID-small.jpg", result.URLs["small"])
+	})
+
+	t.Run("File too large", func(t *testing.T) {
+		result, err := service.Upload(context.Background(), "userID", make([]byte, 1<<20+1))
+		assert.EqualError(t, err, "file too large")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		result, err := service.Upload(context.Background(), "userID", []byte("%PDF-1.4 not an image"))
+		assert.EqualError(t, err, "unsupported image type")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Storage failed", func(t *testing.T) {
+		userData.On("GetByID", "userID").Return(&users.User{ID: "userID"}, nil).Once()
+		generator.On("GenerateUUID").Return("newID", nil).Once()
+		storage.On("Put", mock.Anything, "avatars/userID/newID-large.jpg", "image/jpeg", mock.Anything).Return(nil).Once()
+		storage.On("Put", mock.Anything, "avatars/userID/newID-medium.jpg", "image/jpeg", mock.Anything).Return(errors.New("storage: PUT answered 503")).Once()
+		storage.On("Delete", mock.Anything, "avatars/userID/newID-large.jpg").Return(nil).Once()
+
+		result, err := service.Upload(context.Background(), "userID", body)
+		assert.EqualError(t, err, "upload process failed")
+		assert.Nil(t, result)
+	})
+
+	t.Run("User outside the organization", func(t *testing.T) {
+		ctx := realHelper.WithTenant(context.Background(), "acmeID")
+		allData := userMocks.NewUserDataInterface(t)
+		tenantData := userMocks.NewUserDataInterface(t)
+		tenantService := New(allData, storage, auditor, generator, configs.ProgramConfig{AvatarMaxBytes: 1 << 20})
+		allData.On("WithContext", ctx).Return(tenantData).Once()
+		tenantData.On("GetByID", "userID").Return(nil, errors.New("record not found")).Once()
+
+		result, err := tenantService.Upload(ctx, "userID", body)
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	userData := userMocks.NewUserDataInterface(t)
+	storage := helper.NewStorageInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	service := New(userData, storage, auditor, helper.NewGeneratorInterface(t), configs.ProgramConfig{AvatarMaxBytes: 1 << 20})
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+
+	t.Run("Success delete", func(t *testing.T) {
+		userData.On("GetByID", "userID").Return(&users.User{ID: "userID", AvatarKey: "avatars/userID/oldID"}, nil).Once()
+		userData.On("UpdateAvatar", "userID", "").Return(nil).Once()
+		for _, size := range realHelper.AvatarSizes {
+			storage.On("Delete", mock.Anything, "avatars/userID/oldID-"+size.Name+".jpg").Return(nil).Once()
+		}
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "userID", Action: "user.avatar_removed", TargetID: "userID", Before: map[string]any{"avatar_key": "avatars/userID/oldID"}}).Return(nil).Once()
+
+		assert.Nil(t, service.Delete(context.Background(), "userID"))
+	})
+
+	t.Run("No avatar", func(t *testing.T) {
+		userData.On("GetByID", "userID").Return(&users.User{ID: "userID"}, nil).Once()
+
+		assert.EqualError(t, service.Delete(context.Background(), "userID"), "data not found")
+	})
+}
@@ -0,0 +1,33 @@
+package data
+
+import "time"
+
+type Group struct {
+	ID          string `gorm:"type:varchar(255);primaryKey"`
+	Name        string `gorm:"type:varchar(100);uniqueIndex"`
+	Description string `gorm:"type:varchar(255)"`
+	Permissions string `gorm:"type:varchar(255)"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type GroupMember struct {
+	GroupID   string `gorm:"type:varchar(255);primaryKey"`
+	UserID    string `gorm:"type:varchar(255);primaryKey;index"`
+	CreatedAt time.Time
+}
+
+// GroupNesting makes the members of the child group members of the parent
+// group as well.
+type GroupNesting struct {
+	ParentID  string `gorm:"type:varchar(255);primaryKey"`
+	ChildID   string `gorm:"type:varchar(255);primaryKey;index"`
+	CreatedAt time.Time
+}
+
+// memberRow is a group member joined with its user.
+type memberRow struct {
+	GroupMember
+	Nama string
+	HP   string
+}
@@ -0,0 +1,243 @@
+package data
+
+import (
+	"errors"
+	"strings"
+	"test/features/groups"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type GroupData struct {
+	gorm *gorm.DB
+}
+
+func New(g *gorm.DB) groups.GroupDataInterface {
+	return &GroupData{
+		gorm: g,
+	}
+}
+
+func (gd *GroupData) Insert(newData groups.Group) (*groups.Group, error) {
+	var dbData = new(Group)
+	dbData.ID = newData.ID
+	dbData.Name = newData.Name
+	dbData.Description = newData.Description
+	dbData.Permissions = strings.Join(newData.Permissions, " ")
+	dbData.CreatedAt = newData.CreatedAt
+	dbData.UpdatedAt = newData.UpdatedAt
+
+	if err := gd.gorm.Create(dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return &newData, nil
+}
+
+func (gd *GroupData) GetByID(id string) (*groups.Group, error) {
+	var dbData = new(Group)
+
+	if err := gd.gorm.Where("id = ?", id).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toGroup(dbData), nil
+}
+
+func (gd *GroupData) GetByName(name string) (*groups.Group, error) {
+	var dbData = new(Group)
+
+	if err := gd.gorm.Where("name = ?", name).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toGroup(dbData), nil
+}
+
+func (gd *GroupData) GetByIDs(ids []string) ([]groups.Group, error) {
+	if len(ids) == 0 {
+		return []groups.Group{}, nil
+	}
+
+	return gd.find(gd.gorm.Where("id IN ?", ids))
+}
+
+func (gd *GroupData) List() ([]groups.Group, error) {
+	return gd.find(gd.gorm)
+}
+
+func (gd *GroupData) Update(newData groups.Group) error {
+	var qry = gd.gorm.Model(&Group{}).Where("id = ?", newData.ID).Updates(map[string]any{
+		"name":        newData.Name,
+		"description": newData.Description,
+		"permissions": strings.Join(newData.Permissions, " "),
+		"updated_at":  newData.UpdatedAt,
+	})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+// Delete also ends the memberships and the nesting on both sides, the members
+// of the group do not move to its parents.
+func (gd *GroupData) Delete(id string) error {
+	return gd.gorm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&GroupMember{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("parent_id = ? OR child_id = ?", id, id).Delete(&GroupNesting{}).Error; err != nil {
+			return err
+		}
+
+		var qry = tx.Where("id = ?", id).Delete(&Group{})
+
+		if err := qry.Error; err != nil {
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("data not found")
+		}
+
+		return nil
+	})
+}
+
+func (gd *GroupData) InsertMember(groupID string, userID string, createdAt time.Time) error {
+	var dbData = new(GroupMember)
+	dbData.GroupID = groupID
+	dbData.UserID = userID
+	dbData.CreatedAt = createdAt
+
+	return gd.gorm.Create(dbData).Error
+}
+
+// GetMembers leaves out deleted accounts.
+func (gd *GroupData) GetMembers(groupID string) ([]groups.Member, error) {
+	var dbData = []memberRow{}
+
+	var qry = gd.gorm.Table("group_members").
+		Select("group_members.*, users.nama, COALESCE(users.hp, '') AS hp").
+		Joins("JOIN users ON users.id = group_members.user_id AND users.deleted_at IS NULL").
+		Where("group_members.group_id = ?", groupID).
+		Order("group_members.created_at")
+	if err := qry.Scan(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]groups.Member, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, groups.Member{
+			GroupID:   dbData[i].GroupID,
+			UserID:    dbData[i].UserID,
+			Nama:      dbData[i].Nama,
+			HP:        dbData[i].HP,
+			CreatedAt: dbData[i].CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (gd *GroupData) DeleteMember(groupID string, userID string) error {
+	var qry = gd.gorm.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&GroupMember{})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (gd *GroupData) GetUserGroupIDs(userID string) ([]string, error) {
+	var result = []string{}
+
+	if err := gd.gorm.Model(&GroupMember{}).Where("user_id = ?", userID).Pluck("group_id", &result).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (gd *GroupData) InsertNesting(parentID string, childID string, createdAt time.Time) error {
+	var dbData = new(GroupNesting)
+	dbData.ParentID = parentID
+	dbData.ChildID = childID
+	dbData.CreatedAt = createdAt
+
+	return gd.gorm.Create(dbData).Error
+}
+
+func (gd *GroupData) GetChildren(groupID string) ([]groups.Group, error) {
+	return gd.find(gd.gorm.Where("id IN (?)", gd.gorm.Model(&GroupNesting{}).Select("child_id").Where("parent_id = ?", groupID)))
+}
+
+func (gd *GroupData) GetParentIDs(groupIDs []string) ([]string, error) {
+	var result = []string{}
+	if len(groupIDs) == 0 {
+		return result, nil
+	}
+
+	if err := gd.gorm.Model(&GroupNesting{}).Distinct().Where("child_id IN ?", groupIDs).Pluck("parent_id", &result).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (gd *GroupData) DeleteNesting(parentID string, childID string) error {
+	var qry = gd.gorm.Where("parent_id = ? AND child_id = ?", parentID, childID).Delete(&GroupNesting{})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (gd *GroupData) find(qry *gorm.DB) ([]groups.Group, error) {
+	var dbData = []Group{}
+
+	if err := qry.Order("name").Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]groups.Group, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toGroup(&dbData[i]))
+	}
+
+	return result, nil
+}
+
+func toGroup(dbData *Group) *groups.Group {
+	var result = new(groups.Group)
+	result.ID = dbData.ID
+	result.Name = dbData.Name
+	result.Description = dbData.Description
+	result.Permissions = strings.Fields(dbData.Permissions)
+	result.CreatedAt = dbData.CreatedAt
+	result.UpdatedAt = dbData.UpdatedAt
+
+	return result
+}
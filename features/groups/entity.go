@@ -0,0 +1,74 @@
+package groups
+
+import (
+	"context"
+	"test/helper"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Group struct {
+	ID          string
+	Name        string
+	Description string
+	Permissions []string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type Member struct {
+	GroupID   string
+	UserID    string
+	Nama      string
+	HP        string
+	CreatedAt time.Time
+}
+
+type GroupHandlerInterface interface {
+	Create() echo.HandlerFunc
+	List() echo.HandlerFunc
+	Get() echo.HandlerFunc
+	Update() echo.HandlerFunc
+	Delete() echo.HandlerFunc
+	ListMembers() echo.HandlerFunc
+	AddMember() echo.HandlerFunc
+	RemoveMember() echo.HandlerFunc
+	ListSubgroups() echo.HandlerFunc
+	AddSubgroup() echo.HandlerFunc
+	RemoveSubgroup() echo.HandlerFunc
+	ListMine() echo.HandlerFunc
+}
+type GroupServiceInterface interface {
+	Create(ctx context.Context, actor helper.Principal, newData Group) (*Group, error)
+	List(ctx context.Context) ([]Group, error)
+	Get(ctx context.Context, groupID string) (*Group, error)
+	Update(ctx context.Context, actor helper.Principal, newData Group) (*Group, error)
+	Delete(ctx context.Context, actor helper.Principal, groupID string) error
+	ListMembers(ctx context.Context, groupID string) ([]Member, error)
+	AddMember(ctx context.Context, actor helper.Principal, groupID string, userID string) error
+	RemoveMember(ctx context.Context, actor helper.Principal, groupID string, userID string) error
+	ListSubgroups(ctx context.Context, groupID string) ([]Group, error)
+	AddSubgroup(ctx context.Context, actor helper.Principal, groupID string, childID string) error
+	RemoveSubgroup(ctx context.Context, actor helper.Principal, groupID string, childID string) error
+	ListForUser(ctx context.Context, userID string) ([]Group, error)
+	Permissions(ctx context.Context, principal helper.Principal) ([]string, error)
+	GroupsOf(userID string) ([]string, error)
+}
+type GroupDataInterface interface {
+	Insert(newData Group) (*Group, error)
+	GetByID(id string) (*Group, error)
+	GetByName(name string) (*Group, error)
+	GetByIDs(ids []string) ([]Group, error)
+	List() ([]Group, error)
+	Update(newData Group) error
+	Delete(id string) error
+	InsertMember(groupID string, userID string, createdAt time.Time) error
+	GetMembers(groupID string) ([]Member, error)
+	DeleteMember(groupID string, userID string) error
+	GetUserGroupIDs(userID string) ([]string, error)
+	InsertNesting(parentID string, childID string, createdAt time.Time) error
+	GetChildren(groupID string) ([]Group, error)
+	GetParentIDs(groupIDs []string) ([]string, error)
+	DeleteNesting(parentID string, childID string) error
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"test/features/groups"
+	"test/helper"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GroupHandler struct {
+	s groups.GroupServiceInterface
+}
+
+func NewHandler(service groups.GroupServiceInterface) groups.GroupHandlerInterface {
+	return &GroupHandler{
+		s: service,
+	}
+}
+
+func (gh *GroupHandler) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(GroupInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := gh.s.Create(c.Request().Context(), *principal, groups.Group{Name: input.Name, Description: input.Description, Permissions: input.Permissions})
+
+		if err != nil {
+			c.Logger().Error("handler: create group process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", groupResponse(*result)))
+	}
+}
+
+func (gh *GroupHandler) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := gh.s.List(c.Request().Context())
+
+		if err != nil {
+			c.Logger().Error("handler: list groups process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", groupResponses(result)))
+	}
+}
+
+func (gh *GroupHandler) Get() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := gh.s.Get(c.Request().Context(), c.Param("id"))
+
+		if err != nil {
+			c.Logger().Error("handler: get group process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", groupResponse(*result)))
+	}
+}
+
+func (gh *GroupHandler) Update() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(GroupInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := gh.s.Update(c.Request().Context(), *principal, groups.Group{ID: c.Param("id"), Name: input.Name, Description: input.Description, Permissions: input.Permissions})
+
+		if err != nil {
+			c.Logger().Error("handler: update group process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", groupResponse(*result)))
+	}
+}
+
+func (gh *GroupHandler) Delete() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := gh.s.Delete(c.Request().Context(), *principal, c.Param("id")); err != nil {
+			c.Logger().Error("handler: delete group process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (gh *GroupHandler) ListMembers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := gh.s.ListMembers(c.Request().Context(), c.Param("id"))
+
+		if err != nil {
+			c.Logger().Error("handler: list group members process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		var response = make([]MemberResponse, 0, len(result))
+		for _, member := range result {
+			response = append(response, MemberResponse{
+				UserID:   member.UserID,
+				Nama:     member.Nama,
+				HP:       member.HP,
+				JoinedAt: member.CreatedAt,
+			})
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (gh *GroupHandler) AddMember() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(MemberInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if err := gh.s.AddMember(c.Request().Context(), *principal, c.Param("id"), input.UserID); err != nil {
+			c.Logger().Error("handler: add group member process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", nil))
+	}
+}
+
+func (gh *GroupHandler) RemoveMember() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := gh.s.RemoveMember(c.Request().Context(), *principal, c.Param("id"), c.Param("user_id")); err != nil {
+			c.Logger().Error("handler: remove group member process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (gh *GroupHandler) ListSubgroups() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := gh.s.ListSubgroups(c.Request().Context(), c.Param("id"))
+
+		if err != nil {
+			c.Logger().Error("handler: list subgroups process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", groupResponses(result)))
+	}
+}
+
+func (gh *GroupHandler) AddSubgroup() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(SubgroupInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if err := gh.s.AddSubgroup(c.Request().Context(), *principal, c.Param("id"), input.GroupID); err != nil {
+			c.Logger().Error("handler: add subgroup process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", nil))
+	}
+}
+
+func (gh *GroupHandler) RemoveSubgroup() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := gh.s.RemoveSubgroup(c.Request().Context(), *principal, c.Param("id"), c.Param("child_id")); err != nil {
+			c.Logger().Error("handler: remove subgroup process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (gh *GroupHandler) ListMine() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := gh.s.ListForUser(c.Request().Context(), principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: list my groups process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		permissions, err := gh.s.Permissions(c.Request().Context(), *principal)
+
+		if err != nil {
+			c.Logger().Error("handler: list my permissions process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = new(MyGroupsResponse)
+		response.Groups = groupResponses(result)
+		response.Permissions = permissions
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func errorResponse(c echo.Context, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "invalid"), strings.Contains(err.Error(), "cannot"):
+		return c.JSON(http.StatusBadRequest, helper.FormatResponse(err.Error(), nil))
+	case strings.Contains(err.Error(), "forbidden"):
+		return c.JSON(http.StatusForbidden, helper.FormatResponse("forbidden", nil))
+	case strings.Contains(err.Error(), "not found"):
+		return c.JSON(http.StatusNotFound, helper.FormatResponse(err.Error(), nil))
+	case strings.Contains(err.Error(), "already"):
+		return c.JSON(http.StatusConflict, helper.FormatResponse(err.Error(), nil))
+	}
+	return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+}
+
+func groupResponse(group groups.Group) *GroupResponse {
+	var response = new(GroupResponse)
+	response.ID = group.ID
+	response.Name = group.Name
+	response.Description = group.Description
+	response.Permissions = group.Permissions
+	if response.Permissions == nil {
+		response.Permissions = []string{}
+	}
+	response.CreatedAt = group.CreatedAt
+	response.UpdatedAt = group.UpdatedAt
+
+	return response
+}
+
+func groupResponses(result []groups.Group) []GroupResponse {
+	var response = make([]GroupResponse, 0, len(result))
+	for _, group := range result {
+		response = append(response, *groupResponse(group))
+	}
+
+	return response
+}
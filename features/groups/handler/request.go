@@ -0,0 +1,15 @@
+package handler
+
+type GroupInput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type MemberInput struct {
+	UserID string `json:"user_id"`
+}
+
+type SubgroupInput struct {
+	GroupID string `json:"group_id"`
+}
@@ -0,0 +1,24 @@
+package handler
+
+import "time"
+
+type GroupResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type MemberResponse struct {
+	UserID   string    `json:"user_id"`
+	Nama     string    `json:"nama"`
+	HP       string    `json:"hp"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type MyGroupsResponse struct {
+	Groups      []GroupResponse `json:"groups"`
+	Permissions []string        `json:"permissions"`
+}
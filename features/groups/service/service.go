@@ -0,0 +1,486 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"test/features/audit"
+	"test/features/groups"
+	"test/features/users"
+	"test/helper"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxNameLength        = 100
+	maxDescriptionLength = 255
+)
+
+type GroupService struct {
+	d groups.GroupDataInterface
+	u users.UserDataInterface
+	a audit.AuditServiceInterface
+	g helper.GeneratorInterface
+	c helper.ClockInterface
+}
+
+func New(data groups.GroupDataInterface, userData users.UserDataInterface, auditor audit.AuditServiceInterface, generator helper.GeneratorInterface, clock helper.ClockInterface) groups.GroupServiceInterface {
+	return &GroupService{
+		d: data,
+		u: userData,
+		a: auditor,
+		g: generator,
+		c: clock,
+	}
+}
+
+// Create and the other changes to groups can only hand out permissions the
+// actor holds, so managing groups does not lead to any other permission.
+func (gs *GroupService) Create(ctx context.Context, actor helper.Principal, newData groups.Group) (*groups.Group, error) {
+	if err := gs.validate(&newData); err != nil {
+		return nil, err
+	}
+
+	if err := gs.grantable(ctx, actor, newData.Permissions); err != nil {
+		return nil, err
+	}
+
+	if err := gs.checkNameAvailable("", newData.Name); err != nil {
+		return nil, err
+	}
+
+	newID, err := gs.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	var now = gs.c.Now()
+	newData.ID = newID
+	newData.CreatedAt = now
+	newData.UpdatedAt = now
+
+	result, err := gs.d.Insert(newData)
+	if err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	gs.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "group.created", TargetID: result.ID, After: map[string]any{"name": result.Name, "permissions": result.Permissions}})
+
+	return result, nil
+}
+
+func (gs *GroupService) List(ctx context.Context) ([]groups.Group, error) {
+	result, err := gs.d.List()
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (gs *GroupService) Get(ctx context.Context, groupID string) (*groups.Group, error) {
+	return gs.getGroup(groupID)
+}
+
+func (gs *GroupService) Update(ctx context.Context, actor helper.Principal, newData groups.Group) (*groups.Group, error) {
+	result, err := gs.getGroup(newData.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gs.validate(&newData); err != nil {
+		return nil, err
+	}
+
+	if err := gs.grantable(ctx, actor, append(append([]string{}, result.Permissions...), newData.Permissions...)); err != nil {
+		return nil, err
+	}
+
+	if newData.Name != result.Name {
+		if err := gs.checkNameAvailable(result.ID, newData.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	var before = map[string]any{"name": result.Name, "description": result.Description, "permissions": result.Permissions}
+	result.Name = newData.Name
+	result.Description = newData.Description
+	result.Permissions = newData.Permissions
+	result.UpdatedAt = gs.c.Now()
+	if err := gs.d.Update(*result); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("update process failed")
+	}
+
+	gs.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "group.updated", TargetID: result.ID, Before: before, After: map[string]any{"name": result.Name, "description": result.Description, "permissions": result.Permissions}})
+
+	return result, nil
+}
+
+// Delete, RemoveMember and RemoveSubgroup take the permissions of the group
+// away from members, like granting them only someone holding all of them may
+// do that.
+func (gs *GroupService) Delete(ctx context.Context, actor helper.Principal, groupID string) error {
+	if _, err := gs.getGroup(groupID); err != nil {
+		return err
+	}
+
+	if err := gs.grantGroup(ctx, actor, groupID); err != nil {
+		return err
+	}
+
+	if err := gs.d.Delete(groupID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("delete process failed")
+	}
+
+	gs.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "group.deleted", TargetID: groupID})
+
+	return nil
+}
+
+// ListMembers only lists the direct members, not the ones of subgroups.
+func (gs *GroupService) ListMembers(ctx context.Context, groupID string) ([]groups.Member, error) {
+	if _, err := gs.getGroup(groupID); err != nil {
+		return nil, err
+	}
+
+	result, err := gs.d.GetMembers(groupID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (gs *GroupService) AddMember(ctx context.Context, actor helper.Principal, groupID string, userID string) error {
+	if _, err := gs.getGroup(groupID); err != nil {
+		return err
+	}
+
+	if _, err := gs.u.WithContext(ctx).GetByID(userID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("user not found")
+		}
+		return errors.New("process failed")
+	}
+
+	if err := gs.grantGroup(ctx, actor, groupID); err != nil {
+		return err
+	}
+
+	groupIDs, err := gs.d.GetUserGroupIDs(userID)
+	if err != nil {
+		return errors.New("process failed")
+	}
+
+	if containsString(groupIDs, groupID) {
+		return errors.New("already a member")
+	}
+
+	if err := gs.d.InsertMember(groupID, userID, gs.c.Now()); err != nil {
+		return errors.New("insert process failed")
+	}
+
+	gs.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "group.member_added", TargetID: userID, After: map[string]any{"group_id": groupID}})
+
+	return nil
+}
+
+func (gs *GroupService) RemoveMember(ctx context.Context, actor helper.Principal, groupID string, userID string) error {
+	if _, err := gs.getGroup(groupID); err != nil {
+		return err
+	}
+
+	if err := gs.grantGroup(ctx, actor, groupID); err != nil {
+		return err
+	}
+
+	if err := gs.d.DeleteMember(groupID, userID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("delete process failed")
+	}
+
+	gs.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "group.member_removed", TargetID: userID, Before: map[string]any{"group_id": groupID}})
+
+	return nil
+}
+
+func (gs *GroupService) ListSubgroups(ctx context.Context, groupID string) ([]groups.Group, error) {
+	if _, err := gs.getGroup(groupID); err != nil {
+		return nil, err
+	}
+
+	result, err := gs.d.GetChildren(groupID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+// AddSubgroup makes the members of childID members of groupID. A group cannot
+// end up inside itself, not even through other groups.
+func (gs *GroupService) AddSubgroup(ctx context.Context, actor helper.Principal, groupID string, childID string) error {
+	if groupID == childID {
+		return errors.New("cannot nest group in itself")
+	}
+
+	if _, err := gs.getGroup(groupID); err != nil {
+		return err
+	}
+
+	if _, err := gs.getGroup(childID); err != nil {
+		return err
+	}
+
+	ancestors, err := gs.ancestors([]string{groupID})
+	if err != nil {
+		return err
+	}
+
+	if containsString(ancestors, childID) {
+		return errors.New("cannot nest group in its own subgroup")
+	}
+
+	parents, err := gs.d.GetParentIDs([]string{childID})
+	if err != nil {
+		return errors.New("process failed")
+	}
+
+	if containsString(parents, groupID) {
+		return errors.New("already a subgroup")
+	}
+
+	if err := gs.grantGroup(ctx, actor, groupID); err != nil {
+		return err
+	}
+
+	if err := gs.d.InsertNesting(groupID, childID, gs.c.Now()); err != nil {
+		return errors.New("insert process failed")
+	}
+
+	gs.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "group.subgroup_added", TargetID: groupID, After: map[string]any{"child_id": childID}})
+
+	return nil
+}
+
+func (gs *GroupService) RemoveSubgroup(ctx context.Context, actor helper.Principal, groupID string, childID string) error {
+	if _, err := gs.getGroup(groupID); err != nil {
+		return err
+	}
+
+	if err := gs.grantGroup(ctx, actor, groupID); err != nil {
+		return err
+	}
+
+	if err := gs.d.DeleteNesting(groupID, childID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("delete process failed")
+	}
+
+	gs.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "group.subgroup_removed", TargetID: groupID, Before: map[string]any{"child_id": childID}})
+
+	return nil
+}
+
+// ListForUser returns every group the user is in, directly or through a
+// subgroup.
+func (gs *GroupService) ListForUser(ctx context.Context, userID string) ([]groups.Group, error) {
+	return gs.effectiveGroups(userID)
+}
+
+// Permissions makes the service the helper.PermissionResolverInterface of the
+// RBAC middleware.
+func (gs *GroupService) Permissions(ctx context.Context, principal helper.Principal) ([]string, error) {
+	if principal.HasRole(users.RoleAdmin) {
+		return append([]string{}, helper.Permissions...), nil
+	}
+
+	result, err := gs.effectiveGroups(principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectPermissions(result), nil
+}
+
+// GroupsOf makes the service the helper.GroupSourceInterface of the JWT.
+func (gs *GroupService) GroupsOf(userID string) ([]string, error) {
+	result, err := gs.effectiveGroups(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var names = make([]string, 0, len(result))
+	for _, group := range result {
+		names = append(names, group.Name)
+	}
+
+	return names, nil
+}
+
+func (gs *GroupService) effectiveGroups(userID string) ([]groups.Group, error) {
+	groupIDs, err := gs.d.GetUserGroupIDs(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	groupIDs, err = gs.ancestors(groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gs.d.GetByIDs(groupIDs)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+// ancestors returns groupIDs with every group they are nested in, one level
+// of parents at a time.
+func (gs *GroupService) ancestors(groupIDs []string) ([]string, error) {
+	var result = append([]string{}, groupIDs...)
+	var level = groupIDs
+	for len(level) > 0 {
+		parents, err := gs.d.GetParentIDs(level)
+		if err != nil {
+			return nil, errors.New("process failed")
+		}
+
+		level = nil
+		for _, parentID := range parents {
+			if !containsString(result, parentID) {
+				result = append(result, parentID)
+				level = append(level, parentID)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// grantGroup checks that the actor holds every permission members of groupID
+// get, including the ones of the groups it is nested in.
+func (gs *GroupService) grantGroup(ctx context.Context, actor helper.Principal, groupID string) error {
+	groupIDs, err := gs.ancestors([]string{groupID})
+	if err != nil {
+		return err
+	}
+
+	result, err := gs.d.GetByIDs(groupIDs)
+	if err != nil {
+		return errors.New("process failed")
+	}
+
+	return gs.grantable(ctx, actor, collectPermissions(result))
+}
+
+func (gs *GroupService) grantable(ctx context.Context, actor helper.Principal, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	held, err := gs.Permissions(ctx, actor)
+	if err != nil {
+		return err
+	}
+
+	for _, permission := range permissions {
+		if !containsString(held, permission) {
+			return errors.New("forbidden")
+		}
+	}
+
+	return nil
+}
+
+func (gs *GroupService) validate(newData *groups.Group) error {
+	newData.Name = strings.TrimSpace(newData.Name)
+	if newData.Name == "" || utf8.RuneCountInString(newData.Name) > maxNameLength {
+		return errors.New("invalid name")
+	}
+
+	newData.Description = strings.TrimSpace(newData.Description)
+	if utf8.RuneCountInString(newData.Description) > maxDescriptionLength {
+		return errors.New("invalid description")
+	}
+
+	permissions, err := helper.NormalizePermissions(newData.Permissions)
+	if err != nil {
+		return err
+	}
+	newData.Permissions = permissions
+
+	return nil
+}
+
+func (gs *GroupService) checkNameAvailable(groupID string, name string) error {
+	existing, err := gs.d.GetByName(name)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return errors.New("process failed")
+	}
+
+	if existing.ID != groupID {
+		return errors.New("name already taken")
+	}
+
+	return nil
+}
+
+func (gs *GroupService) getGroup(groupID string) (*groups.Group, error) {
+	result, err := gs.d.GetByID(groupID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (gs *GroupService) audit(ctx context.Context, event audit.Event) {
+	if err := gs.a.Record(ctx, event); err != nil {
+		logrus.Error("service: audit record error:", err.Error())
+	}
+}
+
+func collectPermissions(result []groups.Group) []string {
+	var permissions = []string{}
+	for _, group := range result {
+		for _, permission := range group.Permissions {
+			if !containsString(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	sort.Strings(permissions)
+
+	return permissions
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"test/features/audit"
+	auditMocks "test/features/audit/mocks"
+	"test/features/groups"
+	"test/features/groups/mocks"
+	"test/features/users"
+	userMocks "test/features/users/mocks"
+	realHelper "test/helper"
+	helper "test/helper/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreate(t *testing.T) {
+	data := mocks.NewGroupDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, auditor, generator, clock)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	admin := realHelper.Principal{UserID: "adminID", Roles: []string{users.RoleAdmin}}
+	manager := realHelper.Principal{UserID: "managerID", Roles: []string{users.RoleUser}}
+
+	t.Run("Success create", func(t *testing.T) {
+		permissions := []string{realHelper.PermissionAuditRead, realHelper.PermissionUsersManage}
+		expected := groups.Group{ID: "randomGroupID", Name: "Support", Permissions: permissions, CreatedAt: now, UpdatedAt: now}
+		data.On("GetByName", "Support").Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateUUID").Return("randomGroupID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("Insert", expected).Return(&expected, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "adminID", Action: "group.created", TargetID: "randomGroupID", After: map[string]any{"name": "Support", "permissions": permissions}}).Return(nil).Once()
+
+		result, err := service.Create(context.Background(), admin, groups.Group{Name: " Support ", Permissions: []string{realHelper.PermissionUsersManage, realHelper.PermissionAuditRead}})
+		assert.Nil(t, err)
+		assert.Equal(t, "randomGroupID", result.ID)
+	})
+
+	t.Run("Invalid permission", func(t *testing.T) {
+		result, err := service.Create(context.Background(), admin, groups.Group{Name: "Support", Permissions: []string{"users:delete"}})
+		assert.EqualError(t, err, "invalid permission")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Permission not held", func(t *testing.T) {
+		data.On("GetUserGroupIDs", "managerID").Return([]string{"managersID"}, nil).Once()
+		data.On("GetParentIDs", []string{"managersID"}).Return([]string{}, nil).Once()
+		data.On("GetByIDs", []string{"managersID"}).Return([]groups.Group{{ID: "managersID", Permissions: []string{realHelper.PermissionGroupsManage}}}, nil).Once()
+
+		result, err := service.Create(context.Background(), manager, groups.Group{Name: "Auditors", Permissions: []string{realHelper.PermissionAuditRead}})
+		assert.EqualError(t, err, "forbidden")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Name taken", func(t *testing.T) {
+		data.On("GetByName", "Support").Return(&groups.Group{ID: "otherGroupID", Name: "Support"}, nil).Once()
+
+		result, err := service.Create(context.Background(), admin, groups.Group{Name: "Support"})
+		assert.EqualError(t, err, "name already taken")
+		assert.Nil(t, result)
+	})
+}
+
+func TestAddMember(t *testing.T) {
+	data := mocks.NewGroupDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, auditor, helper.NewGeneratorInterface(t), clock)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	admin := realHelper.Principal{UserID: "adminID", Roles: []string{users.RoleAdmin}}
+	support := groups.Group{ID: "supportID", Name: "Support"}
+
+	t.Run("Success add", func(t *testing.T) {
+		ctx := context.Background()
+		data.On("GetByID", "supportID").Return(&support, nil).Once()
+		userData.On("WithContext", ctx).Return(userData).Once()
+		userData.On("GetByID", "randomUserID").Return(&users.User{ID: "randomUserID"}, nil).Once()
+		data.On("GetParentIDs", []string{"supportID"}).Return([]string{}, nil).Once()
+		data.On("GetByIDs", []string{"supportID"}).Return([]groups.Group{support}, nil).Once()
+		data.On("GetUserGroupIDs", "randomUserID").Return([]string{}, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertMember", "supportID", "randomUserID", now).Return(nil).Once()
+		auditor.On("Record", ctx, audit.Event{ActorID: "adminID", Action: "group.member_added", TargetID: "randomUserID", After: map[string]any{"group_id": "supportID"}}).Return(nil).Once()
+
+		assert.Nil(t, service.AddMember(ctx, admin, "supportID", "randomUserID"))
+	})
+
+	t.Run("User outside the organization", func(t *testing.T) {
+		ctx := realHelper.WithTenant(context.Background(), "acmeID")
+		tenantData := userMocks.NewUserDataInterface(t)
+		data.On("GetByID", "supportID").Return(&support, nil).Once()
+		userData.On("WithContext", ctx).Return(tenantData).Once()
+		tenantData.On("GetByID", "otherUserID").Return(nil, errors.New("record not found")).Once()
+
+		assert.EqualError(t, service.AddMember(ctx, admin, "supportID", "otherUserID"), "user not found")
+	})
+}
+
+func TestRemove(t *testing.T) {
+	data := mocks.NewGroupDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	service := New(data, userMocks.NewUserDataInterface(t), auditor, helper.NewGeneratorInterface(t), helper.NewClockInterface(t))
+	admin := realHelper.Principal{UserID: "adminID", Roles: []string{users.RoleAdmin}}
+	manager := realHelper.Principal{UserID: "managerID", Roles: []string{users.RoleUser}}
+	auditors := groups.Group{ID: "auditorsID", Name: "Auditors", Permissions: []string{realHelper.PermissionAuditRead}}
+
+	// The manager only holds groups:manage, not what the auditors get.
+	managerGroups := func() {
+		data.On("GetUserGroupIDs", "managerID").Return([]string{"managersID"}, nil).Once()
+		data.On("GetParentIDs", []string{"managersID"}).Return([]string{}, nil).Once()
+		data.On("GetByIDs", []string{"managersID"}).Return([]groups.Group{{ID: "managersID", Permissions: []string{realHelper.PermissionGroupsManage}}}, nil).Once()
+	}
+	auditorsGroup := func() {
+		data.On("GetByID", "auditorsID").Return(&auditors, nil).Once()
+		data.On("GetParentIDs", []string{"auditorsID"}).Return([]string{}, nil).Once()
+		data.On("GetByIDs", []string{"auditorsID"}).Return([]groups.Group{auditors}, nil).Once()
+	}
+
+	t.Run("Delete permission not held", func(t *testing.T) {
+		auditorsGroup()
+		managerGroups()
+
+		assert.EqualError(t, service.Delete(context.Background(), manager, "auditorsID"), "forbidden")
+	})
+
+	t.Run("Remove member permission not held", func(t *testing.T) {
+		auditorsGroup()
+		managerGroups()
+
+		assert.EqualError(t, service.RemoveMember(context.Background(), manager, "auditorsID", "randomUserID"), "forbidden")
+	})
+
+	t.Run("Remove subgroup permission not held", func(t *testing.T) {
+		auditorsGroup()
+		managerGroups()
+
+		assert.EqualError(t, service.RemoveSubgroup(context.Background(), manager, "auditorsID", "childID"), "forbidden")
+	})
+
+	t.Run("Admin removes member", func(t *testing.T) {
+		auditorsGroup()
+		data.On("DeleteMember", "auditorsID", "randomUserID").Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "adminID", Action: "group.member_removed", TargetID: "randomUserID", Before: map[string]any{"group_id": "auditorsID"}}).Return(nil).Once()
+
+		assert.Nil(t, service.RemoveMember(context.Background(), admin, "auditorsID", "randomUserID"))
+	})
+
+	t.Run("Delete unknown group", func(t *testing.T) {
+		data.On("GetByID", "unknownID").Return(nil, errors.New("record not found")).Once()
+
+		assert.EqualError(t, service.Delete(context.Background(), admin, "unknownID"), "data not found")
+	})
+}
+
+func TestSubgroups(t *testing.T) {
+	data := mocks.NewGroupDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userMocks.NewUserDataInterface(t), auditor, helper.NewGeneratorInterface(t), clock)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	admin := realHelper.Principal{UserID: "adminID", Roles: []string{users.RoleAdmin}}
+
+	t.Run("Success add", func(t *testing.T) {
+		data.On("GetByID", "staffID").Return(&groups.Group{ID: "staffID"}, nil).Once()
+		data.On("GetByID", "supportID").Return(&groups.Group{ID: "supportID"}, nil).Once()
+		data.On("GetParentIDs", []string{"staffID"}).Return([]string{"employeesID"}, nil).Twice()
+		data.On("GetParentIDs", []string{"employeesID"}).Return([]string{}, nil).Twice()
+		data.On("GetParentIDs", []string{"supportID"}).Return([]string{}, nil).Once()
+		data.On("GetByIDs", []string{"staffID", "employeesID"}).Return([]groups.Group{{ID: "staffID"}, {ID: "employeesID", Permissions: []string{realHelper.PermissionAuditRead}}}, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertNesting", "staffID", "supportID", now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "adminID", Action: "group.subgroup_added", TargetID: "staffID", After: map[string]any{"child_id": "supportID"}}).Return(nil).Once()
+
+		assert.Nil(t, service.AddSubgroup(context.Background(), admin, "staffID", "supportID"))
+	})
+
+	t.Run("Nested in itself", func(t *testing.T) {
+		assert.EqualError(t, service.AddSubgroup(context.Background(), admin, "staffID", "staffID"), "cannot nest group in itself")
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		data.On("GetByID", "supportID").Return(&groups.Group{ID: "supportID"}, nil).Once()
+		data.On("GetByID", "employeesID").Return(&groups.Group{ID: "employeesID"}, nil).Once()
+		data.On("GetParentIDs", []string{"supportID"}).Return([]string{"staffID"}, nil).Once()
+		data.On("GetParentIDs", []string{"staffID"}).Return([]string{"employeesID"}, nil).Once()
+		data.On("GetParentIDs", []string{"employeesID"}).Return([]string{}, nil).Once()
+
+		assert.EqualError(t, service.AddSubgroup(context.Background(), admin, "supportID", "employeesID"), "cannot nest group in its own subgroup")
+	})
+}
+
+func TestPermissions(t *testing.T) {
+	data := mocks.NewGroupDataInterface(t)
+	service := New(data, userMocks.NewUserDataInterface(t), auditMocks.NewAuditServiceInterface(t), helper.NewGeneratorInterface(t), helper.NewClockInterface(t))
+
+	t.Run("Inherited from parent groups", func(t *testing.T) {
+		data.On("GetUserGroupIDs", "userID").Return([]string{"supportID"}, nil).Once()
+		data.On("GetParentIDs", []string{"supportID"}).Return([]string{"staffID"}, nil).Once()
+		data.On("GetParentIDs", []string{"staffID"}).Return([]string{}, nil).Once()
+		data.On("GetByIDs", []string{"supportID", "staffID"}).Return([]groups.Group{
+			{ID: "staffID", Name: "Staff", Permissions: []string{realHelper.PermissionAuditRead}},
+			{ID: "supportID", Name: "Support", Permissions: []string{realHelper.PermissionUsersManage, realHelper.PermissionAuditRead}},
+		}, nil).Once()
+
+		result, err := service.Permissions(context.Background(), realHelper.Principal{UserID: "userID", Roles: []string{users.RoleUser}})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{realHelper.PermissionAuditRead, realHelper.PermissionUsersManage}, result)
+	})
+
+	t.Run("Admins hold every permission", func(t *testing.T) {
+		result, err := service.Permissions(context.Background(), realHelper.Principal{UserID: "adminID", Roles: []string{users.RoleAdmin}})
+		assert.Nil(t, err)
+		assert.Equal(t, realHelper.Permissions, result)
+	})
+
+	t.Run("Group names", func(t *testing.T) {
+		data.On("GetUserGroupIDs", "userID").Return([]string{"supportID"}, nil).Once()
+		data.On("GetParentIDs", []string{"supportID"}).Return([]string{}, nil).Once()
+		data.On("GetByIDs", []string{"supportID"}).Return([]groups.Group{{ID: "supportID", Name: "Support"}}, nil).Once()
+
+		result, err := service.GroupsOf("userID")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"Support"}, result)
+	})
+}
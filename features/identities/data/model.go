@@ -0,0 +1,24 @@
+package data
+
+import "time"
+
+type ExternalIdentity struct {
+	ID          string `gorm:"type:varchar(255);primaryKey"`
+	UserID      string `gorm:"type:varchar(255);index"`
+	Provider    string `gorm:"type:varchar(64);uniqueIndex:idx_external_identity_subject"`
+	Subject     string `gorm:"type:varchar(255);uniqueIndex:idx_external_identity_subject"`
+	Email       string
+	Name        string
+	CreatedAt   time.Time
+	LastLoginAt *time.Time
+}
+
+type ExternalLoginState struct {
+	StateHash    string    `gorm:"type:varchar(64);primaryKey"`
+	Provider     string    `gorm:"type:varchar(64)"`
+	Nonce        string    `gorm:"type:varchar(64)"`
+	CodeVerifier string    `gorm:"type:varchar(128)"`
+	UserID       string    `gorm:"type:varchar(255)"`
+	ExpiresAt    time.Time `gorm:"index"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,160 @@
+package data
+
+import (
+	"errors"
+	"test/features/identities"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type IdentityData struct {
+	gorm *gorm.DB
+}
+
+func New(g *gorm.DB) identities.IdentityDataInterface {
+	return &IdentityData{
+		gorm: g,
+	}
+}
+
+// InsertState also drops the expired states of abandoned logins.
+func (ids *IdentityData) InsertState(newData identities.LoginState) error {
+	if err := ids.gorm.Where("expires_at < ?", newData.CreatedAt).Delete(&ExternalLoginState{}).Error; err != nil {
+		logrus.Error("db error:", err.Error())
+	}
+
+	var dbData = new(ExternalLoginState)
+	dbData.StateHash = newData.StateHash
+	dbData.Provider = newData.Provider
+	dbData.Nonce = newData.Nonce
+	dbData.CodeVerifier = newData.CodeVerifier
+	dbData.UserID = newData.UserID
+	dbData.ExpiresAt = newData.ExpiresAt
+	dbData.CreatedAt = newData.CreatedAt
+
+	return ids.gorm.Create(dbData).Error
+}
+
+// TakeState returns the state and deletes it, so a callback can only be
+// completed once.
+func (ids *IdentityData) TakeState(stateHash string) (*identities.LoginState, error) {
+	var dbData = new(ExternalLoginState)
+
+	err := ids.gorm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state_hash = ?", stateHash).First(dbData).Error; err != nil {
+			return err
+		}
+
+		var qry = tx.Where("state_hash = ?", stateHash).Delete(&ExternalLoginState{})
+		if err := qry.Error; err != nil {
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("data not found")
+		}
+
+		return nil
+	})
+	if err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	var result = new(identities.LoginState)
+	result.StateHash = dbData.StateHash
+	result.Provider = dbData.Provider
+	result.Nonce = dbData.Nonce
+	result.CodeVerifier = dbData.CodeVerifier
+	result.UserID = dbData.UserID
+	result.ExpiresAt = dbData.ExpiresAt
+	result.CreatedAt = dbData.CreatedAt
+
+	return result, nil
+}
+
+func (ids *IdentityData) Insert(newData identities.Identity) error {
+	var dbData = new(ExternalIdentity)
+	dbData.ID = newData.ID
+	dbData.UserID = newData.UserID
+	dbData.Provider = newData.Provider
+	dbData.Subject = newData.Subject
+	dbData.Email = newData.Email
+	dbData.Name = newData.Name
+	dbData.CreatedAt = newData.CreatedAt
+	dbData.LastLoginAt = newData.LastLoginAt
+
+	return ids.gorm.Create(dbData).Error
+}
+
+func (ids *IdentityData) GetBySubject(provider string, subject string) (*identities.Identity, error) {
+	var dbData = new(ExternalIdentity)
+
+	if err := ids.gorm.Where("provider = ? AND subject = ?", provider, subject).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toIdentity(dbData), nil
+}
+
+func (ids *IdentityData) GetByUser(userID string) ([]identities.Identity, error) {
+	var dbData = []ExternalIdentity{}
+
+	if err := ids.gorm.Where("user_id = ?", userID).Order("created_at").Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]identities.Identity, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toIdentity(&dbData[i]))
+	}
+
+	return result, nil
+}
+
+func (ids *IdentityData) UpdateLastLogin(identityID string, loginAt time.Time) error {
+	return ids.gorm.Model(&ExternalIdentity{}).Where("id = ?", identityID).Update("last_login_at", loginAt).Error
+}
+
+func (ids *IdentityData) Delete(userID string, identityID string) error {
+	var qry = ids.gorm.Where("id = ? AND user_id = ?", identityID, userID).Delete(&ExternalIdentity{})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (ids *IdentityData) EraseUser(userID string) error {
+	return ids.gorm.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{&ExternalIdentity{}, &ExternalLoginState{}} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func toIdentity(dbData *ExternalIdentity) *identities.Identity {
+	var result = new(identities.Identity)
+	result.ID = dbData.ID
+	result.UserID = dbData.UserID
+	result.Provider = dbData.Provider
+	result.Subject = dbData.Subject
+	result.Email = dbData.Email
+	result.Name = dbData.Name
+	result.CreatedAt = dbData.CreatedAt
+	result.LastLoginAt = dbData.LastLoginAt
+
+	return result
+}
@@ -0,0 +1,71 @@
+package identities
+
+import (
+	"context"
+	"test/features/users"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Identity links an account at an external OIDC provider, by the provider's
+// subject, to a user.
+type Identity struct {
+	ID          string
+	UserID      string
+	Provider    string
+	Subject     string
+	Email       string
+	Name        string
+	CreatedAt   time.Time
+	LastLoginAt *time.Time
+}
+
+// LoginState is kept between sending the browser to the provider and its
+// callback. UserID is only set when a logged in user links another identity.
+type LoginState struct {
+	StateHash    string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	UserID       string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// CallbackResult holds the credential of a login, or only the identity when
+// the callback completed linking it. Created is set when the login created
+// a new account.
+type CallbackResult struct {
+	Credential *users.UserCredential
+	Identity   *Identity
+	Created    bool
+}
+
+type IdentityHandlerInterface interface {
+	Providers() echo.HandlerFunc
+	Start() echo.HandlerFunc
+	Callback() echo.HandlerFunc
+	List() echo.HandlerFunc
+	Link() echo.HandlerFunc
+	Unlink() echo.HandlerFunc
+}
+type IdentityServiceInterface interface {
+	Providers() []string
+	Start(ctx context.Context, provider string, userID string) (string, error)
+	Callback(ctx context.Context, provider string, state string, code string) (*CallbackResult, error)
+	List(ctx context.Context, userID string) ([]Identity, error)
+	Unlink(ctx context.Context, userID string, identityID string) error
+}
+type IdentityDataInterface interface {
+	InsertState(newData LoginState) error
+	TakeState(stateHash string) (*LoginState, error)
+	Insert(newData Identity) error
+	GetBySubject(provider string, subject string) (*Identity, error)
+	GetByUser(userID string) ([]Identity, error)
+	UpdateLastLogin(identityID string, loginAt time.Time) error
+	Delete(userID string, identityID string) error
+	// EraseUser deletes the linked identities and pending logins of the user,
+	// it is the users feature's erasure hook.
+	EraseUser(userID string) error
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"test/features/identities"
+	"test/helper"
+
+	"github.com/labstack/echo/v4"
+)
+
+type IdentityHandler struct {
+	s identities.IdentityServiceInterface
+}
+
+func NewHandler(service identities.IdentityServiceInterface) identities.IdentityHandlerInterface {
+	return &IdentityHandler{
+		s: service,
+	}
+}
+
+func (ih *IdentityHandler) Providers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", ih.s.Providers()))
+	}
+}
+
+// Start answers with the provider URL the frontend has to send the browser
+// to. The provider redirects back to the frontend, which hands code and state
+// to Callback.
+func (ih *IdentityHandler) Start() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := ih.s.Start(c.Request().Context(), c.Param("provider"), "")
+
+		if err != nil {
+			c.Logger().Error("handler: start external login process error:", err.Error())
+			return c.JSON(startErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", StartResponse{RedirectTo: result}))
+	}
+}
+
+func (ih *IdentityHandler) Callback() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(CallbackInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := ih.s.Callback(c.Request().Context(), c.Param("provider"), input.State, input.Code)
+
+		if err != nil {
+			c.Logger().Error("handler: external login callback process error:", err.Error())
+			switch {
+			case strings.Contains(err.Error(), "provider not found"):
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			case strings.Contains(err.Error(), "invalid"):
+				return c.JSON(http.StatusUnauthorized, helper.FormatResponse("fail", nil))
+			case strings.Contains(err.Error(), "already linked"):
+				return c.JSON(http.StatusConflict, helper.FormatResponse("identity already linked", nil))
+			case strings.Contains(err.Error(), "signup disabled"):
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("signup disabled", nil))
+			case strings.Contains(err.Error(), "deactivated"):
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("account deactivated", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = new(CallbackResponse)
+		response.Identity = identityResponse(*result.Identity)
+		response.Created = result.Created
+		if result.Credential != nil {
+			response.Nama = result.Credential.Nama
+			if tokens, csrfToken := helper.DeliverTokens(c, result.Credential.Access); tokens != nil {
+				response.Token = tokens
+			} else {
+				response.CSRFToken = csrfToken
+			}
+			response.MFARequired = result.Credential.MFARequired
+			response.MFAToken = result.Credential.MFAToken
+			response.StepUpRequired = result.Credential.StepUpRequired
+			response.StepUpToken = result.Credential.StepUpToken
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (ih *IdentityHandler) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := ih.s.List(c.Request().Context(), principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: list identities process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = make([]IdentityResponse, 0, len(result))
+		for _, identity := range result {
+			response = append(response, *identityResponse(identity))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+// Link starts the same flow as Start, its callback adds the identity to the
+// logged in user.
+func (ih *IdentityHandler) Link() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(LinkInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := ih.s.Start(c.Request().Context(), input.Provider, principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: link identity process error:", err.Error())
+			return c.JSON(startErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", StartResponse{RedirectTo: result}))
+	}
+}
+
+func (ih *IdentityHandler) Unlink() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := ih.s.Unlink(c.Request().Context(), principal.UserID, c.Param("id")); err != nil {
+			c.Logger().Error("handler: unlink identity process error:", err.Error())
+			switch {
+			case strings.Contains(err.Error(), "not found"):
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			case strings.Contains(err.Error(), "last login method"):
+				return c.JSON(http.StatusConflict, helper.FormatResponse("last login method", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func identityResponse(identity identities.Identity) *IdentityResponse {
+	var response = new(IdentityResponse)
+	response.ID = identity.ID
+	response.Provider = identity.Provider
+	response.Email = identity.Email
+	response.Name = identity.Name
+	response.CreatedAt = identity.CreatedAt
+	response.LastLoginAt = identity.LastLoginAt
+
+	return response
+}
+
+func startErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "deactivated"):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "unavailable"):
+		return http.StatusBadGateway
+	}
+
+	return http.StatusInternalServerError
+}
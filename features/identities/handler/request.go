@@ -0,0 +1,10 @@
+package handler
+
+type CallbackInput struct {
+	Code  string `json:"code" form:"code"`
+	State string `json:"state" form:"state"`
+}
+
+type LinkInput struct {
+	Provider string `json:"provider"`
+}
@@ -0,0 +1,30 @@
+package handler
+
+import "time"
+
+type StartResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+type IdentityResponse struct {
+	ID          string     `json:"id"`
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email,omitempty"`
+	Name        string     `json:"name,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// CallbackResponse carries the same fields as the response to a password
+// login, plus the identity the login went through.
+type CallbackResponse struct {
+	Nama           string            `json:"nama,omitempty"`
+	Token          any               `json:"token,omitempty"`
+	CSRFToken      string            `json:"csrf_token,omitempty"`
+	MFARequired    bool              `json:"mfa_required,omitempty"`
+	MFAToken       string            `json:"mfa_token,omitempty"`
+	StepUpRequired bool              `json:"step_up_required,omitempty"`
+	StepUpToken    string            `json:"step_up_token,omitempty"`
+	Created        bool              `json:"created,omitempty"`
+	Identity       *IdentityResponse `json:"identity"`
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+	"test/features/audit"
+	"test/features/identities"
+	"test/features/users"
+	"test/helper"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const stateTTL = time.Minute * 10
+
+type IdentityService struct {
+	d         identities.IdentityDataInterface
+	u         users.UserDataInterface
+	us        users.UserServiceInterface
+	providers map[string]helper.OIDCProviderInterface
+	a         audit.AuditServiceInterface
+	g         helper.GeneratorInterface
+	c         helper.ClockInterface
+}
+
+func New(data identities.IdentityDataInterface, userData users.UserDataInterface, userService users.UserServiceInterface, providers map[string]helper.OIDCProviderInterface, auditor audit.AuditServiceInterface, generator helper.GeneratorInterface, clock helper.ClockInterface) identities.IdentityServiceInterface {
+	return &IdentityService{
+		d:         data,
+		u:         userData,
+		us:        userService,
+		providers: providers,
+		a:         auditor,
+		g:         generator,
+		c:         clock,
+	}
+}
+
+func (is *IdentityService) Providers() []string {
+	var result = make([]string, 0, len(is.providers))
+	for name := range is.providers {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// Start returns the URL the browser has to be sent to. With a userID the
+// callback links the identity to that user instead of logging in.
+func (is *IdentityService) Start(ctx context.Context, provider string, userID string) (string, error) {
+	p, found := is.providers[provider]
+	if !found {
+		return "", errors.New("provider not found")
+	}
+
+	if userID != "" {
+		if _, err := is.activeUser(ctx, userID); err != nil {
+			return "", err
+		}
+	}
+
+	state, errState := randomToken()
+	nonce, errNonce := randomToken()
+	verifier, errVerifier := randomToken()
+	if errState != nil || errNonce != nil || errVerifier != nil {
+		return "", errors.New("state process failed")
+	}
+
+	var now = is.c.Now()
+	var newData = new(identities.LoginState)
+	newData.StateHash = helper.HashToken(state)
+	newData.Provider = provider
+	newData.Nonce = nonce
+	newData.CodeVerifier = verifier
+	newData.UserID = userID
+	newData.ExpiresAt = now.Add(stateTTL)
+	newData.CreatedAt = now
+
+	if err := is.d.InsertState(*newData); err != nil {
+		return "", errors.New("insert process failed")
+	}
+
+	result, err := p.AuthCodeURL(state, nonce, pkceChallenge(verifier))
+	if err != nil {
+		logrus.Error("service: provider discovery error:", err.Error())
+		return "", errors.New("provider unavailable")
+	}
+
+	return result, nil
+}
+
+// Callback completes the flow Start began. Logins of an identity that is not
+// linked yet create a new account, unless the provider config says otherwise.
+func (is *IdentityService) Callback(ctx context.Context, provider string, state string, code string) (*identities.CallbackResult, error) {
+	p, found := is.providers[provider]
+	if !found {
+		return nil, errors.New("provider not found")
+	}
+
+	if state == "" || code == "" {
+		return nil, errors.New("invalid request")
+	}
+
+	loginState, err := is.d.TakeState(helper.HashToken(state))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("invalid state")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	var now = is.c.Now()
+	if loginState.Provider != provider || now.After(loginState.ExpiresAt) {
+		return nil, errors.New("invalid state")
+	}
+
+	external, err := p.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		logrus.Error("service: provider exchange error:", err.Error())
+		return nil, errors.New("invalid authorization")
+	}
+
+	identity, err := is.d.GetBySubject(provider, external.Subject)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return nil, errors.New("process failed")
+	}
+
+	if loginState.UserID != "" {
+		return is.link(ctx, loginState.UserID, provider, external, identity, now)
+	}
+
+	return is.login(ctx, p.Config(), external, identity, now)
+}
+
+func (is *IdentityService) List(ctx context.Context, userID string) ([]identities.Identity, error) {
+	result, err := is.d.GetByUser(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+// Unlink refuses to remove the last identity of an account without a
+// password, nothing would be left to log in with.
+func (is *IdentityService) Unlink(ctx context.Context, userID string, identityID string) error {
+	user, err := is.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	linked, err := is.d.GetByUser(userID)
+	if err != nil {
+		return errors.New("process failed")
+	}
+
+	var identity *identities.Identity
+	for i := range linked {
+		if linked[i].ID == identityID {
+			identity = &linked[i]
+		}
+	}
+	if identity == nil {
+		return errors.New("data not found")
+	}
+
+	if user.Password == "" && len(linked) == 1 {
+		return errors.New("last login method")
+	}
+
+	if err := is.d.Delete(userID, identityID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("delete process failed")
+	}
+
+	is.audit(ctx, audit.Event{ActorID: userID, Action: "identity.unlinked", TargetID: userID, Before: map[string]any{"provider": identity.Provider, "subject": identity.Subject}})
+
+	return nil
+}
+
+func (is *IdentityService) link(ctx context.Context, userID string, provider string, external *helper.ExternalIdentity, identity *identities.Identity, now time.Time) (*identities.CallbackResult, error) {
+	if identity != nil {
+		if identity.UserID != userID {
+			return nil, errors.New("identity already linked")
+		}
+		return &identities.CallbackResult{Identity: identity}, nil
+	}
+
+	if _, err := is.activeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	identity, err := is.insertIdentity(ctx, userID, provider, external, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identities.CallbackResult{Identity: identity}, nil
+}
+
+func (is *IdentityService) login(ctx context.Context, provider helper.ExternalProvider, external *helper.ExternalIdentity, identity *identities.Identity, now time.Time) (*identities.CallbackResult, error) {
+	if identity != nil {
+		// The account behind the identity was deleted or erased, the identity
+		// can be treated as unknown again. An account outside the organization
+		// is not a deleted one, LoginExternal refuses it.
+		user, err := is.getUser(context.Background(), identity.UserID)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return nil, err
+		}
+		if err != nil || user.AnonymizedAt != nil {
+			if err := is.d.Delete(identity.UserID, identity.ID); err != nil {
+				return nil, errors.New("delete process failed")
+			}
+			identity = nil
+		}
+	}
+
+	var created bool
+	if identity == nil {
+		user, isNew, err := is.resolveUser(ctx, provider, external)
+		if err != nil {
+			return nil, err
+		}
+		created = isNew
+
+		identity, err = is.insertIdentity(ctx, user.ID, provider.Name, external, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := is.d.UpdateLastLogin(identity.ID, now); err != nil {
+		logrus.Error("service: update identity last login error:", err.Error())
+	}
+	identity.LastLoginAt = &now
+
+	credential, err := is.us.LoginExternal(ctx, identity.UserID, provider.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identities.CallbackResult{Credential: credential, Identity: identity, Created: created}, nil
+}
+
+// resolveUser finds the account for an identity seen for the first time. An
+// existing account is only taken over when both sides verified the same HP,
+// anything weaker would let whoever controls the provider account in.
+func (is *IdentityService) resolveUser(ctx context.Context, provider helper.ExternalProvider, external *helper.ExternalIdentity) (*users.User, bool, error) {
+	if provider.LinkVerifiedPhone && external.PhoneNumberVerified && external.PhoneNumber != "" {
+		existing, err := is.u.GetByHP(external.PhoneNumber)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return nil, false, errors.New("process failed")
+		}
+		if existing != nil && existing.HPVerifiedAt != nil {
+			return existing, false, nil
+		}
+	}
+
+	if provider.DisableSignup {
+		return nil, false, errors.New("signup disabled")
+	}
+
+	newID, err := is.g.GenerateUUID()
+	if err != nil {
+		return nil, false, errors.New("id generator failed")
+	}
+
+	var newData = new(users.User)
+	newData.ID = newID
+	newData.Nama = external.Name
+	if newData.Nama == "" {
+		newData.Nama = external.Email
+	}
+	newData.Role = users.RoleUser
+
+	result, err := is.u.Insert(*newData)
+	if err != nil {
+		return nil, false, errors.New("insert process failed")
+	}
+
+	is.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.registered", TargetID: result.ID, After: map[string]any{"role": newData.Role, "provider": provider.Name}})
+
+	return result, true, nil
+}
+
+func (is *IdentityService) insertIdentity(ctx context.Context, userID string, provider string, external *helper.ExternalIdentity, now time.Time) (*identities.Identity, error) {
+	identityID, err := is.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	var newData = new(identities.Identity)
+	newData.ID = identityID
+	newData.UserID = userID
+	newData.Provider = provider
+	newData.Subject = external.Subject
+	newData.Email = external.Email
+	newData.Name = external.Name
+	newData.CreatedAt = now
+
+	if err := is.d.Insert(*newData); err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	is.audit(ctx, audit.Event{ActorID: userID, Action: "identity.linked", TargetID: userID, After: map[string]any{"provider": provider, "subject": external.Subject}})
+
+	return newData, nil
+}
+
+func (is *IdentityService) activeUser(ctx context.Context, userID string) (*users.User, error) {
+	result, err := is.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.DeactivatedAt != nil {
+		return nil, errors.New("account deactivated")
+	}
+
+	return result, nil
+}
+
+func (is *IdentityService) getUser(ctx context.Context, userID string) (*users.User, error) {
+	result, err := is.u.WithContext(ctx).GetByID(userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (is *IdentityService) audit(ctx context.Context, event audit.Event) {
+	if err := is.a.Record(ctx, event); err != nil {
+		logrus.Error("service: audit record error:", err.Error())
+	}
+}
+
+func randomToken() (string, error) {
+	var raw = make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func pkceChallenge(verifier string) string {
+	var sum = sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"test/features/audit"
+	auditMocks "test/features/audit/mocks"
+	"test/features/identities"
+	"test/features/identities/mocks"
+	"test/features/users"
+	userMocks "test/features/users/mocks"
+	realHelper "test/helper"
+	helper "test/helper/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStart(t *testing.T) {
+	data := mocks.NewIdentityDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	provider := helper.NewOIDCProviderInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, userMocks.NewUserServiceInterface(t), map[string]realHelper.OIDCProviderInterface{"google": provider}, auditMocks.NewAuditServiceInterface(t), helper.NewGeneratorInterface(t), clock).(*IdentityService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Success login", func(t *testing.T) {
+		var inserted identities.LoginState
+		clock.On("Now").Return(now).Once()
+		data.On("InsertState", mock.MatchedBy(func(s identities.LoginState) bool {
+			inserted = s
+			return s.Provider == "google" && s.UserID == "" && s.Nonce != "" && s.CodeVerifier != "" && s.ExpiresAt.Equal(now.Add(stateTTL))
+		})).Return(nil).Once()
+		provider.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything).Return(func(state string, nonce string, challenge string) (string, error) {
+			assert.Equal(t, inserted.StateHash, realHelper.HashToken(state))
+			assert.Equal(t, inserted.Nonce, nonce)
+			assert.Equal(t, pkceChallenge(inserted.CodeVerifier), challenge)
+			return "https://accounts.example.com/authorize?state=" + state, nil
+		}).Once()
+
+		result, err := service.Start(context.Background(), "google", "")
+		assert.Nil(t, err)
+		assert.Contains(t, result, "https://accounts.example.com/authorize")
+	})
+
+	t.Run("Unknown provider", func(t *testing.T) {
+		result, err := service.Start(context.Background(), "github", "")
+		assert.EqualError(t, err, "provider not found")
+		assert.Empty(t, result)
+	})
+
+	t.Run("Link for deactivated user", func(t *testing.T) {
+		userData.On("GetByID", "randomUserID").Return(&users.User{ID: "randomUserID", DeactivatedAt: &now}, nil).Once()
+
+		result, err := service.Start(context.Background(), "google", "randomUserID")
+		assert.EqualError(t, err, "account deactivated")
+		assert.Empty(t, result)
+	})
+}
+
+func TestCallback(t *testing.T) {
+	data := mocks.NewIdentityDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	userService := userMocks.NewUserServiceInterface(t)
+	provider := helper.NewOIDCProviderInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, userService, map[string]realHelper.OIDCProviderInterface{"google": provider}, auditor, generator, clock).(*IdentityService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	stateHash := realHelper.HashToken("randomState")
+	loginState := identities.LoginState{StateHash: stateHash, Provider: "google", Nonce: "randomNonce", CodeVerifier: "randomVerifier", ExpiresAt: now.Add(stateTTL), CreatedAt: now}
+	external := realHelper.ExternalIdentity{Subject: "externalSubject", Email: "dida@example.com", Name: "dida", PhoneNumber: "123", PhoneNumberVerified: true}
+	identity := identities.Identity{ID: "randomIdentityID", UserID: "randomUserID", Provider: "google", Subject: "externalSubject", CreatedAt: now.Add(-time.Hour)}
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", HPVerifiedAt: &now, Role: users.RoleUser}
+	credential := users.UserCredential{Nama: "dida", Access: map[string]any{"access_token": "randomAccessToken"}}
+
+	t.Run("Login with linked identity", func(t *testing.T) {
+		data.On("TakeState", stateHash).Return(&loginState, nil).Once()
+		clock.On("Now").Return(now).Once()
+		provider.On("Exchange", mock.Anything, "randomCode", "randomVerifier", "randomNonce").Return(&external, nil).Once()
+		data.On("GetBySubject", "google", "externalSubject").Return(&identity, nil).Once()
+		provider.On("Config").Return(realHelper.ExternalProvider{Name: "google"}).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		data.On("UpdateLastLogin", identity.ID, now).Return(nil).Once()
+		userService.On("LoginExternal", mock.Anything, user.ID, "google").Return(&credential, nil).Once()
+
+		result, err := service.Callback(context.Background(), "google", "randomState", "randomCode")
+		assert.Nil(t, err)
+		assert.Equal(t, &credential, result.Credential)
+		assert.False(t, result.Created)
+	})
+
+	t.Run("Unknown identity creates account", func(t *testing.T) {
+		data.On("TakeState", stateHash).Return(&loginState, nil).Once()
+		clock.On("Now").Return(now).Once()
+		provider.On("Exchange", mock.Anything, "randomCode", "randomVerifier", "randomNonce").Return(&external, nil).Once()
+		data.On("GetBySubject", "google", "externalSubject").Return(nil, errors.New("record not found")).Once()
+		provider.On("Config").Return(realHelper.ExternalProvider{Name: "google"}).Once()
+		generator.On("GenerateUUID").Return("newUserID", nil).Once()
+		userData.On("Insert", users.User{ID: "newUserID", Nama: "dida", Role: users.RoleUser}).Return(&users.User{ID: "newUserID", Nama: "dida", Role: users.RoleUser}, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "newUserID", Action: "user.registered", TargetID: "newUserID", After: map[string]any{"role": users.RoleUser, "provider": "google"}}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("newIdentityID", nil).Once()
+		data.On("Insert", identities.Identity{ID: "newIdentityID", UserID: "newUserID", Provider: "google", Subject: "externalSubject", Email: "dida@example.com", Name: "dida", CreatedAt: now}).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "newUserID", Action: "identity.linked", TargetID: "newUserID", After: map[string]any{"provider": "google", "subject": "externalSubject"}}).Return(nil).Once()
+		data.On("UpdateLastLogin", "newIdentityID", now).Return(nil).Once()
+		userService.On("LoginExternal", mock.Anything, "newUserID", "google").Return(&credential, nil).Once()
+
+		result, err := service.Callback(context.Background(), "google", "randomState", "randomCode")
+		assert.Nil(t, err)
+		assert.True(t, result.Created)
+		assert.Equal(t, "newUserID", result.Identity.UserID)
+	})
+
+	t.Run("Verified phone links existing account", func(t *testing.T) {
+		data.On("TakeState", stateHash).Return(&loginState, nil).Once()
+		clock.On("Now").Return(now).Once()
+		provider.On("Exchange", mock.Anything, "randomCode", "randomVerifier", "randomNonce").Return(&external, nil).Once()
+		data.On("GetBySubject", "google", "externalSubject").Return(nil, errors.New("record not found")).Once()
+		provider.On("Config").Return(realHelper.ExternalProvider{Name: "google", LinkVerifiedPhone: true}).Once()
+		userData.On("GetByHP", "123").Return(&user, nil).Once()
+		generator.On("GenerateUUID").Return("newIdentityID", nil).Once()
+		data.On("Insert", mock.MatchedBy(func(i identities.Identity) bool { return i.UserID == user.ID })).Return(nil).Once()
+		auditor.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool { return e.Action == "identity.linked" })).Return(nil).Once()
+		data.On("UpdateLastLogin", "newIdentityID", now).Return(nil).Once()
+		userService.On("LoginExternal", mock.Anything, user.ID, "google").Return(&credential, nil).Once()
+
+		result, err := service.Callback(context.Background(), "google", "randomState", "randomCode")
+		assert.Nil(t, err)
+		assert.False(t, result.Created)
+		assert.Equal(t, user.ID, result.Identity.UserID)
+	})
+
+	t.Run("Signup disabled", func(t *testing.T) {
+		data.On("TakeState", stateHash).Return(&loginState, nil).Once()
+		clock.On("Now").Return(now).Once()
+		provider.On("Exchange", mock.Anything, "randomCode", "randomVerifier", "randomNonce").Return(&external, nil).Once()
+		data.On("GetBySubject", "google", "externalSubject").Return(nil, errors.New("record not found")).Once()
+		provider.On("Config").Return(realHelper.ExternalProvider{Name: "google", DisableSignup: true}).Once()
+
+		result, err := service.Callback(context.Background(), "google", "randomState", "randomCode")
+		assert.EqualError(t, err, "signup disabled")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Identity of deleted account is released", func(t *testing.T) {
+		data.On("TakeState", stateHash).Return(&loginState, nil).Once()
+		clock.On("Now").Return(now).Once()
+		provider.On("Exchange", mock.Anything, "randomCode", "randomVerifier", "randomNonce").Return(&external, nil).Once()
+		data.On("GetBySubject", "google", "externalSubject").Return(&identity, nil).Once()
+		userData.On("GetByID", user.ID).Return(nil, errors.New("record not found")).Once()
+		data.On("Delete", user.ID, identity.ID).Return(nil).Once()
+		provider.On("Config").Return(realHelper.ExternalProvider{Name: "google", DisableSignup: true}).Once()
+
+		result, err := service.Callback(context.Background(), "google", "randomState", "randomCode")
+		assert.EqualError(t, err, "signup disabled")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Identity of erased account is released", func(t *testing.T) {
+		anonymized := user
+		anonymized.AnonymizedAt = &now
+		data.On("TakeState", stateHash).Return(&loginState, nil).Once()
+		clock.On("Now").Return(now).Once()
+		provider.On("Exchange", mock.Anything, "randomCode", "randomVerifier", "randomNonce").Return(&external, nil).Once()
+		data.On("GetBySubject", "google", "externalSubject").Return(&identity, nil).Once()
+		userData.On("GetByID", user.ID).Return(&anonymized, nil).Once()
+		data.On("Delete", user.ID, identity.ID).Return(nil).Once()
+		provider.On("Config").Return(realHelper.ExternalProvider{Name: "google", DisableSignup: true}).Once()
+
+		result, err := service.Callback(context.Background(), "google", "randomState", "randomCode")
+		assert.EqualError(t, err, "signup disabled")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Link to logged in user", func(t *testing.T) {
+		linkState := loginState
+		linkState.UserID = user.ID
+		data.On("TakeState", stateHash).Return(&linkState, nil).Once()
+		clock.On("Now").Return(now).Once()
+		provider.On("Exchange", mock.Anything, "randomCode", "randomVerifier", "randomNonce").Return(&external, nil).Once()
+		data.On("GetBySubject", "google", "externalSubject").Return(nil, errors.New("record not found")).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		generator.On("GenerateUUID").Return("newIdentityID", nil).Once()
+		data.On("Insert", mock.MatchedBy(func(i identities.Identity) bool { return i.ID == "newIdentityID" && i.UserID == user.ID })).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: user.ID, Action: "identity.linked", TargetID: user.ID, After: map[string]any{"provider": "google", "subject": "externalSubject"}}).Return(nil).Once()
+
+		result, err := service.Callback(context.Background(), "google", "randomState", "randomCode")
+		assert.Nil(t, err)
+		assert.Nil(t, result.Credential)
+		assert.Equal(t, "newIdentityID", result.Identity.ID)
+	})
+
+	t.Run("Link identity of another user", func(t *testing.T) {
+		linkState := loginState
+		linkState.UserID = "otherUserID"
+		data.On("TakeState", stateHash).Return(&linkState, nil).Once()
+		clock.On("Now").Return(now).Once()
+		provider.On("Exchange", mock.Anything, "randomCode", "randomVerifier", "randomNonce").Return(&external, nil).Once()
+		data.On("GetBySubject", "google", "externalSubject").Return(&identity, nil).Once()
+
+		result, err := service.Callback(context.Background(), "google", "randomState", "randomCode")
+		assert.EqualError(t, err, "identity already linked")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Unknown state", func(t *testing.T) {
+		data.On("TakeState", stateHash).Return(nil, errors.New("record not found")).Once()
+
+		result, err := service.Callback(context.Background(), "google", "randomState", "randomCode")
+		assert.EqualError(t, err, "invalid state")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Expired state", func(t *testing.T) {
+		data.On("TakeState", stateHash).Return(&loginState, nil).Once()
+		clock.On("Now").Return(now.Add(stateTTL + time.Second)).Once()
+
+		result, err := service.Callback(context.Background(), "google", "randomState", "randomCode")
+		assert.EqualError(t, err, "invalid state")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Provider rejects code", func(t *testing.T) {
+		data.On("TakeState", stateHash).Return(&loginState, nil).Once()
+		clock.On("Now").Return(now).Once()
+		provider.On("Exchange", mock.Anything, "randomCode", "randomVerifier", "randomNonce").Return(nil, errors.New("invalid id token")).Once()
+
+		result, err := service.Callback(context.Background(), "google", "randomState", "randomCode")
+		assert.EqualError(t, err, "invalid authorization")
+		assert.Nil(t, result)
+	})
+}
+
+func TestUnlink(t *testing.T) {
+	data := mocks.NewIdentityDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	service := New(data, userData, userMocks.NewUserServiceInterface(t), map[string]realHelper.OIDCProviderInterface{"google": helper.NewOIDCProviderInterface(t)}, auditor, helper.NewGeneratorInterface(t), helper.NewClockInterface(t)).(*IdentityService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	identity := identities.Identity{ID: "randomIdentityID", UserID: "randomUserID", Provider: "google", Subject: "externalSubject"}
+
+	t.Run("Success", func(t *testing.T) {
+		userData.On("GetByID", "randomUserID").Return(&users.User{ID: "randomUserID", Password: "$2a$10$hash"}, nil).Once()
+		data.On("GetByUser", "randomUserID").Return([]identities.Identity{identity}, nil).Once()
+		data.On("Delete", "randomUserID", identity.ID).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "randomUserID", Action: "identity.unlinked", TargetID: "randomUserID", Before: map[string]any{"provider": "google", "subject": "externalSubject"}}).Return(nil).Once()
+
+		err := service.Unlink(context.Background(), "randomUserID", identity.ID)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Last login method", func(t *testing.T) {
+		userData.On("GetByID", "randomUserID").Return(&users.User{ID: "randomUserID"}, nil).Once()
+		data.On("GetByUser", "randomUserID").Return([]identities.Identity{identity}, nil).Once()
+
+		err := service.Unlink(context.Background(), "randomUserID", identity.ID)
+		assert.EqualError(t, err, "last login method")
+	})
+
+	t.Run("Identity of another user", func(t *testing.T) {
+		userData.On("GetByID", "randomUserID").Return(&users.User{ID: "randomUserID"}, nil).Once()
+		data.On("GetByUser", "randomUserID").Return([]identities.Identity{}, nil).Once()
+
+		err := service.Unlink(context.Background(), "randomUserID", identity.ID)
+		assert.EqualError(t, err, "data not found")
+	})
+}
@@ -0,0 +1,15 @@
+package data
+
+import "time"
+
+type Invitation struct {
+	ID         string `gorm:"type:varchar(255);primaryKey"`
+	HP         string `gorm:"type:varchar(255);index"`
+	Role       string `gorm:"type:varchar(32)"`
+	InvitedBy  string `gorm:"type:varchar(255)"`
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	UserID     string `gorm:"type:varchar(255)"`
+	AcceptedAt *time.Time
+	RevokedAt  *time.Time
+}
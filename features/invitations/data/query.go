@@ -0,0 +1,114 @@
+package data
+
+import (
+	"errors"
+	"test/features/invitations"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type InvitationData struct {
+	gorm *gorm.DB
+}
+
+func New(g *gorm.DB) invitations.InvitationDataInterface {
+	return &InvitationData{
+		gorm: g,
+	}
+}
+
+func (ivd *InvitationData) Insert(newData invitations.Invitation) (*invitations.Invitation, error) {
+	var dbData = new(Invitation)
+	dbData.ID = newData.ID
+	dbData.HP = newData.HP
+	dbData.Role = newData.Role
+	dbData.InvitedBy = newData.InvitedBy
+	dbData.ExpiresAt = newData.ExpiresAt
+	dbData.CreatedAt = newData.CreatedAt
+
+	if err := ivd.gorm.Create(dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return &newData, nil
+}
+
+func (ivd *InvitationData) GetByID(invitationID string) (*invitations.Invitation, error) {
+	var dbData = new(Invitation)
+
+	if err := ivd.gorm.Where("id = ?", invitationID).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toInvitation(dbData), nil
+}
+
+func (ivd *InvitationData) List() ([]invitations.Invitation, error) {
+	var dbData = []Invitation{}
+
+	if err := ivd.gorm.Order("created_at DESC").Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]invitations.Invitation, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toInvitation(&dbData[i]))
+	}
+
+	return result, nil
+}
+
+// Revoke and Accept only change invitations that are still open, so an
+// invitation cannot be accepted after it was revoked or the other way round.
+func (ivd *InvitationData) Revoke(invitationID string, revokedAt time.Time) error {
+	var qry = ivd.gorm.Model(&Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID).
+		Update("revoked_at", revokedAt)
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (ivd *InvitationData) Accept(invitationID string, userID string, acceptedAt time.Time) error {
+	var qry = ivd.gorm.Model(&Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID).
+		Updates(map[string]any{
+			"user_id":     userID,
+			"accepted_at": acceptedAt,
+		})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func toInvitation(dbData *Invitation) *invitations.Invitation {
+	var result = new(invitations.Invitation)
+	result.ID = dbData.ID
+	result.HP = dbData.HP
+	result.Role = dbData.Role
+	result.InvitedBy = dbData.InvitedBy
+	result.ExpiresAt = dbData.ExpiresAt
+	result.CreatedAt = dbData.CreatedAt
+	result.UserID = dbData.UserID
+	result.AcceptedAt = dbData.AcceptedAt
+	result.RevokedAt = dbData.RevokedAt
+
+	return result
+}
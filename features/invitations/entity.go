@@ -0,0 +1,65 @@
+package invitations
+
+import (
+	"context"
+	"test/features/users"
+	"test/helper"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusRevoked  = "revoked"
+	StatusExpired  = "expired"
+)
+
+type Invitation struct {
+	ID        string
+	HP        string
+	Role      string
+	InvitedBy string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	// UserID is the account that was registered by accepting the invitation.
+	UserID     string
+	AcceptedAt *time.Time
+	RevokedAt  *time.Time
+	// Token is the signed invitation token, only set on the result of Create.
+	Token string
+}
+
+// Status is the state of the invitation at now.
+func (i Invitation) Status(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return StatusAccepted
+	case i.RevokedAt != nil:
+		return StatusRevoked
+	case !now.Before(i.ExpiresAt):
+		return StatusExpired
+	}
+	return StatusPending
+}
+
+type InvitationHandlerInterface interface {
+	Create() echo.HandlerFunc
+	List() echo.HandlerFunc
+	Revoke() echo.HandlerFunc
+	Accept() echo.HandlerFunc
+}
+type InvitationServiceInterface interface {
+	Create(ctx context.Context, actor helper.Principal, hp string, role string, expiresAt *time.Time) (*Invitation, error)
+	List(ctx context.Context) ([]Invitation, error)
+	Revoke(ctx context.Context, actorID string, invitationID string) error
+	Accept(ctx context.Context, token string, nama string, password string) (*users.User, error)
+}
+type InvitationDataInterface interface {
+	Insert(newData Invitation) (*Invitation, error)
+	GetByID(id string) (*Invitation, error)
+	List() ([]Invitation, error)
+	Revoke(id string, revokedAt time.Time) error
+	Accept(id string, userID string, acceptedAt time.Time) error
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"test/features/invitations"
+	"test/features/users"
+	"test/helper"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InvitationHandler struct {
+	s invitations.InvitationServiceInterface
+}
+
+func NewHandler(service invitations.InvitationServiceInterface) invitations.InvitationHandlerInterface {
+	return &InvitationHandler{
+		s: service,
+	}
+}
+
+func (ih *InvitationHandler) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(CreateInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if input.Role == "" {
+			input.Role = users.RoleUser
+		}
+
+		result, err := ih.s.Create(c.Request().Context(), *principal, input.HP, input.Role, input.ExpiresAt)
+
+		if err != nil {
+			c.Logger().Error("handler: create invitation process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		var response = invitationResponse(*result, time.Now())
+		response.Token = result.Token
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", response))
+	}
+}
+
+func (ih *InvitationHandler) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := ih.s.List(c.Request().Context())
+
+		if err != nil {
+			c.Logger().Error("handler: list invitations process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var now = time.Now()
+		var status = c.QueryParam("status")
+		var response = make([]InvitationResponse, 0, len(result))
+		for _, invitation := range result {
+			if status != "" && invitation.Status(now) != status {
+				continue
+			}
+			response = append(response, *invitationResponse(invitation, now))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (ih *InvitationHandler) Revoke() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := ih.s.Revoke(c.Request().Context(), principal.UserID, c.Param("id")); err != nil {
+			c.Logger().Error("handler: revoke invitation process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (ih *InvitationHandler) Accept() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(AcceptInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := ih.s.Accept(c.Request().Context(), input.Token, input.Nama, input.Password)
+
+		if err != nil {
+			c.Logger().Error("handler: accept invitation process error:", err.Error())
+			var policyErr *helper.PasswordPolicyError
+			if errors.As(err, &policyErr) {
+				return c.JSON(http.StatusBadRequest, helper.FormatResponse("invalid password", policyErr.Violations))
+			}
+			return errorResponse(c, err)
+		}
+
+		var response = new(AcceptResponse)
+		response.Nama = result.Nama
+		response.HP = result.HP
+		response.Role = result.Role
+		response.Verified = result.HPVerifiedAt != nil
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", response))
+	}
+}
+
+func errorResponse(c echo.Context, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "invalid"), strings.Contains(err.Error(), "invitation"):
+		return c.JSON(http.StatusBadRequest, helper.FormatResponse(err.Error(), nil))
+	case strings.Contains(err.Error(), "forbidden"):
+		return c.JSON(http.StatusForbidden, helper.FormatResponse("forbidden", nil))
+	case strings.Contains(err.Error(), "not found"):
+		return c.JSON(http.StatusNotFound, helper.FormatResponse(err.Error(), nil))
+	case strings.Contains(err.Error(), "already"):
+		return c.JSON(http.StatusConflict, helper.FormatResponse(err.Error(), nil))
+	}
+	return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+}
+
+func invitationResponse(invitation invitations.Invitation, now time.Time) *InvitationResponse {
+	var response = new(InvitationResponse)
+	response.ID = invitation.ID
+	response.HP = invitation.HP
+	response.Role = invitation.Role
+	response.Status = invitation.Status(now)
+	response.InvitedBy = invitation.InvitedBy
+	response.ExpiresAt = invitation.ExpiresAt
+	response.CreatedAt = invitation.CreatedAt
+	response.UserID = invitation.UserID
+	response.AcceptedAt = invitation.AcceptedAt
+	response.RevokedAt = invitation.RevokedAt
+
+	return response
+}
@@ -0,0 +1,15 @@
+package handler
+
+import "time"
+
+type CreateInput struct {
+	HP        string     `json:"hp"`
+	Role      string     `json:"role"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type AcceptInput struct {
+	Token    string `json:"token"`
+	Nama     string `json:"nama"`
+	Password string `json:"password"`
+}
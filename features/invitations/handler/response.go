@@ -0,0 +1,25 @@
+package handler
+
+import "time"
+
+type InvitationResponse struct {
+	ID         string     `json:"id"`
+	HP         string     `json:"hp"`
+	Role       string     `json:"role"`
+	Status     string     `json:"status"`
+	InvitedBy  string     `json:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UserID     string     `json:"user_id,omitempty"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// Token is only returned once, in the response to the create request.
+	Token string `json:"token,omitempty"`
+}
+
+type AcceptResponse struct {
+	Nama     string `json:"nama"`
+	HP       string `json:"hp"`
+	Role     string `json:"role"`
+	Verified bool   `json:"verified"`
+}
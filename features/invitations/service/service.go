@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"test/features/audit"
+	"test/features/invitations"
+	"test/features/users"
+	"test/helper"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	invitationPurpose = "invitation"
+	defaultTTL        = time.Hour * 24 * 7
+	maxTTL            = time.Hour * 24 * 30
+	invitationMessage = "You are invited to Restfull, use this token to set up your account: %s"
+)
+
+type InvitationService struct {
+	d  invitations.InvitationDataInterface
+	u  users.UserDataInterface
+	us users.UserServiceInterface
+	j  helper.JWTInterface
+	n  helper.NotifierInterface
+	a  audit.AuditServiceInterface
+	g  helper.GeneratorInterface
+	c  helper.ClockInterface
+}
+
+func New(data invitations.InvitationDataInterface, userData users.UserDataInterface, userService users.UserServiceInterface, jwt helper.JWTInterface, notifier helper.NotifierInterface, auditor audit.AuditServiceInterface, generator helper.GeneratorInterface, clock helper.ClockInterface) invitations.InvitationServiceInterface {
+	return &InvitationService{
+		d:  data,
+		u:  userData,
+		us: userService,
+		j:  jwt,
+		n:  notifier,
+		a:  auditor,
+		g:  generator,
+		c:  clock,
+	}
+}
+
+// Create returns the invitation with its signed token in Token, the token is
+// also sent to the HP. Only the invitation is stored, the token is verified by
+// its signature and carries the invitation ID, so revoking the invitation
+// invalidates it. Only admins can invite admins, holding the permission to
+// invite through a group is not enough.
+func (is *InvitationService) Create(ctx context.Context, actor helper.Principal, hp string, role string, expiresAt *time.Time) (*invitations.Invitation, error) {
+	hp = strings.TrimSpace(hp)
+	if hp == "" {
+		return nil, errors.New("invalid hp")
+	}
+
+	if role != users.RoleUser && role != users.RoleAdmin {
+		return nil, errors.New("invalid role")
+	}
+
+	if role == users.RoleAdmin && !actor.HasRole(users.RoleAdmin) {
+		return nil, errors.New("forbidden")
+	}
+
+	var now = is.c.Now()
+	var expiry = now.Add(defaultTTL)
+	if expiresAt != nil {
+		if !expiresAt.After(now) || expiresAt.Sub(now) > maxTTL {
+			return nil, errors.New("invalid expiry")
+		}
+		expiry = *expiresAt
+	}
+
+	if _, err := is.u.GetByHP(hp); err == nil {
+		return nil, errors.New("hp already used")
+	} else if !strings.Contains(err.Error(), "not found") {
+		return nil, errors.New("process failed")
+	}
+
+	newID, err := is.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	var newData = new(invitations.Invitation)
+	newData.ID = newID
+	newData.HP = hp
+	newData.Role = role
+	newData.InvitedBy = actor.UserID
+	newData.ExpiresAt = expiry
+	newData.CreatedAt = now
+
+	token := is.j.GenerateChallengeToken(newID, invitationPurpose, expiry.Sub(now))
+	if token == "" {
+		return nil, errors.New("token process failed")
+	}
+
+	result, err := is.d.Insert(*newData)
+	if err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	is.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "invitation.created", TargetID: result.ID, After: map[string]any{"hp": hp, "role": role, "expires_at": expiry}})
+
+	// The token is in the response as well, a failed delivery can be handed
+	// over by the admin.
+	if err := is.n.Send(hp, fmt.Sprintf(invitationMessage, token)); err != nil {
+		logrus.Error("service: send invitation error:", err.Error())
+	}
+
+	result.Token = token
+
+	return result, nil
+}
+
+// List includes accepted, revoked and expired invitations.
+func (is *InvitationService) List(ctx context.Context) ([]invitations.Invitation, error) {
+	result, err := is.d.List()
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (is *InvitationService) Revoke(ctx context.Context, actorID string, invitationID string) error {
+	if err := is.d.Revoke(invitationID, is.c.Now()); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("update process failed")
+	}
+
+	is.audit(ctx, audit.Event{ActorID: actorID, Action: "invitation.revoked", TargetID: invitationID})
+
+	return nil
+}
+
+// Accept registers the invited account. The token reached the user through
+// their HP, so the HP counts as verified like on provisioning. Registration
+// stays possible through invitations while open self-registration is closed.
+func (is *InvitationService) Accept(ctx context.Context, token string, nama string, password string) (*users.User, error) {
+	invitationID, err := is.j.ParseChallengeToken(token, invitationPurpose)
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+
+	invitation, err := is.d.GetByID(invitationID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("invalid token")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	if status := invitation.Status(is.c.Now()); status != invitations.StatusPending {
+		return nil, errors.New("invitation " + status)
+	}
+
+	if password == "" {
+		return nil, errors.New("invalid password")
+	}
+
+	result, err := is.us.Provision(ctx, invitation.InvitedBy, users.User{Nama: nama, HP: invitation.HP, Password: password})
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.Role != users.RoleUser {
+		if err := is.u.UpdateRole(result.ID, invitation.Role); err != nil {
+			return nil, errors.New("update process failed")
+		}
+		result.Role = invitation.Role
+	}
+
+	// The account exists at this point, an invitation revoked in the meantime
+	// is only logged.
+	if err := is.d.Accept(invitation.ID, result.ID, is.c.Now()); err != nil {
+		logrus.Error("service: accept invitation error:", err.Error())
+	}
+
+	is.audit(ctx, audit.Event{ActorID: result.ID, Action: "invitation.accepted", TargetID: result.ID, After: map[string]any{"invitation_id": invitation.ID, "role": invitation.Role}})
+
+	return result, nil
+}
+
+func (is *InvitationService) audit(ctx context.Context, event audit.Event) {
+	if err := is.a.Record(ctx, event); err != nil {
+		logrus.Error("service: audit record error:", err.Error())
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"test/features/audit"
+	auditMocks "test/features/audit/mocks"
+	"test/features/invitations"
+	"test/features/invitations/mocks"
+	"test/features/users"
+	userMocks "test/features/users/mocks"
+	realHelper "test/helper"
+	helper "test/helper/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreate(t *testing.T) {
+	data := mocks.NewInvitationDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	userService := userMocks.NewUserServiceInterface(t)
+	j := helper.NewJWTInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, userService, j, notifier, auditor, generator, clock)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	admin := realHelper.Principal{UserID: "adminID", Roles: []string{users.RoleAdmin}}
+
+	t.Run("Success create", func(t *testing.T) {
+		expiresAt := now.Add(time.Hour * 24 * 7)
+		expected := invitations.Invitation{ID: "randomInvitationID", HP: "0812", Role: users.RoleAdmin, InvitedBy: "adminID", ExpiresAt: expiresAt, CreatedAt: now}
+		clock.On("Now").Return(now).Once()
+		userData.On("GetByHP", "0812").Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateUUID").Return("randomInvitationID", nil).Once()
+		j.On("GenerateChallengeToken", "randomInvitationID", "invitation", time.Hour*24*7).Return("invitationToken").Once()
+		data.On("Insert", expected).Return(&expected, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "adminID", Action: "invitation.created", TargetID: "randomInvitationID", After: map[string]any{"hp": "0812", "role": users.RoleAdmin, "expires_at": expiresAt}}).Return(nil).Once()
+		notifier.On("Send", "0812", mock.MatchedBy(func(message string) bool {
+			return strings.Contains(message, "invitationToken")
+		})).Return(nil).Once()
+
+		result, err := service.Create(context.Background(), admin, " 0812 ", users.RoleAdmin, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "invitationToken", result.Token)
+		assert.Equal(t, expiresAt, result.ExpiresAt)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		result, err := service.Create(context.Background(), admin, "0812", "owner", nil)
+		assert.EqualError(t, err, "invalid role")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Only admins invite admins", func(t *testing.T) {
+		manager := realHelper.Principal{UserID: "managerID", Roles: []string{users.RoleUser}}
+
+		result, err := service.Create(context.Background(), manager, "0812", users.RoleAdmin, nil)
+		assert.EqualError(t, err, "forbidden")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Invalid expiry", func(t *testing.T) {
+		for _, expiresAt := range []time.Time{now, now.Add(time.Hour * 24 * 31)} {
+			clock.On("Now").Return(now).Once()
+
+			result, err := service.Create(context.Background(), admin, "0812", users.RoleUser, &expiresAt)
+			assert.EqualError(t, err, "invalid expiry")
+			assert.Nil(t, result)
+		}
+	})
+
+	t.Run("HP already registered", func(t *testing.T) {
+		clock.On("Now").Return(now).Once()
+		userData.On("GetByHP", "0812").Return(&users.User{ID: "existingID", HP: "0812"}, nil).Once()
+
+		result, err := service.Create(context.Background(), admin, "0812", users.RoleUser, nil)
+		assert.EqualError(t, err, "hp already used")
+		assert.Nil(t, result)
+	})
+}
+
+func TestAccept(t *testing.T) {
+	data := mocks.NewInvitationDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	userService := userMocks.NewUserServiceInterface(t)
+	j := helper.NewJWTInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, userService, j, notifier, auditor, generator, clock)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	invitation := invitations.Invitation{ID: "randomInvitationID", HP: "0812", Role: users.RoleAdmin, InvitedBy: "adminID", ExpiresAt: now.Add(time.Hour), CreatedAt: now.Add(-time.Hour)}
+
+	t.Run("Success accept", func(t *testing.T) {
+		j.On("ParseChallengeToken", "invitationToken", "invitation").Return("randomInvitationID", nil).Once()
+		data.On("GetByID", "randomInvitationID").Return(&invitation, nil).Once()
+		clock.On("Now").Return(now).Twice()
+		userService.On("Provision", mock.Anything, "adminID", users.User{Nama: "dida", HP: "0812", Password: "Rahasia#2023"}).Return(&users.User{ID: "newUserID", Nama: "dida", HP: "0812", Role: users.RoleUser, HPVerifiedAt: &now}, nil).Once()
+		userData.On("UpdateRole", "newUserID", users.RoleAdmin).Return(nil).Once()
+		data.On("Accept", "randomInvitationID", "newUserID", now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "newUserID", Action: "invitation.accepted", TargetID: "newUserID", After: map[string]any{"invitation_id": "randomInvitationID", "role": users.RoleAdmin}}).Return(nil).Once()
+
+		result, err := service.Accept(context.Background(), "invitationToken", "dida", "Rahasia#2023")
+		assert.Nil(t, err)
+		assert.Equal(t, users.RoleAdmin, result.Role)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		j.On("ParseChallengeToken", "badToken", "invitation").Return("", errors.New("token is expired")).Once()
+
+		result, err := service.Accept(context.Background(), "badToken", "dida", "Rahasia#2023")
+		assert.EqualError(t, err, "invalid token")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Revoked invitation", func(t *testing.T) {
+		revoked := invitation
+		revoked.RevokedAt = &now
+		j.On("ParseChallengeToken", "invitationToken", "invitation").Return("randomInvitationID", nil).Once()
+		data.On("GetByID", "randomInvitationID").Return(&revoked, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		result, err := service.Accept(context.Background(), "invitationToken", "dida", "Rahasia#2023")
+		assert.EqualError(t, err, "invitation revoked")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Expired invitation", func(t *testing.T) {
+		j.On("ParseChallengeToken", "invitationToken", "invitation").Return("randomInvitationID", nil).Once()
+		data.On("GetByID", "randomInvitationID").Return(&invitation, nil).Once()
+		clock.On("Now").Return(invitation.ExpiresAt).Once()
+
+		result, err := service.Accept(context.Background(), "invitationToken", "dida", "Rahasia#2023")
+		assert.EqualError(t, err, "invitation expired")
+		assert.Nil(t, result)
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	data := mocks.NewInvitationDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userMocks.NewUserDataInterface(t), userMocks.NewUserServiceInterface(t), helper.NewJWTInterface(t), helper.NewNotifierInterface(t), auditor, helper.NewGeneratorInterface(t), clock)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Success revoke", func(t *testing.T) {
+		clock.On("Now").Return(now).Once()
+		data.On("Revoke", "randomInvitationID", now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "adminID", Action: "invitation.revoked", TargetID: "randomInvitationID"}).Return(nil).Once()
+
+		assert.Nil(t, service.Revoke(context.Background(), "adminID", "randomInvitationID"))
+	})
+
+	t.Run("Already closed", func(t *testing.T) {
+		clock.On("Now").Return(now).Once()
+		data.On("Revoke", "randomInvitationID", now).Return(errors.New("data not found")).Once()
+
+		assert.EqualError(t, service.Revoke(context.Background(), "adminID", "randomInvitationID"), "data not found")
+	})
+}
@@ -0,0 +1,46 @@
+package data
+
+import "time"
+
+type OAuthClient struct {
+	ID           string `gorm:"type:varchar(255);primaryKey"`
+	OwnerID      string `gorm:"type:varchar(255);index"`
+	Name         string `gorm:"type:varchar(100)"`
+	SecretHash   string `gorm:"type:varchar(64)"`
+	RedirectURIs string `gorm:"type:text"`
+	Scopes       string `gorm:"type:varchar(255)"`
+	Confidential bool
+	CreatedAt    time.Time
+}
+
+type OAuthConsent struct {
+	UserID    string `gorm:"type:varchar(255);primaryKey"`
+	ClientID  string `gorm:"type:varchar(255);primaryKey;index"`
+	Scopes    string `gorm:"type:varchar(255)"`
+	GrantedAt time.Time
+}
+
+type OAuthCode struct {
+	CodeHash      string `gorm:"type:varchar(64);primaryKey"`
+	ClientID      string `gorm:"type:varchar(255);index"`
+	UserID        string `gorm:"type:varchar(255)"`
+	RedirectURI   string `gorm:"type:text"`
+	Scopes        string `gorm:"type:varchar(255)"`
+	CodeChallenge string `gorm:"type:varchar(128)"`
+	Nonce         string `gorm:"type:varchar(255)"`
+	GrantID       string `gorm:"type:varchar(255)"`
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	CreatedAt     time.Time
+}
+
+type OAuthGrant struct {
+	ID               string `gorm:"type:varchar(255);primaryKey"`
+	ClientID         string `gorm:"type:varchar(255);index"`
+	UserID           string `gorm:"type:varchar(255);index"`
+	Scopes           string `gorm:"type:varchar(255)"`
+	RefreshTokenHash string `gorm:"type:varchar(64)"`
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+	RevokedAt        *time.Time
+}
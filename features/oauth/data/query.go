@@ -0,0 +1,302 @@
+package data
+
+import (
+	"errors"
+	"strings"
+	"test/features/oauth"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OAuthData struct {
+	gorm *gorm.DB
+}
+
+func New(g *gorm.DB) oauth.OAuthDataInterface {
+	return &OAuthData{
+		gorm: g,
+	}
+}
+
+func (od *OAuthData) InsertClient(newData oauth.Client) (*oauth.Client, error) {
+	var dbData = new(OAuthClient)
+	dbData.ID = newData.ID
+	dbData.OwnerID = newData.OwnerID
+	dbData.Name = newData.Name
+	dbData.SecretHash = newData.SecretHash
+	dbData.RedirectURIs = strings.Join(newData.RedirectURIs, " ")
+	dbData.Scopes = strings.Join(newData.Scopes, " ")
+	dbData.Confidential = newData.Confidential
+	dbData.CreatedAt = newData.CreatedAt
+
+	if err := od.gorm.Create(dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return &newData, nil
+}
+
+func (od *OAuthData) GetClient(clientID string) (*oauth.Client, error) {
+	var dbData = new(OAuthClient)
+
+	if err := od.gorm.Where("id = ?", clientID).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toClient(dbData), nil
+}
+
+func (od *OAuthData) GetClientsByOwner(ownerID string) ([]oauth.Client, error) {
+	var dbData = []OAuthClient{}
+
+	if err := od.gorm.Where("owner_id = ?", ownerID).Order("created_at desc").Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]oauth.Client, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toClient(&dbData[i]))
+	}
+
+	return result, nil
+}
+
+// DeleteClient also drops the consents given to the client and its pending
+// codes. Its grants are revoked separately with RevokeClientGrants.
+func (od *OAuthData) DeleteClient(ownerID string, clientID string) error {
+	return od.gorm.Transaction(func(tx *gorm.DB) error {
+		var qry = tx.Where("id = ? AND owner_id = ?", clientID, ownerID).Delete(&OAuthClient{})
+
+		if err := qry.Error; err != nil {
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("data not found")
+		}
+
+		for _, model := range []any{&OAuthConsent{}, &OAuthCode{}} {
+			if err := tx.Where("client_id = ?", clientID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (od *OAuthData) GetConsent(userID string, clientID string) (*oauth.Consent, error) {
+	var dbData = new(OAuthConsent)
+
+	if err := od.gorm.Where("user_id = ? AND client_id = ?", userID, clientID).First(dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return toConsent(dbData), nil
+}
+
+func (od *OAuthData) GetConsentsByUser(userID string) ([]oauth.Consent, error) {
+	var dbData = []OAuthConsent{}
+
+	if err := od.gorm.Where("user_id = ?", userID).Order("granted_at desc").Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]oauth.Consent, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toConsent(&dbData[i]))
+	}
+
+	return result, nil
+}
+
+func (od *OAuthData) SaveConsent(newData oauth.Consent) error {
+	var dbData = new(OAuthConsent)
+	dbData.UserID = newData.UserID
+	dbData.ClientID = newData.ClientID
+	dbData.Scopes = strings.Join(newData.Scopes, " ")
+	dbData.GrantedAt = newData.GrantedAt
+
+	return od.gorm.Clauses(clause.OnConflict{UpdateAll: true}).Create(dbData).Error
+}
+
+func (od *OAuthData) InsertCode(newData oauth.AuthorizationCode) error {
+	var dbData = new(OAuthCode)
+	dbData.CodeHash = newData.CodeHash
+	dbData.ClientID = newData.ClientID
+	dbData.UserID = newData.UserID
+	dbData.RedirectURI = newData.RedirectURI
+	dbData.Scopes = strings.Join(newData.Scopes, " ")
+	dbData.CodeChallenge = newData.CodeChallenge
+	dbData.Nonce = newData.Nonce
+	dbData.ExpiresAt = newData.ExpiresAt
+	dbData.CreatedAt = newData.CreatedAt
+
+	return od.gorm.Create(dbData).Error
+}
+
+func (od *OAuthData) GetCode(codeHash string) (*oauth.AuthorizationCode, error) {
+	var dbData = new(OAuthCode)
+
+	if err := od.gorm.Where("code_hash = ?", codeHash).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	var result = new(oauth.AuthorizationCode)
+	result.CodeHash = dbData.CodeHash
+	result.ClientID = dbData.ClientID
+	result.UserID = dbData.UserID
+	result.RedirectURI = dbData.RedirectURI
+	result.Scopes = strings.Fields(dbData.Scopes)
+	result.CodeChallenge = dbData.CodeChallenge
+	result.Nonce = dbData.Nonce
+	result.GrantID = dbData.GrantID
+	result.ExpiresAt = dbData.ExpiresAt
+	result.UsedAt = dbData.UsedAt
+	result.CreatedAt = dbData.CreatedAt
+
+	return result, nil
+}
+
+// UseCode only marks a code that was not used yet, so of two concurrent
+// exchanges of the same code only one succeeds.
+func (od *OAuthData) UseCode(codeHash string, grantID string, usedAt time.Time) error {
+	var qry = od.gorm.Model(&OAuthCode{}).Where("code_hash = ? AND used_at IS NULL", codeHash).Updates(map[string]any{
+		"grant_id": grantID,
+		"used_at":  usedAt,
+	})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (od *OAuthData) InsertGrant(newData oauth.Grant) error {
+	var dbData = new(OAuthGrant)
+	dbData.ID = newData.ID
+	dbData.ClientID = newData.ClientID
+	dbData.UserID = newData.UserID
+	dbData.Scopes = strings.Join(newData.Scopes, " ")
+	dbData.RefreshTokenHash = newData.RefreshTokenHash
+	dbData.CreatedAt = newData.CreatedAt
+	dbData.ExpiresAt = newData.ExpiresAt
+
+	return od.gorm.Create(dbData).Error
+}
+
+func (od *OAuthData) GetGrant(grantID string) (*oauth.Grant, error) {
+	var dbData = new(OAuthGrant)
+
+	if err := od.gorm.Where("id = ?", grantID).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toGrant(dbData), nil
+}
+
+func (od *OAuthData) GetGrantsByUser(userID string) ([]oauth.Grant, error) {
+	var dbData = []OAuthGrant{}
+
+	if err := od.gorm.Where("user_id = ?", userID).Order("created_at desc").Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]oauth.Grant, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toGrant(&dbData[i]))
+	}
+
+	return result, nil
+}
+
+func (od *OAuthData) UpdateGrant(newData oauth.Grant) error {
+	var qry = od.gorm.Model(&OAuthGrant{}).Where("id = ? AND revoked_at IS NULL", newData.ID).Updates(map[string]any{
+		"scopes":             strings.Join(newData.Scopes, " "),
+		"refresh_token_hash": newData.RefreshTokenHash,
+		"expires_at":         newData.ExpiresAt,
+	})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (od *OAuthData) RevokeGrant(grantID string, revokedAt time.Time) error {
+	return od.gorm.Model(&OAuthGrant{}).Where("id = ? AND revoked_at IS NULL", grantID).Update("revoked_at", revokedAt).Error
+}
+
+func (od *OAuthData) RevokeClientGrants(clientID string, revokedAt time.Time) error {
+	return od.gorm.Model(&OAuthGrant{}).Where("client_id = ? AND revoked_at IS NULL", clientID).Update("revoked_at", revokedAt).Error
+}
+
+func (od *OAuthData) RevokeUser(userID string, revokedAt time.Time) error {
+	return od.gorm.Model(&OAuthGrant{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", revokedAt).Error
+}
+
+func (od *OAuthData) EraseUser(userID string) error {
+	return od.gorm.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{&OAuthGrant{}, &OAuthConsent{}, &OAuthCode{}} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func toClient(dbData *OAuthClient) *oauth.Client {
+	var result = new(oauth.Client)
+	result.ID = dbData.ID
+	result.OwnerID = dbData.OwnerID
+	result.Name = dbData.Name
+	result.SecretHash = dbData.SecretHash
+	result.RedirectURIs = strings.Fields(dbData.RedirectURIs)
+	result.Scopes = strings.Fields(dbData.Scopes)
+	result.Confidential = dbData.Confidential
+	result.CreatedAt = dbData.CreatedAt
+
+	return result
+}
+
+func toConsent(dbData *OAuthConsent) *oauth.Consent {
+	var result = new(oauth.Consent)
+	result.UserID = dbData.UserID
+	result.ClientID = dbData.ClientID
+	result.Scopes = strings.Fields(dbData.Scopes)
+	result.GrantedAt = dbData.GrantedAt
+
+	return result
+}
+
+func toGrant(dbData *OAuthGrant) *oauth.Grant {
+	var result = new(oauth.Grant)
+	result.ID = dbData.ID
+	result.ClientID = dbData.ClientID
+	result.UserID = dbData.UserID
+	result.Scopes = strings.Fields(dbData.Scopes)
+	result.RefreshTokenHash = dbData.RefreshTokenHash
+	result.CreatedAt = dbData.CreatedAt
+	result.ExpiresAt = dbData.ExpiresAt
+	result.RevokedAt = dbData.RevokedAt
+
+	return result
+}
@@ -0,0 +1,206 @@
+package oauth
+
+import (
+	"context"
+	"test/helper"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Client struct {
+	ID           string
+	OwnerID      string
+	Name         string
+	SecretHash   string
+	RedirectURIs []string
+	Scopes       []string
+	// Confidential clients can keep a secret and may use the client
+	// credentials grant, public ones (mobile and browser apps) have to use
+	// PKCE instead.
+	Confidential bool
+	CreatedAt    time.Time
+	// Secret is only set on the result of RegisterClient.
+	Secret string
+}
+
+type Consent struct {
+	UserID    string
+	ClientID  string
+	Scopes    []string
+	GrantedAt time.Time
+}
+
+type AuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        string
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	Nonce         string
+	GrantID       string
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	CreatedAt     time.Time
+}
+
+// Grant is what a user (or, for client credentials, the client's owner)
+// allowed a client. Access tokens carry its id, so revoking the grant ends
+// them together with its refresh token.
+type Grant struct {
+	ID               string
+	ClientID         string
+	UserID           string
+	Scopes           []string
+	RefreshTokenHash string
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+	RevokedAt        *time.Time
+}
+
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+	GrantRefreshToken      = "refresh_token"
+)
+
+// Error codes from RFC 6749 section 5.2 and 4.1.2.1. Service errors start with
+// one of them, followed by a description.
+const (
+	ErrInvalidRequest       = "invalid_request"
+	ErrInvalidClient        = "invalid_client"
+	ErrInvalidGrant         = "invalid_grant"
+	ErrUnauthorizedClient   = "unauthorized_client"
+	ErrUnsupportedGrantType = "unsupported_grant_type"
+	ErrInvalidScope         = "invalid_scope"
+	ErrAccessDenied         = "access_denied"
+	ErrUnsupportedResponse  = "unsupported_response_type"
+	ErrServerError          = "server_error"
+)
+
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce is handed back in the ID token, the relying party uses it to
+	// refuse replayed tokens.
+	Nonce string
+}
+
+// Authorization is the answer to an authorization request. Either consent is
+// still needed and the client and scopes are shown to the user, or RedirectTo
+// holds the client's redirect URI with the code or an error attached.
+type Authorization struct {
+	Client          *Client
+	Scopes          []string
+	ConsentRequired bool
+	RedirectTo      string
+}
+
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+}
+
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	// IDToken is only issued to users' grants with the openid scope.
+	IDToken   string
+	ExpiresIn int
+	Scopes    []string
+}
+
+// UserInfo holds the claims of the OIDC userinfo endpoint, the optional ones
+// depend on the scopes of the access token.
+type UserInfo struct {
+	Subject             string
+	Name                string
+	Picture             string
+	PhoneNumber         string
+	PhoneNumberVerified *bool
+}
+
+type ProviderMetadata struct {
+	Issuer                string
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	UserInfoEndpoint      string
+	JWKSURI               string
+	IntrospectionEndpoint string
+	RevocationEndpoint    string
+	Scopes                []string
+	Claims                []string
+}
+
+type Introspection struct {
+	Active    bool
+	Scopes    []string
+	ClientID  string
+	Subject   string
+	TokenType string
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+}
+
+type OAuthHandlerInterface interface {
+	RegisterClient() echo.HandlerFunc
+	ListClients() echo.HandlerFunc
+	DeleteClient() echo.HandlerFunc
+	Authorize() echo.HandlerFunc
+	Consent() echo.HandlerFunc
+	Token() echo.HandlerFunc
+	Introspect() echo.HandlerFunc
+	Revoke() echo.HandlerFunc
+	UserInfo() echo.HandlerFunc
+	Discovery() echo.HandlerFunc
+	JWKS() echo.HandlerFunc
+}
+type OAuthServiceInterface interface {
+	RegisterClient(ctx context.Context, ownerID string, newData Client) (*Client, error)
+	ListClients(ctx context.Context, ownerID string) ([]Client, error)
+	DeleteClient(ctx context.Context, ownerID string, clientID string) error
+	Authorize(ctx context.Context, userID string, request AuthorizationRequest) (*Authorization, error)
+	Consent(ctx context.Context, userID string, request AuthorizationRequest, approved bool) (*Authorization, error)
+	Token(ctx context.Context, request TokenRequest) (*Token, error)
+	Introspect(ctx context.Context, clientID string, clientSecret string, token string) (*Introspection, error)
+	Revoke(ctx context.Context, clientID string, clientSecret string, token string) error
+	ValidateSession(principal helper.Principal) error
+	UserInfo(ctx context.Context, principal helper.Principal) (*UserInfo, error)
+	Discovery() ProviderMetadata
+	JWKS() helper.JWKSet
+}
+type OAuthDataInterface interface {
+	InsertClient(newData Client) (*Client, error)
+	GetClient(clientID string) (*Client, error)
+	GetClientsByOwner(ownerID string) ([]Client, error)
+	DeleteClient(ownerID string, clientID string) error
+	GetConsent(userID string, clientID string) (*Consent, error)
+	GetConsentsByUser(userID string) ([]Consent, error)
+	SaveConsent(newData Consent) error
+	InsertCode(newData AuthorizationCode) error
+	GetCode(codeHash string) (*AuthorizationCode, error)
+	UseCode(codeHash string, grantID string, usedAt time.Time) error
+	InsertGrant(newData Grant) error
+	GetGrant(grantID string) (*Grant, error)
+	GetGrantsByUser(userID string) ([]Grant, error)
+	UpdateGrant(newData Grant) error
+	RevokeGrant(grantID string, revokedAt time.Time) error
+	RevokeClientGrants(clientID string, revokedAt time.Time) error
+	// RevokeUser and EraseUser are the users feature's hooks. Refresh tokens
+	// given to clients go with the user's sessions, and the grants, consents
+	// and pending codes with the account.
+	RevokeUser(userID string, revokedAt time.Time) error
+	EraseUser(userID string) error
+}
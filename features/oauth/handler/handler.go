@@ -0,0 +1,383 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"test/features/oauth"
+	"test/helper"
+
+	"github.com/labstack/echo/v4"
+)
+
+type OAuthHandler struct {
+	s oauth.OAuthServiceInterface
+}
+
+func NewHandler(service oauth.OAuthServiceInterface) oauth.OAuthHandlerInterface {
+	return &OAuthHandler{
+		s: service,
+	}
+}
+
+func (oh *OAuthHandler) RegisterClient() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(ClientInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		var newData = new(oauth.Client)
+		newData.Name = input.Name
+		newData.RedirectURIs = input.RedirectURIs
+		newData.Scopes = input.Scopes
+		newData.Confidential = input.Confidential
+
+		result, err := oh.s.RegisterClient(c.Request().Context(), principal.UserID, *newData)
+
+		if err != nil {
+			c.Logger().Error("handler: register client process error:", err.Error())
+			switch {
+			case strings.Contains(err.Error(), "invalid"):
+				return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+			case strings.Contains(err.Error(), "not allowed"):
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("fail", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = clientResponse(*result)
+		response.Secret = result.Secret
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", response))
+	}
+}
+
+func (oh *OAuthHandler) ListClients() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := oh.s.ListClients(c.Request().Context(), principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: list clients process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = make([]ClientResponse, 0, len(result))
+		for _, client := range result {
+			response = append(response, *clientResponse(client))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (oh *OAuthHandler) DeleteClient() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := oh.s.DeleteClient(c.Request().Context(), principal.UserID, c.Param("id")); err != nil {
+			c.Logger().Error("handler: delete client process error:", err.Error())
+			if strings.Contains(err.Error(), "not found") {
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+// Authorize is called by the frontend with the query of the authorization
+// request. It either answers with what the consent screen has to show, or with
+// the URL the browser has to be sent back to.
+func (oh *OAuthHandler) Authorize() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(AuthorizeInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := oh.s.Authorize(c.Request().Context(), principal.UserID, authorizationRequest(input))
+
+		if err != nil {
+			c.Logger().Error("handler: authorize process error:", err.Error())
+			return c.JSON(authorizationErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", authorizationResponse(result)))
+	}
+}
+
+func (oh *OAuthHandler) Consent() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(ConsentInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := oh.s.Consent(c.Request().Context(), principal.UserID, authorizationRequest(&input.AuthorizeInput), input.Approved)
+
+		if err != nil {
+			c.Logger().Error("handler: consent process error:", err.Error())
+			return c.JSON(authorizationErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", authorizationResponse(result)))
+	}
+}
+
+func (oh *OAuthHandler) Token() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(TokenInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return oauthErrorResponse(c, oauth.ErrInvalidRequest+": malformed request")
+		}
+
+		var request = new(oauth.TokenRequest)
+		request.GrantType = input.GrantType
+		request.ClientID, request.ClientSecret = clientCredentials(c, input.ClientID, input.ClientSecret)
+		request.Code = input.Code
+		request.RedirectURI = input.RedirectURI
+		request.CodeVerifier = input.CodeVerifier
+		request.RefreshToken = input.RefreshToken
+		request.Scope = input.Scope
+
+		result, err := oh.s.Token(c.Request().Context(), *request)
+
+		if err != nil {
+			c.Logger().Error("handler: token process error:", err.Error())
+			return oauthErrorResponse(c, err.Error())
+		}
+
+		var response = new(TokenResponse)
+		response.AccessToken = result.AccessToken
+		response.TokenType = "Bearer"
+		response.ExpiresIn = result.ExpiresIn
+		response.RefreshToken = result.RefreshToken
+		response.IDToken = result.IDToken
+		response.Scope = strings.Join(result.Scopes, " ")
+
+		noStore(c)
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+func (oh *OAuthHandler) Introspect() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(TokenActionInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return oauthErrorResponse(c, oauth.ErrInvalidRequest+": malformed request")
+		}
+
+		clientID, clientSecret := clientCredentials(c, input.ClientID, input.ClientSecret)
+		result, err := oh.s.Introspect(c.Request().Context(), clientID, clientSecret, input.Token)
+
+		if err != nil {
+			c.Logger().Error("handler: introspect process error:", err.Error())
+			return oauthErrorResponse(c, err.Error())
+		}
+
+		var response = new(IntrospectionResponse)
+		response.Active = result.Active
+		if result.Active {
+			response.Scope = strings.Join(result.Scopes, " ")
+			response.ClientID = result.ClientID
+			response.Subject = result.Subject
+			response.TokenType = result.TokenType
+			response.ExpiresAt = result.ExpiresAt.Unix()
+			response.IssuedAt = result.IssuedAt.Unix()
+		}
+
+		noStore(c)
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+func (oh *OAuthHandler) Revoke() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(TokenActionInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return oauthErrorResponse(c, oauth.ErrInvalidRequest+": malformed request")
+		}
+
+		clientID, clientSecret := clientCredentials(c, input.ClientID, input.ClientSecret)
+		if err := oh.s.Revoke(c.Request().Context(), clientID, clientSecret, input.Token); err != nil {
+			c.Logger().Error("handler: revoke process error:", err.Error())
+			return oauthErrorResponse(c, err.Error())
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+func (oh *OAuthHandler) UserInfo() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := oh.s.UserInfo(c.Request().Context(), *principal)
+
+		if err != nil {
+			c.Logger().Error("handler: userinfo process error:", err.Error())
+			switch {
+			case strings.Contains(err.Error(), "deactivated"), strings.Contains(err.Error(), "not found"):
+				return c.JSON(http.StatusUnauthorized, helper.FormatResponse("unauthorized", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = new(UserInfoResponse)
+		response.Subject = result.Subject
+		response.Name = result.Name
+		response.Picture = result.Picture
+		response.PhoneNumber = result.PhoneNumber
+		response.PhoneNumberVerified = result.PhoneNumberVerified
+
+		noStore(c)
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+func (oh *OAuthHandler) Discovery() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var result = oh.s.Discovery()
+
+		var response = new(DiscoveryResponse)
+		response.Issuer = result.Issuer
+		response.AuthorizationEndpoint = result.AuthorizationEndpoint
+		response.TokenEndpoint = result.TokenEndpoint
+		response.UserInfoEndpoint = result.UserInfoEndpoint
+		response.JWKSURI = result.JWKSURI
+		response.IntrospectionEndpoint = result.IntrospectionEndpoint
+		response.RevocationEndpoint = result.RevocationEndpoint
+		response.ScopesSupported = result.Scopes
+		response.ClaimsSupported = result.Claims
+		response.ResponseTypesSupported = []string{"code"}
+		response.GrantTypesSupported = []string{oauth.GrantAuthorizationCode, oauth.GrantClientCredentials, oauth.GrantRefreshToken}
+		response.SubjectTypesSupported = []string{"public"}
+		response.IDTokenSigningAlgValuesSupported = []string{"RS256"}
+		response.TokenEndpointAuthMethodsSupported = []string{"client_secret_basic", "client_secret_post", "none"}
+		response.CodeChallengeMethodsSupported = []string{"S256"}
+
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+func (oh *OAuthHandler) JWKS() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, oh.s.JWKS())
+	}
+}
+
+// clientCredentials prefers HTTP Basic authentication over credentials in the
+// body, RFC 6749 section 2.3.1 has both form encode the values first.
+func clientCredentials(c echo.Context, clientID string, clientSecret string) (string, string) {
+	username, password, ok := c.Request().BasicAuth()
+	if !ok {
+		return clientID, clientSecret
+	}
+
+	if decoded, err := url.QueryUnescape(username); err == nil {
+		username = decoded
+	}
+	if decoded, err := url.QueryUnescape(password); err == nil {
+		password = decoded
+	}
+
+	return username, password
+}
+
+func oauthErrorResponse(c echo.Context, message string) error {
+	code, description, _ := strings.Cut(message, ": ")
+
+	var status = http.StatusBadRequest
+	switch code {
+	case oauth.ErrInvalidClient:
+		status = http.StatusUnauthorized
+		if _, _, ok := c.Request().BasicAuth(); ok {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+		}
+	case oauth.ErrInvalidRequest, oauth.ErrInvalidGrant, oauth.ErrUnauthorizedClient,
+		oauth.ErrUnsupportedGrantType, oauth.ErrInvalidScope:
+	default:
+		code = oauth.ErrServerError
+		description = ""
+		status = http.StatusInternalServerError
+	}
+
+	noStore(c)
+	return c.JSON(status, ErrorResponse{Error: code, ErrorDescription: description})
+}
+
+func noStore(c echo.Context) {
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("Pragma", "no-cache")
+}
+
+func authorizationRequest(input *AuthorizeInput) oauth.AuthorizationRequest {
+	var request = new(oauth.AuthorizationRequest)
+	request.ResponseType = input.ResponseType
+	request.ClientID = input.ClientID
+	request.RedirectURI = input.RedirectURI
+	request.Scope = input.Scope
+	request.State = input.State
+	request.CodeChallenge = input.CodeChallenge
+	request.CodeChallengeMethod = input.CodeChallengeMethod
+	request.Nonce = input.Nonce
+
+	return *request
+}
+
+func authorizationResponse(result *oauth.Authorization) *AuthorizationResponse {
+	var response = new(AuthorizationResponse)
+	if result.Client != nil {
+		response.ClientID = result.Client.ID
+		response.ClientName = result.Client.Name
+	}
+	response.Scopes = result.Scopes
+	response.ConsentRequired = result.ConsentRequired
+	response.RedirectTo = result.RedirectTo
+
+	return response
+}
+
+func authorizationErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), oauth.ErrInvalidRequest):
+		return http.StatusBadRequest
+	case strings.Contains(err.Error(), "deactivated"):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func clientResponse(client oauth.Client) *ClientResponse {
+	var response = new(ClientResponse)
+	response.ID = client.ID
+	response.Name = client.Name
+	response.RedirectURIs = client.RedirectURIs
+	response.Scopes = client.Scopes
+	response.Confidential = client.Confidential
+	response.CreatedAt = client.CreatedAt
+
+	return response
+}
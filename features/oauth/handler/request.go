@@ -0,0 +1,42 @@
+package handler
+
+type ClientInput struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Confidential bool     `json:"confidential"`
+}
+
+type AuthorizeInput struct {
+	ResponseType        string `query:"response_type" json:"response_type"`
+	ClientID            string `query:"client_id" json:"client_id"`
+	RedirectURI         string `query:"redirect_uri" json:"redirect_uri"`
+	Scope               string `query:"scope" json:"scope"`
+	State               string `query:"state" json:"state"`
+	CodeChallenge       string `query:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `query:"code_challenge_method" json:"code_challenge_method"`
+	Nonce               string `query:"nonce" json:"nonce"`
+}
+
+type ConsentInput struct {
+	AuthorizeInput
+	Approved bool `json:"approved"`
+}
+
+type TokenInput struct {
+	GrantType    string `form:"grant_type"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+}
+
+type TokenActionInput struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
@@ -0,0 +1,914 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"test/configs"
+	"test/features/audit"
+	"test/features/oauth"
+	"test/features/users"
+	"test/helper"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	codeTTL         = time.Minute * 5
+	grantTTL        = time.Hour * 24 * 30
+	maxNameLength   = 100
+	maxRedirectURIs = 10
+	maxNonceLength  = 255
+)
+
+type OAuthService struct {
+	d oauth.OAuthDataInterface
+	u users.UserDataInterface
+	j helper.JWTInterface
+	s helper.IDTokenSignerInterface
+	a audit.AuditServiceInterface
+	g helper.GeneratorInterface
+	c helper.ClockInterface
+	// st links the avatars in the picture claim.
+	st helper.StorageInterface
+
+	cfg configs.ProgramConfig
+}
+
+func New(data oauth.OAuthDataInterface, userData users.UserDataInterface, jwt helper.JWTInterface, signer helper.IDTokenSignerInterface, auditor audit.AuditServiceInterface, generator helper.GeneratorInterface, clock helper.ClockInterface, storage helper.StorageInterface, cfg configs.ProgramConfig) oauth.OAuthServiceInterface {
+	return &OAuthService{
+		d:   data,
+		u:   userData,
+		j:   jwt,
+		s:   signer,
+		a:   auditor,
+		g:   generator,
+		c:   clock,
+		st:  storage,
+		cfg: cfg,
+	}
+}
+
+// RegisterClient returns the new client with its secret in Secret, only a hash
+// of it is stored. Public clients get no secret.
+func (oa *OAuthService) RegisterClient(ctx context.Context, ownerID string, newData oauth.Client) (*oauth.Client, error) {
+	newData.Name = strings.TrimSpace(newData.Name)
+	if newData.Name == "" || len(newData.Name) > maxNameLength {
+		return nil, errors.New("invalid name")
+	}
+
+	if len(newData.RedirectURIs) > maxRedirectURIs || (len(newData.RedirectURIs) == 0 && !newData.Confidential) {
+		return nil, errors.New("invalid redirect uri")
+	}
+	for _, uri := range newData.RedirectURIs {
+		if !validRedirectURI(uri) {
+			return nil, errors.New("invalid redirect uri")
+		}
+	}
+
+	scopes, err := helper.NormalizeScopes(newData.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := oa.getUser(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !helper.ScopesAllowed(scopes, owner.Role == users.RoleAdmin) {
+		return nil, errors.New("scope not allowed")
+	}
+
+	clientID, err := oa.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	var secret string
+	newData.ID = clientID
+	newData.OwnerID = owner.ID
+	newData.Scopes = scopes
+	newData.SecretHash = ""
+	newData.CreatedAt = oa.c.Now()
+	if newData.Confidential {
+		secret, err = generateSecret()
+		if err != nil {
+			return nil, errors.New("secret process failed")
+		}
+		newData.SecretHash = helper.HashToken(secret)
+	}
+
+	result, err := oa.d.InsertClient(newData)
+	if err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	oa.audit(ctx, audit.Event{ActorID: owner.ID, Action: "oauth.client_registered", TargetID: owner.ID, After: map[string]any{"client_id": clientID, "name": newData.Name}})
+
+	result.Secret = secret
+
+	return result, nil
+}
+
+func (oa *OAuthService) ListClients(ctx context.Context, ownerID string) ([]oauth.Client, error) {
+	result, err := oa.d.GetClientsByOwner(ownerID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+// DeleteClient also revokes everything issued to the client, its tokens stop
+// working right away.
+func (oa *OAuthService) DeleteClient(ctx context.Context, ownerID string, clientID string) error {
+	if err := oa.d.DeleteClient(ownerID, clientID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("delete process failed")
+	}
+
+	if err := oa.d.RevokeClientGrants(clientID, oa.c.Now()); err != nil {
+		logrus.Error("service: revoke client grants error:", err.Error())
+	}
+
+	oa.audit(ctx, audit.Event{ActorID: ownerID, Action: "oauth.client_deleted", TargetID: ownerID, After: map[string]any{"client_id": clientID}})
+
+	return nil
+}
+
+// Authorize checks an authorization request for the logged in user. When the
+// user already consented to the requested scopes the code is issued right
+// away, otherwise the consent screen has to be shown first.
+func (oa *OAuthService) Authorize(ctx context.Context, userID string, request oauth.AuthorizationRequest) (*oauth.Authorization, error) {
+	pending, refused, err := oa.checkAuthorization(ctx, userID, request)
+	if err != nil || refused != nil {
+		return refused, err
+	}
+
+	consent, err := oa.d.GetConsent(userID, pending.client.ID)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return nil, errors.New("process failed")
+	}
+
+	if consent != nil && containsAll(consent.Scopes, pending.scopes) {
+		return oa.issueCode(userID, request, pending)
+	}
+
+	var result = new(oauth.Authorization)
+	result.Client = pending.client
+	result.Scopes = pending.scopes
+	result.ConsentRequired = true
+
+	return result, nil
+}
+
+// Consent records the user's decision on the consent screen. Approved scopes
+// are remembered, so the next request for them skips the screen.
+func (oa *OAuthService) Consent(ctx context.Context, userID string, request oauth.AuthorizationRequest, approved bool) (*oauth.Authorization, error) {
+	pending, refused, err := oa.checkAuthorization(ctx, userID, request)
+	if err != nil || refused != nil {
+		return refused, err
+	}
+
+	if !approved {
+		return &oauth.Authorization{RedirectTo: errorRedirect(pending.redirectURI, oauth.ErrAccessDenied, "the user denied the request", request.State)}, nil
+	}
+
+	var consent = new(oauth.Consent)
+	consent.UserID = userID
+	consent.ClientID = pending.client.ID
+	consent.Scopes = pending.scopes
+	consent.GrantedAt = oa.c.Now()
+
+	previous, err := oa.d.GetConsent(userID, pending.client.ID)
+	if err == nil {
+		consent.Scopes = union(previous.Scopes, pending.scopes)
+	}
+
+	if err := oa.d.SaveConsent(*consent); err != nil {
+		return nil, errors.New("update process failed")
+	}
+
+	oa.audit(ctx, audit.Event{ActorID: userID, Action: "oauth.consent_granted", TargetID: userID, After: map[string]any{"client_id": pending.client.ID, "scopes": pending.scopes}})
+
+	return oa.issueCode(userID, request, pending)
+}
+
+func (oa *OAuthService) Token(ctx context.Context, request oauth.TokenRequest) (*oauth.Token, error) {
+	client, err := oa.authenticateClient(request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch request.GrantType {
+	case oauth.GrantAuthorizationCode:
+		return oa.exchangeCode(ctx, client, request)
+	case oauth.GrantClientCredentials:
+		return oa.clientCredentials(ctx, client, request)
+	case oauth.GrantRefreshToken:
+		return oa.refresh(ctx, client, request)
+	default:
+		return nil, oauthError(oauth.ErrUnsupportedGrantType, "grant type is not supported")
+	}
+}
+
+// Introspect implements RFC 7662 for confidential clients. A client only
+// learns about tokens issued to itself, every other token is reported as
+// inactive.
+func (oa *OAuthService) Introspect(ctx context.Context, clientID string, clientSecret string, token string) (*oauth.Introspection, error) {
+	client, err := oa.authenticateClient(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.Confidential {
+		return nil, oauthError(oauth.ErrInvalidClient, "introspection needs a confidential client")
+	}
+
+	var inactive = &oauth.Introspection{Active: false}
+	var result = new(oauth.Introspection)
+	var grant *oauth.Grant
+
+	if isRefreshToken(token) {
+		grant, err = oa.refreshGrant(token)
+		if err != nil {
+			return inactive, nil
+		}
+		result.Scopes = grant.Scopes
+		result.TokenType = oauth.GrantRefreshToken
+		result.IssuedAt = grant.CreatedAt
+		result.ExpiresAt = grant.ExpiresAt
+	} else {
+		principal, err := oa.j.ParseToken(token)
+		if err != nil || principal.ClientID == "" {
+			return inactive, nil
+		}
+		grant, err = oa.activeGrant(principal.GrantID)
+		if err != nil {
+			return inactive, nil
+		}
+		result.Scopes = principal.Scopes
+		result.TokenType = "access_token"
+		result.IssuedAt = principal.IssuedAt
+		result.ExpiresAt = principal.IssuedAt.Add(helper.AccessTokenTTL)
+	}
+
+	if grant.ClientID != client.ID {
+		return inactive, nil
+	}
+
+	if _, err := oa.activeUser(ctx, grant.UserID); err != nil {
+		return inactive, nil
+	}
+
+	result.Active = true
+	result.ClientID = grant.ClientID
+	result.Subject = grant.UserID
+
+	return result, nil
+}
+
+// Revoke implements RFC 7009. Access and refresh tokens of a grant are revoked
+// together, and like the RFC asks, unknown tokens are not an error.
+func (oa *OAuthService) Revoke(ctx context.Context, clientID string, clientSecret string, token string) error {
+	client, err := oa.authenticateClient(clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+
+	var grant *oauth.Grant
+	if isRefreshToken(token) {
+		grant, err = oa.refreshGrant(token)
+	} else {
+		var principal *helper.Principal
+		principal, err = oa.j.ParseToken(token)
+		if err == nil {
+			grant, err = oa.activeGrant(principal.GrantID)
+		}
+	}
+
+	if err != nil || grant.ClientID != client.ID {
+		return nil
+	}
+
+	if err := oa.d.RevokeGrant(grant.ID, oa.c.Now()); err != nil {
+		return oauthError(oauth.ErrServerError, "revoke process failed")
+	}
+
+	oa.audit(ctx, audit.Event{ActorID: grant.UserID, Action: "oauth.token_revoked", TargetID: grant.UserID, After: map[string]any{"client_id": client.ID, "grant_id": grant.ID}})
+
+	return nil
+}
+
+// ValidateSession refuses access tokens of revoked or expired grants. Tokens
+// not issued to an OAuth client are left to the other validators.
+func (oa *OAuthService) ValidateSession(principal helper.Principal) error {
+	if principal.ClientID == "" {
+		return nil
+	}
+
+	grant, err := oa.activeGrant(principal.GrantID)
+	if err != nil {
+		return err
+	}
+
+	if grant.ClientID != principal.ClientID {
+		return errors.New("session revoked")
+	}
+
+	return nil
+}
+
+// UserInfo answers the OIDC userinfo endpoint. Access tokens from an
+// interactive login are not limited to scopes and see every claim.
+func (oa *OAuthService) UserInfo(ctx context.Context, principal helper.Principal) (*oauth.UserInfo, error) {
+	user, err := oa.activeUser(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var scopes = principal.Scopes
+	if !principal.Limited() {
+		scopes = helper.Scopes
+	}
+
+	return oa.userInfo(user, scopes), nil
+}
+
+func (oa *OAuthService) Discovery() oauth.ProviderMetadata {
+	var issuer = oa.cfg.TokenIssuer
+
+	return oauth.ProviderMetadata{
+		Issuer:                issuer,
+		AuthorizationEndpoint: oa.cfg.OAuthAuthorizeURL,
+		TokenEndpoint:         issuer + "/oauth/token",
+		UserInfoEndpoint:      issuer + "/userinfo",
+		JWKSURI:               issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint: issuer + "/oauth/introspect",
+		RevocationEndpoint:    issuer + "/oauth/revoke",
+		Scopes:                helper.Scopes,
+		Claims:                []string{"iss", "sub", "aud", "exp", "iat", "nonce", "name", "picture", "phone_number", "phone_number_verified"},
+	}
+}
+
+func (oa *OAuthService) JWKS() helper.JWKSet {
+	return oa.s.JWKS()
+}
+
+type pendingAuthorization struct {
+	client      *oauth.Client
+	redirectURI string
+	scopes      []string
+}
+
+// checkAuthorization validates an authorization request. Without a known
+// client and redirect URI the error goes back to the user, every later problem
+// is reported to the client through its redirect URI in refused.
+func (oa *OAuthService) checkAuthorization(ctx context.Context, userID string, request oauth.AuthorizationRequest) (*pendingAuthorization, *oauth.Authorization, error) {
+	client, err := oa.d.GetClient(request.ClientID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, nil, oauthError(oauth.ErrInvalidRequest, "unknown client")
+		}
+		return nil, nil, oauthError(oauth.ErrServerError, "process failed")
+	}
+
+	var redirectURI = request.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !containsAll(client.RedirectURIs, []string{redirectURI}) {
+		return nil, nil, oauthError(oauth.ErrInvalidRequest, "redirect uri is not registered")
+	}
+
+	var refuse = func(code string, description string) (*pendingAuthorization, *oauth.Authorization, error) {
+		return nil, &oauth.Authorization{RedirectTo: errorRedirect(redirectURI, code, description, request.State)}, nil
+	}
+
+	if request.ResponseType != "code" {
+		return refuse(oauth.ErrUnsupportedResponse, "only the code response type is supported")
+	}
+
+	if request.CodeChallenge == "" && !client.Confidential {
+		return refuse(oauth.ErrInvalidRequest, "public clients have to use pkce")
+	}
+	if request.CodeChallenge != "" && (request.CodeChallengeMethod != "S256" || len(request.CodeChallenge) != 43) {
+		return refuse(oauth.ErrInvalidRequest, "code challenge has to use S256")
+	}
+
+	if len(request.Nonce) > maxNonceLength {
+		return refuse(oauth.ErrInvalidRequest, "nonce is too long")
+	}
+
+	scopes, err := helper.NormalizeScopes(strings.Fields(request.Scope))
+	if err != nil || !containsAll(client.Scopes, scopes) {
+		return refuse(oauth.ErrInvalidScope, "scope is not allowed for the client")
+	}
+
+	user, err := oa.activeUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !helper.ScopesAllowed(scopes, user.Role == users.RoleAdmin) {
+		return refuse(oauth.ErrInvalidScope, "scope is not allowed for the user")
+	}
+
+	return &pendingAuthorization{client: client, redirectURI: redirectURI, scopes: scopes}, nil, nil
+}
+
+func (oa *OAuthService) issueCode(userID string, request oauth.AuthorizationRequest, pending *pendingAuthorization) (*oauth.Authorization, error) {
+	code, err := generateSecret()
+	if err != nil {
+		return nil, errors.New("code process failed")
+	}
+
+	var now = oa.c.Now()
+	var newData = new(oauth.AuthorizationCode)
+	newData.CodeHash = helper.HashToken(code)
+	newData.ClientID = pending.client.ID
+	newData.UserID = userID
+	newData.RedirectURI = pending.redirectURI
+	newData.Scopes = pending.scopes
+	newData.CodeChallenge = request.CodeChallenge
+	newData.Nonce = request.Nonce
+	newData.ExpiresAt = now.Add(codeTTL)
+	newData.CreatedAt = now
+
+	if err := oa.d.InsertCode(*newData); err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	var params = url.Values{}
+	params.Set("code", code)
+	if request.State != "" {
+		params.Set("state", request.State)
+	}
+
+	var result = new(oauth.Authorization)
+	result.Client = pending.client
+	result.Scopes = pending.scopes
+	result.RedirectTo = withQuery(pending.redirectURI, params)
+
+	return result, nil
+}
+
+// exchangeCode redeems an authorization code. A code that comes back after it
+// was used already was most likely intercepted, the grant it produced is
+// revoked as RFC 6749 section 4.1.2 recommends.
+func (oa *OAuthService) exchangeCode(ctx context.Context, client *oauth.Client, request oauth.TokenRequest) (*oauth.Token, error) {
+	var codeHash = helper.HashToken(request.Code)
+	code, err := oa.d.GetCode(codeHash)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, oauthError(oauth.ErrInvalidGrant, "invalid code")
+		}
+		return nil, oauthError(oauth.ErrServerError, "process failed")
+	}
+
+	if code.ClientID != client.ID {
+		return nil, oauthError(oauth.ErrInvalidGrant, "invalid code")
+	}
+
+	var now = oa.c.Now()
+	if code.UsedAt != nil {
+		if code.GrantID != "" {
+			if err := oa.d.RevokeGrant(code.GrantID, now); err != nil {
+				logrus.Error("service: revoke grant of reused code error:", err.Error())
+			}
+		}
+		return nil, oauthError(oauth.ErrInvalidGrant, "code already used")
+	}
+
+	if now.After(code.ExpiresAt) {
+		return nil, oauthError(oauth.ErrInvalidGrant, "code expired")
+	}
+
+	if request.RedirectURI != code.RedirectURI {
+		return nil, oauthError(oauth.ErrInvalidGrant, "redirect uri mismatch")
+	}
+
+	if code.CodeChallenge != "" || request.CodeVerifier != "" {
+		if subtle.ConstantTimeCompare([]byte(pkceChallenge(request.CodeVerifier)), []byte(code.CodeChallenge)) != 1 {
+			return nil, oauthError(oauth.ErrInvalidGrant, "invalid code verifier")
+		}
+	}
+
+	user, err := oa.activeUser(ctx, code.UserID)
+	if err != nil {
+		return nil, oauthError(oauth.ErrInvalidGrant, "user is not active")
+	}
+
+	grantID, err := oa.g.GenerateUUID()
+	if err != nil {
+		return nil, oauthError(oauth.ErrServerError, "id generator failed")
+	}
+
+	if err := oa.d.UseCode(codeHash, grantID, now); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, oauthError(oauth.ErrInvalidGrant, "code already used")
+		}
+		return nil, oauthError(oauth.ErrServerError, "update process failed")
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, oauthError(oauth.ErrServerError, "token process failed")
+	}
+
+	var grant = new(oauth.Grant)
+	grant.ID = grantID
+	grant.ClientID = client.ID
+	grant.UserID = user.ID
+	grant.Scopes = code.Scopes
+	grant.RefreshTokenHash = helper.HashToken(secret)
+	grant.CreatedAt = now
+	grant.ExpiresAt = now.Add(grantTTL)
+
+	if err := oa.d.InsertGrant(*grant); err != nil {
+		return nil, oauthError(oauth.ErrServerError, "insert process failed")
+	}
+
+	result, err := oa.issueToken(user, grant, grant.Scopes, grant.ID+"."+secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return oa.withIDToken(result, user, client.ID, code.Nonce)
+}
+
+// clientCredentials issues a token for the client itself. It acts for the
+// user who registered the client, within the client's scopes. No refresh
+// token is issued, the client can simply ask again.
+func (oa *OAuthService) clientCredentials(ctx context.Context, client *oauth.Client, request oauth.TokenRequest) (*oauth.Token, error) {
+	if !client.Confidential {
+		return nil, oauthError(oauth.ErrUnauthorizedClient, "public clients cannot use client credentials")
+	}
+
+	var scopes = client.Scopes
+	if request.Scope != "" {
+		scopes = strings.Fields(request.Scope)
+		if !containsAll(client.Scopes, scopes) {
+			return nil, oauthError(oauth.ErrInvalidScope, "scope is not allowed for the client")
+		}
+	}
+
+	owner, err := oa.activeUser(ctx, client.OwnerID)
+	if err != nil {
+		return nil, oauthError(oauth.ErrInvalidGrant, "client owner is not active")
+	}
+
+	if !helper.ScopesAllowed(scopes, owner.Role == users.RoleAdmin) {
+		return nil, oauthError(oauth.ErrInvalidScope, "scope is not allowed for the client owner")
+	}
+
+	grantID, err := oa.g.GenerateUUID()
+	if err != nil {
+		return nil, oauthError(oauth.ErrServerError, "id generator failed")
+	}
+
+	var now = oa.c.Now()
+	var grant = new(oauth.Grant)
+	grant.ID = grantID
+	grant.ClientID = client.ID
+	grant.UserID = owner.ID
+	grant.Scopes = scopes
+	grant.CreatedAt = now
+	grant.ExpiresAt = now.Add(helper.AccessTokenTTL)
+
+	if err := oa.d.InsertGrant(*grant); err != nil {
+		return nil, oauthError(oauth.ErrServerError, "insert process failed")
+	}
+
+	return oa.issueToken(owner, grant, scopes, "")
+}
+
+// refresh rotates the refresh token like login sessions do: an old one coming
+// back revokes the grant. A narrower scope only applies to the new access
+// token, the grant keeps what the user consented to.
+func (oa *OAuthService) refresh(ctx context.Context, client *oauth.Client, request oauth.TokenRequest) (*oauth.Token, error) {
+	grantID, secret, found := strings.Cut(request.RefreshToken, ".")
+	if !found || grantID == "" || secret == "" {
+		return nil, oauthError(oauth.ErrInvalidGrant, "invalid refresh token")
+	}
+
+	grant, err := oa.activeGrant(grantID)
+	if err != nil || grant.ClientID != client.ID || grant.RefreshTokenHash == "" {
+		return nil, oauthError(oauth.ErrInvalidGrant, "invalid refresh token")
+	}
+
+	var now = oa.c.Now()
+	if subtle.ConstantTimeCompare([]byte(helper.HashToken(secret)), []byte(grant.RefreshTokenHash)) != 1 {
+		if err := oa.d.RevokeGrant(grant.ID, now); err != nil {
+			logrus.Error("service: revoke reused grant error:", err.Error())
+		}
+		oa.audit(ctx, audit.Event{ActorID: grant.UserID, Action: "oauth.refresh_reuse_detected", TargetID: grant.UserID, After: map[string]any{"client_id": client.ID, "grant_id": grant.ID}})
+		return nil, oauthError(oauth.ErrInvalidGrant, "invalid refresh token")
+	}
+
+	var scopes = grant.Scopes
+	if request.Scope != "" {
+		scopes = strings.Fields(request.Scope)
+		if !containsAll(grant.Scopes, scopes) {
+			return nil, oauthError(oauth.ErrInvalidScope, "scope exceeds the grant")
+		}
+	}
+
+	user, err := oa.activeUser(ctx, grant.UserID)
+	if err != nil {
+		return nil, oauthError(oauth.ErrInvalidGrant, "user is not active")
+	}
+
+	// RevokeTokens revokes the grants as well, this covers a grant that was
+	// read before that.
+	if user.TokensRevokedAt != nil && grant.CreatedAt.Before(*user.TokensRevokedAt) {
+		return nil, oauthError(oauth.ErrInvalidGrant, "invalid refresh token")
+	}
+
+	newSecret, err := generateSecret()
+	if err != nil {
+		return nil, oauthError(oauth.ErrServerError, "token process failed")
+	}
+
+	grant.RefreshTokenHash = helper.HashToken(newSecret)
+	grant.ExpiresAt = now.Add(grantTTL)
+	if err := oa.d.UpdateGrant(*grant); err != nil {
+		return nil, oauthError(oauth.ErrInvalidGrant, "invalid refresh token")
+	}
+
+	result, err := oa.issueToken(user, grant, scopes, grant.ID+"."+newSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return oa.withIDToken(result, user, client.ID, "")
+}
+
+// withIDToken adds an OIDC ID token when the openid scope was granted. The
+// profile claims follow the scopes like they do on the userinfo endpoint.
+func (oa *OAuthService) withIDToken(result *oauth.Token, user *users.User, clientID string, nonce string) (*oauth.Token, error) {
+	if !containsAll(result.Scopes, []string{helper.ScopeOpenID}) {
+		return result, nil
+	}
+
+	var now = oa.c.Now()
+	var info = oa.userInfo(user, result.Scopes)
+	var claims = new(helper.IDTokenClaims)
+	claims.Issuer = oa.cfg.TokenIssuer
+	claims.Subject = user.ID
+	claims.Audience = jwt.ClaimStrings{clientID}
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(helper.AccessTokenTTL))
+	claims.Nonce = nonce
+	claims.Name = info.Name
+	claims.Picture = info.Picture
+	claims.PhoneNumber = info.PhoneNumber
+	claims.PhoneNumberVerified = info.PhoneNumberVerified
+
+	idToken, err := oa.s.SignIDToken(*claims)
+	if err != nil {
+		return nil, oauthError(oauth.ErrServerError, "token process failed")
+	}
+	result.IDToken = idToken
+
+	return result, nil
+}
+
+func (oa *OAuthService) issueToken(user *users.User, grant *oauth.Grant, scopes []string, refreshToken string) (*oauth.Token, error) {
+	var role = user.Role
+	if role == "" {
+		role = users.RoleUser
+	}
+
+	accessToken := oa.j.GenerateToken(helper.Principal{UserID: user.ID, Roles: []string{role}, Scopes: scopes, ClientID: grant.ClientID, GrantID: grant.ID})
+	if accessToken == "" {
+		return nil, oauthError(oauth.ErrServerError, "token process failed")
+	}
+
+	var result = new(oauth.Token)
+	result.AccessToken = accessToken
+	result.RefreshToken = refreshToken
+	result.ExpiresIn = int(helper.AccessTokenTTL.Seconds())
+	result.Scopes = scopes
+
+	return result, nil
+}
+
+// authenticateClient checks the client secret of confidential clients. Public
+// clients only identify themselves and must not send a secret.
+func (oa *OAuthService) authenticateClient(clientID string, clientSecret string) (*oauth.Client, error) {
+	if clientID == "" {
+		return nil, oauthError(oauth.ErrInvalidClient, "client authentication failed")
+	}
+
+	client, err := oa.d.GetClient(clientID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, oauthError(oauth.ErrInvalidClient, "client authentication failed")
+		}
+		return nil, oauthError(oauth.ErrServerError, "process failed")
+	}
+
+	if !client.Confidential {
+		if clientSecret != "" {
+			return nil, oauthError(oauth.ErrInvalidClient, "client authentication failed")
+		}
+		return client, nil
+	}
+
+	if clientSecret == "" || subtle.ConstantTimeCompare([]byte(helper.HashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, oauthError(oauth.ErrInvalidClient, "client authentication failed")
+	}
+
+	return client, nil
+}
+
+func (oa *OAuthService) activeGrant(grantID string) (*oauth.Grant, error) {
+	if grantID == "" {
+		return nil, errors.New("session revoked")
+	}
+
+	grant, err := oa.d.GetGrant(grantID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("session revoked")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	if grant.RevokedAt != nil || oa.c.Now().After(grant.ExpiresAt) {
+		return nil, errors.New("session revoked")
+	}
+
+	return grant, nil
+}
+
+func (oa *OAuthService) refreshGrant(token string) (*oauth.Grant, error) {
+	grantID, secret, _ := strings.Cut(token, ".")
+
+	grant, err := oa.activeGrant(grantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if grant.RefreshTokenHash == "" || subtle.ConstantTimeCompare([]byte(helper.HashToken(secret)), []byte(grant.RefreshTokenHash)) != 1 {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	return grant, nil
+}
+
+func (oa *OAuthService) activeUser(ctx context.Context, userID string) (*users.User, error) {
+	result, err := oa.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.DeactivatedAt != nil || result.DeletedAt != nil || result.AnonymizedAt != nil {
+		return nil, errors.New("account deactivated")
+	}
+
+	return result, nil
+}
+
+func (oa *OAuthService) getUser(ctx context.Context, userID string) (*users.User, error) {
+	result, err := oa.u.WithContext(ctx).GetByID(userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (oa *OAuthService) audit(ctx context.Context, event audit.Event) {
+	if err := oa.a.Record(ctx, event); err != nil {
+		logrus.Error("service: audit record error:", err.Error())
+	}
+}
+
+// userInfo maps the user to the standard OIDC claims the scopes allow: name
+// from Nama and picture from the large avatar for profile, phone_number from
+// HP for phone.
+func (oa *OAuthService) userInfo(user *users.User, scopes []string) *oauth.UserInfo {
+	var result = new(oauth.UserInfo)
+	result.Subject = user.ID
+	if containsAll(scopes, []string{helper.ScopeProfile}) {
+		result.Name = user.Nama
+		result.Picture = helper.AvatarURLs(oa.st, user.AvatarKey)["large"]
+	}
+	if containsAll(scopes, []string{helper.ScopePhone}) {
+		var verified = user.HPVerifiedAt != nil
+		result.PhoneNumber = user.HP
+		result.PhoneNumberVerified = &verified
+	}
+
+	return result
+}
+
+func oauthError(code string, description string) error {
+	return errors.New(code + ": " + description)
+}
+
+// validRedirectURI accepts https URLs, http only on the loopback interface for
+// native apps, and private-use schemes like com.example.app (RFC 8252).
+func validRedirectURI(uri string) bool {
+	parsed, err := url.Parse(uri)
+	if err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+		return false
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		return parsed.Host != ""
+	case "http":
+		var host = parsed.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	default:
+		return strings.Contains(parsed.Scheme, ".")
+	}
+}
+
+func errorRedirect(redirectURI string, code string, description string, state string) string {
+	var params = url.Values{}
+	params.Set("error", code)
+	params.Set("error_description", description)
+	if state != "" {
+		params.Set("state", state)
+	}
+
+	return withQuery(redirectURI, params)
+}
+
+func withQuery(uri string, params url.Values) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+
+	var query = parsed.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String()
+}
+
+// isRefreshToken tells the "grant.secret" refresh tokens apart from access
+// tokens, which are JWTs with three dot separated parts.
+func isRefreshToken(token string) bool {
+	return strings.Count(token, ".") == 1
+}
+
+func pkceChallenge(verifier string) string {
+	var sum = sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func containsAll(values []string, wanted []string) bool {
+	for _, w := range wanted {
+		var found bool
+		for _, v := range values {
+			if v == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func union(a []string, b []string) []string {
+	var result = append([]string{}, a...)
+	for _, value := range b {
+		if !containsAll(result, []string{value}) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func generateSecret() (string, error) {
+	var raw = make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,606 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"test/configs"
+	"test/features/audit"
+	auditMocks "test/features/audit/mocks"
+	"test/features/oauth"
+	"test/features/oauth/mocks"
+	"test/features/users"
+	userMocks "test/features/users/mocks"
+	realHelper "test/helper"
+	helper "test/helper/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	codeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	codeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestRegisterClient(t *testing.T) {
+	data := mocks.NewOAuthDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	storage := realHelper.NewLocalStorage(t.TempDir(), "https://auth.example.com/media")
+	service := New(data, userData, helper.NewJWTInterface(t), helper.NewIDTokenSignerInterface(t), auditor, generator, clock, storage, configs.ProgramConfig{TokenIssuer: "https://auth.example.com", OAuthAuthorizeURL: "https://app.example.com/authorize"}).(*OAuthService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	owner := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Role: users.RoleUser}
+
+	t.Run("Success confidential client", func(t *testing.T) {
+		var inserted oauth.Client
+		userData.On("GetByID", owner.ID).Return(&owner, nil).Once()
+		generator.On("GenerateUUID").Return("randomClientID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertClient", mock.MatchedBy(func(c oauth.Client) bool {
+			inserted = c
+			return c.ID == "randomClientID" && c.OwnerID == owner.ID && c.Confidential && c.SecretHash != ""
+		})).Return(func(c oauth.Client) *oauth.Client { return &c }, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: owner.ID, Action: "oauth.client_registered", TargetID: owner.ID, After: map[string]any{"client_id": "randomClientID", "name": "Backup App"}}).Return(nil).Once()
+
+		result, err := service.RegisterClient(context.Background(), owner.ID, oauth.Client{Name: "Backup App", RedirectURIs: []string{"https://backup.example.com/callback"}, Scopes: []string{realHelper.ScopePrivacyExport}, Confidential: true})
+		assert.Nil(t, err)
+		assert.Equal(t, inserted.SecretHash, realHelper.HashToken(result.Secret))
+	})
+
+	t.Run("Public client without redirect uri", func(t *testing.T) {
+		result, err := service.RegisterClient(context.Background(), owner.ID, oauth.Client{Name: "Mobile App", Scopes: []string{realHelper.ScopePrivacyExport}})
+		assert.EqualError(t, err, "invalid redirect uri")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Plain http redirect uri", func(t *testing.T) {
+		result, err := service.RegisterClient(context.Background(), owner.ID, oauth.Client{Name: "Web App", RedirectURIs: []string{"http://backup.example.com/callback"}, Scopes: []string{realHelper.ScopePrivacyExport}})
+		assert.EqualError(t, err, "invalid redirect uri")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Admin scope for user", func(t *testing.T) {
+		userData.On("GetByID", owner.ID).Return(&owner, nil).Once()
+
+		result, err := service.RegisterClient(context.Background(), owner.ID, oauth.Client{Name: "Audit App", RedirectURIs: []string{"http://127.0.0.1:8080/callback"}, Scopes: []string{realHelper.ScopeAuditRead}})
+		assert.EqualError(t, err, "scope not allowed")
+		assert.Nil(t, result)
+	})
+}
+
+func TestAuthorize(t *testing.T) {
+	data := mocks.NewOAuthDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	clock := helper.NewClockInterface(t)
+	storage := realHelper.NewLocalStorage(t.TempDir(), "https://auth.example.com/media")
+	service := New(data, userData, helper.NewJWTInterface(t), helper.NewIDTokenSignerInterface(t), auditor, helper.NewGeneratorInterface(t), clock, storage, configs.ProgramConfig{TokenIssuer: "https://auth.example.com", OAuthAuthorizeURL: "https://app.example.com/authorize"}).(*OAuthService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Role: users.RoleUser}
+	client := oauth.Client{ID: "randomClientID", OwnerID: "ownerID", Name: "Mobile App", RedirectURIs: []string{"com.example.app:/callback"}, Scopes: []string{realHelper.ScopePrivacyExport}}
+	request := oauth.AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            client.ID,
+		RedirectURI:         "com.example.app:/callback",
+		Scope:               realHelper.ScopePrivacyExport,
+		State:               "randomState",
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: "S256",
+	}
+
+	t.Run("Consent required", func(t *testing.T) {
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		data.On("GetConsent", user.ID, client.ID).Return(nil, errors.New("record not found")).Once()
+
+		result, err := service.Authorize(context.Background(), user.ID, request)
+		assert.Nil(t, err)
+		assert.True(t, result.ConsentRequired)
+		assert.Equal(t, []string{realHelper.ScopePrivacyExport}, result.Scopes)
+		assert.Empty(t, result.RedirectTo)
+	})
+
+	t.Run("Earlier consent issues code", func(t *testing.T) {
+		var issued oauth.AuthorizationCode
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		data.On("GetConsent", user.ID, client.ID).Return(&oauth.Consent{UserID: user.ID, ClientID: client.ID, Scopes: []string{realHelper.ScopePrivacyExport}}, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertCode", mock.MatchedBy(func(c oauth.AuthorizationCode) bool {
+			issued = c
+			return c.ClientID == client.ID && c.UserID == user.ID && c.CodeChallenge == codeChallenge && c.ExpiresAt.Equal(now.Add(codeTTL))
+		})).Return(nil).Once()
+
+		result, err := service.Authorize(context.Background(), user.ID, request)
+		assert.Nil(t, err)
+		assert.False(t, result.ConsentRequired)
+
+		redirect, _ := url.Parse(result.RedirectTo)
+		assert.Equal(t, "com.example.app", redirect.Scheme)
+		assert.Equal(t, "randomState", redirect.Query().Get("state"))
+		assert.Equal(t, issued.CodeHash, realHelper.HashToken(redirect.Query().Get("code")))
+	})
+
+	t.Run("Public client without pkce", func(t *testing.T) {
+		withoutPKCE := request
+		withoutPKCE.CodeChallenge = ""
+		withoutPKCE.CodeChallengeMethod = ""
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+
+		result, err := service.Authorize(context.Background(), user.ID, withoutPKCE)
+		assert.Nil(t, err)
+		redirect, _ := url.Parse(result.RedirectTo)
+		assert.Equal(t, "invalid_request", redirect.Query().Get("error"))
+		assert.Equal(t, "randomState", redirect.Query().Get("state"))
+	})
+
+	t.Run("Scope outside the client", func(t *testing.T) {
+		wider := request
+		wider.Scope = realHelper.ScopePrivacyExport + " " + realHelper.ScopeUsersManage
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+
+		result, err := service.Authorize(context.Background(), user.ID, wider)
+		assert.Nil(t, err)
+		redirect, _ := url.Parse(result.RedirectTo)
+		assert.Equal(t, "invalid_scope", redirect.Query().Get("error"))
+	})
+
+	t.Run("Nonce too long", func(t *testing.T) {
+		longNonce := request
+		longNonce.Nonce = strings.Repeat("n", maxNonceLength+1)
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+
+		result, err := service.Authorize(context.Background(), user.ID, longNonce)
+		assert.Nil(t, err)
+		redirect, _ := url.Parse(result.RedirectTo)
+		assert.Equal(t, "invalid_request", redirect.Query().Get("error"))
+	})
+
+	t.Run("Unregistered redirect uri", func(t *testing.T) {
+		other := request
+		other.RedirectURI = "https://attacker.example.com/callback"
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+
+		result, err := service.Authorize(context.Background(), user.ID, other)
+		assert.ErrorContains(t, err, "invalid_request")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Consent denied", func(t *testing.T) {
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+
+		result, err := service.Consent(context.Background(), user.ID, request, false)
+		assert.Nil(t, err)
+		redirect, _ := url.Parse(result.RedirectTo)
+		assert.Equal(t, "access_denied", redirect.Query().Get("error"))
+	})
+
+	t.Run("Consent approved", func(t *testing.T) {
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		clock.On("Now").Return(now).Twice()
+		data.On("GetConsent", user.ID, client.ID).Return(nil, errors.New("record not found")).Once()
+		data.On("SaveConsent", oauth.Consent{UserID: user.ID, ClientID: client.ID, Scopes: []string{realHelper.ScopePrivacyExport}, GrantedAt: now}).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: user.ID, Action: "oauth.consent_granted", TargetID: user.ID, After: map[string]any{"client_id": client.ID, "scopes": []string{realHelper.ScopePrivacyExport}}}).Return(nil).Once()
+		data.On("InsertCode", mock.Anything).Return(nil).Once()
+
+		result, err := service.Consent(context.Background(), user.ID, request, true)
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectTo, "com.example.app:/callback?code="))
+	})
+}
+
+func TestTokenAuthorizationCode(t *testing.T) {
+	data := mocks.NewOAuthDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	j := helper.NewJWTInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	signer := helper.NewIDTokenSignerInterface(t)
+	storage := realHelper.NewLocalStorage(t.TempDir(), "https://auth.example.com/media")
+	service := New(data, userData, j, signer, auditMocks.NewAuditServiceInterface(t), generator, clock, storage, configs.ProgramConfig{TokenIssuer: "https://auth.example.com", OAuthAuthorizeURL: "https://app.example.com/authorize"}).(*OAuthService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Role: users.RoleUser}
+	client := oauth.Client{ID: "randomClientID", Name: "Mobile App", RedirectURIs: []string{"com.example.app:/callback"}, Scopes: []string{realHelper.ScopePrivacyExport}}
+	code := oauth.AuthorizationCode{
+		CodeHash:      realHelper.HashToken("randomCode"),
+		ClientID:      client.ID,
+		UserID:        user.ID,
+		RedirectURI:   "com.example.app:/callback",
+		Scopes:        []string{realHelper.ScopePrivacyExport},
+		CodeChallenge: codeChallenge,
+		ExpiresAt:     now.Add(codeTTL),
+		CreatedAt:     now,
+	}
+	request := oauth.TokenRequest{GrantType: oauth.GrantAuthorizationCode, ClientID: client.ID, Code: "randomCode", RedirectURI: "com.example.app:/callback", CodeVerifier: codeVerifier}
+
+	t.Run("Success exchange", func(t *testing.T) {
+		var grant oauth.Grant
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetCode", code.CodeHash).Return(&code, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		generator.On("GenerateUUID").Return("randomGrantID", nil).Once()
+		data.On("UseCode", code.CodeHash, "randomGrantID", now.Add(time.Minute)).Return(nil).Once()
+		data.On("InsertGrant", mock.MatchedBy(func(g oauth.Grant) bool {
+			grant = g
+			return g.ID == "randomGrantID" && g.ClientID == client.ID && g.UserID == user.ID && g.ExpiresAt.Equal(now.Add(time.Minute+grantTTL))
+		})).Return(nil).Once()
+		j.On("GenerateToken", realHelper.Principal{UserID: user.ID, Roles: []string{"user"}, Scopes: code.Scopes, ClientID: client.ID, GrantID: "randomGrantID"}).Return("randomAccessToken").Once()
+
+		result, err := service.Token(context.Background(), request)
+		assert.Nil(t, err)
+		assert.Equal(t, "randomAccessToken", result.AccessToken)
+		assert.Equal(t, 600, result.ExpiresIn)
+		assert.True(t, strings.HasPrefix(result.RefreshToken, "randomGrantID."))
+		assert.Equal(t, grant.RefreshTokenHash, realHelper.HashToken(strings.TrimPrefix(result.RefreshToken, "randomGrantID.")))
+	})
+
+	t.Run("Success exchange with openid", func(t *testing.T) {
+		verifiedAt := now.Add(-time.Hour)
+		verified := user
+		verified.HPVerifiedAt = &verifiedAt
+		oidcCode := code
+		oidcCode.Scopes = []string{realHelper.ScopeOpenID, realHelper.ScopeProfile, realHelper.ScopePhone}
+		oidcCode.Nonce = "randomNonce"
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetCode", code.CodeHash).Return(&oidcCode, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Twice()
+		userData.On("GetByID", user.ID).Return(&verified, nil).Once()
+		generator.On("GenerateUUID").Return("randomGrantID", nil).Once()
+		data.On("UseCode", code.CodeHash, "randomGrantID", now.Add(time.Minute)).Return(nil).Once()
+		data.On("InsertGrant", mock.Anything).Return(nil).Once()
+		j.On("GenerateToken", realHelper.Principal{UserID: user.ID, Roles: []string{"user"}, Scopes: oidcCode.Scopes, ClientID: client.ID, GrantID: "randomGrantID"}).Return("randomAccessToken").Once()
+		signer.On("SignIDToken", mock.MatchedBy(func(c realHelper.IDTokenClaims) bool {
+			return c.Issuer == "https://auth.example.com" && c.Subject == user.ID && c.Audience[0] == client.ID &&
+				c.Nonce == "randomNonce" && c.Name == "dida" && c.PhoneNumber == "123" && *c.PhoneNumberVerified &&
+				c.ExpiresAt.Time.Equal(now.Add(time.Minute+realHelper.AccessTokenTTL))
+		})).Return("randomIDToken", nil).Once()
+
+		result, err := service.Token(context.Background(), request)
+		assert.Nil(t, err)
+		assert.Equal(t, "randomIDToken", result.IDToken)
+	})
+
+	t.Run("Wrong code verifier", func(t *testing.T) {
+		wrong := request
+		wrong.CodeVerifier = "otherVerifier"
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetCode", code.CodeHash).Return(&code, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+
+		result, err := service.Token(context.Background(), wrong)
+		assert.EqualError(t, err, "invalid_grant: invalid code verifier")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Reused code revokes grant", func(t *testing.T) {
+		usedAt := now.Add(time.Minute)
+		used := code
+		used.UsedAt = &usedAt
+		used.GrantID = "randomGrantID"
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetCode", code.CodeHash).Return(&used, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute * 2)).Once()
+		data.On("RevokeGrant", "randomGrantID", now.Add(time.Minute*2)).Return(nil).Once()
+
+		result, err := service.Token(context.Background(), request)
+		assert.EqualError(t, err, "invalid_grant: code already used")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Expired code", func(t *testing.T) {
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetCode", code.CodeHash).Return(&code, nil).Once()
+		clock.On("Now").Return(now.Add(codeTTL + time.Second)).Once()
+
+		result, err := service.Token(context.Background(), request)
+		assert.EqualError(t, err, "invalid_grant: code expired")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Public client with secret", func(t *testing.T) {
+		withSecret := request
+		withSecret.ClientSecret = "randomSecret"
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+
+		result, err := service.Token(context.Background(), withSecret)
+		assert.EqualError(t, err, "invalid_client: client authentication failed")
+		assert.Nil(t, result)
+	})
+}
+
+func TestTokenClientCredentials(t *testing.T) {
+	data := mocks.NewOAuthDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	j := helper.NewJWTInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	storage := realHelper.NewLocalStorage(t.TempDir(), "https://auth.example.com/media")
+	service := New(data, userData, j, helper.NewIDTokenSignerInterface(t), auditMocks.NewAuditServiceInterface(t), generator, clock, storage, configs.ProgramConfig{TokenIssuer: "https://auth.example.com", OAuthAuthorizeURL: "https://app.example.com/authorize"}).(*OAuthService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	owner := users.User{ID: "ownerID", Nama: "dida", HP: "123", Role: users.RoleAdmin}
+	client := oauth.Client{ID: "randomClientID", OwnerID: owner.ID, Name: "Audit Export", SecretHash: realHelper.HashToken("randomSecret"), Scopes: []string{realHelper.ScopeAuditRead}, Confidential: true}
+
+	t.Run("Success client credentials", func(t *testing.T) {
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		userData.On("GetByID", owner.ID).Return(&owner, nil).Once()
+		generator.On("GenerateUUID").Return("randomGrantID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertGrant", oauth.Grant{ID: "randomGrantID", ClientID: client.ID, UserID: owner.ID, Scopes: client.Scopes, CreatedAt: now, ExpiresAt: now.Add(realHelper.AccessTokenTTL)}).Return(nil).Once()
+		j.On("GenerateToken", realHelper.Principal{UserID: owner.ID, Roles: []string{"admin"}, Scopes: client.Scopes, ClientID: client.ID, GrantID: "randomGrantID"}).Return("randomAccessToken").Once()
+
+		result, err := service.Token(context.Background(), oauth.TokenRequest{GrantType: oauth.GrantClientCredentials, ClientID: client.ID, ClientSecret: "randomSecret"})
+		assert.Nil(t, err)
+		assert.Equal(t, "randomAccessToken", result.AccessToken)
+		assert.Empty(t, result.RefreshToken)
+	})
+
+	t.Run("Wrong secret", func(t *testing.T) {
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+
+		result, err := service.Token(context.Background(), oauth.TokenRequest{GrantType: oauth.GrantClientCredentials, ClientID: client.ID, ClientSecret: "otherSecret"})
+		assert.EqualError(t, err, "invalid_client: client authentication failed")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Public client", func(t *testing.T) {
+		public := client
+		public.Confidential = false
+		data.On("GetClient", client.ID).Return(&public, nil).Once()
+
+		result, err := service.Token(context.Background(), oauth.TokenRequest{GrantType: oauth.GrantClientCredentials, ClientID: client.ID})
+		assert.EqualError(t, err, "unauthorized_client: public clients cannot use client credentials")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Unsupported grant type", func(t *testing.T) {
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+
+		result, err := service.Token(context.Background(), oauth.TokenRequest{GrantType: "password", ClientID: client.ID, ClientSecret: "randomSecret"})
+		assert.EqualError(t, err, "unsupported_grant_type: grant type is not supported")
+		assert.Nil(t, result)
+	})
+}
+
+func TestTokenRefresh(t *testing.T) {
+	data := mocks.NewOAuthDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	j := helper.NewJWTInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	clock := helper.NewClockInterface(t)
+	storage := realHelper.NewLocalStorage(t.TempDir(), "https://auth.example.com/media")
+	service := New(data, userData, j, helper.NewIDTokenSignerInterface(t), auditor, helper.NewGeneratorInterface(t), clock, storage, configs.ProgramConfig{TokenIssuer: "https://auth.example.com", OAuthAuthorizeURL: "https://app.example.com/authorize"}).(*OAuthService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Role: users.RoleUser}
+	client := oauth.Client{ID: "randomClientID", Name: "Mobile App", Scopes: []string{realHelper.ScopePrivacyExport}}
+	grant := oauth.Grant{ID: "randomGrantID", ClientID: client.ID, UserID: user.ID, Scopes: []string{realHelper.ScopePrivacyExport}, RefreshTokenHash: realHelper.HashToken("randomSecret"), CreatedAt: now, ExpiresAt: now.Add(grantTTL)}
+
+	t.Run("Success refresh", func(t *testing.T) {
+		current := grant
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetGrant", grant.ID).Return(&current, nil).Once()
+		clock.On("Now").Return(now.Add(time.Hour)).Twice()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		data.On("UpdateGrant", mock.MatchedBy(func(g oauth.Grant) bool {
+			return g.ID == grant.ID && g.RefreshTokenHash != grant.RefreshTokenHash && g.ExpiresAt.Equal(now.Add(time.Hour+grantTTL))
+		})).Return(nil).Once()
+		j.On("GenerateToken", realHelper.Principal{UserID: user.ID, Roles: []string{"user"}, Scopes: grant.Scopes, ClientID: client.ID, GrantID: grant.ID}).Return("randomAccessToken").Once()
+
+		result, err := service.Token(context.Background(), oauth.TokenRequest{GrantType: oauth.GrantRefreshToken, ClientID: client.ID, RefreshToken: "randomGrantID.randomSecret"})
+		assert.Nil(t, err)
+		assert.Equal(t, "randomAccessToken", result.AccessToken)
+		assert.NotEqual(t, "randomGrantID.randomSecret", result.RefreshToken)
+	})
+
+	t.Run("Reused refresh token", func(t *testing.T) {
+		current := grant
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetGrant", grant.ID).Return(&current, nil).Once()
+		clock.On("Now").Return(now.Add(time.Hour)).Twice()
+		data.On("RevokeGrant", grant.ID, now.Add(time.Hour)).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: user.ID, Action: "oauth.refresh_reuse_detected", TargetID: user.ID, After: map[string]any{"client_id": client.ID, "grant_id": grant.ID}}).Return(nil).Once()
+
+		result, err := service.Token(context.Background(), oauth.TokenRequest{GrantType: oauth.GrantRefreshToken, ClientID: client.ID, RefreshToken: "randomGrantID.oldSecret"})
+		assert.EqualError(t, err, "invalid_grant: invalid refresh token")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Wider scope", func(t *testing.T) {
+		current := grant
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetGrant", grant.ID).Return(&current, nil).Once()
+		clock.On("Now").Return(now.Add(time.Hour)).Twice()
+
+		result, err := service.Token(context.Background(), oauth.TokenRequest{GrantType: oauth.GrantRefreshToken, ClientID: client.ID, RefreshToken: "randomGrantID.randomSecret", Scope: realHelper.ScopeAuditRead})
+		assert.EqualError(t, err, "invalid_scope: scope exceeds the grant")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Tokens revoked after the grant", func(t *testing.T) {
+		current := grant
+		revokedAt := now.Add(time.Minute)
+		revoked := user
+		revoked.TokensRevokedAt = &revokedAt
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetGrant", grant.ID).Return(&current, nil).Once()
+		clock.On("Now").Return(now.Add(time.Hour)).Twice()
+		userData.On("GetByID", user.ID).Return(&revoked, nil).Once()
+
+		result, err := service.Token(context.Background(), oauth.TokenRequest{GrantType: oauth.GrantRefreshToken, ClientID: client.ID, RefreshToken: "randomGrantID.randomSecret"})
+		assert.EqualError(t, err, "invalid_grant: invalid refresh token")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Erased user", func(t *testing.T) {
+		current := grant
+		anonymizedAt := now.Add(time.Minute)
+		anonymized := user
+		anonymized.AnonymizedAt = &anonymizedAt
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetGrant", grant.ID).Return(&current, nil).Once()
+		clock.On("Now").Return(now.Add(time.Hour)).Twice()
+		userData.On("GetByID", user.ID).Return(&anonymized, nil).Once()
+
+		result, err := service.Token(context.Background(), oauth.TokenRequest{GrantType: oauth.GrantRefreshToken, ClientID: client.ID, RefreshToken: "randomGrantID.randomSecret"})
+		assert.EqualError(t, err, "invalid_grant: user is not active")
+		assert.Nil(t, result)
+	})
+}
+
+func TestIntrospectAndRevoke(t *testing.T) {
+	data := mocks.NewOAuthDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	j := helper.NewJWTInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	clock := helper.NewClockInterface(t)
+	storage := realHelper.NewLocalStorage(t.TempDir(), "https://auth.example.com/media")
+	service := New(data, userData, j, helper.NewIDTokenSignerInterface(t), auditor, helper.NewGeneratorInterface(t), clock, storage, configs.ProgramConfig{TokenIssuer: "https://auth.example.com", OAuthAuthorizeURL: "https://app.example.com/authorize"}).(*OAuthService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Role: users.RoleUser}
+	client := oauth.Client{ID: "randomClientID", Name: "Backup App", SecretHash: realHelper.HashToken("randomSecret"), Scopes: []string{realHelper.ScopePrivacyExport}, Confidential: true}
+	grant := oauth.Grant{ID: "randomGrantID", ClientID: client.ID, UserID: user.ID, Scopes: []string{realHelper.ScopePrivacyExport}, RefreshTokenHash: realHelper.HashToken("refreshSecret"), CreatedAt: now, ExpiresAt: now.Add(grantTTL)}
+	principal := realHelper.Principal{UserID: user.ID, Roles: []string{"user"}, Scopes: grant.Scopes, ClientID: client.ID, GrantID: grant.ID, IssuedAt: now}
+
+	t.Run("Active access token", func(t *testing.T) {
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		j.On("ParseToken", "header.payload.signature").Return(&principal, nil).Once()
+		data.On("GetGrant", grant.ID).Return(&grant, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+
+		result, err := service.Introspect(context.Background(), client.ID, "randomSecret", "header.payload.signature")
+		assert.Nil(t, err)
+		assert.Equal(t, &oauth.Introspection{Active: true, Scopes: grant.Scopes, ClientID: client.ID, Subject: user.ID, TokenType: "access_token", IssuedAt: now, ExpiresAt: now.Add(realHelper.AccessTokenTTL)}, result)
+	})
+
+	t.Run("Token of another client", func(t *testing.T) {
+		other := grant
+		other.ClientID = "otherClientID"
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetGrant", grant.ID).Return(&other, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+
+		result, err := service.Introspect(context.Background(), client.ID, "randomSecret", "randomGrantID.refreshSecret")
+		assert.Nil(t, err)
+		assert.False(t, result.Active)
+	})
+
+	t.Run("Revoke refresh token", func(t *testing.T) {
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		data.On("GetGrant", grant.ID).Return(&grant, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Twice()
+		data.On("RevokeGrant", grant.ID, now.Add(time.Minute)).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: user.ID, Action: "oauth.token_revoked", TargetID: user.ID, After: map[string]any{"client_id": client.ID, "grant_id": grant.ID}}).Return(nil).Once()
+
+		err := service.Revoke(context.Background(), client.ID, "randomSecret", "randomGrantID.refreshSecret")
+		assert.Nil(t, err)
+	})
+
+	t.Run("Revoke unknown token", func(t *testing.T) {
+		data.On("GetClient", client.ID).Return(&client, nil).Once()
+		j.On("ParseToken", "unknownToken").Return(nil, errors.New("token is malformed")).Once()
+
+		err := service.Revoke(context.Background(), client.ID, "randomSecret", "unknownToken")
+		assert.Nil(t, err)
+	})
+}
+
+func TestValidateSession(t *testing.T) {
+	data := mocks.NewOAuthDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	storage := realHelper.NewLocalStorage(t.TempDir(), "https://auth.example.com/media")
+	service := New(data, userMocks.NewUserDataInterface(t), helper.NewJWTInterface(t), helper.NewIDTokenSignerInterface(t), auditMocks.NewAuditServiceInterface(t), helper.NewGeneratorInterface(t), clock, storage, configs.ProgramConfig{TokenIssuer: "https://auth.example.com", OAuthAuthorizeURL: "https://app.example.com/authorize"}).(*OAuthService)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	grant := oauth.Grant{ID: "randomGrantID", ClientID: "randomClientID", UserID: "randomUserID", ExpiresAt: now.Add(grantTTL)}
+
+	t.Run("Login token", func(t *testing.T) {
+		assert.Nil(t, service.ValidateSession(realHelper.Principal{UserID: "randomUserID", SessionID: "randomSessionID"}))
+	})
+
+	t.Run("Active grant", func(t *testing.T) {
+		data.On("GetGrant", grant.ID).Return(&grant, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		assert.Nil(t, service.ValidateSession(realHelper.Principal{UserID: "randomUserID", ClientID: "randomClientID", GrantID: grant.ID}))
+	})
+
+	t.Run("Revoked grant", func(t *testing.T) {
+		revoked := grant
+		revoked.RevokedAt = &now
+		data.On("GetGrant", grant.ID).Return(&revoked, nil).Once()
+
+		assert.EqualError(t, service.ValidateSession(realHelper.Principal{UserID: "randomUserID", ClientID: "randomClientID", GrantID: grant.ID}), "session revoked")
+	})
+}
+
+func TestUserInfo(t *testing.T) {
+	userData := userMocks.NewUserDataInterface(t)
+	storage := realHelper.NewLocalStorage(t.TempDir(), "https://auth.example.com/media")
+	service := New(mocks.NewOAuthDataInterface(t), userData, helper.NewJWTInterface(t), helper.NewIDTokenSignerInterface(t), auditMocks.NewAuditServiceInterface(t), helper.NewGeneratorInterface(t), helper.NewClockInterface(t), storage, configs.ProgramConfig{TokenIssuer: "https://auth.example.com", OAuthAuthorizeURL: "https://app.example.com/authorize"}).(*OAuthService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Role: users.RoleUser}
+
+	t.Run("Profile scope only", func(t *testing.T) {
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+
+		result, err := service.UserInfo(context.Background(), realHelper.Principal{UserID: user.ID, Scopes: []string{realHelper.ScopeOpenID, realHelper.ScopeProfile}, ClientID: "randomClientID"})
+		assert.Nil(t, err)
+		assert.Equal(t, &oauth.UserInfo{Subject: user.ID, Name: "dida"}, result)
+	})
+
+	t.Run("Avatar as picture", func(t *testing.T) {
+		withAvatar := user
+		withAvatar.AvatarKey = "avatars/randomUserID/avatarID"
+		userData.On("GetByID", user.ID).Return(&withAvatar, nil).Once()
+
+		result, err := service.UserInfo(context.Background(), realHelper.Principal{UserID: user.ID, Scopes: []string{realHelper.ScopeOpenID, realHelper.ScopeProfile}, ClientID: "randomClientID"})
+		assert.Nil(t, err)
+		assert.Equal(t, "https://auth.example.com/media/avatars/randomUserID/avatarID-large.jpg", result.Picture)
+	})
+
+	t.Run("Interactive login sees every claim", func(t *testing.T) {
+		unverified := false
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+
+		result, err := service.UserInfo(context.Background(), realHelper.Principal{UserID: user.ID, Roles: []string{"user"}})
+		assert.Nil(t, err)
+		assert.Equal(t, &oauth.UserInfo{Subject: user.ID, Name: "dida", PhoneNumber: "123", PhoneNumberVerified: &unverified}, result)
+	})
+
+	t.Run("Deactivated user", func(t *testing.T) {
+		deactivatedAt := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+		deactivated := user
+		deactivated.DeactivatedAt = &deactivatedAt
+		userData.On("GetByID", user.ID).Return(&deactivated, nil).Once()
+
+		result, err := service.UserInfo(context.Background(), realHelper.Principal{UserID: user.ID, Scopes: []string{realHelper.ScopeOpenID}, ClientID: "randomClientID"})
+		assert.EqualError(t, err, "account deactivated")
+		assert.Nil(t, result)
+	})
+}
+
+func TestDiscovery(t *testing.T) {
+	storage := realHelper.NewLocalStorage(t.TempDir(), "https://auth.example.com/media")
+	service := New(mocks.NewOAuthDataInterface(t), userMocks.NewUserDataInterface(t), helper.NewJWTInterface(t), helper.NewIDTokenSignerInterface(t), auditMocks.NewAuditServiceInterface(t), helper.NewGeneratorInterface(t), helper.NewClockInterface(t), storage, configs.ProgramConfig{TokenIssuer: "https://auth.example.com", OAuthAuthorizeURL: "https://app.example.com/authorize"}).(*OAuthService)
+
+	result := service.Discovery()
+	assert.Equal(t, "https://auth.example.com", result.Issuer)
+	assert.Equal(t, "https://app.example.com/authorize", result.AuthorizationEndpoint)
+	assert.Equal(t, "https://auth.example.com/oauth/token", result.TokenEndpoint)
+	assert.Equal(t, "https://auth.example.com/.well-known/jwks.json", result.JWKSURI)
+	assert.Contains(t, result.Scopes, realHelper.ScopeOpenID)
+}
@@ -0,0 +1,34 @@
+package data
+
+import "time"
+
+type Organization struct {
+	ID        string `gorm:"type:varchar(255);primaryKey"`
+	Slug      string `gorm:"type:varchar(63);uniqueIndex"`
+	Name      string `gorm:"type:varchar(100)"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Membership is also read by the users data package, which limits users to
+// the members of the current organization.
+type Membership struct {
+	OrganizationID string `gorm:"type:varchar(255);primaryKey"`
+	UserID         string `gorm:"type:varchar(255);primaryKey;index"`
+	Role           string `gorm:"type:varchar(32)"`
+	CreatedAt      time.Time
+}
+
+// memberRow is a membership joined with its user.
+type memberRow struct {
+	Membership
+	Nama string
+	HP   string
+}
+
+// membershipRow is a membership joined with its organization.
+type membershipRow struct {
+	Organization
+	Role     string
+	JoinedAt time.Time
+}
@@ -0,0 +1,244 @@
+package data
+
+import (
+	"errors"
+	"test/features/organizations"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type OrganizationData struct {
+	gorm *gorm.DB
+}
+
+func New(g *gorm.DB) organizations.OrganizationDataInterface {
+	return &OrganizationData{
+		gorm: g,
+	}
+}
+
+func (od *OrganizationData) Insert(newData organizations.Organization) (*organizations.Organization, error) {
+	var dbData = new(Organization)
+	dbData.ID = newData.ID
+	dbData.Slug = newData.Slug
+	dbData.Name = newData.Name
+	dbData.CreatedAt = newData.CreatedAt
+	dbData.UpdatedAt = newData.UpdatedAt
+
+	if err := od.gorm.Create(dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return &newData, nil
+}
+
+func (od *OrganizationData) GetByID(id string) (*organizations.Organization, error) {
+	var dbData = new(Organization)
+
+	if err := od.gorm.Where("id = ?", id).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toOrganization(dbData), nil
+}
+
+func (od *OrganizationData) GetBySlug(slug string) (*organizations.Organization, error) {
+	var dbData = new(Organization)
+
+	if err := od.gorm.Where("slug = ?", slug).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toOrganization(dbData), nil
+}
+
+func (od *OrganizationData) List() ([]organizations.Organization, error) {
+	var dbData = []Organization{}
+
+	if err := od.gorm.Order("slug").Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]organizations.Organization, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toOrganization(&dbData[i]))
+	}
+
+	return result, nil
+}
+
+func (od *OrganizationData) Update(newData organizations.Organization) error {
+	var qry = od.gorm.Model(&Organization{}).Where("id = ?", newData.ID).Updates(map[string]any{
+		"name":       newData.Name,
+		"updated_at": newData.UpdatedAt,
+	})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+// Delete also ends every membership, the accounts themselves stay.
+func (od *OrganizationData) Delete(id string) error {
+	return od.gorm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", id).Delete(&Membership{}).Error; err != nil {
+			return err
+		}
+
+		var qry = tx.Where("id = ?", id).Delete(&Organization{})
+
+		if err := qry.Error; err != nil {
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("data not found")
+		}
+
+		return nil
+	})
+}
+
+func (od *OrganizationData) InsertMember(newData organizations.Member) error {
+	var dbData = new(Membership)
+	dbData.OrganizationID = newData.OrganizationID
+	dbData.UserID = newData.UserID
+	dbData.Role = newData.Role
+	dbData.CreatedAt = newData.CreatedAt
+
+	return od.gorm.Create(dbData).Error
+}
+
+func (od *OrganizationData) GetMember(organizationID string, userID string) (*organizations.Member, error) {
+	var dbData = new(memberRow)
+
+	var qry = od.members().Where("memberships.organization_id = ? AND memberships.user_id = ?", organizationID, userID).Limit(1).Scan(dbData)
+	if err := qry.Error; err != nil {
+		return nil, err
+	}
+
+	if qry.RowsAffected < 1 {
+		return nil, errors.New("data not found")
+	}
+
+	return toMember(dbData), nil
+}
+
+func (od *OrganizationData) GetMembers(organizationID string) ([]organizations.Member, error) {
+	var dbData = []memberRow{}
+
+	if err := od.members().Where("memberships.organization_id = ?", organizationID).Order("memberships.created_at").Scan(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]organizations.Member, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toMember(&dbData[i]))
+	}
+
+	return result, nil
+}
+
+func (od *OrganizationData) CountMembers(organizationID string, role string) (int, error) {
+	var total int64
+
+	var qry = od.gorm.Table("memberships").
+		Joins("JOIN users ON users.id = memberships.user_id AND users.deleted_at IS NULL").
+		Where("memberships.organization_id = ? AND memberships.role = ?", organizationID, role)
+	if err := qry.Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	return int(total), nil
+}
+
+func (od *OrganizationData) UpdateMemberRole(organizationID string, userID string, role string) error {
+	var qry = od.gorm.Model(&Membership{}).Where("organization_id = ? AND user_id = ?", organizationID, userID).Update("role", role)
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (od *OrganizationData) DeleteMember(organizationID string, userID string) error {
+	var qry = od.gorm.Where("organization_id = ? AND user_id = ?", organizationID, userID).Delete(&Membership{})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (od *OrganizationData) GetMemberships(userID string) ([]organizations.Membership, error) {
+	var dbData = []membershipRow{}
+
+	var qry = od.gorm.Table("memberships").
+		Select("organizations.*, memberships.role, memberships.created_at AS joined_at").
+		Joins("JOIN organizations ON organizations.id = memberships.organization_id").
+		Where("memberships.user_id = ?", userID).
+		Order("organizations.slug")
+	if err := qry.Scan(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]organizations.Membership, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, organizations.Membership{
+			Organization: *toOrganization(&dbData[i].Organization),
+			Role:         dbData[i].Role,
+			JoinedAt:     dbData[i].JoinedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// members leaves out the memberships of deleted accounts.
+func (od *OrganizationData) members() *gorm.DB {
+	return od.gorm.Table("memberships").
+		Select("memberships.*, users.nama, COALESCE(users.hp, '') AS hp").
+		Joins("JOIN users ON users.id = memberships.user_id AND users.deleted_at IS NULL")
+}
+
+func toOrganization(dbData *Organization) *organizations.Organization {
+	var result = new(organizations.Organization)
+	result.ID = dbData.ID
+	result.Slug = dbData.Slug
+	result.Name = dbData.Name
+	result.CreatedAt = dbData.CreatedAt
+	result.UpdatedAt = dbData.UpdatedAt
+
+	return result
+}
+
+func toMember(dbData *memberRow) *organizations.Member {
+	var result = new(organizations.Member)
+	result.OrganizationID = dbData.OrganizationID
+	result.UserID = dbData.UserID
+	result.Nama = dbData.Nama
+	result.HP = dbData.HP
+	result.Role = dbData.Role
+	result.CreatedAt = dbData.CreatedAt
+
+	return result
+}
@@ -0,0 +1,85 @@
+package organizations
+
+import (
+	"context"
+	"test/helper"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Roles a member has inside one organization, independent of the global role
+// of the account. Owners manage the organization and its admins, admins
+// manage members.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+type Organization struct {
+	ID        string
+	Slug      string
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Member struct {
+	OrganizationID string
+	UserID         string
+	Nama           string
+	HP             string
+	Role           string
+	CreatedAt      time.Time
+}
+
+// Membership is an organization as one of its members sees it.
+type Membership struct {
+	Organization Organization
+	Role         string
+	JoinedAt     time.Time
+}
+
+type OrganizationHandlerInterface interface {
+	Create() echo.HandlerFunc
+	List() echo.HandlerFunc
+	Get() echo.HandlerFunc
+	Update() echo.HandlerFunc
+	Delete() echo.HandlerFunc
+	ListMembers() echo.HandlerFunc
+	AddMember() echo.HandlerFunc
+	UpdateMember() echo.HandlerFunc
+	RemoveMember() echo.HandlerFunc
+	ListMine() echo.HandlerFunc
+	Switch() echo.HandlerFunc
+}
+type OrganizationServiceInterface interface {
+	Create(ctx context.Context, actorID string, newData Organization) (*Organization, error)
+	List(ctx context.Context) ([]Organization, error)
+	Get(ctx context.Context, actor helper.Principal, organizationID string) (*Organization, error)
+	Update(ctx context.Context, actor helper.Principal, newData Organization) (*Organization, error)
+	Delete(ctx context.Context, actorID string, organizationID string) error
+	ListMembers(ctx context.Context, actor helper.Principal, organizationID string) ([]Member, error)
+	AddMember(ctx context.Context, actor helper.Principal, organizationID string, hp string, role string) (*Member, error)
+	UpdateMember(ctx context.Context, actor helper.Principal, organizationID string, userID string, role string) error
+	RemoveMember(ctx context.Context, actor helper.Principal, organizationID string, userID string) error
+	ListForUser(ctx context.Context, userID string) ([]Membership, error)
+	Switch(ctx context.Context, principal helper.Principal, organizationID string) (map[string]any, error)
+	ResolveTenant(slug string) (string, error)
+}
+type OrganizationDataInterface interface {
+	Insert(newData Organization) (*Organization, error)
+	GetByID(id string) (*Organization, error)
+	GetBySlug(slug string) (*Organization, error)
+	List() ([]Organization, error)
+	Update(newData Organization) error
+	Delete(id string) error
+	InsertMember(newData Member) error
+	GetMember(organizationID string, userID string) (*Member, error)
+	GetMembers(organizationID string) ([]Member, error)
+	CountMembers(organizationID string, role string) (int, error)
+	UpdateMemberRole(organizationID string, userID string, role string) error
+	DeleteMember(organizationID string, userID string) error
+	GetMemberships(userID string) ([]Membership, error)
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"test/features/organizations"
+	"test/helper"
+
+	"github.com/labstack/echo/v4"
+)
+
+type OrganizationHandler struct {
+	s organizations.OrganizationServiceInterface
+}
+
+func NewHandler(service organizations.OrganizationServiceInterface) organizations.OrganizationHandlerInterface {
+	return &OrganizationHandler{
+		s: service,
+	}
+}
+
+func (oh *OrganizationHandler) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(OrganizationInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := oh.s.Create(c.Request().Context(), principal.UserID, organizations.Organization{Slug: input.Slug, Name: input.Name})
+
+		if err != nil {
+			c.Logger().Error("handler: create organization process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", organizationResponse(*result)))
+	}
+}
+
+func (oh *OrganizationHandler) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := oh.s.List(c.Request().Context())
+
+		if err != nil {
+			c.Logger().Error("handler: list organizations process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = make([]OrganizationResponse, 0, len(result))
+		for _, organization := range result {
+			response = append(response, *organizationResponse(organization))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (oh *OrganizationHandler) Get() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := oh.s.Get(c.Request().Context(), *principal, c.Param("id"))
+
+		if err != nil {
+			c.Logger().Error("handler: get organization process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", organizationResponse(*result)))
+	}
+}
+
+func (oh *OrganizationHandler) Update() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(OrganizationInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := oh.s.Update(c.Request().Context(), *principal, organizations.Organization{ID: c.Param("id"), Name: input.Name})
+
+		if err != nil {
+			c.Logger().Error("handler: update organization process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", organizationResponse(*result)))
+	}
+}
+
+func (oh *OrganizationHandler) Delete() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := oh.s.Delete(c.Request().Context(), principal.UserID, c.Param("id")); err != nil {
+			c.Logger().Error("handler: delete organization process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (oh *OrganizationHandler) ListMembers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := oh.s.ListMembers(c.Request().Context(), *principal, c.Param("id"))
+
+		if err != nil {
+			c.Logger().Error("handler: list members process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		var response = make([]MemberResponse, 0, len(result))
+		for _, member := range result {
+			response = append(response, *memberResponse(member))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (oh *OrganizationHandler) AddMember() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(MemberInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if input.Role == "" {
+			input.Role = organizations.RoleMember
+		}
+
+		result, err := oh.s.AddMember(c.Request().Context(), *principal, c.Param("id"), input.HP, input.Role)
+
+		if err != nil {
+			c.Logger().Error("handler: add member process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", memberResponse(*result)))
+	}
+}
+
+func (oh *OrganizationHandler) UpdateMember() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(RoleInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if err := oh.s.UpdateMember(c.Request().Context(), *principal, c.Param("id"), c.Param("user_id"), input.Role); err != nil {
+			c.Logger().Error("handler: update member process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (oh *OrganizationHandler) RemoveMember() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := oh.s.RemoveMember(c.Request().Context(), *principal, c.Param("id"), c.Param("user_id")); err != nil {
+			c.Logger().Error("handler: remove member process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (oh *OrganizationHandler) ListMine() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := oh.s.ListForUser(c.Request().Context(), principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: list memberships process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = make([]MembershipResponse, 0, len(result))
+		for _, membership := range result {
+			response = append(response, MembershipResponse{
+				OrganizationResponse: *organizationResponse(membership.Organization),
+				Role:                 membership.Role,
+				JoinedAt:             membership.JoinedAt,
+			})
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (oh *OrganizationHandler) Switch() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := oh.s.Switch(c.Request().Context(), *principal, c.Param("id"))
+
+		if err != nil {
+			c.Logger().Error("handler: switch organization process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		var response = new(SwitchResponse)
+		response.OrganizationID = c.Param("id")
+		if tokens, csrfToken := helper.DeliverTokens(c, result); tokens != nil {
+			response.Token = tokens
+		} else {
+			response.CSRFToken = csrfToken
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func errorResponse(c echo.Context, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "invalid"), strings.Contains(err.Error(), "cannot"), strings.Contains(err.Error(), "deactivated"):
+		return c.JSON(http.StatusBadRequest, helper.FormatResponse(err.Error(), nil))
+	case strings.Contains(err.Error(), "forbidden"):
+		return c.JSON(http.StatusForbidden, helper.FormatResponse("forbidden", nil))
+	case strings.Contains(err.Error(), "not found"):
+		return c.JSON(http.StatusNotFound, helper.FormatResponse(err.Error(), nil))
+	case strings.Contains(err.Error(), "already"):
+		return c.JSON(http.StatusConflict, helper.FormatResponse(err.Error(), nil))
+	}
+	return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+}
+
+func organizationResponse(organization organizations.Organization) *OrganizationResponse {
+	var response = new(OrganizationResponse)
+	response.ID = organization.ID
+	response.Slug = organization.Slug
+	response.Name = organization.Name
+	response.CreatedAt = organization.CreatedAt
+	response.UpdatedAt = organization.UpdatedAt
+
+	return response
+}
+
+func memberResponse(member organizations.Member) *MemberResponse {
+	var response = new(MemberResponse)
+	response.UserID = member.UserID
+	response.Nama = member.Nama
+	response.HP = member.HP
+	response.Role = member.Role
+	response.JoinedAt = member.CreatedAt
+
+	return response
+}
@@ -0,0 +1,15 @@
+package handler
+
+type OrganizationInput struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type MemberInput struct {
+	HP   string `json:"hp"`
+	Role string `json:"role"`
+}
+
+type RoleInput struct {
+	Role string `json:"role"`
+}
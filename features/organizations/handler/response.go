@@ -0,0 +1,31 @@
+package handler
+
+import "time"
+
+type OrganizationResponse struct {
+	ID        string    `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type MemberResponse struct {
+	UserID   string    `json:"user_id"`
+	Nama     string    `json:"nama"`
+	HP       string    `json:"hp"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type MembershipResponse struct {
+	OrganizationResponse
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type SwitchResponse struct {
+	OrganizationID string `json:"organization_id"`
+	Token          any    `json:"token,omitempty"`
+	CSRFToken      string `json:"csrf_token,omitempty"`
+}
@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"test/features/audit"
+	"test/features/organizations"
+	"test/features/users"
+	"test/helper"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const maxNameLength = 100
+
+// Slugs double as subdomains, so they have to be valid DNS labels and must not
+// take the names of the service's own hosts.
+var (
+	slugPattern   = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	reservedSlugs = map[string]bool{"www": true, "api": true, "app": true, "admin": true, "auth": true, "login": true}
+)
+
+type OrganizationService struct {
+	d organizations.OrganizationDataInterface
+	u users.UserDataInterface
+	j helper.JWTInterface
+	a audit.AuditServiceInterface
+	g helper.GeneratorInterface
+	c helper.ClockInterface
+}
+
+func New(data organizations.OrganizationDataInterface, userData users.UserDataInterface, jwt helper.JWTInterface, auditor audit.AuditServiceInterface, generator helper.GeneratorInterface, clock helper.ClockInterface) organizations.OrganizationServiceInterface {
+	return &OrganizationService{
+		d: data,
+		u: userData,
+		j: jwt,
+		a: auditor,
+		g: generator,
+		c: clock,
+	}
+}
+
+// Create is for admins of the whole service, the organization starts without
+// members. Its first owner is added with AddMember.
+func (ors *OrganizationService) Create(ctx context.Context, actorID string, newData organizations.Organization) (*organizations.Organization, error) {
+	newData.Slug = strings.ToLower(strings.TrimSpace(newData.Slug))
+	if !slugPattern.MatchString(newData.Slug) || reservedSlugs[newData.Slug] {
+		return nil, errors.New("invalid slug")
+	}
+
+	name, err := validName(newData.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ors.d.GetBySlug(newData.Slug); err == nil {
+		return nil, errors.New("slug already taken")
+	} else if !strings.Contains(err.Error(), "not found") {
+		return nil, errors.New("process failed")
+	}
+
+	newID, err := ors.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	var now = ors.c.Now()
+	newData.ID = newID
+	newData.Name = name
+	newData.CreatedAt = now
+	newData.UpdatedAt = now
+
+	result, err := ors.d.Insert(newData)
+	if err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	ors.audit(ctx, audit.Event{ActorID: actorID, Action: "organization.created", TargetID: result.ID, After: map[string]any{"slug": result.Slug, "name": result.Name}})
+
+	return result, nil
+}
+
+func (ors *OrganizationService) List(ctx context.Context) ([]organizations.Organization, error) {
+	result, err := ors.d.List()
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (ors *OrganizationService) Get(ctx context.Context, actor helper.Principal, organizationID string) (*organizations.Organization, error) {
+	result, err := ors.getOrganization(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ors.authorize(actor, result.ID); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Update only renames, the slug stays because clients may have it in URLs.
+func (ors *OrganizationService) Update(ctx context.Context, actor helper.Principal, newData organizations.Organization) (*organizations.Organization, error) {
+	result, err := ors.getOrganization(newData.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ors.authorize(actor, result.ID, organizations.RoleOwner, organizations.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	name, err := validName(newData.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == result.Name {
+		return result, nil
+	}
+
+	var before = map[string]any{"name": result.Name}
+	result.Name = name
+	result.UpdatedAt = ors.c.Now()
+	if err := ors.d.Update(*result); err != nil {
+		return nil, errors.New("update process failed")
+	}
+
+	ors.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "organization.updated", TargetID: result.ID, Before: before, After: map[string]any{"name": result.Name}})
+
+	return result, nil
+}
+
+func (ors *OrganizationService) Delete(ctx context.Context, actorID string, organizationID string) error {
+	if err := ors.d.Delete(organizationID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("delete process failed")
+	}
+
+	ors.audit(ctx, audit.Event{ActorID: actorID, Action: "organization.deleted", TargetID: organizationID})
+
+	return nil
+}
+
+func (ors *OrganizationService) ListMembers(ctx context.Context, actor helper.Principal, organizationID string) ([]organizations.Member, error) {
+	if _, err := ors.getOrganization(organizationID); err != nil {
+		return nil, err
+	}
+
+	if _, err := ors.authorize(actor, organizationID); err != nil {
+		return nil, err
+	}
+
+	result, err := ors.d.GetMembers(organizationID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+// AddMember invites an existing account by its HP. Only owners can make
+// someone else an owner.
+func (ors *OrganizationService) AddMember(ctx context.Context, actor helper.Principal, organizationID string, hp string, role string) (*organizations.Member, error) {
+	if !validRole(role) {
+		return nil, errors.New("invalid role")
+	}
+
+	if _, err := ors.getOrganization(organizationID); err != nil {
+		return nil, err
+	}
+
+	actorRole, err := ors.authorize(actor, organizationID, organizations.RoleOwner, organizations.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if role == organizations.RoleOwner && actorRole != organizations.RoleOwner {
+		return nil, errors.New("forbidden")
+	}
+
+	user, err := ors.u.GetByHP(strings.TrimSpace(hp))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("user not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	if user.DeactivatedAt != nil {
+		return nil, errors.New("account deactivated")
+	}
+
+	if _, err := ors.d.GetMember(organizationID, user.ID); err == nil {
+		return nil, errors.New("already a member")
+	} else if !strings.Contains(err.Error(), "not found") {
+		return nil, errors.New("process failed")
+	}
+
+	var newData = new(organizations.Member)
+	newData.OrganizationID = organizationID
+	newData.UserID = user.ID
+	newData.Nama = user.Nama
+	newData.HP = user.HP
+	newData.Role = role
+	newData.CreatedAt = ors.c.Now()
+
+	if err := ors.d.InsertMember(*newData); err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	ors.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "organization.member_added", TargetID: user.ID, After: map[string]any{"organization_id": organizationID, "role": role}})
+
+	return newData, nil
+}
+
+func (ors *OrganizationService) UpdateMember(ctx context.Context, actor helper.Principal, organizationID string, userID string, role string) error {
+	if !validRole(role) {
+		return errors.New("invalid role")
+	}
+
+	actorRole, err := ors.authorize(actor, organizationID, organizations.RoleOwner, organizations.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	member, err := ors.getMember(organizationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if member.Role == role {
+		return nil
+	}
+
+	if (member.Role == organizations.RoleOwner || role == organizations.RoleOwner) && actorRole != organizations.RoleOwner {
+		return errors.New("forbidden")
+	}
+
+	if member.Role == organizations.RoleOwner {
+		if err := ors.keepOwner(organizationID); err != nil {
+			return err
+		}
+	}
+
+	if err := ors.d.UpdateMemberRole(organizationID, userID, role); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("update process failed")
+	}
+
+	ors.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "organization.member_role_changed", TargetID: userID, Before: map[string]any{"role": member.Role}, After: map[string]any{"organization_id": organizationID, "role": role}})
+
+	return nil
+}
+
+// RemoveMember also lets members leave on their own.
+func (ors *OrganizationService) RemoveMember(ctx context.Context, actor helper.Principal, organizationID string, userID string) error {
+	var actorRole string
+	var err error
+	if userID == actor.UserID {
+		actorRole, err = ors.authorize(actor, organizationID)
+	} else {
+		actorRole, err = ors.authorize(actor, organizationID, organizations.RoleOwner, organizations.RoleAdmin)
+	}
+	if err != nil {
+		return err
+	}
+
+	member, err := ors.getMember(organizationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if member.Role == organizations.RoleOwner {
+		if actorRole != organizations.RoleOwner {
+			return errors.New("forbidden")
+		}
+		if err := ors.keepOwner(organizationID); err != nil {
+			return err
+		}
+	}
+
+	if err := ors.d.DeleteMember(organizationID, userID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("delete process failed")
+	}
+
+	ors.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "organization.member_removed", TargetID: userID, Before: map[string]any{"organization_id": organizationID, "role": member.Role}})
+
+	return nil
+}
+
+func (ors *OrganizationService) ListForUser(ctx context.Context, userID string) ([]organizations.Membership, error) {
+	result, err := ors.d.GetMemberships(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+// Switch issues an access token for the same session that carries the
+// organization in its org claim. Requests with it are scoped to that
+// organization without naming it.
+func (ors *OrganizationService) Switch(ctx context.Context, principal helper.Principal, organizationID string) (map[string]any, error) {
+	if _, err := ors.getOrganization(organizationID); err != nil {
+		return nil, err
+	}
+
+	if _, err := ors.authorize(principal, organizationID); err != nil {
+		return nil, err
+	}
+
+	principal.OrganizationID = organizationID
+
+	result := ors.j.GenerateJWT(principal)
+	if result == nil {
+		return nil, errors.New("token process failed")
+	}
+
+	return result, nil
+}
+
+// ResolveTenant makes the service the helper.TenantResolverInterface of the
+// tenant middleware.
+func (ors *OrganizationService) ResolveTenant(slug string) (string, error) {
+	result, err := ors.d.GetBySlug(slug)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return "", errors.New("data not found")
+		}
+		return "", errors.New("process failed")
+	}
+
+	return result.ID, nil
+}
+
+// authorize returns the role of the actor in the organization, when it is one
+// of roles or roles is empty. Admins of the whole service count as owners of
+// every organization. Non-members get "data not found" so they do not learn
+// which organizations exist.
+func (ors *OrganizationService) authorize(actor helper.Principal, organizationID string, roles ...string) (string, error) {
+	if actor.HasRole(users.RoleAdmin) {
+		return organizations.RoleOwner, nil
+	}
+
+	member, err := ors.getMember(organizationID, actor.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(roles) == 0 {
+		return member.Role, nil
+	}
+
+	for _, role := range roles {
+		if member.Role == role {
+			return member.Role, nil
+		}
+	}
+
+	return "", errors.New("forbidden")
+}
+
+// keepOwner refuses to take away the role of the last owner.
+func (ors *OrganizationService) keepOwner(organizationID string) error {
+	owners, err := ors.d.CountMembers(organizationID, organizations.RoleOwner)
+	if err != nil {
+		return errors.New("process failed")
+	}
+
+	if owners <= 1 {
+		return errors.New("cannot remove last owner")
+	}
+
+	return nil
+}
+
+func (ors *OrganizationService) getOrganization(organizationID string) (*organizations.Organization, error) {
+	result, err := ors.d.GetByID(organizationID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (ors *OrganizationService) getMember(organizationID string, userID string) (*organizations.Member, error) {
+	result, err := ors.d.GetMember(organizationID, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (ors *OrganizationService) audit(ctx context.Context, event audit.Event) {
+	if err := ors.a.Record(ctx, event); err != nil {
+		logrus.Error("service: audit record error:", err.Error())
+	}
+}
+
+func validName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return "", errors.New("invalid name")
+	}
+
+	return name, nil
+}
+
+func validRole(role string) bool {
+	switch role {
+	case organizations.RoleOwner, organizations.RoleAdmin, organizations.RoleMember:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"test/features/audit"
+	auditMocks "test/features/audit/mocks"
+	"test/features/organizations"
+	"test/features/organizations/mocks"
+	"test/features/users"
+	userMocks "test/features/users/mocks"
+	realHelper "test/helper"
+	helper "test/helper/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreate(t *testing.T) {
+	data := mocks.NewOrganizationDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	j := helper.NewJWTInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, j, auditor, generator, clock)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Success create", func(t *testing.T) {
+		expected := organizations.Organization{ID: "randomOrgID", Slug: "acme", Name: "Acme Inc", CreatedAt: now, UpdatedAt: now}
+		data.On("GetBySlug", "acme").Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateUUID").Return("randomOrgID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("Insert", expected).Return(&expected, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "adminID", Action: "organization.created", TargetID: "randomOrgID", After: map[string]any{"slug": "acme", "name": "Acme Inc"}}).Return(nil).Once()
+
+		result, err := service.Create(context.Background(), "adminID", organizations.Organization{Slug: " Acme ", Name: " Acme Inc "})
+		assert.Nil(t, err)
+		assert.Equal(t, "randomOrgID", result.ID)
+	})
+
+	t.Run("Invalid slug", func(t *testing.T) {
+		for _, slug := range []string{"", "-acme", "acme.example", "acme_inc", "www"} {
+			result, err := service.Create(context.Background(), "adminID", organizations.Organization{Slug: slug, Name: "Acme Inc"})
+			assert.EqualError(t, err, "invalid slug")
+			assert.Nil(t, result)
+		}
+	})
+
+	t.Run("Invalid name", func(t *testing.T) {
+		result, err := service.Create(context.Background(), "adminID", organizations.Organization{Slug: "acme", Name: " "})
+		assert.EqualError(t, err, "invalid name")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Slug taken", func(t *testing.T) {
+		data.On("GetBySlug", "acme").Return(&organizations.Organization{ID: "otherOrgID", Slug: "acme"}, nil).Once()
+
+		result, err := service.Create(context.Background(), "adminID", organizations.Organization{Slug: "acme", Name: "Acme Inc"})
+		assert.EqualError(t, err, "slug already taken")
+		assert.Nil(t, result)
+	})
+}
+
+func TestMembers(t *testing.T) {
+	data := mocks.NewOrganizationDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	j := helper.NewJWTInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, j, auditor, generator, clock)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	organization := organizations.Organization{ID: "randomOrgID", Slug: "acme", Name: "Acme Inc"}
+	owner := realHelper.Principal{UserID: "ownerID", Roles: []string{users.RoleUser}}
+	orgAdmin := realHelper.Principal{UserID: "orgAdminID", Roles: []string{users.RoleUser}}
+	admin := realHelper.Principal{UserID: "adminID", Roles: []string{users.RoleAdmin}}
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123"}
+
+	t.Run("Owner adds a member", func(t *testing.T) {
+		data.On("GetByID", organization.ID).Return(&organization, nil).Once()
+		data.On("GetMember", organization.ID, owner.UserID).Return(&organizations.Member{UserID: owner.UserID, Role: organizations.RoleOwner}, nil).Once()
+		userData.On("GetByHP", "123").Return(&user, nil).Once()
+		data.On("GetMember", organization.ID, user.ID).Return(nil, errors.New("data not found")).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertMember", organizations.Member{OrganizationID: organization.ID, UserID: user.ID, Nama: "dida", HP: "123", Role: organizations.RoleAdmin, CreatedAt: now}).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: owner.UserID, Action: "organization.member_added", TargetID: user.ID, After: map[string]any{"organization_id": organization.ID, "role": organizations.RoleAdmin}}).Return(nil).Once()
+
+		result, err := service.AddMember(context.Background(), owner, organization.ID, "123", organizations.RoleAdmin)
+		assert.Nil(t, err)
+		assert.Equal(t, organizations.RoleAdmin, result.Role)
+	})
+
+	t.Run("Org admin cannot add an owner", func(t *testing.T) {
+		data.On("GetByID", organization.ID).Return(&organization, nil).Once()
+		data.On("GetMember", organization.ID, orgAdmin.UserID).Return(&organizations.Member{UserID: orgAdmin.UserID, Role: organizations.RoleAdmin}, nil).Once()
+
+		result, err := service.AddMember(context.Background(), orgAdmin, organization.ID, "123", organizations.RoleOwner)
+		assert.EqualError(t, err, "forbidden")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Plain member cannot add members", func(t *testing.T) {
+		data.On("GetByID", organization.ID).Return(&organization, nil).Once()
+		data.On("GetMember", organization.ID, user.ID).Return(&organizations.Member{UserID: user.ID, Role: organizations.RoleMember}, nil).Once()
+
+		result, err := service.AddMember(context.Background(), realHelper.Principal{UserID: user.ID}, organization.ID, "456", organizations.RoleMember)
+		assert.EqualError(t, err, "forbidden")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Non member does not see the organization", func(t *testing.T) {
+		data.On("GetByID", organization.ID).Return(&organization, nil).Once()
+		data.On("GetMember", organization.ID, "strangerID").Return(nil, errors.New("data not found")).Once()
+
+		result, err := service.ListMembers(context.Background(), realHelper.Principal{UserID: "strangerID"}, organization.ID)
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Already a member", func(t *testing.T) {
+		data.On("GetByID", organization.ID).Return(&organization, nil).Once()
+		userData.On("GetByHP", "123").Return(&user, nil).Once()
+		data.On("GetMember", organization.ID, user.ID).Return(&organizations.Member{UserID: user.ID, Role: organizations.RoleMember}, nil).Once()
+
+		result, err := service.AddMember(context.Background(), admin, organization.ID, "123", organizations.RoleMember)
+		assert.EqualError(t, err, "already a member")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		result, err := service.AddMember(context.Background(), admin, organization.ID, "123", "superuser")
+		assert.EqualError(t, err, "invalid role")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Last owner cannot be demoted", func(t *testing.T) {
+		data.On("GetMember", organization.ID, owner.UserID).Return(&organizations.Member{UserID: owner.UserID, Role: organizations.RoleOwner}, nil).Twice()
+		data.On("CountMembers", organization.ID, organizations.RoleOwner).Return(1, nil).Once()
+
+		err := service.UpdateMember(context.Background(), owner, organization.ID, owner.UserID, organizations.RoleMember)
+		assert.EqualError(t, err, "cannot remove last owner")
+	})
+
+	t.Run("Org admin cannot demote an owner", func(t *testing.T) {
+		data.On("GetMember", organization.ID, orgAdmin.UserID).Return(&organizations.Member{UserID: orgAdmin.UserID, Role: organizations.RoleAdmin}, nil).Once()
+		data.On("GetMember", organization.ID, owner.UserID).Return(&organizations.Member{UserID: owner.UserID, Role: organizations.RoleOwner}, nil).Once()
+
+		err := service.UpdateMember(context.Background(), orgAdmin, organization.ID, owner.UserID, organizations.RoleMember)
+		assert.EqualError(t, err, "forbidden")
+	})
+
+	t.Run("Member leaves", func(t *testing.T) {
+		data.On("GetMember", organization.ID, user.ID).Return(&organizations.Member{UserID: user.ID, Role: organizations.RoleMember}, nil).Twice()
+		data.On("DeleteMember", organization.ID, user.ID).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: user.ID, Action: "organization.member_removed", TargetID: user.ID, Before: map[string]any{"organization_id": organization.ID, "role": organizations.RoleMember}}).Return(nil).Once()
+
+		err := service.RemoveMember(context.Background(), realHelper.Principal{UserID: user.ID}, organization.ID, user.ID)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Switch into an organization", func(t *testing.T) {
+		principal := realHelper.Principal{UserID: user.ID, Roles: []string{users.RoleUser}, SessionID: "randomSessionID"}
+		data.On("GetByID", organization.ID).Return(&organization, nil).Once()
+		data.On("GetMember", organization.ID, user.ID).Return(&organizations.Member{UserID: user.ID, Role: organizations.RoleMember}, nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: user.ID, Roles: []string{users.RoleUser}, SessionID: "randomSessionID", OrganizationID: organization.ID}).Return(map[string]any{"access_token": "randomAccessToken"}).Once()
+
+		result, err := service.Switch(context.Background(), principal, organization.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]any{"access_token": "randomAccessToken"}, result)
+	})
+}
+
+func TestResolveTenant(t *testing.T) {
+	data := mocks.NewOrganizationDataInterface(t)
+	service := New(data, nil, nil, nil, nil, nil)
+
+	t.Run("Known slug", func(t *testing.T) {
+		data.On("GetBySlug", "acme").Return(&organizations.Organization{ID: "randomOrgID", Slug: "acme"}, nil).Once()
+
+		result, err := service.ResolveTenant("acme")
+		assert.Nil(t, err)
+		assert.Equal(t, "randomOrgID", result)
+	})
+
+	t.Run("Unknown slug", func(t *testing.T) {
+		data.On("GetBySlug", "other").Return(nil, errors.New("record not found")).Once()
+
+		result, err := service.ResolveTenant("other")
+		assert.EqualError(t, err, "data not found")
+		assert.Empty(t, result)
+	})
+}
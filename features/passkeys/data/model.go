@@ -0,0 +1,24 @@
+package data
+
+import "time"
+
+// CredentialID is base64url, compared case sensitive and kept ascii so the
+// longest IDs authenticators may send still fit in an index.
+type Passkey struct {
+	ID           string `gorm:"type:varchar(255);primaryKey"`
+	UserID       string `gorm:"type:varchar(255);index"`
+	CredentialID string `gorm:"type:varchar(1400) CHARACTER SET ascii COLLATE ascii_bin;uniqueIndex"`
+	PublicKey    []byte
+	SignCount    uint32
+	Name         string `gorm:"type:varchar(64)"`
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+type PasskeyCeremony struct {
+	ID        string    `gorm:"type:varchar(255);primaryKey"`
+	UserID    string    `gorm:"type:varchar(255)"`
+	Challenge string    `gorm:"type:varchar(64)"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,157 @@
+package data
+
+import (
+	"errors"
+	"test/features/passkeys"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PasskeyData struct {
+	gorm *gorm.DB
+}
+
+func New(g *gorm.DB) passkeys.PasskeyDataInterface {
+	return &PasskeyData{
+		gorm: g,
+	}
+}
+
+// InsertCeremony also drops the expired ceremonies of abandoned logins and
+// registrations.
+func (pd *PasskeyData) InsertCeremony(newData passkeys.Ceremony) error {
+	if err := pd.gorm.Where("expires_at < ?", newData.CreatedAt).Delete(&PasskeyCeremony{}).Error; err != nil {
+		logrus.Error("db error:", err.Error())
+	}
+
+	var dbData = new(PasskeyCeremony)
+	dbData.ID = newData.ID
+	dbData.UserID = newData.UserID
+	dbData.Challenge = newData.Challenge
+	dbData.ExpiresAt = newData.ExpiresAt
+	dbData.CreatedAt = newData.CreatedAt
+
+	return pd.gorm.Create(dbData).Error
+}
+
+// TakeCeremony returns the ceremony and deletes it, so its challenge can
+// only be answered once.
+func (pd *PasskeyData) TakeCeremony(ceremonyID string) (*passkeys.Ceremony, error) {
+	var dbData = new(PasskeyCeremony)
+
+	err := pd.gorm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", ceremonyID).First(dbData).Error; err != nil {
+			return err
+		}
+
+		var qry = tx.Where("id = ?", ceremonyID).Delete(&PasskeyCeremony{})
+		if err := qry.Error; err != nil {
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("data not found")
+		}
+
+		return nil
+	})
+	if err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	var result = new(passkeys.Ceremony)
+	result.ID = dbData.ID
+	result.UserID = dbData.UserID
+	result.Challenge = dbData.Challenge
+	result.ExpiresAt = dbData.ExpiresAt
+	result.CreatedAt = dbData.CreatedAt
+
+	return result, nil
+}
+
+func (pd *PasskeyData) Insert(newData passkeys.Passkey) error {
+	var dbData = new(Passkey)
+	dbData.ID = newData.ID
+	dbData.UserID = newData.UserID
+	dbData.CredentialID = newData.CredentialID
+	dbData.PublicKey = newData.PublicKey
+	dbData.SignCount = newData.SignCount
+	dbData.Name = newData.Name
+	dbData.CreatedAt = newData.CreatedAt
+	dbData.LastUsedAt = newData.LastUsedAt
+
+	return pd.gorm.Create(dbData).Error
+}
+
+func (pd *PasskeyData) GetByCredentialID(credentialID string) (*passkeys.Passkey, error) {
+	var dbData = new(Passkey)
+
+	if err := pd.gorm.Where("credential_id = ?", credentialID).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toPasskey(dbData), nil
+}
+
+func (pd *PasskeyData) GetByUser(userID string) ([]passkeys.Passkey, error) {
+	var dbData = []Passkey{}
+
+	if err := pd.gorm.Where("user_id = ?", userID).Order("created_at").Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]passkeys.Passkey, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toPasskey(&dbData[i]))
+	}
+
+	return result, nil
+}
+
+func (pd *PasskeyData) UpdateSignCount(passkeyID string, signCount uint32, usedAt time.Time) error {
+	return pd.gorm.Model(&Passkey{}).Where("id = ?", passkeyID).Updates(map[string]any{"sign_count": signCount, "last_used_at": usedAt}).Error
+}
+
+func (pd *PasskeyData) Delete(userID string, passkeyID string) error {
+	var qry = pd.gorm.Where("id = ? AND user_id = ?", passkeyID, userID).Delete(&Passkey{})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (pd *PasskeyData) EraseUser(userID string) error {
+	return pd.gorm.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{&Passkey{}, &PasskeyCeremony{}} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func toPasskey(dbData *Passkey) *passkeys.Passkey {
+	var result = new(passkeys.Passkey)
+	result.ID = dbData.ID
+	result.UserID = dbData.UserID
+	result.CredentialID = dbData.CredentialID
+	result.PublicKey = dbData.PublicKey
+	result.SignCount = dbData.SignCount
+	result.Name = dbData.Name
+	result.CreatedAt = dbData.CreatedAt
+	result.LastUsedAt = dbData.LastUsedAt
+
+	return result
+}
@@ -0,0 +1,99 @@
+package passkeys
+
+import (
+	"context"
+	"test/features/users"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Passkey is a WebAuthn credential a user registered to log in with.
+// CredentialID is base64url encoded, PublicKey is the COSE key the
+// authenticator sent at registration.
+type Passkey struct {
+	ID           string
+	UserID       string
+	CredentialID string
+	PublicKey    []byte
+	SignCount    uint32
+	Name         string
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+// Ceremony is a registration or login waiting for the authenticator's
+// response. UserID is only set for registrations.
+type Ceremony struct {
+	ID        string
+	UserID    string
+	Challenge string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// RegistrationOptions are the parameters of navigator.credentials.create.
+// UserHandle is the base64url encoded user ID the passkey is stored with.
+type RegistrationOptions struct {
+	CeremonyID         string
+	Challenge          string
+	RPID               string
+	RPName             string
+	UserHandle         string
+	UserName           string
+	DisplayName        string
+	Algorithms         []int
+	ExcludeCredentials []string
+	Timeout            time.Duration
+}
+
+// LoginOptions are the parameters of navigator.credentials.get. No
+// credentials are listed, the authenticator offers the passkeys it holds.
+type LoginOptions struct {
+	CeremonyID string
+	Challenge  string
+	RPID       string
+	Timeout    time.Duration
+}
+
+type RegistrationResponse struct {
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+type AssertionResponse struct {
+	CredentialID      string
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+type PasskeyHandlerInterface interface {
+	BeginRegistration() echo.HandlerFunc
+	FinishRegistration() echo.HandlerFunc
+	BeginLogin() echo.HandlerFunc
+	FinishLogin() echo.HandlerFunc
+	List() echo.HandlerFunc
+	Delete() echo.HandlerFunc
+}
+type PasskeyServiceInterface interface {
+	BeginRegistration(ctx context.Context, userID string) (*RegistrationOptions, error)
+	FinishRegistration(ctx context.Context, userID string, ceremonyID string, name string, response RegistrationResponse) (*Passkey, error)
+	BeginLogin(ctx context.Context) (*LoginOptions, error)
+	FinishLogin(ctx context.Context, ceremonyID string, response AssertionResponse) (*users.UserCredential, error)
+	List(ctx context.Context, userID string) ([]Passkey, error)
+	Delete(ctx context.Context, userID string, passkeyID string) error
+}
+type PasskeyDataInterface interface {
+	InsertCeremony(newData Ceremony) error
+	TakeCeremony(ceremonyID string) (*Ceremony, error)
+	Insert(newData Passkey) error
+	GetByCredentialID(credentialID string) (*Passkey, error)
+	GetByUser(userID string) ([]Passkey, error)
+	UpdateSignCount(passkeyID string, signCount uint32, usedAt time.Time) error
+	Delete(userID string, passkeyID string) error
+	// EraseUser deletes the passkeys and pending ceremonies of the user, it is
+	// the users feature's erasure hook.
+	EraseUser(userID string) error
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"test/features/passkeys"
+	"test/features/users"
+	"test/helper"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+const maxNameLength = 64
+
+type PasskeyHandler struct {
+	s passkeys.PasskeyServiceInterface
+}
+
+func NewHandler(service passkeys.PasskeyServiceInterface) passkeys.PasskeyHandlerInterface {
+	return &PasskeyHandler{
+		s: service,
+	}
+}
+
+func (ph *PasskeyHandler) BeginRegistration() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := ph.s.BeginRegistration(c.Request().Context(), principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: begin passkey registration process error:", err.Error())
+			switch {
+			case strings.Contains(err.Error(), "not found"):
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			case strings.Contains(err.Error(), "deactivated"):
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("account deactivated", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = new(CreationOptionsResponse)
+		response.CeremonyID = result.CeremonyID
+		response.PublicKey.Challenge = result.Challenge
+		response.PublicKey.RP = RelyingParty{ID: result.RPID, Name: result.RPName}
+		response.PublicKey.User = UserEntity{ID: result.UserHandle, Name: result.UserName, DisplayName: result.DisplayName}
+		for _, alg := range result.Algorithms {
+			response.PublicKey.PubKeyCredParams = append(response.PublicKey.PubKeyCredParams, CredentialParameter{Type: "public-key", Alg: alg})
+		}
+		response.PublicKey.Timeout = result.Timeout.Milliseconds()
+		response.PublicKey.ExcludeCredentials = make([]CredentialDescriptor, 0, len(result.ExcludeCredentials))
+		for _, id := range result.ExcludeCredentials {
+			response.PublicKey.ExcludeCredentials = append(response.PublicKey.ExcludeCredentials, CredentialDescriptor{Type: "public-key", ID: id})
+		}
+		response.PublicKey.AuthenticatorSelection = AuthenticatorSelection{ResidentKey: "required", RequireResident: true, UserVerification: "preferred"}
+		response.PublicKey.Attestation = "none"
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (ph *PasskeyHandler) FinishRegistration() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(RegistrationInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if utf8.RuneCountInString(input.Name) > maxNameLength {
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("name too long", nil))
+		}
+
+		clientData, errClientData := decodeBase64URL(input.Credential.Response.ClientDataJSON)
+		attestation, errAttestation := decodeBase64URL(input.Credential.Response.AttestationObject)
+		if errClientData != nil || errAttestation != nil {
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("invalid credential", nil))
+		}
+
+		result, err := ph.s.FinishRegistration(c.Request().Context(), principal.UserID, input.CeremonyID, strings.TrimSpace(input.Name), passkeys.RegistrationResponse{ClientDataJSON: clientData, AttestationObject: attestation})
+
+		if err != nil {
+			c.Logger().Error("handler: finish passkey registration process error:", err.Error())
+			switch {
+			case strings.Contains(err.Error(), "invalid"):
+				return c.JSON(http.StatusBadRequest, helper.FormatResponse(err.Error(), nil))
+			case strings.Contains(err.Error(), "already registered"):
+				return c.JSON(http.StatusConflict, helper.FormatResponse("passkey already registered", nil))
+			case strings.Contains(err.Error(), "not found"):
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			case strings.Contains(err.Error(), "deactivated"):
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("account deactivated", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", passkeyResponse(*result)))
+	}
+}
+
+func (ph *PasskeyHandler) BeginLogin() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := ph.s.BeginLogin(c.Request().Context())
+
+		if err != nil {
+			c.Logger().Error("handler: begin passkey login process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = new(RequestOptionsResponse)
+		response.CeremonyID = result.CeremonyID
+		response.PublicKey.Challenge = result.Challenge
+		response.PublicKey.RPID = result.RPID
+		response.PublicKey.Timeout = result.Timeout.Milliseconds()
+		response.PublicKey.UserVerification = "preferred"
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (ph *PasskeyHandler) FinishLogin() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(LoginInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		credentialID, errID := decodeBase64URL(input.Credential.ID)
+		clientData, errClientData := decodeBase64URL(input.Credential.Response.ClientDataJSON)
+		authData, errAuthData := decodeBase64URL(input.Credential.Response.AuthenticatorData)
+		signature, errSignature := decodeBase64URL(input.Credential.Response.Signature)
+		userHandle, errUserHandle := decodeBase64URL(input.Credential.Response.UserHandle)
+		if errID != nil || errClientData != nil || errAuthData != nil || errSignature != nil || errUserHandle != nil || len(credentialID) == 0 {
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("invalid credential", nil))
+		}
+
+		var response = passkeys.AssertionResponse{
+			CredentialID:      base64.RawURLEncoding.EncodeToString(credentialID),
+			ClientDataJSON:    clientData,
+			AuthenticatorData: authData,
+			Signature:         signature,
+			UserHandle:        userHandle,
+		}
+
+		result, err := ph.s.FinishLogin(c.Request().Context(), input.CeremonyID, response)
+
+		if err != nil {
+			c.Logger().Error("handler: passkey login process error:", err.Error())
+			switch {
+			case strings.Contains(err.Error(), "invalid"), strings.Contains(err.Error(), "not found"):
+				return c.JSON(http.StatusUnauthorized, helper.FormatResponse("fail", nil))
+			case strings.Contains(err.Error(), "deactivated"):
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("account deactivated", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", loginResponse(c, result)))
+	}
+}
+
+func (ph *PasskeyHandler) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := ph.s.List(c.Request().Context(), principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: list passkeys process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = make([]PasskeyResponse, 0, len(result))
+		for _, passkey := range result {
+			response = append(response, *passkeyResponse(passkey))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (ph *PasskeyHandler) Delete() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := ph.s.Delete(c.Request().Context(), principal.UserID, c.Param("id")); err != nil {
+			c.Logger().Error("handler: delete passkey process error:", err.Error())
+			if strings.Contains(err.Error(), "not found") {
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func passkeyResponse(passkey passkeys.Passkey) *PasskeyResponse {
+	var response = new(PasskeyResponse)
+	response.ID = passkey.ID
+	response.Name = passkey.Name
+	response.CreatedAt = passkey.CreatedAt
+	response.LastUsedAt = passkey.LastUsedAt
+
+	return response
+}
+
+func loginResponse(c echo.Context, credential *users.UserCredential) *LoginResponse {
+	var response = new(LoginResponse)
+	response.Nama = credential.Nama
+	if tokens, csrfToken := helper.DeliverTokens(c, credential.Access); tokens != nil {
+		response.Token = tokens
+	} else {
+		response.CSRFToken = csrfToken
+	}
+	response.MFARequired = credential.MFARequired
+	response.MFAToken = credential.MFAToken
+	response.StepUpRequired = credential.StepUpRequired
+	response.StepUpToken = credential.StepUpToken
+
+	return response
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// CredentialInput is the PublicKeyCredential the browser returned, with the
+// binary fields base64url encoded like its toJSON() does.
+type CredentialInput struct {
+	ID       string                     `json:"id"`
+	Type     string                     `json:"type"`
+	Response AuthenticatorResponseInput `json:"response"`
+}
+
+type AuthenticatorResponseInput struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle"`
+}
+
+type RegistrationInput struct {
+	CeremonyID string          `json:"ceremony_id"`
+	Name       string          `json:"name"`
+	Credential CredentialInput `json:"credential"`
+}
+
+type LoginInput struct {
+	CeremonyID string          `json:"ceremony_id"`
+	Credential CredentialInput `json:"credential"`
+}
+
+// decodeBase64URL accepts the encoding with and without padding.
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -0,0 +1,78 @@
+package handler
+
+import "time"
+
+// The options are passed to the WebAuthn browser API as they are, their
+// fields are named like its dictionaries.
+type CreationOptionsResponse struct {
+	CeremonyID string                   `json:"ceremony_id"`
+	PublicKey  PublicKeyCreationOptions `json:"public_key"`
+}
+
+type PublicKeyCreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	RequireResident  bool   `json:"requireResidentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type RequestOptionsResponse struct {
+	CeremonyID string                  `json:"ceremony_id"`
+	PublicKey  PublicKeyRequestOptions `json:"public_key"`
+}
+
+type PublicKeyRequestOptions struct {
+	Challenge        string `json:"challenge"`
+	RPID             string `json:"rpId"`
+	Timeout          int64  `json:"timeout"`
+	UserVerification string `json:"userVerification"`
+}
+
+type PasskeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// LoginResponse carries the same fields as the response to a password login.
+type LoginResponse struct {
+	Nama           string `json:"nama"`
+	Token          any    `json:"token,omitempty"`
+	CSRFToken      string `json:"csrf_token,omitempty"`
+	MFARequired    bool   `json:"mfa_required,omitempty"`
+	MFAToken       string `json:"mfa_token,omitempty"`
+	StepUpRequired bool   `json:"step_up_required,omitempty"`
+	StepUpToken    string `json:"step_up_token,omitempty"`
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"test/features/audit"
+	"test/features/passkeys"
+	"test/features/users"
+	"test/helper"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ceremonyTTL        = time.Minute * 5
+	defaultPasskeyName = "Passkey"
+)
+
+type PasskeyService struct {
+	d  passkeys.PasskeyDataInterface
+	u  users.UserDataInterface
+	us users.UserServiceInterface
+	w  helper.WebAuthnInterface
+	a  audit.AuditServiceInterface
+	g  helper.GeneratorInterface
+	c  helper.ClockInterface
+}
+
+func New(data passkeys.PasskeyDataInterface, userData users.UserDataInterface, userService users.UserServiceInterface, webAuthn helper.WebAuthnInterface, auditor audit.AuditServiceInterface, generator helper.GeneratorInterface, clock helper.ClockInterface) passkeys.PasskeyServiceInterface {
+	return &PasskeyService{
+		d:  data,
+		u:  userData,
+		us: userService,
+		w:  webAuthn,
+		a:  auditor,
+		g:  generator,
+		c:  clock,
+	}
+}
+
+// BeginRegistration asks for a discoverable passkey, so logins need no HP.
+// Passkeys the user already has are excluded, an authenticator holds one per
+// account.
+func (ps *PasskeyService) BeginRegistration(ctx context.Context, userID string) (*passkeys.RegistrationOptions, error) {
+	user, err := ps.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := ps.d.GetByUser(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	ceremony, err := ps.newCeremony(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg = ps.w.Config()
+	var result = new(passkeys.RegistrationOptions)
+	result.CeremonyID = ceremony.ID
+	result.Challenge = ceremony.Challenge
+	result.RPID = cfg.RPID
+	result.RPName = cfg.RPName
+	result.UserHandle = base64.RawURLEncoding.EncodeToString([]byte(user.ID))
+	result.UserName = user.HP
+	if result.UserName == "" {
+		result.UserName = user.Nama
+	}
+	result.DisplayName = user.Nama
+	result.Algorithms = helper.WebAuthnAlgorithms
+	result.Timeout = ceremonyTTL
+	for _, passkey := range existing {
+		result.ExcludeCredentials = append(result.ExcludeCredentials, passkey.CredentialID)
+	}
+
+	return result, nil
+}
+
+func (ps *PasskeyService) FinishRegistration(ctx context.Context, userID string, ceremonyID string, name string, response passkeys.RegistrationResponse) (*passkeys.Passkey, error) {
+	ceremony, err := ps.takeCeremony(ceremonyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ceremony.UserID == "" || ceremony.UserID != userID {
+		return nil, errors.New("invalid ceremony")
+	}
+
+	if _, err := ps.activeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	credential, err := ps.w.VerifyRegistration(ceremony.Challenge, response.ClientDataJSON, response.AttestationObject)
+	if err != nil {
+		logrus.Info("service: passkey registration refused:", err.Error())
+		return nil, errors.New("invalid passkey")
+	}
+
+	var credentialID = base64.RawURLEncoding.EncodeToString(credential.ID)
+	if _, err := ps.d.GetByCredentialID(credentialID); err == nil {
+		return nil, errors.New("passkey already registered")
+	} else if !strings.Contains(err.Error(), "not found") {
+		return nil, errors.New("process failed")
+	}
+
+	newID, err := ps.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	var newData = new(passkeys.Passkey)
+	newData.ID = newID
+	newData.UserID = userID
+	newData.CredentialID = credentialID
+	newData.PublicKey = credential.PublicKey
+	newData.SignCount = credential.SignCount
+	newData.Name = name
+	if newData.Name == "" {
+		newData.Name = defaultPasskeyName
+	}
+	newData.CreatedAt = ps.c.Now()
+
+	if err := ps.d.Insert(*newData); err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	ps.audit(ctx, audit.Event{ActorID: userID, Action: "passkey.registered", TargetID: userID, After: map[string]any{"passkey_id": newData.ID, "name": newData.Name}})
+
+	return newData, nil
+}
+
+func (ps *PasskeyService) BeginLogin(ctx context.Context) (*passkeys.LoginOptions, error) {
+	ceremony, err := ps.newCeremony("")
+	if err != nil {
+		return nil, err
+	}
+
+	var result = new(passkeys.LoginOptions)
+	result.CeremonyID = ceremony.ID
+	result.Challenge = ceremony.Challenge
+	result.RPID = ps.w.Config().RPID
+	result.Timeout = ceremonyTTL
+
+	return result, nil
+}
+
+// FinishLogin verifies the assertion and hands the account to the users
+// service, which issues the tokens like for any other login. A sign count
+// that did not go up means the passkey was copied, and the login is refused.
+// Authenticators that do not count always send zero.
+func (ps *PasskeyService) FinishLogin(ctx context.Context, ceremonyID string, response passkeys.AssertionResponse) (*users.UserCredential, error) {
+	ceremony, err := ps.takeCeremony(ceremonyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ceremony.UserID != "" {
+		return nil, errors.New("invalid ceremony")
+	}
+
+	passkey, err := ps.d.GetByCredentialID(response.CredentialID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			ps.loginFailed(ctx, "", "unknown passkey")
+			return nil, errors.New("invalid passkey")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	if len(response.UserHandle) > 0 && subtle.ConstantTimeCompare(response.UserHandle, []byte(passkey.UserID)) != 1 {
+		ps.loginFailed(ctx, passkey.UserID, "passkey user mismatch")
+		return nil, errors.New("invalid passkey")
+	}
+
+	assertion, err := ps.w.VerifyAssertion(ceremony.Challenge, passkey.PublicKey, response.ClientDataJSON, response.AuthenticatorData, response.Signature)
+	if err != nil {
+		logrus.Info("service: passkey assertion refused:", err.Error())
+		ps.loginFailed(ctx, passkey.UserID, "invalid passkey")
+		return nil, errors.New("invalid passkey")
+	}
+
+	if (assertion.SignCount != 0 || passkey.SignCount != 0) && assertion.SignCount <= passkey.SignCount {
+		ps.loginFailed(ctx, passkey.UserID, "passkey sign count")
+		return nil, errors.New("invalid passkey")
+	}
+
+	if err := ps.d.UpdateSignCount(passkey.ID, assertion.SignCount, ps.c.Now()); err != nil {
+		return nil, errors.New("update process failed")
+	}
+
+	return ps.us.LoginPasskey(ctx, passkey.UserID, assertion.UserVerified)
+}
+
+func (ps *PasskeyService) List(ctx context.Context, userID string) ([]passkeys.Passkey, error) {
+	result, err := ps.d.GetByUser(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (ps *PasskeyService) Delete(ctx context.Context, userID string, passkeyID string) error {
+	if err := ps.d.Delete(userID, passkeyID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("delete process failed")
+	}
+
+	ps.audit(ctx, audit.Event{ActorID: userID, Action: "passkey.removed", TargetID: userID, Before: map[string]any{"passkey_id": passkeyID}})
+
+	return nil
+}
+
+func (ps *PasskeyService) newCeremony(userID string) (*passkeys.Ceremony, error) {
+	ceremonyID, err := ps.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	challenge, err := randomChallenge()
+	if err != nil {
+		return nil, errors.New("challenge process failed")
+	}
+
+	var now = ps.c.Now()
+	var newData = new(passkeys.Ceremony)
+	newData.ID = ceremonyID
+	newData.UserID = userID
+	newData.Challenge = challenge
+	newData.ExpiresAt = now.Add(ceremonyTTL)
+	newData.CreatedAt = now
+
+	if err := ps.d.InsertCeremony(*newData); err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	return newData, nil
+}
+
+func (ps *PasskeyService) takeCeremony(ceremonyID string) (*passkeys.Ceremony, error) {
+	if ceremonyID == "" {
+		return nil, errors.New("invalid ceremony")
+	}
+
+	result, err := ps.d.TakeCeremony(ceremonyID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("invalid ceremony")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	if ps.c.Now().After(result.ExpiresAt) {
+		return nil, errors.New("invalid ceremony")
+	}
+
+	return result, nil
+}
+
+func (ps *PasskeyService) activeUser(ctx context.Context, userID string) (*users.User, error) {
+	result, err := ps.u.WithContext(ctx).GetByID(userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	if result.DeactivatedAt != nil {
+		return nil, errors.New("account deactivated")
+	}
+
+	return result, nil
+}
+
+func (ps *PasskeyService) loginFailed(ctx context.Context, userID string, reason string) {
+	ps.audit(ctx, audit.Event{ActorID: userID, Action: "user.login_failed", TargetID: userID, After: map[string]any{"reason": reason}})
+}
+
+func (ps *PasskeyService) audit(ctx context.Context, event audit.Event) {
+	if err := ps.a.Record(ctx, event); err != nil {
+		logrus.Error("service: audit record error:", err.Error())
+	}
+}
+
+func randomChallenge() (string, error) {
+	var raw = make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
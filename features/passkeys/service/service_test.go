@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"test/features/audit"
+	auditMocks "test/features/audit/mocks"
+	"test/features/passkeys"
+	"test/features/passkeys/mocks"
+	"test/features/users"
+	userMocks "test/features/users/mocks"
+	realHelper "test/helper"
+	helper "test/helper/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBeginRegistration(t *testing.T) {
+	data := mocks.NewPasskeyDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	webAuthn := helper.NewWebAuthnInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, userMocks.NewUserServiceInterface(t), webAuthn, auditMocks.NewAuditServiceInterface(t), generator, clock).(*PasskeyService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Role: users.RoleUser}
+
+	t.Run("Success", func(t *testing.T) {
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		data.On("GetByUser", user.ID).Return([]passkeys.Passkey{{ID: "randomPasskeyID", CredentialID: "existingCredential"}}, nil).Once()
+		generator.On("GenerateUUID").Return("randomCeremonyID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertCeremony", mock.MatchedBy(func(c passkeys.Ceremony) bool {
+			return c.ID == "randomCeremonyID" && c.UserID == user.ID && len(c.Challenge) == 43 && c.ExpiresAt.Equal(now.Add(ceremonyTTL))
+		})).Return(nil).Once()
+		webAuthn.On("Config").Return(realHelper.WebAuthnConfig{RPID: "example.com", RPName: "Example"}).Once()
+
+		result, err := service.BeginRegistration(context.Background(), user.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, "randomCeremonyID", result.CeremonyID)
+		assert.Equal(t, "example.com", result.RPID)
+		assert.Equal(t, "cmFuZG9tVXNlcklE", result.UserHandle)
+		assert.Equal(t, "123", result.UserName)
+		assert.Equal(t, []string{"existingCredential"}, result.ExcludeCredentials)
+	})
+
+	t.Run("Deactivated account", func(t *testing.T) {
+		deactivated := user
+		deactivated.DeactivatedAt = &now
+		userData.On("GetByID", user.ID).Return(&deactivated, nil).Once()
+
+		result, err := service.BeginRegistration(context.Background(), user.ID)
+		assert.EqualError(t, err, "account deactivated")
+		assert.Nil(t, result)
+	})
+}
+
+func TestFinishRegistration(t *testing.T) {
+	data := mocks.NewPasskeyDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	webAuthn := helper.NewWebAuthnInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, userMocks.NewUserServiceInterface(t), webAuthn, auditor, generator, clock).(*PasskeyService)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Role: users.RoleUser}
+	ceremony := passkeys.Ceremony{ID: "randomCeremonyID", UserID: user.ID, Challenge: "randomChallenge", ExpiresAt: now.Add(ceremonyTTL), CreatedAt: now}
+	response := passkeys.RegistrationResponse{ClientDataJSON: []byte("clientData"), AttestationObject: []byte("attestation")}
+	credential := realHelper.WebAuthnCredential{ID: []byte("credential"), PublicKey: []byte("publicKey"), SignCount: 0}
+
+	t.Run("Success", func(t *testing.T) {
+		data.On("TakeCeremony", ceremony.ID).Return(&ceremony, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Twice()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		webAuthn.On("VerifyRegistration", "randomChallenge", response.ClientDataJSON, response.AttestationObject).Return(&credential, nil).Once()
+		data.On("GetByCredentialID", "Y3JlZGVudGlhbA").Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateUUID").Return("randomPasskeyID", nil).Once()
+		data.On("Insert", passkeys.Passkey{ID: "randomPasskeyID", UserID: user.ID, CredentialID: "Y3JlZGVudGlhbA", PublicKey: []byte("publicKey"), Name: "Passkey", CreatedAt: now.Add(time.Minute)}).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: user.ID, Action: "passkey.registered", TargetID: user.ID, After: map[string]any{"passkey_id": "randomPasskeyID", "name": "Passkey"}}).Return(nil).Once()
+
+		result, err := service.FinishRegistration(context.Background(), user.ID, ceremony.ID, "", response)
+		assert.Nil(t, err)
+		assert.Equal(t, "randomPasskeyID", result.ID)
+	})
+
+	t.Run("Ceremony of another user", func(t *testing.T) {
+		data.On("TakeCeremony", ceremony.ID).Return(&ceremony, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+
+		result, err := service.FinishRegistration(context.Background(), "otherUserID", ceremony.ID, "", response)
+		assert.EqualError(t, err, "invalid ceremony")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Login ceremony", func(t *testing.T) {
+		login := ceremony
+		login.UserID = ""
+		data.On("TakeCeremony", ceremony.ID).Return(&login, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+
+		result, err := service.FinishRegistration(context.Background(), user.ID, ceremony.ID, "", response)
+		assert.EqualError(t, err, "invalid ceremony")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Expired ceremony", func(t *testing.T) {
+		data.On("TakeCeremony", ceremony.ID).Return(&ceremony, nil).Once()
+		clock.On("Now").Return(now.Add(ceremonyTTL + time.Second)).Once()
+
+		result, err := service.FinishRegistration(context.Background(), user.ID, ceremony.ID, "", response)
+		assert.EqualError(t, err, "invalid ceremony")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Refused by verification", func(t *testing.T) {
+		data.On("TakeCeremony", ceremony.ID).Return(&ceremony, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		webAuthn.On("VerifyRegistration", "randomChallenge", response.ClientDataJSON, response.AttestationObject).Return(nil, errors.New("origin not allowed")).Once()
+
+		result, err := service.FinishRegistration(context.Background(), user.ID, ceremony.ID, "", response)
+		assert.EqualError(t, err, "invalid passkey")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Credential already registered", func(t *testing.T) {
+		data.On("TakeCeremony", ceremony.ID).Return(&ceremony, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		webAuthn.On("VerifyRegistration", "randomChallenge", response.ClientDataJSON, response.AttestationObject).Return(&credential, nil).Once()
+		data.On("GetByCredentialID", "Y3JlZGVudGlhbA").Return(&passkeys.Passkey{ID: "otherPasskeyID", UserID: "otherUserID"}, nil).Once()
+
+		result, err := service.FinishRegistration(context.Background(), user.ID, ceremony.ID, "", response)
+		assert.EqualError(t, err, "passkey already registered")
+		assert.Nil(t, result)
+	})
+}
+
+func TestFinishLogin(t *testing.T) {
+	data := mocks.NewPasskeyDataInterface(t)
+	userService := userMocks.NewUserServiceInterface(t)
+	webAuthn := helper.NewWebAuthnInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userMocks.NewUserDataInterface(t), userService, webAuthn, auditor, helper.NewGeneratorInterface(t), clock).(*PasskeyService)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	ceremony := passkeys.Ceremony{ID: "randomCeremonyID", Challenge: "randomChallenge", ExpiresAt: now.Add(ceremonyTTL), CreatedAt: now}
+	passkey := passkeys.Passkey{ID: "randomPasskeyID", UserID: "randomUserID", CredentialID: "Y3JlZGVudGlhbA", PublicKey: []byte("publicKey"), SignCount: 5}
+	response := passkeys.AssertionResponse{CredentialID: "Y3JlZGVudGlhbA", ClientDataJSON: []byte("clientData"), AuthenticatorData: []byte("authData"), Signature: []byte("signature"), UserHandle: []byte("randomUserID")}
+	credential := users.UserCredential{Nama: "dida", Access: map[string]any{"access_token": "randomAccessToken"}}
+
+	t.Run("Success", func(t *testing.T) {
+		data.On("TakeCeremony", ceremony.ID).Return(&ceremony, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Twice()
+		data.On("GetByCredentialID", "Y3JlZGVudGlhbA").Return(&passkey, nil).Once()
+		webAuthn.On("VerifyAssertion", "randomChallenge", passkey.PublicKey, response.ClientDataJSON, response.AuthenticatorData, response.Signature).Return(&realHelper.WebAuthnAssertion{SignCount: 6, UserVerified: true}, nil).Once()
+		data.On("UpdateSignCount", passkey.ID, uint32(6), now.Add(time.Minute)).Return(nil).Once()
+		userService.On("LoginPasskey", mock.Anything, passkey.UserID, true).Return(&credential, nil).Once()
+
+		result, err := service.FinishLogin(context.Background(), ceremony.ID, response)
+		assert.Nil(t, err)
+		assert.Equal(t, "randomAccessToken", result.Access["access_token"])
+	})
+
+	t.Run("Authenticator without counter", func(t *testing.T) {
+		uncounted := passkey
+		uncounted.SignCount = 0
+		data.On("TakeCeremony", ceremony.ID).Return(&ceremony, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Twice()
+		data.On("GetByCredentialID", "Y3JlZGVudGlhbA").Return(&uncounted, nil).Once()
+		webAuthn.On("VerifyAssertion", "randomChallenge", passkey.PublicKey, response.ClientDataJSON, response.AuthenticatorData, response.Signature).Return(&realHelper.WebAuthnAssertion{SignCount: 0}, nil).Once()
+		data.On("UpdateSignCount", passkey.ID, uint32(0), now.Add(time.Minute)).Return(nil).Once()
+		userService.On("LoginPasskey", mock.Anything, passkey.UserID, false).Return(&credential, nil).Once()
+
+		result, err := service.FinishLogin(context.Background(), ceremony.ID, response)
+		assert.Nil(t, err)
+		assert.NotNil(t, result)
+	})
+
+	t.Run("Sign count went back", func(t *testing.T) {
+		data.On("TakeCeremony", ceremony.ID).Return(&ceremony, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		data.On("GetByCredentialID", "Y3JlZGVudGlhbA").Return(&passkey, nil).Once()
+		webAuthn.On("VerifyAssertion", "randomChallenge", passkey.PublicKey, response.ClientDataJSON, response.AuthenticatorData, response.Signature).Return(&realHelper.WebAuthnAssertion{SignCount: 5}, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: passkey.UserID, Action: "user.login_failed", TargetID: passkey.UserID, After: map[string]any{"reason": "passkey sign count"}}).Return(nil).Once()
+
+		result, err := service.FinishLogin(context.Background(), ceremony.ID, response)
+		assert.EqualError(t, err, "invalid passkey")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Unknown passkey", func(t *testing.T) {
+		data.On("TakeCeremony", ceremony.ID).Return(&ceremony, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		data.On("GetByCredentialID", "Y3JlZGVudGlhbA").Return(nil, errors.New("record not found")).Once()
+		auditor.On("Record", mock.Anything, audit.Event{Action: "user.login_failed", After: map[string]any{"reason": "unknown passkey"}}).Return(nil).Once()
+
+		result, err := service.FinishLogin(context.Background(), ceremony.ID, response)
+		assert.EqualError(t, err, "invalid passkey")
+		assert.Nil(t, result)
+	})
+
+	t.Run("User handle of another account", func(t *testing.T) {
+		other := response
+		other.UserHandle = []byte("otherUserID")
+		data.On("TakeCeremony", ceremony.ID).Return(&ceremony, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		data.On("GetByCredentialID", "Y3JlZGVudGlhbA").Return(&passkey, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: passkey.UserID, Action: "user.login_failed", TargetID: passkey.UserID, After: map[string]any{"reason": "passkey user mismatch"}}).Return(nil).Once()
+
+		result, err := service.FinishLogin(context.Background(), ceremony.ID, other)
+		assert.EqualError(t, err, "invalid passkey")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Registration ceremony", func(t *testing.T) {
+		registration := ceremony
+		registration.UserID = "randomUserID"
+		data.On("TakeCeremony", ceremony.ID).Return(&registration, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+
+		result, err := service.FinishLogin(context.Background(), ceremony.ID, response)
+		assert.EqualError(t, err, "invalid ceremony")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Ceremony already used", func(t *testing.T) {
+		data.On("TakeCeremony", ceremony.ID).Return(nil, errors.New("record not found")).Once()
+
+		result, err := service.FinishLogin(context.Background(), ceremony.ID, response)
+		assert.EqualError(t, err, "invalid ceremony")
+		assert.Nil(t, result)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	data := mocks.NewPasskeyDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	service := New(data, userMocks.NewUserDataInterface(t), userMocks.NewUserServiceInterface(t), helper.NewWebAuthnInterface(t), auditor, helper.NewGeneratorInterface(t), helper.NewClockInterface(t)).(*PasskeyService)
+
+	t.Run("Success", func(t *testing.T) {
+		data.On("Delete", "randomUserID", "randomPasskeyID").Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "randomUserID", Action: "passkey.removed", TargetID: "randomUserID", Before: map[string]any{"passkey_id": "randomPasskeyID"}}).Return(nil).Once()
+
+		err := service.Delete(context.Background(), "randomUserID", "randomPasskeyID")
+		assert.Nil(t, err)
+	})
+
+	t.Run("Passkey of another user", func(t *testing.T) {
+		data.On("Delete", "randomUserID", "otherPasskeyID").Return(errors.New("data not found")).Once()
+
+		err := service.Delete(context.Background(), "randomUserID", "otherPasskeyID")
+		assert.EqualError(t, err, "data not found")
+	})
+}
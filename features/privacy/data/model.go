@@ -0,0 +1,15 @@
+package data
+
+import "time"
+
+type PrivacyJob struct {
+	ID          string `gorm:"varchar(255);primaryKey;"`
+	UserID      string `gorm:"type:varchar(255);index:idx_privacy_job_owner"`
+	Type        string `gorm:"type:varchar(16);index:idx_privacy_job_owner"`
+	Status      string `gorm:"type:varchar(16);index"`
+	Error       string
+	Archive     []byte `gorm:"type:longblob"`
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+}
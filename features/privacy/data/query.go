@@ -0,0 +1,135 @@
+package data
+
+import (
+	"errors"
+	"test/features/privacy"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PrivacyData struct {
+	gorm *gorm.DB
+}
+
+func New(g *gorm.DB) privacy.PrivacyDataInterface {
+	return &PrivacyData{
+		gorm: g,
+	}
+}
+
+func (pd *PrivacyData) Insert(newData privacy.Job) (*privacy.Job, error) {
+	var dbData = toModel(newData)
+
+	if err := pd.gorm.Create(dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return &newData, nil
+}
+
+func (pd *PrivacyData) GetByID(id string) (*privacy.Job, error) {
+	var dbData = new(PrivacyJob)
+
+	if err := pd.gorm.Where("id = ?", id).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toJob(dbData), nil
+}
+
+func (pd *PrivacyData) GetActive(userID string, jobType string) (*privacy.Job, error) {
+	var dbData = new(PrivacyJob)
+
+	if err := pd.gorm.Where("user_id = ? AND type = ? AND status IN ?", userID, jobType,
+		[]string{privacy.StatusPending, privacy.StatusProcessing}).First(dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return toJob(dbData), nil
+}
+
+// ClaimPending moves the oldest pending job to processing and returns it. The
+// row is locked while it is claimed, so several instances can share the queue
+// without running the same job twice.
+func (pd *PrivacyData) ClaimPending() (*privacy.Job, error) {
+	var dbData = new(PrivacyJob)
+
+	err := pd.gorm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", privacy.StatusPending).Order("created_at").First(dbData).Error; err != nil {
+			return err
+		}
+
+		dbData.Status = privacy.StatusProcessing
+		return tx.Model(dbData).Update("status", dbData.Status).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("data not found")
+		}
+		return nil, err
+	}
+
+	return toJob(dbData), nil
+}
+
+func (pd *PrivacyData) Update(newData privacy.Job) error {
+	var qry = pd.gorm.Model(&PrivacyJob{}).Where("id = ?", newData.ID).Updates(map[string]any{
+		"status":       newData.Status,
+		"error":        newData.Error,
+		"archive":      newData.Archive,
+		"completed_at": newData.CompletedAt,
+		"expires_at":   newData.ExpiresAt,
+	})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+// ClearExpiredArchives drops export bundles past their expiry; the job row is
+// kept so its status can still be polled.
+func (pd *PrivacyData) ClearExpiredArchives(now time.Time) error {
+	return pd.gorm.Model(&PrivacyJob{}).Where("archive IS NOT NULL AND expires_at < ?", now).Update("archive", nil).Error
+}
+
+func toModel(newData privacy.Job) *PrivacyJob {
+	var dbData = new(PrivacyJob)
+	dbData.ID = newData.ID
+	dbData.UserID = newData.UserID
+	dbData.Type = newData.Type
+	dbData.Status = newData.Status
+	dbData.Error = newData.Error
+	dbData.Archive = newData.Archive
+	dbData.CreatedAt = newData.CreatedAt
+	dbData.CompletedAt = newData.CompletedAt
+	dbData.ExpiresAt = newData.ExpiresAt
+
+	return dbData
+}
+
+func toJob(dbData *PrivacyJob) *privacy.Job {
+	var result = new(privacy.Job)
+	result.ID = dbData.ID
+	result.UserID = dbData.UserID
+	result.Type = dbData.Type
+	result.Status = dbData.Status
+	result.Error = dbData.Error
+	result.Archive = dbData.Archive
+	result.CreatedAt = dbData.CreatedAt
+	result.CompletedAt = dbData.CompletedAt
+	result.ExpiresAt = dbData.ExpiresAt
+
+	return result
+}
@@ -0,0 +1,55 @@
+package privacy
+
+import (
+	"context"
+	"test/helper"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Job struct {
+	ID          string
+	UserID      string
+	Type        string
+	Status      string
+	Error       string
+	Archive     []byte
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+}
+
+const (
+	JobExport  = "export"
+	JobErasure = "erasure"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+)
+
+type PrivacyHandlerInterface interface {
+	RequestExport() echo.HandlerFunc
+	RequestErasure() echo.HandlerFunc
+	GetJob() echo.HandlerFunc
+	DownloadExport() echo.HandlerFunc
+}
+type PrivacyServiceInterface interface {
+	RequestExport(ctx context.Context, userID string) (*Job, error)
+	RequestErasure(ctx context.Context, principal helper.Principal, password string) (*Job, error)
+	GetJob(ctx context.Context, jobID string) (*Job, error)
+	DownloadExport(ctx context.Context, userID string, jobID string) (*Job, error)
+	ProcessJobs() (int, error)
+}
+type PrivacyDataInterface interface {
+	Insert(newData Job) (*Job, error)
+	GetByID(id string) (*Job, error)
+	GetActive(userID string, jobType string) (*Job, error)
+	ClaimPending() (*Job, error)
+	Update(newData Job) error
+	ClearExpiredArchives(now time.Time) error
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"test/features/privacy"
+	"test/helper"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PrivacyHandler struct {
+	s privacy.PrivacyServiceInterface
+}
+
+func NewHandler(service privacy.PrivacyServiceInterface) privacy.PrivacyHandlerInterface {
+	return &PrivacyHandler{
+		s: service,
+	}
+}
+
+func (ph *PrivacyHandler) RequestExport() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := ph.s.RequestExport(c.Request().Context(), principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: export request process error:", err.Error())
+			return c.JSON(jobErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusAccepted, helper.FormatResponse("success", jobResponse(result)))
+	}
+}
+
+func (ph *PrivacyHandler) RequestErasure() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(ErasureInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := ph.s.RequestErasure(c.Request().Context(), *principal, input.Password)
+
+		if err != nil {
+			c.Logger().Error("handler: erasure request process error:", err.Error())
+			return c.JSON(jobErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusAccepted, helper.FormatResponse("success", jobResponse(result)))
+	}
+}
+
+// GetJob is reachable without a token: once an erasure completes the owner's
+// sessions are gone, and the random job id is all that is needed to follow it.
+func (ph *PrivacyHandler) GetJob() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := ph.s.GetJob(c.Request().Context(), c.Param("id"))
+
+		if err != nil {
+			c.Logger().Error("handler: get job process error:", err.Error())
+			return c.JSON(jobErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", jobResponse(result)))
+	}
+}
+
+func (ph *PrivacyHandler) DownloadExport() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := ph.s.DownloadExport(c.Request().Context(), principal.UserID, c.Param("id"))
+
+		if err != nil {
+			c.Logger().Error("handler: download export process error:", err.Error())
+			return c.JSON(jobErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "export-"+result.ID+".zip"))
+		return c.Blob(http.StatusOK, "application/zip", result.Archive)
+	}
+}
+
+func jobResponse(result *privacy.Job) *JobResponse {
+	var response = new(JobResponse)
+	response.ID = result.ID
+	response.Type = result.Type
+	response.Status = result.Status
+	response.Error = result.Error
+	response.CreatedAt = result.CreatedAt
+	response.CompletedAt = result.CompletedAt
+	response.ExpiresAt = result.ExpiresAt
+
+	return response
+}
+
+func jobErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "reauthentication"):
+		return http.StatusUnauthorized
+	case strings.Contains(err.Error(), "in progress"),
+		strings.Contains(err.Error(), "not ready"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "expired"):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,5 @@
+package handler
+
+type ErasureInput struct {
+	Password string `json:"password"`
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"test/features/privacy"
+	"test/helper"
+	"time"
+)
+
+type exportProfile struct {
+	ID            string     `json:"id"`
+	Nama          string     `json:"nama"`
+	HP            string     `json:"hp"`
+	Role          string     `json:"role"`
+	HPVerifiedAt  *time.Time `json:"hp_verified_at"`
+	MFAEnabled    bool       `json:"mfa_enabled"`
+	DeactivatedAt *time.Time `json:"deactivated_at"`
+}
+
+type exportSession struct {
+	ID         string     `json:"id"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type exportGroup struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type exportIdentity struct {
+	ID          string     `json:"id"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email"`
+	Name        string     `json:"name"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+type exportPasskey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type exportAPIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type exportOAuthClient struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type exportOAuthConsent struct {
+	ClientID  string    `json:"client_id"`
+	Scopes    []string  `json:"scopes"`
+	GrantedAt time.Time `json:"granted_at"`
+}
+
+type exportOAuthGrant struct {
+	ID        string     `json:"id"`
+	ClientID  string     `json:"client_id"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+type exportAuditEvent struct {
+	ID        string    `json:"id"`
+	ActorID   string    `json:"actor_id"`
+	Action    string    `json:"action"`
+	TargetID  string    `json:"target_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// export builds a ZIP bundle with one JSON file per kind of data stored about
+// the user, along with their avatar. Credentials are left out on purpose, a
+// hash, a TOTP secret or a passkey's public key is of no use to the owner and
+// dangerous if the bundle leaks.
+func (ps *PrivacyService) export(job *privacy.Job) error {
+	user, err := ps.u.GetByID(job.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("process failed")
+	}
+
+	// A negative limit returns every session.
+	sessions, err := ps.u.GetSessions(job.UserID, -1)
+	if err != nil {
+		return errors.New("process failed")
+	}
+
+	events, err := ps.a.ListByUser(job.UserID)
+	if err != nil {
+		return errors.New("process failed")
+	}
+
+	var profile = exportProfile{
+		ID:            user.ID,
+		Nama:          user.Nama,
+		HP:            user.HP,
+		Role:          user.Role,
+		HPVerifiedAt:  user.HPVerifiedAt,
+		MFAEnabled:    user.TOTPEnabled,
+		DeactivatedAt: user.DeactivatedAt,
+	}
+
+	var exportSessions = make([]exportSession, 0, len(sessions))
+	for _, session := range sessions {
+		exportSessions = append(exportSessions, exportSession{
+			ID:         session.ID,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			RevokedAt:  session.RevokedAt,
+		})
+	}
+
+	var auditEvents = make([]exportAuditEvent, 0, len(events))
+	for _, event := range events {
+		auditEvents = append(auditEvents, exportAuditEvent{
+			ID:        event.ID,
+			ActorID:   event.ActorID,
+			Action:    event.Action,
+			TargetID:  event.TargetID,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	var files = []archiveFile{
+		{Name: "profile.json", Content: profile},
+		{Name: "sessions.json", Content: exportSessions},
+		{Name: "audit_events.json", Content: auditEvents},
+	}
+
+	linked, err := ps.exportLinked(job.UserID)
+	if err != nil {
+		return err
+	}
+	files = append(files, linked...)
+
+	avatar, err := ps.exportAvatar(user.AvatarKey)
+	if err != nil {
+		return err
+	}
+	files = append(files, avatar...)
+
+	var now = ps.c.Now()
+	archive, err := buildArchive(now, files)
+	if err != nil {
+		return errors.New("archive process failed")
+	}
+
+	var expiresAt = now.Add(exportTTL)
+	job.Archive = archive
+	job.ExpiresAt = &expiresAt
+
+	return nil
+}
+
+// exportLinked collects what the other features keep about the user in their
+// own tables: group memberships, linked identities, passkeys, API keys and
+// what OAuth clients were allowed.
+func (ps *PrivacyService) exportLinked(userID string) ([]archiveFile, error) {
+	groupIDs, err := ps.gr.GetUserGroupIDs(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+	memberOf, err := ps.gr.GetByIDs(groupIDs)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+	var exportGroups = make([]exportGroup, 0, len(memberOf))
+	for _, group := range memberOf {
+		exportGroups = append(exportGroups, exportGroup{ID: group.ID, Name: group.Name, Description: group.Description})
+	}
+
+	linkedIdentities, err := ps.i.GetByUser(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+	var exportIdentities = make([]exportIdentity, 0, len(linkedIdentities))
+	for _, identity := range linkedIdentities {
+		exportIdentities = append(exportIdentities, exportIdentity{
+			ID:          identity.ID,
+			Provider:    identity.Provider,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			Name:        identity.Name,
+			CreatedAt:   identity.CreatedAt,
+			LastLoginAt: identity.LastLoginAt,
+		})
+	}
+
+	registered, err := ps.p.GetByUser(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+	var exportPasskeys = make([]exportPasskey, 0, len(registered))
+	for _, passkey := range registered {
+		exportPasskeys = append(exportPasskeys, exportPasskey{ID: passkey.ID, Name: passkey.Name, CreatedAt: passkey.CreatedAt, LastUsedAt: passkey.LastUsedAt})
+	}
+
+	keys, err := ps.k.GetByUser(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+	var exportAPIKeys = make([]exportAPIKey, 0, len(keys))
+	for _, key := range keys {
+		exportAPIKeys = append(exportAPIKeys, exportAPIKey{
+			ID:         key.ID,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.Scopes,
+			CreatedAt:  key.CreatedAt,
+			ExpiresAt:  key.ExpiresAt,
+			LastUsedAt: key.LastUsedAt,
+			RevokedAt:  key.RevokedAt,
+		})
+	}
+
+	clients, err := ps.o.GetClientsByOwner(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+	var exportClients = make([]exportOAuthClient, 0, len(clients))
+	for _, client := range clients {
+		exportClients = append(exportClients, exportOAuthClient{
+			ID:           client.ID,
+			Name:         client.Name,
+			RedirectURIs: client.RedirectURIs,
+			Scopes:       client.Scopes,
+			Confidential: client.Confidential,
+			CreatedAt:    client.CreatedAt,
+		})
+	}
+
+	consents, err := ps.o.GetConsentsByUser(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+	var exportConsents = make([]exportOAuthConsent, 0, len(consents))
+	for _, consent := range consents {
+		exportConsents = append(exportConsents, exportOAuthConsent{ClientID: consent.ClientID, Scopes: consent.Scopes, GrantedAt: consent.GrantedAt})
+	}
+
+	grants, err := ps.o.GetGrantsByUser(userID)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+	var exportGrants = make([]exportOAuthGrant, 0, len(grants))
+	for _, grant := range grants {
+		exportGrants = append(exportGrants, exportOAuthGrant{
+			ID:        grant.ID,
+			ClientID:  grant.ClientID,
+			Scopes:    grant.Scopes,
+			CreatedAt: grant.CreatedAt,
+			ExpiresAt: grant.ExpiresAt,
+			RevokedAt: grant.RevokedAt,
+		})
+	}
+
+	return []archiveFile{
+		{Name: "groups.json", Content: exportGroups},
+		{Name: "identities.json", Content: exportIdentities},
+		{Name: "passkeys.json", Content: exportPasskeys},
+		{Name: "api_keys.json", Content: exportAPIKeys},
+		{Name: "oauth_clients.json", Content: exportClients},
+		{Name: "oauth_consents.json", Content: exportConsents},
+		{Name: "oauth_grants.json", Content: exportGrants},
+	}, nil
+}
+
+// exportAvatar adds the largest stored size of the avatar, the smaller ones
+// are scaled down from the same upload.
+func (ps *PrivacyService) exportAvatar(avatarKey string) ([]archiveFile, error) {
+	if avatarKey == "" || ps.s == nil {
+		return nil, nil
+	}
+
+	var size = helper.AvatarSizes[0].Name
+	image, err := ps.s.Get(context.Background(), helper.AvatarKeys(avatarKey)[size])
+	if err != nil {
+		return nil, errors.New("avatar process failed")
+	}
+
+	return []archiveFile{{Name: "avatar-" + size + ".jpg", Raw: image}}, nil
+}
+
+// archiveFile holds either Content, written as JSON, or Raw bytes written as
+// they are.
+type archiveFile struct {
+	Name    string
+	Content any
+	Raw     []byte
+}
+
+func buildArchive(modified time.Time, files []archiveFile) ([]byte, error) {
+	var buf = new(bytes.Buffer)
+	var writer = zip.NewWriter(buf)
+
+	for _, f := range files {
+		file, err := writer.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return nil, err
+		}
+
+		if f.Raw != nil {
+			if _, err := file.Write(f.Raw); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var encoder = json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(f.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"test/features/apikeys"
+	"test/features/audit"
+	"test/features/groups"
+	"test/features/identities"
+	"test/features/oauth"
+	"test/features/passkeys"
+	"test/features/privacy"
+	"test/features/users"
+	"test/helper"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	exportTTL    = time.Hour * 24 * 7
+	jobBatchSize = 10
+)
+
+type PrivacyService struct {
+	d  privacy.PrivacyDataInterface
+	u  users.UserDataInterface
+	gr groups.GroupDataInterface
+	i  identities.IdentityDataInterface
+	p  passkeys.PasskeyDataInterface
+	k  apikeys.APIKeyDataInterface
+	o  oauth.OAuthDataInterface
+	a  audit.AuditServiceInterface
+	g  helper.GeneratorInterface
+	c  helper.ClockInterface
+	s  helper.StorageInterface
+}
+
+func New(data privacy.PrivacyDataInterface, userData users.UserDataInterface, groupData groups.GroupDataInterface, identityData identities.IdentityDataInterface, passkeyData passkeys.PasskeyDataInterface, apiKeyData apikeys.APIKeyDataInterface, oauthData oauth.OAuthDataInterface, auditor audit.AuditServiceInterface, generator helper.GeneratorInterface, clock helper.ClockInterface, storage helper.StorageInterface) privacy.PrivacyServiceInterface {
+	return &PrivacyService{
+		d:  data,
+		u:  userData,
+		gr: groupData,
+		i:  identityData,
+		p:  passkeyData,
+		k:  apiKeyData,
+		o:  oauthData,
+		a:  auditor,
+		g:  generator,
+		c:  clock,
+		s:  storage,
+	}
+}
+
+func (ps *PrivacyService) RequestExport(ctx context.Context, userID string) (*privacy.Job, error) {
+	return ps.enqueue(ctx, userID, privacy.JobExport)
+}
+
+func (ps *PrivacyService) RequestErasure(ctx context.Context, principal helper.Principal, password string) (*privacy.Job, error) {
+	user, err := ps.u.WithContext(ctx).GetByID(principal.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	if err := ps.confirmErasure(principal, user, password); err != nil {
+		return nil, err
+	}
+
+	return ps.enqueue(ctx, principal.UserID, privacy.JobErasure)
+}
+
+// confirmErasure asks accounts with a password to type it again. Accounts
+// without one, linked to a provider or the directory, have to have signed in
+// within users.RecentAuthTTL instead.
+func (ps *PrivacyService) confirmErasure(principal helper.Principal, user *users.User, password string) error {
+	if user.Password != "" {
+		if !helper.ComparePassword(user.Password, password) {
+			return errors.New("invalid password confirmation")
+		}
+		return nil
+	}
+
+	if principal.SessionID == "" {
+		return errors.New("reauthentication required")
+	}
+
+	session, err := ps.u.GetSession(principal.SessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("reauthentication required")
+		}
+		return errors.New("process failed")
+	}
+
+	if !session.RecentlyAuthenticated(principal, ps.c.Now()) {
+		return errors.New("reauthentication required")
+	}
+
+	return nil
+}
+
+// GetJob only reports the job status, the export bundle itself is handed out
+// by DownloadExport to its owner.
+func (ps *PrivacyService) GetJob(ctx context.Context, jobID string) (*privacy.Job, error) {
+	result, err := ps.getJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Archive = nil
+
+	return result, nil
+}
+
+func (ps *PrivacyService) DownloadExport(ctx context.Context, userID string, jobID string) (*privacy.Job, error) {
+	result, err := ps.getJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.UserID != userID || result.Type != privacy.JobExport {
+		return nil, errors.New("data not found")
+	}
+
+	if result.Status != privacy.StatusCompleted {
+		return nil, errors.New("export not ready")
+	}
+
+	if result.Archive == nil || (result.ExpiresAt != nil && ps.c.Now().After(*result.ExpiresAt)) {
+		return nil, errors.New("export expired")
+	}
+
+	ps.audit(ctx, audit.Event{ActorID: userID, Action: "privacy.export_downloaded", TargetID: userID})
+
+	return result, nil
+}
+
+// ProcessJobs works through the pending jobs in batches, it is meant to be
+// called periodically by a background worker. A failed job is marked as
+// failed and does not stop the batch.
+func (ps *PrivacyService) ProcessJobs() (int, error) {
+	if err := ps.d.ClearExpiredArchives(ps.c.Now()); err != nil {
+		logrus.Error("service: clear expired exports error:", err.Error())
+	}
+
+	var processed = 0
+	for processed < jobBatchSize {
+		job, err := ps.d.ClaimPending()
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				break
+			}
+			return processed, errors.New("process failed")
+		}
+
+		switch job.Type {
+		case privacy.JobExport:
+			err = ps.export(job)
+		case privacy.JobErasure:
+			err = ps.erase(job)
+		default:
+			err = errors.New("unknown job type")
+		}
+
+		var now = ps.c.Now()
+		job.Status = privacy.StatusCompleted
+		job.CompletedAt = &now
+		if err != nil {
+			logrus.Error("service: privacy job ", job.ID, " error:", err.Error())
+			job.Status = privacy.StatusFailed
+			job.Error = err.Error()
+			job.Archive = nil
+			job.ExpiresAt = nil
+		}
+
+		if err := ps.d.Update(*job); err != nil {
+			return processed, errors.New("update process failed")
+		}
+
+		processed++
+	}
+
+	return processed, nil
+}
+
+func (ps *PrivacyService) enqueue(ctx context.Context, userID string, jobType string) (*privacy.Job, error) {
+	_, err := ps.d.GetActive(userID, jobType)
+	if err == nil {
+		return nil, errors.New("request already in progress")
+	}
+	if !strings.Contains(err.Error(), "not found") {
+		return nil, errors.New("process failed")
+	}
+
+	newID, err := ps.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	var newJob = new(privacy.Job)
+	newJob.ID = newID
+	newJob.UserID = userID
+	newJob.Type = jobType
+	newJob.Status = privacy.StatusPending
+	newJob.CreatedAt = ps.c.Now()
+
+	result, err := ps.d.Insert(*newJob)
+	if err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	ps.audit(ctx, audit.Event{ActorID: userID, Action: "privacy." + jobType + "_requested", TargetID: userID})
+
+	return result, nil
+}
+
+func (ps *PrivacyService) getJob(jobID string) (*privacy.Job, error) {
+	result, err := ps.d.GetByID(jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+// erase anonymizes the account in place, so audit entries and anything else
+// referencing the user id stay valid while nothing identifies the person.
+func (ps *PrivacyService) erase(job *privacy.Job) error {
+	var now = ps.c.Now()
+
+	if err := ps.u.RevokeTokens(job.UserID, now); err != nil && !strings.Contains(err.Error(), "not found") {
+		return errors.New("revoke process failed")
+	}
+
+	if err := ps.o.RevokeUser(job.UserID, now); err != nil {
+		return errors.New("revoke process failed")
+	}
+
+	// The same features the users service erases through its hooks, erasure
+	// leaves them nothing to log in with either.
+	for _, eraser := range []users.ErasureHookInterface{ps.k, ps.o, ps.i, ps.p} {
+		if err := eraser.EraseUser(job.UserID); err != nil {
+			return errors.New("erase process failed")
+		}
+	}
+
+	avatarKey, err := ps.u.Anonymize(job.UserID, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("anonymize process failed")
+	}
+
+	// The account no longer links the avatar, a failure only leaves
+	// unreachable files behind.
+	if err := helper.DeleteAvatar(context.Background(), ps.s, avatarKey); err != nil {
+		logrus.Error("service: remove avatar of ", job.UserID, " error:", err.Error())
+	}
+
+	ps.audit(context.Background(), audit.Event{ActorID: job.UserID, Action: "user.erased", TargetID: job.UserID})
+
+	return nil
+}
+
+func (ps *PrivacyService) audit(ctx context.Context, event audit.Event) {
+	if err := ps.a.Record(ctx, event); err != nil {
+		logrus.Error("service: audit record error:", err.Error())
+	}
+}
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"test/features/apikeys"
+	apiKeyMocks "test/features/apikeys/mocks"
+	"test/features/audit"
+	auditMocks "test/features/audit/mocks"
+	"test/features/groups"
+	groupMocks "test/features/groups/mocks"
+	"test/features/identities"
+	identityMocks "test/features/identities/mocks"
+	"test/features/oauth"
+	oauthMocks "test/features/oauth/mocks"
+	"test/features/passkeys"
+	passkeyMocks "test/features/passkeys/mocks"
+	"test/features/privacy"
+	"test/features/privacy/mocks"
+	"test/features/users"
+	userMocks "test/features/users/mocks"
+	realHelper "test/helper"
+	helper "test/helper/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRequestExport(t *testing.T) {
+	data := mocks.NewPrivacyDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, nil, nil, nil, nil, nil, auditor, generator, clock, nil)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Success request", func(t *testing.T) {
+		expected := privacy.Job{ID: "randomJobID", UserID: "randomUserID", Type: privacy.JobExport, Status: privacy.StatusPending, CreatedAt: now}
+		data.On("GetActive", "randomUserID", privacy.JobExport).Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateUUID").Return("randomJobID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("Insert", expected).Return(&expected, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "randomUserID", Action: "privacy.export_requested", TargetID: "randomUserID"}).Return(nil).Once()
+
+		result, err := service.RequestExport(context.Background(), "randomUserID")
+		assert.Nil(t, err)
+		assert.Equal(t, &expected, result)
+	})
+
+	t.Run("Request already in progress", func(t *testing.T) {
+		data.On("GetActive", "randomUserID", privacy.JobExport).Return(&privacy.Job{ID: "randomJobID"}, nil).Once()
+
+		result, err := service.RequestExport(context.Background(), "randomUserID")
+		assert.EqualError(t, err, "request already in progress")
+		assert.Nil(t, result)
+	})
+}
+
+func TestRequestErasure(t *testing.T) {
+	data := mocks.NewPrivacyDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, nil, nil, nil, nil, nil, auditor, generator, clock, nil)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	passwordHash, _ := realHelper.HashPassword("Rahasia#2023")
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Password: passwordHash}
+	owner := realHelper.Principal{UserID: user.ID}
+	// linked signs in through a provider and has no password.
+	linked := users.User{ID: "linkedUserID", Nama: "dida"}
+	linkedOwner := realHelper.Principal{UserID: linked.ID, SessionID: "randomSessionID"}
+
+	t.Run("Success request", func(t *testing.T) {
+		expected := privacy.Job{ID: "randomJobID", UserID: user.ID, Type: privacy.JobErasure, Status: privacy.StatusPending, CreatedAt: now}
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		data.On("GetActive", user.ID, privacy.JobErasure).Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateUUID").Return("randomJobID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("Insert", expected).Return(&expected, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: user.ID, Action: "privacy.erasure_requested", TargetID: user.ID}).Return(nil).Once()
+
+		result, err := service.RequestErasure(context.Background(), owner, "Rahasia#2023")
+		assert.Nil(t, err)
+		assert.Equal(t, &expected, result)
+	})
+
+	t.Run("Wrong password confirmation", func(t *testing.T) {
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+
+		result, err := service.RequestErasure(context.Background(), owner, "wrongpassword")
+		assert.EqualError(t, err, "invalid password confirmation")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Account without password signed in recently", func(t *testing.T) {
+		expected := privacy.Job{ID: "randomJobID", UserID: linked.ID, Type: privacy.JobErasure, Status: privacy.StatusPending, CreatedAt: now}
+		userData.On("GetByID", linked.ID).Return(&linked, nil).Once()
+		userData.On("GetSession", "randomSessionID").Return(&users.Session{ID: "randomSessionID", UserID: linked.ID, CreatedAt: now.Add(-time.Minute)}, nil).Once()
+		clock.On("Now").Return(now).Twice()
+		data.On("GetActive", linked.ID, privacy.JobErasure).Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateUUID").Return("randomJobID", nil).Once()
+		data.On("Insert", expected).Return(&expected, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: linked.ID, Action: "privacy.erasure_requested", TargetID: linked.ID}).Return(nil).Once()
+
+		result, err := service.RequestErasure(context.Background(), linkedOwner, "")
+		assert.Nil(t, err)
+		assert.Equal(t, &expected, result)
+	})
+
+	t.Run("Account without password signed in long ago", func(t *testing.T) {
+		userData.On("GetByID", linked.ID).Return(&linked, nil).Once()
+		userData.On("GetSession", "randomSessionID").Return(&users.Session{ID: "randomSessionID", UserID: linked.ID, CreatedAt: now.Add(-time.Hour)}, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		result, err := service.RequestErasure(context.Background(), linkedOwner, "")
+		assert.EqualError(t, err, "reauthentication required")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Account without password while impersonated", func(t *testing.T) {
+		userData.On("GetByID", linked.ID).Return(&linked, nil).Once()
+		userData.On("GetSession", "randomSessionID").Return(&users.Session{ID: "randomSessionID", UserID: linked.ID, ActorID: "adminID", CreatedAt: now}, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		result, err := service.RequestErasure(context.Background(), realHelper.Principal{UserID: linked.ID, SessionID: "randomSessionID", ActorID: "adminID"}, "")
+		assert.EqualError(t, err, "reauthentication required")
+		assert.Nil(t, result)
+	})
+}
+
+func TestDownloadExport(t *testing.T) {
+	data := mocks.NewPrivacyDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	service := New(data, userData, nil, nil, nil, nil, nil, auditor, generator, clock, nil)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(time.Hour)
+	job := privacy.Job{ID: "randomJobID", UserID: "randomUserID", Type: privacy.JobExport, Status: privacy.StatusCompleted, Archive: []byte("zip"), ExpiresAt: &expiresAt}
+
+	t.Run("Success download", func(t *testing.T) {
+		data.On("GetByID", job.ID).Return(&job, nil).Once()
+		clock.On("Now").Return(now).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "randomUserID", Action: "privacy.export_downloaded", TargetID: "randomUserID"}).Return(nil).Once()
+
+		result, err := service.DownloadExport(context.Background(), "randomUserID", job.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("zip"), result.Archive)
+	})
+
+	t.Run("Another user's export", func(t *testing.T) {
+		data.On("GetByID", job.ID).Return(&job, nil).Once()
+
+		result, err := service.DownloadExport(context.Background(), "otherUserID", job.ID)
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Export not ready", func(t *testing.T) {
+		pending := job
+		pending.Status = privacy.StatusPending
+		data.On("GetByID", job.ID).Return(&pending, nil).Once()
+
+		result, err := service.DownloadExport(context.Background(), "randomUserID", job.ID)
+		assert.EqualError(t, err, "export not ready")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Export expired", func(t *testing.T) {
+		expired := job
+		data.On("GetByID", job.ID).Return(&expired, nil).Once()
+		clock.On("Now").Return(now.Add(time.Hour * 2)).Once()
+
+		result, err := service.DownloadExport(context.Background(), "randomUserID", job.ID)
+		assert.EqualError(t, err, "export expired")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Status hides archive", func(t *testing.T) {
+		completed := job
+		data.On("GetByID", job.ID).Return(&completed, nil).Once()
+
+		result, err := service.GetJob(context.Background(), job.ID)
+		assert.Nil(t, err)
+		assert.Nil(t, result.Archive)
+		assert.Equal(t, privacy.StatusCompleted, result.Status)
+	})
+}
+
+func TestProcessJobs(t *testing.T) {
+	data := mocks.NewPrivacyDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	storage := helper.NewStorageInterface(t)
+	identityData := identityMocks.NewIdentityDataInterface(t)
+	passkeyData := passkeyMocks.NewPasskeyDataInterface(t)
+	apiKeyData := apiKeyMocks.NewAPIKeyDataInterface(t)
+	oauthData := oauthMocks.NewOAuthDataInterface(t)
+	service := New(data, userData, nil, identityData, passkeyData, apiKeyData, oauthData, auditor, generator, clock, storage)
+	userData.On("WithContext", mock.Anything).Return(userData).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	clock.On("Now").Return(now)
+	data.On("ClearExpiredArchives", now).Return(nil)
+
+	t.Run("Erasure job", func(t *testing.T) {
+		data.On("ClaimPending").Return(&privacy.Job{ID: "erasureJobID", UserID: "randomUserID", Type: privacy.JobErasure, Status: privacy.StatusProcessing}, nil).Once()
+		data.On("ClaimPending").Return(nil, errors.New("data not found")).Once()
+		userData.On("RevokeTokens", "randomUserID", now).Return(nil).Once()
+		oauthData.On("RevokeUser", "randomUserID", now).Return(nil).Once()
+		apiKeyData.On("EraseUser", "randomUserID").Return(nil).Once()
+		oauthData.On("EraseUser", "randomUserID").Return(nil).Once()
+		identityData.On("EraseUser", "randomUserID").Return(nil).Once()
+		passkeyData.On("EraseUser", "randomUserID").Return(nil).Once()
+		userData.On("Anonymize", "randomUserID", now).Return("avatars/randomUserID/abc", nil).Once()
+		for _, size := range []string{"large", "medium", "small"} {
+			storage.On("Delete", mock.Anything, "avatars/randomUserID/abc-"+size+".jpg").Return(nil).Once()
+		}
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "randomUserID", Action: "user.erased", TargetID: "randomUserID"}).Return(nil).Once()
+		data.On("Update", privacy.Job{ID: "erasureJobID", UserID: "randomUserID", Type: privacy.JobErasure, Status: privacy.StatusCompleted, CompletedAt: &now}).Return(nil).Once()
+
+		processed, err := service.ProcessJobs()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, processed)
+	})
+
+	t.Run("Failed job", func(t *testing.T) {
+		data.On("ClaimPending").Return(&privacy.Job{ID: "erasureJobID", UserID: "randomUserID", Type: privacy.JobErasure, Status: privacy.StatusProcessing}, nil).Once()
+		data.On("ClaimPending").Return(nil, errors.New("data not found")).Once()
+		userData.On("RevokeTokens", "randomUserID", now).Return(nil).Once()
+		oauthData.On("RevokeUser", "randomUserID", now).Return(nil).Once()
+		apiKeyData.On("EraseUser", "randomUserID").Return(nil).Once()
+		oauthData.On("EraseUser", "randomUserID").Return(nil).Once()
+		identityData.On("EraseUser", "randomUserID").Return(nil).Once()
+		passkeyData.On("EraseUser", "randomUserID").Return(nil).Once()
+		userData.On("Anonymize", "randomUserID", now).Return("", errors.New("some db error")).Once()
+		data.On("Update", privacy.Job{ID: "erasureJobID", UserID: "randomUserID", Type: privacy.JobErasure, Status: privacy.StatusFailed, Error: "anonymize process failed", CompletedAt: &now}).Return(nil).Once()
+
+		processed, err := service.ProcessJobs()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, processed)
+	})
+
+	t.Run("Credentials of another feature not erased", func(t *testing.T) {
+		data.On("ClaimPending").Return(&privacy.Job{ID: "erasureJobID", UserID: "randomUserID", Type: privacy.JobErasure, Status: privacy.StatusProcessing}, nil).Once()
+		data.On("ClaimPending").Return(nil, errors.New("data not found")).Once()
+		userData.On("RevokeTokens", "randomUserID", now).Return(nil).Once()
+		oauthData.On("RevokeUser", "randomUserID", now).Return(nil).Once()
+		apiKeyData.On("EraseUser", "randomUserID").Return(errors.New("some db error")).Once()
+		data.On("Update", privacy.Job{ID: "erasureJobID", UserID: "randomUserID", Type: privacy.JobErasure, Status: privacy.StatusFailed, Error: "erase process failed", CompletedAt: &now}).Return(nil).Once()
+
+		processed, err := service.ProcessJobs()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, processed)
+	})
+
+	t.Run("Claim failed", func(t *testing.T) {
+		data.On("ClaimPending").Return(nil, errors.New("some db error")).Once()
+
+		processed, err := service.ProcessJobs()
+		assert.EqualError(t, err, "process failed")
+		assert.Equal(t, 0, processed)
+	})
+}
+
+func TestExport(t *testing.T) {
+	data := mocks.NewPrivacyDataInterface(t)
+	userData := userMocks.NewUserDataInterface(t)
+	groupData := groupMocks.NewGroupDataInterface(t)
+	identityData := identityMocks.NewIdentityDataInterface(t)
+	passkeyData := passkeyMocks.NewPasskeyDataInterface(t)
+	apiKeyData := apiKeyMocks.NewAPIKeyDataInterface(t)
+	oauthData := oauthMocks.NewOAuthDataInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	generator := helper.NewGeneratorInterface(t)
+	clock := helper.NewClockInterface(t)
+	storage := helper.NewStorageInterface(t)
+	service := New(data, userData, groupData, identityData, passkeyData, apiKeyData, oauthData, auditor, generator, clock, storage)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	clock.On("Now").Return(now)
+	data.On("ClearExpiredArchives", now).Return(nil)
+	user := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Password: "secret", TOTPSecret: "TOTPSECRET"}
+
+	// run exports the user and returns the files of the bundle by name. Every
+	// section but the filled one comes back empty.
+	run := func(t *testing.T, user users.User, filled string) map[string]string {
+		data.On("ClaimPending").Return(&privacy.Job{ID: "exportJobID", UserID: user.ID, Type: privacy.JobExport, Status: privacy.StatusProcessing}, nil).Once()
+		data.On("ClaimPending").Return(nil, errors.New("data not found")).Once()
+		userData.On("GetByID", user.ID).Return(&user, nil).Once()
+		if filled != "activity" {
+			userData.On("GetSessions", user.ID, -1).Return([]users.Session{}, nil).Once()
+			auditor.On("ListByUser", user.ID).Return([]audit.Event{}, nil).Once()
+		}
+		if filled != "groups" {
+			groupData.On("GetUserGroupIDs", user.ID).Return([]string{}, nil).Once()
+			groupData.On("GetByIDs", []string{}).Return([]groups.Group{}, nil).Once()
+		}
+		if filled != "identities" {
+			identityData.On("GetByUser", user.ID).Return([]identities.Identity{}, nil).Once()
+		}
+		if filled != "passkeys" {
+			passkeyData.On("GetByUser", user.ID).Return([]passkeys.Passkey{}, nil).Once()
+		}
+		if filled != "api keys" {
+			apiKeyData.On("GetByUser", user.ID).Return([]apikeys.APIKey{}, nil).Once()
+		}
+		if filled != "oauth" {
+			oauthData.On("GetClientsByOwner", user.ID).Return([]oauth.Client{}, nil).Once()
+			oauthData.On("GetConsentsByUser", user.ID).Return([]oauth.Consent{}, nil).Once()
+			oauthData.On("GetGrantsByUser", user.ID).Return([]oauth.Grant{}, nil).Once()
+		}
+
+		var updated privacy.Job
+		data.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+			updated = args.Get(0).(privacy.Job)
+		}).Return(nil).Once()
+
+		processed, err := service.ProcessJobs()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, processed)
+		assert.Equal(t, privacy.StatusCompleted, updated.Status)
+		assert.Equal(t, now.Add(exportTTL), *updated.ExpiresAt)
+
+		reader, err := zip.NewReader(bytes.NewReader(updated.Archive), int64(len(updated.Archive)))
+		assert.Nil(t, err)
+		var files = map[string]string{}
+		for _, file := range reader.File {
+			rc, _ := file.Open()
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			files[file.Name] = string(content)
+		}
+		return files
+	}
+
+	t.Run("Profile, sessions and audit events", func(t *testing.T) {
+		userData.On("GetSessions", user.ID, -1).Return([]users.Session{{ID: "randomSessionID", UserID: user.ID, IP: "10.0.0.1", UserAgent: "curl/8.0", CreatedAt: now, LastSeenAt: now}}, nil).Once()
+		auditor.On("ListByUser", user.ID).Return([]audit.Event{{ID: "eventID", ActorID: user.ID, Action: "user.password_changed", TargetID: user.ID, CreatedAt: now}}, nil).Once()
+
+		files := run(t, user, "activity")
+		assert.Contains(t, files["profile.json"], `"nama": "dida"`)
+		assert.NotContains(t, files["profile.json"], "secret")
+		assert.NotContains(t, files["profile.json"], "TOTPSECRET")
+		assert.Contains(t, files["sessions.json"], `"ip": "10.0.0.1"`)
+		assert.Contains(t, files["audit_events.json"], "user.password_changed")
+		assert.NotContains(t, files, "avatar-large.jpg")
+	})
+
+	t.Run("Group memberships", func(t *testing.T) {
+		groupData.On("GetUserGroupIDs", user.ID).Return([]string{"auditorsID"}, nil).Once()
+		groupData.On("GetByIDs", []string{"auditorsID"}).Return([]groups.Group{{ID: "auditorsID", Name: "Auditors", Permissions: []string{realHelper.PermissionAuditRead}}}, nil).Once()
+
+		files := run(t, user, "groups")
+		assert.Contains(t, files["groups.json"], `"name": "Auditors"`)
+	})
+
+	t.Run("Linked identities", func(t *testing.T) {
+		identityData.On("GetByUser", user.ID).Return([]identities.Identity{{ID: "identityID", UserID: user.ID, Provider: "google", Subject: "subject", Email: "dida@example.com", Name: "Dida", CreatedAt: now}}, nil).Once()
+
+		files := run(t, user, "identities")
+		assert.Contains(t, files["identities.json"], `"provider": "google"`)
+		assert.Contains(t, files["identities.json"], `"email": "dida@example.com"`)
+		assert.Contains(t, files["identities.json"], `"name": "Dida"`)
+	})
+
+	t.Run("Passkeys", func(t *testing.T) {
+		passkeyData.On("GetByUser", user.ID).Return([]passkeys.Passkey{{ID: "passkeyID", UserID: user.ID, CredentialID: "credentialID", PublicKey: []byte("PUBLICKEY"), Name: "Laptop", CreatedAt: now}}, nil).Once()
+
+		files := run(t, user, "passkeys")
+		assert.Contains(t, files["passkeys.json"], `"name": "Laptop"`)
+		assert.NotContains(t, files["passkeys.json"], "credentialID")
+		assert.NotContains(t, files["passkeys.json"], "UFVCTElDS0VZ")
+	})
+
+	t.Run("API keys", func(t *testing.T) {
+		apiKeyData.On("GetByUser", user.ID).Return([]apikeys.APIKey{{ID: "keyID", UserID: user.ID, Name: "CI", Prefix: "ak_prefix", SecretHash: "SECRETHASH", Scopes: []string{"users:read"}, CreatedAt: now}}, nil).Once()
+
+		files := run(t, user, "api keys")
+		assert.Contains(t, files["api_keys.json"], `"name": "CI"`)
+		assert.Contains(t, files["api_keys.json"], `"prefix": "ak_prefix"`)
+		assert.NotContains(t, files["api_keys.json"], "SECRETHASH")
+	})
+
+	t.Run("OAuth clients, consents and grants", func(t *testing.T) {
+		oauthData.On("GetClientsByOwner", user.ID).Return([]oauth.Client{{ID: "ownClientID", OwnerID: user.ID, Name: "My App", SecretHash: "CLIENTSECRETHASH", CreatedAt: now}}, nil).Once()
+		oauthData.On("GetConsentsByUser", user.ID).Return([]oauth.Consent{{UserID: user.ID, ClientID: "clientID", Scopes: []string{"openid", "profile"}, GrantedAt: now}}, nil).Once()
+		oauthData.On("GetGrantsByUser", user.ID).Return([]oauth.Grant{{ID: "grantID", ClientID: "clientID", UserID: user.ID, Scopes: []string{"openid"}, RefreshTokenHash: "REFRESHHASH", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}}, nil).Once()
+
+		files := run(t, user, "oauth")
+		assert.Contains(t, files["oauth_clients.json"], `"name": "My App"`)
+		assert.NotContains(t, files["oauth_clients.json"], "CLIENTSECRETHASH")
+		assert.Contains(t, files["oauth_consents.json"], `"client_id": "clientID"`)
+		assert.Contains(t, files["oauth_grants.json"], `"id": "grantID"`)
+		assert.NotContains(t, files["oauth_grants.json"], "REFRESHHASH")
+	})
+
+	t.Run("Avatar", func(t *testing.T) {
+		withAvatar := user
+		withAvatar.AvatarKey = "avatars/randomUserID/abc"
+		storage.On("Get", mock.Anything, "avatars/randomUserID/abc-large.jpg").Return([]byte("image"), nil).Once()
+
+		files := run(t, withAvatar, "avatar")
+		assert.Equal(t, "image", files["avatar-large.jpg"])
+	})
+
+	t.Run("Avatar unreadable", func(t *testing.T) {
+		withAvatar := user
+		withAvatar.AvatarKey = "avatars/randomUserID/abc"
+		storage.On("Get", mock.Anything, "avatars/randomUserID/abc-large.jpg").Return(nil, errors.New("storage down")).Once()
+		data.On("ClaimPending").Return(&privacy.Job{ID: "exportJobID", UserID: user.ID, Type: privacy.JobExport, Status: privacy.StatusProcessing}, nil).Once()
+		data.On("ClaimPending").Return(nil, errors.New("data not found")).Once()
+		userData.On("GetByID", user.ID).Return(&withAvatar, nil).Once()
+		userData.On("GetSessions", user.ID, -1).Return([]users.Session{}, nil).Once()
+		auditor.On("ListByUser", user.ID).Return([]audit.Event{}, nil).Once()
+		groupData.On("GetUserGroupIDs", user.ID).Return([]string{}, nil).Once()
+		groupData.On("GetByIDs", []string{}).Return([]groups.Group{}, nil).Once()
+		identityData.On("GetByUser", user.ID).Return([]identities.Identity{}, nil).Once()
+		passkeyData.On("GetByUser", user.ID).Return([]passkeys.Passkey{}, nil).Once()
+		apiKeyData.On("GetByUser", user.ID).Return([]apikeys.APIKey{}, nil).Once()
+		oauthData.On("GetClientsByOwner", user.ID).Return([]oauth.Client{}, nil).Once()
+		oauthData.On("GetConsentsByUser", user.ID).Return([]oauth.Consent{}, nil).Once()
+		oauthData.On("GetGrantsByUser", user.ID).Return([]oauth.Grant{}, nil).Once()
+		data.On("Update", privacy.Job{ID: "exportJobID", UserID: user.ID, Type: privacy.JobExport, Status: privacy.StatusFailed, Error: "avatar process failed", CompletedAt: &now}).Return(nil).Once()
+
+		processed, err := service.ProcessJobs()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, processed)
+	})
+}
@@ -0,0 +1,62 @@
+package scim
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+)
+
+// User is the SCIM view of users.User: userName is the HP, displayName the
+// Nama.
+type User struct {
+	ID          string
+	UserName    string
+	DisplayName string
+	Active      bool
+	// Password is write only, it is never set on results.
+	Password string
+}
+
+// PatchOperation is one entry of a PatchOp request. Value is the decoded JSON
+// value, its type depends on the path.
+type PatchOperation struct {
+	Op    string
+	Path  string
+	Value any
+}
+
+type ListResult struct {
+	Resources    []User
+	TotalResults int
+	StartIndex   int
+}
+
+// Error types from RFC 7644 section 3.12. Service errors start with one of
+// them, followed by a description.
+const (
+	ErrInvalidFilter = "invalidFilter"
+	ErrInvalidSyntax = "invalidSyntax"
+	ErrInvalidPath   = "invalidPath"
+	ErrInvalidValue  = "invalidValue"
+	ErrUniqueness    = "uniqueness"
+	ErrMutability    = "mutability"
+	ErrNoTarget      = "noTarget"
+)
+
+type ScimHandlerInterface interface {
+	ServiceProviderConfig() echo.HandlerFunc
+	Create() echo.HandlerFunc
+	Get() echo.HandlerFunc
+	List() echo.HandlerFunc
+	Replace() echo.HandlerFunc
+	Patch() echo.HandlerFunc
+	Delete() echo.HandlerFunc
+}
+type ScimServiceInterface interface {
+	Create(ctx context.Context, newData User) (*User, error)
+	Get(ctx context.Context, userID string) (*User, error)
+	List(ctx context.Context, filter string, startIndex int, count int) (*ListResult, error)
+	Replace(ctx context.Context, newData User) (*User, error)
+	Patch(ctx context.Context, userID string, operations []PatchOperation) (*User, error)
+	Delete(ctx context.Context, userID string) error
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"test/features/scim"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ScimHandler answers in the SCIM format of RFC 7644 instead of
+// helper.FormatResponse, provisioning clients expect it.
+type ScimHandler struct {
+	s scim.ScimServiceInterface
+}
+
+func NewHandler(service scim.ScimServiceInterface) scim.ScimHandlerInterface {
+	return &ScimHandler{
+		s: service,
+	}
+}
+
+func (sh *ScimHandler) ServiceProviderConfig() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var response = new(ServiceProviderConfigResponse)
+		response.Schemas = []string{providerSchema}
+		response.Patch.Supported = true
+		response.Filter.Supported = true
+		response.Filter.MaxResults = defaultCount
+		response.AuthenticationSchemes = []AuthenticationSchemeResponse{{
+			Type:        authenticationType,
+			Name:        "Bearer Token",
+			Description: "The provisioning token in the Authorization header",
+		}}
+
+		return scimJSON(c, http.StatusOK, response)
+	}
+}
+
+func (sh *ScimHandler) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(UserInput)
+
+		if err := decode(c, input); err != nil {
+			c.Logger().Error("handler: decode input error:", err.Error())
+			return scimErrorResponse(c, http.StatusBadRequest, scim.ErrInvalidSyntax, "invalid json")
+		}
+
+		result, err := sh.s.Create(c.Request().Context(), userInput(input))
+
+		if err != nil {
+			c.Logger().Error("handler: scim create process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return scimJSON(c, http.StatusCreated, userResponse(c, *result))
+	}
+}
+
+func (sh *ScimHandler) Get() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := sh.s.Get(c.Request().Context(), c.Param("id"))
+
+		if err != nil {
+			c.Logger().Error("handler: scim get process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return scimJSON(c, http.StatusOK, userResponse(c, *result))
+	}
+}
+
+func (sh *ScimHandler) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var startIndex, count = 1, defaultCount
+		var err error
+
+		if val := c.QueryParam("startIndex"); val != "" {
+			if startIndex, err = strconv.Atoi(val); err != nil {
+				return scimErrorResponse(c, http.StatusBadRequest, scim.ErrInvalidValue, "invalid startIndex")
+			}
+		}
+		if val := c.QueryParam("count"); val != "" {
+			if count, err = strconv.Atoi(val); err != nil {
+				return scimErrorResponse(c, http.StatusBadRequest, scim.ErrInvalidValue, "invalid count")
+			}
+		}
+
+		result, err := sh.s.List(c.Request().Context(), c.QueryParam("filter"), startIndex, count)
+
+		if err != nil {
+			c.Logger().Error("handler: scim list process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		var response = new(ListResponse)
+		response.Schemas = []string{listSchema}
+		response.TotalResults = result.TotalResults
+		response.StartIndex = result.StartIndex
+		response.ItemsPerPage = len(result.Resources)
+		response.Resources = make([]UserResponse, 0, len(result.Resources))
+		for _, user := range result.Resources {
+			response.Resources = append(response.Resources, *userResponse(c, user))
+		}
+
+		return scimJSON(c, http.StatusOK, response)
+	}
+}
+
+func (sh *ScimHandler) Replace() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(UserInput)
+
+		if err := decode(c, input); err != nil {
+			c.Logger().Error("handler: decode input error:", err.Error())
+			return scimErrorResponse(c, http.StatusBadRequest, scim.ErrInvalidSyntax, "invalid json")
+		}
+
+		var newData = userInput(input)
+		newData.ID = c.Param("id")
+
+		result, err := sh.s.Replace(c.Request().Context(), newData)
+
+		if err != nil {
+			c.Logger().Error("handler: scim replace process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return scimJSON(c, http.StatusOK, userResponse(c, *result))
+	}
+}
+
+func (sh *ScimHandler) Patch() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(PatchInput)
+
+		if err := decode(c, input); err != nil {
+			c.Logger().Error("handler: decode input error:", err.Error())
+			return scimErrorResponse(c, http.StatusBadRequest, scim.ErrInvalidSyntax, "invalid json")
+		}
+
+		var operations = make([]scim.PatchOperation, 0, len(input.Operations))
+		for _, operation := range input.Operations {
+			operations = append(operations, scim.PatchOperation{Op: operation.Op, Path: operation.Path, Value: operation.Value})
+		}
+
+		result, err := sh.s.Patch(c.Request().Context(), c.Param("id"), operations)
+
+		if err != nil {
+			c.Logger().Error("handler: scim patch process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return scimJSON(c, http.StatusOK, userResponse(c, *result))
+	}
+}
+
+func (sh *ScimHandler) Delete() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := sh.s.Delete(c.Request().Context(), c.Param("id")); err != nil {
+			c.Logger().Error("handler: scim delete process error:", err.Error())
+			return errorResponse(c, err)
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// decode reads the body itself, Bind does not know the application/scim+json
+// content type.
+func decode(c echo.Context, input any) error {
+	return json.NewDecoder(c.Request().Body).Decode(input)
+}
+
+func userInput(input *UserInput) scim.User {
+	var result = scim.User{UserName: input.UserName, DisplayName: input.DisplayName, Password: input.Password, Active: true}
+	if result.DisplayName == "" && input.Name != nil {
+		result.DisplayName = input.Name.Formatted
+	}
+	if result.UserName == "" && len(input.PhoneNumbers) > 0 {
+		result.UserName = input.PhoneNumbers[0].Value
+	}
+	if input.Active != nil {
+		result.Active = *input.Active
+	}
+
+	return result
+}
+
+func userResponse(c echo.Context, user scim.User) *UserResponse {
+	var response = new(UserResponse)
+	response.Schemas = []string{userSchema}
+	response.ID = user.ID
+	response.UserName = user.UserName
+	response.DisplayName = user.DisplayName
+	if user.DisplayName != "" {
+		response.Name = &NameResponse{Formatted: user.DisplayName}
+	}
+	response.PhoneNumbers = []PhoneNumberResponse{{Value: user.UserName, Type: phoneNumberType, Primary: true}}
+	response.Active = user.Active
+	response.Meta.ResourceType = resourceTypeUser
+	response.Meta.Location = c.Scheme() + "://" + c.Request().Host + "/scim/v2/Users/" + user.ID
+
+	return response
+}
+
+// errorResponse turns service errors into SCIM errors. Errors starting with a
+// SCIM error type were made for the client, the rest is not shown.
+func errorResponse(c echo.Context, err error) error {
+	if code, detail, found := strings.Cut(err.Error(), ": "); found {
+		switch code {
+		case scim.ErrUniqueness:
+			return scimErrorResponse(c, http.StatusConflict, code, detail)
+		case scim.ErrInvalidFilter, scim.ErrInvalidSyntax, scim.ErrInvalidPath, scim.ErrInvalidValue, scim.ErrMutability, scim.ErrNoTarget:
+			return scimErrorResponse(c, http.StatusBadRequest, code, detail)
+		}
+	}
+
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return scimErrorResponse(c, http.StatusNotFound, "", "resource not found")
+	case strings.Contains(err.Error(), "invalid page"):
+		return scimErrorResponse(c, http.StatusBadRequest, scim.ErrInvalidValue, "invalid page")
+	}
+
+	return scimErrorResponse(c, http.StatusInternalServerError, "", "")
+}
+
+func scimErrorResponse(c echo.Context, status int, scimType string, detail string) error {
+	var response = new(ErrorResponse)
+	response.Schemas = []string{errorSchema}
+	response.Status = strconv.Itoa(status)
+	response.ScimType = scimType
+	response.Detail = detail
+
+	return scimJSON(c, status, response)
+}
+
+func scimJSON(c echo.Context, status int, response any) error {
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	return c.JSON(status, response)
+}
@@ -0,0 +1,34 @@
+package handler
+
+type NameInput struct {
+	Formatted string `json:"formatted"`
+}
+
+type PhoneNumberInput struct {
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Primary bool   `json:"primary"`
+}
+
+// UserInput is the User resource clients send on POST and PUT. Active is a
+// pointer because an absent active means an active account.
+type UserInput struct {
+	Schemas      []string           `json:"schemas"`
+	UserName     string             `json:"userName"`
+	DisplayName  string             `json:"displayName"`
+	Name         *NameInput         `json:"name"`
+	PhoneNumbers []PhoneNumberInput `json:"phoneNumbers"`
+	Active       *bool              `json:"active"`
+	Password     string             `json:"password"`
+}
+
+type PatchOperationInput struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+type PatchInput struct {
+	Schemas    []string              `json:"schemas"`
+	Operations []PatchOperationInput `json:"Operations"`
+}
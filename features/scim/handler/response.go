@@ -0,0 +1,86 @@
+package handler
+
+const (
+	userSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	listSchema         = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	errorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+	providerSchema     = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	contentType        = "application/scim+json"
+	defaultCount       = 100
+	resourceTypeUser   = "User"
+	phoneNumberType    = "mobile"
+	authenticationType = "oauthbearertoken"
+)
+
+type NameResponse struct {
+	Formatted string `json:"formatted"`
+}
+
+type PhoneNumberResponse struct {
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Primary bool   `json:"primary"`
+}
+
+type MetaResponse struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location"`
+}
+
+type UserResponse struct {
+	Schemas      []string              `json:"schemas"`
+	ID           string                `json:"id"`
+	UserName     string                `json:"userName"`
+	DisplayName  string                `json:"displayName,omitempty"`
+	Name         *NameResponse         `json:"name,omitempty"`
+	PhoneNumbers []PhoneNumberResponse `json:"phoneNumbers"`
+	Active       bool                  `json:"active"`
+	Meta         MetaResponse          `json:"meta"`
+}
+
+type ListResponse struct {
+	Schemas      []string       `json:"schemas"`
+	TotalResults int            `json:"totalResults"`
+	StartIndex   int            `json:"startIndex"`
+	ItemsPerPage int            `json:"itemsPerPage"`
+	Resources    []UserResponse `json:"Resources"`
+}
+
+type ErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+type SupportedResponse struct {
+	Supported bool `json:"supported"`
+}
+
+type FilterResponse struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type BulkResponse struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type AuthenticationSchemeResponse struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ServiceProviderConfigResponse struct {
+	Schemas               []string                       `json:"schemas"`
+	Patch                 SupportedResponse              `json:"patch"`
+	Bulk                  BulkResponse                   `json:"bulk"`
+	Filter                FilterResponse                 `json:"filter"`
+	ChangePassword        SupportedResponse              `json:"changePassword"`
+	Sort                  SupportedResponse              `json:"sort"`
+	Etag                  SupportedResponse              `json:"etag"`
+	AuthenticationSchemes []AuthenticationSchemeResponse `json:"authenticationSchemes"`
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"test/features/scim"
+	"test/features/users"
+)
+
+const userSchema = "urn:ietf:params:scim:schemas:core:2.0:user:"
+
+// filterFields maps the SCIM attributes that can be filtered on, lower cased,
+// to the user fields behind them.
+var filterFields = map[string]string{
+	"id":                 users.FieldID,
+	"username":           users.FieldHP,
+	"phonenumbers.value": users.FieldHP,
+	"displayname":        users.FieldNama,
+	"name.formatted":     users.FieldNama,
+	"active":             users.FieldActive,
+}
+
+var filterOperators = map[string]string{
+	"eq": users.OperatorEqual,
+	"ne": users.OperatorNotEqual,
+	"co": users.OperatorContains,
+	"sw": users.OperatorStartsWith,
+	"ew": users.OperatorEndsWith,
+	"pr": users.OperatorPresent,
+}
+
+type filterToken struct {
+	value  string
+	quoted bool
+}
+
+// parseFilter supports the part of the RFC 7644 filter grammar provisioning
+// clients use: attribute expressions joined by "and". Anything else is
+// refused rather than half applied.
+func parseFilter(filter string) ([]users.UserFilter, error) {
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var result = []users.UserFilter{}
+	for len(tokens) > 0 {
+		if len(result) > 0 {
+			if tokens[0].quoted || !strings.EqualFold(tokens[0].value, "and") {
+				return nil, scimError(scim.ErrInvalidFilter, "only and is supported between expressions")
+			}
+			tokens = tokens[1:]
+		}
+
+		if len(tokens) < 2 || tokens[0].quoted || tokens[1].quoted {
+			return nil, scimError(scim.ErrInvalidFilter, "incomplete expression")
+		}
+
+		field, found := filterFields[strings.TrimPrefix(strings.ToLower(tokens[0].value), userSchema)]
+		if !found {
+			return nil, scimError(scim.ErrInvalidFilter, "unsupported attribute "+tokens[0].value)
+		}
+
+		operator, found := filterOperators[strings.ToLower(tokens[1].value)]
+		if !found {
+			return nil, scimError(scim.ErrInvalidFilter, "unsupported operator "+tokens[1].value)
+		}
+
+		var condition = users.UserFilter{Field: field, Operator: operator}
+		tokens = tokens[2:]
+
+		if operator != users.OperatorPresent {
+			if len(tokens) == 0 {
+				return nil, scimError(scim.ErrInvalidFilter, "missing value")
+			}
+
+			var value = tokens[0]
+			switch {
+			case field == users.FieldActive && !value.quoted && (strings.EqualFold(value.value, "true") || strings.EqualFold(value.value, "false")):
+				condition.Value = strings.ToLower(value.value)
+			case field != users.FieldActive && value.quoted:
+				condition.Value = value.value
+			default:
+				return nil, scimError(scim.ErrInvalidFilter, "invalid value for "+field)
+			}
+			tokens = tokens[1:]
+
+			if field == users.FieldActive && operator != users.OperatorEqual && operator != users.OperatorNotEqual {
+				return nil, scimError(scim.ErrInvalidFilter, "active only supports eq and ne")
+			}
+		}
+
+		result = append(result, condition)
+	}
+
+	return result, nil
+}
+
+func tokenizeFilter(filter string) ([]filterToken, error) {
+	var result = []filterToken{}
+
+	for i := 0; i < len(filter); {
+		switch c := filter[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"':
+			var end = i + 1
+			for end < len(filter) && filter[end] != '"' {
+				if filter[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(filter) {
+				return nil, scimError(scim.ErrInvalidFilter, "unterminated string")
+			}
+
+			var value string
+			if err := json.Unmarshal([]byte(filter[i:end+1]), &value); err != nil {
+				return nil, scimError(scim.ErrInvalidFilter, "invalid string")
+			}
+			result = append(result, filterToken{value: value, quoted: true})
+			i = end + 1
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			return nil, scimError(scim.ErrInvalidFilter, "grouping is not supported")
+		default:
+			var end = i
+			for end < len(filter) && filter[end] != ' ' && filter[end] != '\t' && filter[end] != '"' && filter[end] != '(' && filter[end] != '[' {
+				end++
+			}
+			result = append(result, filterToken{value: filter[i:end]})
+			i = end
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"test/features/scim"
+	"test/features/users"
+	"test/helper"
+)
+
+const (
+	// actorID names the provisioning system in the audit log.
+	actorID  = "scim"
+	maxCount = 100
+)
+
+// provisioner is the provisioning system acting on accounts, it manages
+// every account, admins included.
+var provisioner = helper.Principal{UserID: actorID, Roles: []string{users.RoleAdmin}}
+
+type ScimService struct {
+	u users.UserServiceInterface
+}
+
+func New(userService users.UserServiceInterface) scim.ScimServiceInterface {
+	return &ScimService{
+		u: userService,
+	}
+}
+
+func (ss *ScimService) Create(ctx context.Context, newData scim.User) (*scim.User, error) {
+	if strings.TrimSpace(newData.UserName) == "" {
+		return nil, scimError(scim.ErrInvalidValue, "userName is required")
+	}
+
+	result, err := ss.u.Provision(ctx, actorID, users.User{Nama: newData.DisplayName, HP: newData.UserName, Password: newData.Password})
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	if !newData.Active {
+		if err := ss.u.Deactivate(ctx, provisioner, result.ID); err != nil {
+			return nil, userError(err)
+		}
+	}
+
+	var created = toScimUser(result)
+	created.Active = newData.Active
+
+	return created, nil
+}
+
+func (ss *ScimService) Get(ctx context.Context, userID string) (*scim.User, error) {
+	result, err := ss.u.GetUser(ctx, userID)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	return toScimUser(result), nil
+}
+
+// List takes the 1-based startIndex and the page size of RFC 7644, a count of
+// zero only returns the number of matches.
+func (ss *ScimService) List(ctx context.Context, filter string, startIndex int, count int) (*scim.ListResult, error) {
+	var query = new(users.UserQuery)
+
+	if strings.TrimSpace(filter) != "" {
+		filters, err := parseFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		query.Filters = filters
+	}
+
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > maxCount {
+		count = maxCount
+	}
+	query.Offset = startIndex - 1
+	// The user list needs a page size, with a count of zero the one user it
+	// returns is dropped again.
+	query.Limit = count
+	if count == 0 {
+		query.Limit = 1
+	}
+
+	var result = new(scim.ListResult)
+	result.StartIndex = startIndex
+	result.Resources = []scim.User{}
+
+	found, total, err := ss.u.ListUsers(ctx, *query)
+	if err != nil {
+		return nil, userError(err)
+	}
+	result.TotalResults = total
+
+	if count == 0 {
+		return result, nil
+	}
+
+	for i := range found {
+		result.Resources = append(result.Resources, *toScimUser(&found[i]))
+	}
+
+	return result, nil
+}
+
+// Replace applies a PUT. A missing password keeps the current one, the
+// password can not be read back so clients do not send it.
+func (ss *ScimService) Replace(ctx context.Context, newData scim.User) (*scim.User, error) {
+	current, err := ss.Get(ctx, newData.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(newData.UserName) == "" {
+		return nil, scimError(scim.ErrInvalidValue, "userName is required")
+	}
+
+	return ss.apply(ctx, current, newData)
+}
+
+func (ss *ScimService) Patch(ctx context.Context, userID string, operations []scim.PatchOperation) (*scim.User, error) {
+	current, err := ss.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(operations) == 0 {
+		return nil, scimError(scim.ErrInvalidSyntax, "no operations")
+	}
+
+	var target = *current
+	for _, operation := range operations {
+		if err := applyOperation(&target, operation); err != nil {
+			return nil, err
+		}
+	}
+
+	return ss.apply(ctx, current, target)
+}
+
+func (ss *ScimService) Delete(ctx context.Context, userID string) error {
+	if err := ss.u.RemoveAccount(ctx, actorID, userID); err != nil {
+		return userError(err)
+	}
+
+	return nil
+}
+
+// apply makes the changes between current and target through the user
+// service, the profile first so a refused userName leaves the rest alone.
+func (ss *ScimService) apply(ctx context.Context, current *scim.User, target scim.User) (*scim.User, error) {
+	if target.UserName != current.UserName || target.DisplayName != current.DisplayName {
+		if _, err := ss.u.UpdateProfile(ctx, actorID, users.User{ID: current.ID, Nama: target.DisplayName, HP: target.UserName}); err != nil {
+			return nil, userError(err)
+		}
+	}
+
+	if target.Password != "" {
+		if err := ss.u.SetPassword(ctx, actorID, current.ID, target.Password); err != nil {
+			return nil, userError(err)
+		}
+	}
+
+	if target.Active != current.Active {
+		var err error
+		if target.Active {
+			err = ss.u.Reactivate(ctx, provisioner, current.ID)
+		} else {
+			err = ss.u.Deactivate(ctx, provisioner, current.ID)
+		}
+		if err != nil {
+			return nil, userError(err)
+		}
+	}
+
+	target.ID = current.ID
+	target.Password = ""
+
+	return &target, nil
+}
+
+// applyOperation supports the attributes this server maps, with or without a
+// path. Without one the value is an object of attributes, the way some
+// clients send every replace.
+func applyOperation(target *scim.User, operation scim.PatchOperation) error {
+	var op = strings.ToLower(operation.Op)
+	if op != "add" && op != "replace" && op != "remove" {
+		return scimError(scim.ErrInvalidSyntax, "unsupported operation "+operation.Op)
+	}
+
+	if operation.Path == "" {
+		if op == "remove" {
+			return scimError(scim.ErrNoTarget, "remove needs a path")
+		}
+
+		values, ok := operation.Value.(map[string]any)
+		if !ok {
+			return scimError(scim.ErrInvalidValue, "value has to be an object")
+		}
+		for path, value := range values {
+			if err := applyValue(target, path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if op == "remove" {
+		switch normalizePath(operation.Path) {
+		case "displayname", "name.formatted", "name":
+			target.DisplayName = ""
+			return nil
+		}
+		return scimError(scim.ErrMutability, operation.Path+" can not be removed")
+	}
+
+	return applyValue(target, operation.Path, operation.Value)
+}
+
+func applyValue(target *scim.User, path string, value any) error {
+	switch normalizePath(path) {
+	case "username":
+		userName, ok := value.(string)
+		if !ok {
+			return scimError(scim.ErrInvalidValue, "userName has to be a string")
+		}
+		target.UserName = userName
+	case "displayname", "name.formatted":
+		displayName, ok := value.(string)
+		if !ok {
+			return scimError(scim.ErrInvalidValue, path+" has to be a string")
+		}
+		target.DisplayName = displayName
+	case "name":
+		name, ok := value.(map[string]any)
+		if !ok {
+			return scimError(scim.ErrInvalidValue, "name has to be an object")
+		}
+		if formatted, found := name["formatted"]; found {
+			return applyValue(target, "name.formatted", formatted)
+		}
+	case "active":
+		active, err := boolValue(value)
+		if err != nil {
+			return err
+		}
+		target.Active = active
+	case "password":
+		password, ok := value.(string)
+		if !ok || password == "" {
+			return scimError(scim.ErrInvalidValue, "password has to be a string")
+		}
+		target.Password = password
+	case "phonenumbers", `phonenumbers[type eq "mobile"].value`, "phonenumbers.value":
+		phone, err := phoneValue(value)
+		if err != nil {
+			return err
+		}
+		target.UserName = phone
+	default:
+		return scimError(scim.ErrInvalidPath, "unsupported path "+path)
+	}
+
+	return nil
+}
+
+func normalizePath(path string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(path)), userSchema)
+}
+
+// boolValue also takes "True" and "False", some clients send booleans as
+// strings.
+func boolValue(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if strings.EqualFold(v, "true") {
+			return true, nil
+		}
+		if strings.EqualFold(v, "false") {
+			return false, nil
+		}
+	}
+
+	return false, scimError(scim.ErrInvalidValue, "active has to be a boolean")
+}
+
+// phoneValue takes a plain number or the phoneNumbers list, the HP is both the
+// userName and the only phone number.
+func phoneValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []any:
+		if len(v) == 1 {
+			if entry, ok := v[0].(map[string]any); ok {
+				if phone, ok := entry["value"].(string); ok {
+					return phone, nil
+				}
+			}
+		}
+	}
+
+	return "", scimError(scim.ErrInvalidValue, "phoneNumbers has to hold one number")
+}
+
+func toScimUser(user *users.User) *scim.User {
+	var result = new(scim.User)
+	result.ID = user.ID
+	result.UserName = user.HP
+	result.DisplayName = user.Nama
+	result.Active = user.DeactivatedAt == nil
+
+	return result
+}
+
+// userError translates user service errors into SCIM error types, the ones
+// without a SCIM counterpart are passed on.
+func userError(err error) error {
+	var policyErr *helper.PasswordPolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		return scimError(scim.ErrInvalidValue, err.Error())
+	case strings.Contains(err.Error(), "hp already used"):
+		return scimError(scim.ErrUniqueness, "userName is already taken")
+	case strings.Contains(err.Error(), "invalid hp"):
+		return scimError(scim.ErrInvalidValue, "invalid userName")
+	case strings.Contains(err.Error(), "invalid filter"):
+		return scimError(scim.ErrInvalidFilter, "unsupported filter")
+	case strings.Contains(err.Error(), "managed by directory"):
+		return scimError(scim.ErrMutability, "password is managed by a directory")
+	}
+
+	return err
+}
+
+func scimError(code string, description string) error {
+	return errors.New(code + ": " + description)
+}
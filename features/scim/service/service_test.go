@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"test/features/scim"
+	"test/features/users"
+	userMocks "test/features/users/mocks"
+	"test/helper"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreate(t *testing.T) {
+	userService := userMocks.NewUserServiceInterface(t)
+	service := New(userService)
+
+	t.Run("Success inactive user", func(t *testing.T) {
+		userService.On("Provision", mock.Anything, "scim", users.User{Nama: "dida", HP: "0812", Password: "Rahasia#2023"}).Return(&users.User{ID: "randomUserID", Nama: "dida", HP: "0812"}, nil).Once()
+		userService.On("Deactivate", mock.Anything, helper.Principal{UserID: "scim", Roles: []string{users.RoleAdmin}}, "randomUserID").Return(nil).Once()
+
+		result, err := service.Create(context.Background(), scim.User{UserName: "0812", DisplayName: "dida", Password: "Rahasia#2023"})
+		assert.Nil(t, err)
+		assert.Equal(t, &scim.User{ID: "randomUserID", UserName: "0812", DisplayName: "dida"}, result)
+	})
+
+	t.Run("Duplicate userName", func(t *testing.T) {
+		userService.On("Provision", mock.Anything, "scim", users.User{HP: "0812"}).Return(nil, errors.New("hp already used")).Once()
+
+		result, err := service.Create(context.Background(), scim.User{UserName: "0812", Active: true})
+		assert.EqualError(t, err, "uniqueness: userName is already taken")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Missing userName", func(t *testing.T) {
+		result, err := service.Create(context.Background(), scim.User{DisplayName: "dida", Active: true})
+		assert.EqualError(t, err, "invalidValue: userName is required")
+		assert.Nil(t, result)
+	})
+}
+
+func TestParseFilter(t *testing.T) {
+	t.Run("Equal", func(t *testing.T) {
+		result, err := parseFilter(`userName eq "0812"`)
+		assert.Nil(t, err)
+		assert.Equal(t, []users.UserFilter{{Field: users.FieldHP, Operator: users.OperatorEqual, Value: "0812"}}, result)
+	})
+
+	t.Run("And with schema prefix", func(t *testing.T) {
+		result, err := parseFilter(`urn:ietf:params:scim:schemas:core:2.0:User:displayName sw "di\"da" AND active eq True and name.formatted pr`)
+		assert.Nil(t, err)
+		assert.Equal(t, []users.UserFilter{
+			{Field: users.FieldNama, Operator: users.OperatorStartsWith, Value: `di"da`},
+			{Field: users.FieldActive, Operator: users.OperatorEqual, Value: "true"},
+			{Field: users.FieldNama, Operator: users.OperatorPresent},
+		}, result)
+	})
+
+	var invalid = []string{
+		`userName eq "0812" or userName eq "0813"`,
+		`(userName eq "0812")`,
+		`emails.value eq "dida@example.com"`,
+		`userName gt "0812"`,
+		`userName eq 0812`,
+		`active co true`,
+		`userName eq "0812`,
+		`userName eq`,
+	}
+	for _, filter := range invalid {
+		t.Run("Invalid "+filter, func(t *testing.T) {
+			result, err := parseFilter(filter)
+			assert.ErrorContains(t, err, scim.ErrInvalidFilter+": ")
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	userService := userMocks.NewUserServiceInterface(t)
+	service := New(userService)
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		userService.On("ListUsers", mock.Anything, users.UserQuery{
+			Filters: []users.UserFilter{{Field: users.FieldHP, Operator: users.OperatorEqual, Value: "0812"}},
+			Offset:  10,
+			Limit:   100,
+		}).Return([]users.User{{ID: "randomUserID", Nama: "dida", HP: "0812", DeactivatedAt: &now}}, 11, nil).Once()
+
+		result, err := service.List(context.Background(), `userName eq "0812"`, 11, 500)
+		assert.Nil(t, err)
+		assert.Equal(t, 11, result.TotalResults)
+		assert.Equal(t, 11, result.StartIndex)
+		assert.Equal(t, []scim.User{{ID: "randomUserID", UserName: "0812", DisplayName: "dida"}}, result.Resources)
+	})
+
+	t.Run("Count only", func(t *testing.T) {
+		userService.On("ListUsers", mock.Anything, users.UserQuery{Limit: 1}).Return([]users.User{{ID: "randomUserID"}}, 42, nil).Once()
+
+		result, err := service.List(context.Background(), "", 0, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, 42, result.TotalResults)
+		assert.Equal(t, 1, result.StartIndex)
+		assert.Empty(t, result.Resources)
+	})
+
+	t.Run("Invalid filter", func(t *testing.T) {
+		result, err := service.List(context.Background(), `userName eq "0812" or active eq true`, 1, 10)
+		assert.ErrorContains(t, err, "invalidFilter")
+		assert.Nil(t, result)
+	})
+}
+
+func TestReplace(t *testing.T) {
+	userService := userMocks.NewUserServiceInterface(t)
+	service := New(userService)
+
+	t.Run("Success", func(t *testing.T) {
+		userService.On("GetUser", mock.Anything, "randomUserID").Return(&users.User{ID: "randomUserID", Nama: "dida", HP: "0812"}, nil).Once()
+		userService.On("UpdateProfile", mock.Anything, "scim", users.User{ID: "randomUserID", Nama: "dida putra", HP: "0813"}).Return(&users.User{ID: "randomUserID", Nama: "dida putra", HP: "0813"}, nil).Once()
+		userService.On("SetPassword", mock.Anything, "scim", "randomUserID", "Rahasia#2023").Return(nil).Once()
+
+		result, err := service.Replace(context.Background(), scim.User{ID: "randomUserID", UserName: "0813", DisplayName: "dida putra", Active: true, Password: "Rahasia#2023"})
+		assert.Nil(t, err)
+		assert.Equal(t, &scim.User{ID: "randomUserID", UserName: "0813", DisplayName: "dida putra", Active: true}, result)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		userService.On("GetUser", mock.Anything, "unknownID").Return(nil, errors.New("data not found")).Once()
+
+		result, err := service.Replace(context.Background(), scim.User{ID: "unknownID", UserName: "0813", Active: true})
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+	})
+}
+
+func TestPatch(t *testing.T) {
+	userService := userMocks.NewUserServiceInterface(t)
+	service := New(userService)
+
+	t.Run("Deactivate with string value", func(t *testing.T) {
+		userService.On("GetUser", mock.Anything, "randomUserID").Return(&users.User{ID: "randomUserID", Nama: "dida", HP: "0812"}, nil).Once()
+		userService.On("Deactivate", mock.Anything, helper.Principal{UserID: "scim", Roles: []string{users.RoleAdmin}}, "randomUserID").Return(nil).Once()
+
+		result, err := service.Patch(context.Background(), "randomUserID", []scim.PatchOperation{{Op: "Replace", Path: "active", Value: "False"}})
+		assert.Nil(t, err)
+		assert.False(t, result.Active)
+	})
+
+	t.Run("Replace without path", func(t *testing.T) {
+		userService.On("GetUser", mock.Anything, "randomUserID").Return(&users.User{ID: "randomUserID", Nama: "dida", HP: "0812"}, nil).Once()
+		userService.On("UpdateProfile", mock.Anything, "scim", users.User{ID: "randomUserID", Nama: "dida putra", HP: "0812"}).Return(&users.User{ID: "randomUserID", Nama: "dida putra", HP: "0812"}, nil).Once()
+
+		result, err := service.Patch(context.Background(), "randomUserID", []scim.PatchOperation{{Op: "replace", Value: map[string]any{"name": map[string]any{"formatted": "dida putra"}}}})
+		assert.Nil(t, err)
+		assert.Equal(t, "dida putra", result.DisplayName)
+	})
+
+	t.Run("Remove userName", func(t *testing.T) {
+		userService.On("GetUser", mock.Anything, "randomUserID").Return(&users.User{ID: "randomUserID", Nama: "dida", HP: "0812"}, nil).Once()
+
+		result, err := service.Patch(context.Background(), "randomUserID", []scim.PatchOperation{{Op: "remove", Path: "userName"}})
+		assert.EqualError(t, err, "mutability: userName can not be removed")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Unsupported path", func(t *testing.T) {
+		userService.On("GetUser", mock.Anything, "randomUserID").Return(&users.User{ID: "randomUserID", Nama: "dida", HP: "0812"}, nil).Once()
+
+		result, err := service.Patch(context.Background(), "randomUserID", []scim.PatchOperation{{Op: "add", Path: "emails", Value: "dida@example.com"}})
+		assert.EqualError(t, err, "invalidPath: unsupported path emails")
+		assert.Nil(t, result)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	userService := userMocks.NewUserServiceInterface(t)
+	service := New(userService)
+
+	t.Run("Unknown user", func(t *testing.T) {
+		userService.On("RemoveAccount", mock.Anything, "scim", "unknownID").Return(errors.New("data not found")).Once()
+
+		err := service.Delete(context.Background(), "unknownID")
+		assert.EqualError(t, err, "data not found")
+	})
+}
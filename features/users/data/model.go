@@ -1,8 +1,76 @@
 package data
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
 type User struct {
-	ID       string `gorm:"varchar(255);primaryKey;"`
-	Nama     string
-	HP       string
-	Password string
+	ID              string `gorm:"varchar(255);primaryKey;"`
+	Nama            string
+	HP              *string `gorm:"type:varchar(255);uniqueIndex"`
+	Password        string
+	TOTPSecret      string
+	TOTPEnabled     bool
+	TOTPLastStep    int64
+	Role            string `gorm:"type:varchar(32);default:user"`
+	HPVerifiedAt    *time.Time
+	TokensRevokedAt *time.Time
+	DeactivatedAt   *time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+	AnonymizedAt    *time.Time
+	Directory       string `gorm:"type:varchar(64);index:idx_user_directory"`
+	DirectoryID     string `gorm:"type:varchar(255);index:idx_user_directory"`
+	AvatarKey       string `gorm:"type:varchar(255)"`
+}
+
+// TenantCondition limits users to the members of an organization, see
+// helper.TenantPlugin.
+func (User) TenantCondition(tenantID string) clause.Expression {
+	return clause.Expr{SQL: "users.id IN (SELECT user_id FROM memberships WHERE organization_id = ?)", Vars: []any{tenantID}}
+}
+
+type RecoveryCode struct {
+	ID       uint   `gorm:"primaryKey"`
+	UserID   string `gorm:"type:varchar(255);index"`
+	CodeHash string `gorm:"type:varchar(64);uniqueIndex"`
+	UsedAt   *time.Time
+}
+
+type OneTimeCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    string `gorm:"type:varchar(255);index:idx_one_time_code_owner"`
+	Purpose   string `gorm:"type:varchar(32);index:idx_one_time_code_owner"`
+	CodeHash  string
+	Attempts  int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type PasswordHistory struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       string `gorm:"type:varchar(255);index"`
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+type Session struct {
+	ID               string `gorm:"type:varchar(255);primaryKey"`
+	UserID           string `gorm:"type:varchar(255);index"`
+	IP               string `gorm:"type:varchar(64)"`
+	UserAgent        string `gorm:"type:varchar(512)"`
+	Fingerprint      string `gorm:"type:varchar(64)"`
+	Country          string `gorm:"type:varchar(8)"`
+	City             string
+	Latitude         *float64
+	Longitude        *float64
+	RefreshTokenHash string `gorm:"type:varchar(64)"`
+	ActorID          string `gorm:"type:varchar(255)"`
+	CreatedAt        time.Time
+	LastSeenAt       time.Time
+	ExpiresAt        time.Time
+	RevokedAt        *time.Time
 }
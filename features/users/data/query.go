@@ -1,7 +1,11 @@
 package data
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"test/features/users"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -17,34 +21,679 @@ func New(g *gorm.DB) users.UserDataInterface {
 	}
 }
 
+// WithContext runs the queries with ctx, which scopes them to the tenant in
+// it, if any.
+func (ud *UserData) WithContext(ctx context.Context) users.UserDataInterface {
+	return &UserData{
+		gorm: ud.gorm.WithContext(ctx),
+	}
+}
+
 func (ud *UserData) Insert(newData users.User) (*users.User, error) {
 	var dbData = new(User)
 	dbData.ID = newData.ID
-	dbData.HP = newData.HP
+	dbData.HP = nullableHP(newData.HP)
 	dbData.Nama = newData.Nama
 	dbData.Password = newData.Password
+	dbData.Role = newData.Role
+	dbData.Directory = newData.Directory
+	dbData.DirectoryID = newData.DirectoryID
 
-	if err := ud.gorm.Create(dbData).Error; err != nil {
+	var err = ud.gorm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dbData).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&PasswordHistory{UserID: dbData.ID, PasswordHash: dbData.Password}).Error
+	})
+
+	if err != nil {
 		return nil, err
 	}
 
 	return &newData, nil
 }
 
-func (ud *UserData) Login(hp string, password string) (*users.User, error) {
+func (ud *UserData) GetByHP(hp string) (*users.User, error) {
 	var dbData = new(User)
-	dbData.HP = hp
-	dbData.Password = password
 
-	if err := ud.gorm.Where("hp = ? AND password = ?", dbData.HP, dbData.Password).First(dbData).Error; err != nil {
+	if err := ud.gorm.Where("hp = ?", hp).First(dbData).Error; err != nil {
 		logrus.Info("db error:", err.Error())
 		return nil, err
 	}
 
+	return toUser(dbData), nil
+}
+
+func (ud *UserData) GetByDirectory(directory string, directoryID string) (*users.User, error) {
+	var dbData = new(User)
+
+	if err := ud.gorm.Where("directory = ? AND directory_id = ?", directory, directoryID).First(dbData).Error; err != nil {
+		return nil, err
+	}
+
+	return toUser(dbData), nil
+}
+
+func (ud *UserData) GetByID(id string) (*users.User, error) {
+	var dbData = new(User)
+
+	if err := ud.gorm.Where("id = ?", id).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toUser(dbData), nil
+}
+
+// List refuses fields and operators it does not know instead of ignoring them,
+// the filters come from outside callers.
+func (ud *UserData) List(query users.UserQuery) ([]users.User, int, error) {
+	var qry = ud.gorm.Model(&User{})
+
+	for _, filter := range query.Filters {
+		condition, args, err := userCondition(filter)
+		if err != nil {
+			return nil, 0, err
+		}
+		qry = qry.Where(condition, args...)
+	}
+
+	var total int64
+	if err := qry.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var dbData = []User{}
+	if err := qry.Order("id").Offset(query.Offset).Limit(query.Limit).Find(&dbData).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var result = make([]users.User, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toUser(&dbData[i]))
+	}
+
+	return result, int(total), nil
+}
+
+func (ud *UserData) UpdateProfile(newData users.User) error {
+	var qry = ud.gorm.Model(&User{}).Where("id = ?", newData.ID).Updates(map[string]any{
+		"nama": newData.Nama,
+		"hp":   nullableHP(newData.HP),
+	})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+// UpdatePassword also keeps the new hash in the password history, which the
+// password policy uses to refuse recently used passwords.
+func (ud *UserData) UpdatePassword(userID string, password string) error {
+	return ud.gorm.Transaction(func(tx *gorm.DB) error {
+		var qry = tx.Model(&User{}).Where("id = ?", userID).Update("password", password)
+
+		if err := qry.Error; err != nil {
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("data not found")
+		}
+
+		return tx.Create(&PasswordHistory{UserID: userID, PasswordHash: password}).Error
+	})
+}
+
+func (ud *UserData) UpdateRole(userID string, role string) error {
+	var qry = ud.gorm.Model(&User{}).Where("id = ?", userID).Update("role", role)
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (ud *UserData) UpdateAvatar(userID string, avatarKey string) error {
+	var qry = ud.gorm.Model(&User{}).Where("id = ?", userID).Update("avatar_key", avatarKey)
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (ud *UserData) GetPasswordHistory(userID string, limit int) ([]string, error) {
+	var dbData = []PasswordHistory{}
+
+	if err := ud.gorm.Where("user_id = ?", userID).Order("created_at desc, id desc").Limit(limit).Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]string, 0, len(dbData))
+	for _, history := range dbData {
+		result = append(result, history.PasswordHash)
+	}
+
+	return result, nil
+}
+
+// RevokeTokens ends every session of the user along with the access tokens
+// issued before revokedAt.
+func (ud *UserData) RevokeTokens(userID string, revokedAt time.Time) error {
+	return ud.gorm.Transaction(func(tx *gorm.DB) error {
+		var qry = tx.Model(&User{}).Where("id = ?", userID).Update("tokens_revoked_at", revokedAt)
+
+		if err := qry.Error; err != nil {
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("data not found")
+		}
+
+		return tx.Model(&Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", revokedAt).Error
+	})
+}
+
+func (ud *UserData) SetHPVerified(userID string, verifiedAt time.Time) error {
+	var qry = ud.gorm.Model(&User{}).Where("id = ?", userID).Update("hp_verified_at", verifiedAt)
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (ud *UserData) UpdateTOTP(newData users.User) error {
+	var qry = ud.gorm.Model(&User{}).Where("id = ?", newData.ID).Updates(map[string]any{
+		"totp_secret":    newData.TOTPSecret,
+		"totp_enabled":   newData.TOTPEnabled,
+		"totp_last_step": newData.TOTPLastStep,
+	})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (ud *UserData) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	return ud.gorm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		if len(codeHashes) == 0 {
+			return nil
+		}
+
+		var codes = make([]RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+func (ud *UserData) UseRecoveryCode(userID string, codeHash string) error {
+	var qry = ud.gorm.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+// InsertOneTimeCode retires any code of the same purpose that is still pending
+// for the user, so only the most recently sent code can be redeemed. Retired
+// codes are kept as used, they still count for CountOneTimeCodes.
+func (ud *UserData) InsertOneTimeCode(newData users.OneTimeCode) error {
+	return ud.gorm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&OneTimeCode{}).Where("user_id = ? AND purpose = ? AND used_at IS NULL", newData.UserID, newData.Purpose).Update("used_at", newData.CreatedAt).Error; err != nil {
+			return err
+		}
+
+		var dbData = new(OneTimeCode)
+		dbData.UserID = newData.UserID
+		dbData.Purpose = newData.Purpose
+		dbData.CodeHash = newData.CodeHash
+		dbData.Attempts = newData.Attempts
+		dbData.ExpiresAt = newData.ExpiresAt
+		dbData.CreatedAt = newData.CreatedAt
+
+		return tx.Create(dbData).Error
+	})
+}
+
+func (ud *UserData) GetOneTimeCode(userID string, purpose string) (*users.OneTimeCode, error) {
+	var dbData = new(OneTimeCode)
+
+	if err := ud.gorm.Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).Order("created_at desc").First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	var result = new(users.OneTimeCode)
+	result.ID = dbData.ID
+	result.UserID = dbData.UserID
+	result.Purpose = dbData.Purpose
+	result.CodeHash = dbData.CodeHash
+	result.Attempts = dbData.Attempts
+	result.ExpiresAt = dbData.ExpiresAt
+	result.UsedAt = dbData.UsedAt
+	result.CreatedAt = dbData.CreatedAt
+
+	return result, nil
+}
+
+func (ud *UserData) CountOneTimeCodes(userID string, purpose string, since time.Time) (int, error) {
+	var count int64
+
+	if err := ud.gorm.Model(&OneTimeCode{}).Where("user_id = ? AND purpose = ? AND created_at >= ?", userID, purpose, since).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+func (ud *UserData) UpdateOneTimeCode(newData users.OneTimeCode) error {
+	var qry = ud.gorm.Model(&OneTimeCode{}).Where("id = ?", newData.ID).Updates(map[string]any{
+		"attempts": newData.Attempts,
+		"used_at":  newData.UsedAt,
+	})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (ud *UserData) Deactivate(userID string, deactivatedAt time.Time) error {
+	var qry = ud.gorm.Model(&User{}).Where("id = ? AND deactivated_at IS NULL", userID).Update("deactivated_at", deactivatedAt)
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+// Reactivate also brings back an account that was deleted by its owner, as
+// long as the purge job has not anonymized it yet.
+func (ud *UserData) Reactivate(userID string) error {
+	var qry = ud.gorm.Unscoped().Model(&User{}).
+		Where("id = ? AND anonymized_at IS NULL AND (deactivated_at IS NOT NULL OR deleted_at IS NOT NULL)", userID).
+		Updates(map[string]any{
+			"deactivated_at": nil,
+			"deleted_at":     nil,
+		})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (ud *UserData) SoftDelete(userID string) error {
+	var qry = ud.gorm.Where("id = ?", userID).Delete(&User{})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (ud *UserData) GetDeletedBefore(before time.Time) ([]string, error) {
+	var result = []string{}
+
+	if err := ud.gorm.Unscoped().Model(&User{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND anonymized_at IS NULL", before).
+		Pluck("id", &result).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Anonymize keeps the row, and therefore every reference to its id, but wipes
+// what identifies the person and every credential in the users tables that
+// could still log in.
+// It returns the avatar key the account linked, the stored files are left to
+// the caller.
+func (ud *UserData) Anonymize(userID string, anonymizedAt time.Time) (string, error) {
+	var avatarKey string
+
+	err := ud.gorm.Transaction(func(tx *gorm.DB) error {
+		key, err := linkedAvatar(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		var qry = tx.Unscoped().Model(&User{}).Where("id = ? AND anonymized_at IS NULL", userID).Updates(map[string]any{
+			"nama":           "Deleted User",
+			"hp":             "deleted:" + userID,
+			"password":       "",
+			"totp_secret":    "",
+			"totp_enabled":   false,
+			"totp_last_step": 0,
+			"avatar_key":     "",
+			"anonymized_at":  anonymizedAt,
+		})
+
+		if err := qry.Error; err != nil {
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("data not found")
+		}
+
+		avatarKey = key
+		return deleteCredentials(tx, userID)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return avatarKey, nil
+}
+
+// HardDelete returns the avatar key of the removed account like Anonymize.
+func (ud *UserData) HardDelete(userID string) (string, error) {
+	var avatarKey string
+
+	err := ud.gorm.Transaction(func(tx *gorm.DB) error {
+		key, err := linkedAvatar(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := deleteCredentials(tx, userID); err != nil {
+			return err
+		}
+
+		var qry = tx.Unscoped().Where("id = ?", userID).Delete(&User{})
+
+		if err := qry.Error; err != nil {
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("data not found")
+		}
+
+		avatarKey = key
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return avatarKey, nil
+}
+
+func (ud *UserData) InsertSession(newData users.Session) error {
+	var dbData = new(Session)
+	dbData.ID = newData.ID
+	dbData.UserID = newData.UserID
+	dbData.IP = newData.IP
+	dbData.UserAgent = newData.UserAgent
+	dbData.Fingerprint = newData.Fingerprint
+	dbData.Country = newData.Country
+	dbData.City = newData.City
+	dbData.Latitude = newData.Latitude
+	dbData.Longitude = newData.Longitude
+	dbData.RefreshTokenHash = newData.RefreshTokenHash
+	dbData.ActorID = newData.ActorID
+	dbData.CreatedAt = newData.CreatedAt
+	dbData.LastSeenAt = newData.LastSeenAt
+	dbData.ExpiresAt = newData.ExpiresAt
+
+	return ud.gorm.Create(dbData).Error
+}
+
+func (ud *UserData) GetSession(sessionID string) (*users.Session, error) {
+	var dbData = new(Session)
+
+	if err := ud.gorm.Where("id = ?", sessionID).First(dbData).Error; err != nil {
+		logrus.Info("db error:", err.Error())
+		return nil, err
+	}
+
+	return toSession(dbData), nil
+}
+
+func (ud *UserData) GetSessions(userID string, limit int) ([]users.Session, error) {
+	var dbData = []Session{}
+
+	if err := ud.gorm.Where("user_id = ?", userID).Order("created_at desc").Limit(limit).Find(&dbData).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make([]users.Session, 0, len(dbData))
+	for i := range dbData {
+		result = append(result, *toSession(&dbData[i]))
+	}
+
+	return result, nil
+}
+
+func (ud *UserData) UpdateSession(newData users.Session) error {
+	var qry = ud.gorm.Model(&Session{}).Where("id = ? AND revoked_at IS NULL", newData.ID).Updates(map[string]any{
+		"refresh_token_hash": newData.RefreshTokenHash,
+		"last_seen_at":       newData.LastSeenAt,
+		"expires_at":         newData.ExpiresAt,
+	})
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+func (ud *UserData) RevokeSession(userID string, sessionID string, revokedAt time.Time) error {
+	var qry = ud.gorm.Model(&Session{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).Update("revoked_at", revokedAt)
+
+	if err := qry.Error; err != nil {
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("data not found")
+	}
+
+	return nil
+}
+
+// deleteCredentials removes everything in the users tables that could still
+// log in as the user. The other features delete theirs through their
+// users.ErasureHookInterface.
+func deleteCredentials(tx *gorm.DB, userID string) error {
+	for _, model := range []any{&RecoveryCode{}, &OneTimeCode{}, &PasswordHistory{}, &Session{}} {
+		if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var userColumns = map[string]string{
+	users.FieldID:   "id",
+	users.FieldNama: "nama",
+	users.FieldHP:   "hp",
+}
+
+func userCondition(filter users.UserFilter) (string, []any, error) {
+	if filter.Field == users.FieldActive {
+		var active = filter.Value == "true"
+		if filter.Operator == users.OperatorNotEqual {
+			active = !active
+		} else if filter.Operator != users.OperatorEqual {
+			return "", nil, errors.New("invalid filter")
+		}
+
+		if active {
+			return "deactivated_at IS NULL", nil, nil
+		}
+		return "deactivated_at IS NOT NULL", nil, nil
+	}
+
+	column, found := userColumns[filter.Field]
+	if !found {
+		return "", nil, errors.New("invalid filter")
+	}
+
+	var pattern = likeEscaper.Replace(filter.Value)
+	switch filter.Operator {
+	case users.OperatorEqual:
+		return column + " = ?", []any{filter.Value}, nil
+	case users.OperatorNotEqual:
+		return column + " <> ?", []any{filter.Value}, nil
+	case users.OperatorContains:
+		return column + " LIKE ?", []any{"%" + pattern + "%"}, nil
+	case users.OperatorStartsWith:
+		return column + " LIKE ?", []any{pattern + "%"}, nil
+	case users.OperatorEndsWith:
+		return column + " LIKE ?", []any{"%" + pattern}, nil
+	case users.OperatorPresent:
+		return column + " IS NOT NULL AND " + column + " <> ''", nil, nil
+	}
+
+	return "", nil, errors.New("invalid filter")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// nullableHP stores accounts without an HP as NULL, which the unique index
+// does not compare.
+func nullableHP(hp string) *string {
+	if hp == "" {
+		return nil
+	}
+
+	return &hp
+}
+
+func toUser(dbData *User) *users.User {
 	var result = new(users.User)
 	result.ID = dbData.ID
 	result.Nama = dbData.Nama
-	result.HP = dbData.HP
+	if dbData.HP != nil {
+		result.HP = *dbData.HP
+	}
+	result.Password = dbData.Password
+	result.TOTPSecret = dbData.TOTPSecret
+	result.TOTPEnabled = dbData.TOTPEnabled
+	result.TOTPLastStep = dbData.TOTPLastStep
+	result.Role = dbData.Role
+	result.HPVerifiedAt = dbData.HPVerifiedAt
+	result.TokensRevokedAt = dbData.TokensRevokedAt
+	result.DeactivatedAt = dbData.DeactivatedAt
+	if dbData.DeletedAt.Valid {
+		result.DeletedAt = &dbData.DeletedAt.Time
+	}
+	result.AnonymizedAt = dbData.AnonymizedAt
+	result.Directory = dbData.Directory
+	result.DirectoryID = dbData.DirectoryID
+	result.AvatarKey = dbData.AvatarKey
 
-	return result, nil
+	return result
+}
+
+func toSession(dbData *Session) *users.Session {
+	var result = new(users.Session)
+	result.ID = dbData.ID
+	result.UserID = dbData.UserID
+	result.IP = dbData.IP
+	result.UserAgent = dbData.UserAgent
+	result.Fingerprint = dbData.Fingerprint
+	result.Country = dbData.Country
+	result.City = dbData.City
+	result.Latitude = dbData.Latitude
+	result.Longitude = dbData.Longitude
+	result.RefreshTokenHash = dbData.RefreshTokenHash
+	result.ActorID = dbData.ActorID
+	result.CreatedAt = dbData.CreatedAt
+	result.LastSeenAt = dbData.LastSeenAt
+	result.ExpiresAt = dbData.ExpiresAt
+	result.RevokedAt = dbData.RevokedAt
+
+	return result
+}
+
+// linkedAvatar reads the avatar key of the account, deleted accounts
+// included. A missing account is left to the caller's own check.
+func linkedAvatar(tx *gorm.DB, userID string) (string, error) {
+	var keys = []string{}
+
+	if err := tx.Unscoped().Model(&User{}).Where("id = ?", userID).Pluck("avatar_key", &keys).Error; err != nil {
+		return "", err
+	}
+
+	if len(keys) == 0 {
+		return "", nil
+	}
+
+	return keys[0], nil
 }
@@ -1,28 +1,245 @@
 package users
 
-import "github.com/labstack/echo/v4"
+import (
+	"context"
+	"test/helper"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
 
 type User struct {
-	ID       string
-	Nama     string
-	HP       string
-	Password string
+	ID              string
+	Nama            string
+	HP              string
+	Password        string
+	TOTPSecret      string
+	TOTPEnabled     bool
+	TOTPLastStep    int64
+	Role            string
+	HPVerifiedAt    *time.Time
+	TokensRevokedAt *time.Time
+	DeactivatedAt   *time.Time
+	DeletedAt       *time.Time
+	AnonymizedAt    *time.Time
+	// Directory names the directory that owns the account, its password is
+	// checked there. DirectoryID is the entry the account belongs to.
+	Directory   string
+	DirectoryID string
+	// AvatarKey is where the avatar sizes are stored, see helper.AvatarKeys.
+	// Empty while the user has no avatar.
+	AvatarKey string
 }
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type UserCredential struct {
-	Nama   string
-	Access map[string]any
+	Nama           string
+	Access         map[string]any
+	MFARequired    bool
+	MFAToken       string
+	StepUpRequired bool
+	StepUpToken    string
+}
+
+type OneTimeCode struct {
+	ID        uint
+	UserID    string
+	Purpose   string
+	CodeHash  string
+	Attempts  int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type Session struct {
+	ID               string
+	UserID           string
+	IP               string
+	UserAgent        string
+	Fingerprint      string
+	Country          string
+	City             string
+	Latitude         *float64
+	Longitude        *float64
+	RefreshTokenHash string
+	// ActorID is the admin impersonating the user in this session, if any.
+	ActorID    string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
+
+// RecentAuthTTL is how long after signing in an account without a password,
+// such as one linked to a provider or the directory, may still confirm that
+// it wants to be deleted.
+const RecentAuthTTL = time.Minute * 5
+
+// RecentlyAuthenticated tells whether the user themselves opened the session
+// within RecentAuthTTL. Refreshing keeps the creation time, only a new sign-in
+// through the provider, the directory or a passkey counts.
+func (s Session) RecentlyAuthenticated(principal helper.Principal, now time.Time) bool {
+	return s.ID == principal.SessionID && s.UserID == principal.UserID && s.ActorID == "" && principal.ActorID == "" &&
+		s.RevokedAt == nil && now.Sub(s.CreatedAt) <= RecentAuthTTL
+}
+
+// UserFilter is one condition of a UserQuery. Field is one of the Field
+// constants, Operator one of the Operator constants.
+type UserFilter struct {
+	Field    string
+	Operator string
+	Value    string
+}
+
+// UserQuery selects users for provisioning, a user has to match every filter.
+type UserQuery struct {
+	Filters []UserFilter
+	Offset  int
+	Limit   int
+}
+
+const (
+	FieldID     = "id"
+	FieldNama   = "nama"
+	FieldHP     = "hp"
+	FieldActive = "active"
+)
+
+const (
+	OperatorEqual      = "eq"
+	OperatorNotEqual   = "ne"
+	OperatorContains   = "co"
+	OperatorStartsWith = "sw"
+	OperatorEndsWith   = "ew"
+	OperatorPresent    = "pr"
+)
+
+// AuthenticatorInterface checks the password of a login against one
+// credential store. Authenticate returns "data not found" when the store does
+// not know the login, the next authenticator is asked then, and "invalid
+// password" along with the user when it does. Directories return a user
+// without ID, with Directory and DirectoryID set.
+type AuthenticatorInterface interface {
+	Authenticate(ctx context.Context, login string, password string) (*User, error)
+}
+
+// RevocationHookInterface is implemented by the features that hand out their
+// own tokens for a user, like OAuth grants. RevokeUser runs every time the
+// tokens of the user are revoked.
+type RevocationHookInterface interface {
+	RevokeUser(userID string, revokedAt time.Time) error
+}
+
+// ErasureHookInterface is implemented by the features that keep credentials
+// of a user in their own tables. EraseUser runs before the account is
+// anonymized or deleted, so the users tables never have to know theirs.
+type ErasureHookInterface interface {
+	EraseUser(userID string) error
+}
+
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+	QRCode []byte
 }
 
 type UserHandlerInterface interface {
 	Register() echo.HandlerFunc
 	Login() echo.HandlerFunc
+	RequestLoginCode() echo.HandlerFunc
+	LoginWithCode() echo.HandlerFunc
+	VerifyMFA() echo.HandlerFunc
+	VerifyLogin() echo.HandlerFunc
+	EnrollTOTP() echo.HandlerFunc
+	ConfirmTOTP() echo.HandlerFunc
+	DisableTOTP() echo.HandlerFunc
+	RegenerateRecoveryCodes() echo.HandlerFunc
+	ForgotPassword() echo.HandlerFunc
+	ResetPassword() echo.HandlerFunc
+	VerifyPhone() echo.HandlerFunc
+	ResendVerification() echo.HandlerFunc
+	ChangePassword() echo.HandlerFunc
+	DeleteAccount() echo.HandlerFunc
+	MyProfile() echo.HandlerFunc
+	Deactivate() echo.HandlerFunc
+	Reactivate() echo.HandlerFunc
+	RefreshSession() echo.HandlerFunc
+	ListSessions() echo.HandlerFunc
+	RevokeSession() echo.HandlerFunc
+	Logout() echo.HandlerFunc
+	Impersonate() echo.HandlerFunc
+	StopImpersonation() echo.HandlerFunc
 }
 type UserServiceInterface interface {
-	Register(newData User) (*User, error)
-	Login(hp string, password string) (*UserCredential, error)
+	Register(ctx context.Context, newData User) (*User, error)
+	Login(ctx context.Context, hp string, password string) (*UserCredential, error)
+	LoginExternal(ctx context.Context, userID string, provider string) (*UserCredential, error)
+	LoginPasskey(ctx context.Context, userID string, userVerified bool) (*UserCredential, error)
+	RequestLoginCode(ctx context.Context, hp string) error
+	LoginWithCode(ctx context.Context, hp string, code string) (*UserCredential, error)
+	VerifyMFA(ctx context.Context, mfaToken string, code string) (*UserCredential, error)
+	VerifyLogin(ctx context.Context, stepUpToken string, code string) (*UserCredential, error)
+	EnrollTOTP(ctx context.Context, userID string) (*TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID string, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID string, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID string, code string) ([]string, error)
+	ForgotPassword(ctx context.Context, hp string) error
+	ResetPassword(ctx context.Context, hp string, code string, newPassword string) error
+	VerifyPhone(ctx context.Context, hp string, code string) error
+	ResendVerification(ctx context.Context, hp string) error
+	ChangePassword(ctx context.Context, userID string, currentPassword string, newPassword string) (*UserCredential, error)
+	ValidateSession(principal helper.Principal) error
+	DeleteAccount(ctx context.Context, principal helper.Principal, password string) error
+	Deactivate(ctx context.Context, actor helper.Principal, userID string) error
+	Reactivate(ctx context.Context, actor helper.Principal, userID string) error
+	PurgeDeletedAccounts() (int, error)
+	RefreshSession(ctx context.Context, refreshToken string) (*UserCredential, error)
+	ListSessions(ctx context.Context, userID string) ([]Session, error)
+	RevokeSession(ctx context.Context, userID string, sessionID string) error
+	GetUser(ctx context.Context, userID string) (*User, error)
+	ListUsers(ctx context.Context, query UserQuery) ([]User, int, error)
+	Provision(ctx context.Context, actorID string, newData User) (*User, error)
+	UpdateProfile(ctx context.Context, actorID string, newData User) (*User, error)
+	SetPassword(ctx context.Context, actorID string, userID string, password string) error
+	RemoveAccount(ctx context.Context, actorID string, userID string) error
+	Impersonate(ctx context.Context, actorID string, userID string) (*UserCredential, error)
+	StopImpersonation(ctx context.Context, principal helper.Principal) error
 }
 type UserDataInterface interface {
+	WithContext(ctx context.Context) UserDataInterface
 	Insert(newData User) (*User, error)
-	Login(hp string, password string) (*User, error)
+	GetByHP(hp string) (*User, error)
+	GetByDirectory(directory string, directoryID string) (*User, error)
+	GetByID(id string) (*User, error)
+	List(query UserQuery) ([]User, int, error)
+	UpdateProfile(newData User) error
+	UpdatePassword(userID string, password string) error
+	UpdateRole(userID string, role string) error
+	UpdateAvatar(userID string, avatarKey string) error
+	GetPasswordHistory(userID string, limit int) ([]string, error)
+	RevokeTokens(userID string, revokedAt time.Time) error
+	SetHPVerified(userID string, verifiedAt time.Time) error
+	UpdateTOTP(newData User) error
+	ReplaceRecoveryCodes(userID string, codeHashes []string) error
+	UseRecoveryCode(userID string, codeHash string) error
+	InsertOneTimeCode(newData OneTimeCode) error
+	GetOneTimeCode(userID string, purpose string) (*OneTimeCode, error)
+	CountOneTimeCodes(userID string, purpose string, since time.Time) (int, error)
+	UpdateOneTimeCode(newData OneTimeCode) error
+	Deactivate(userID string, deactivatedAt time.Time) error
+	Reactivate(userID string) error
+	SoftDelete(userID string) error
+	GetDeletedBefore(before time.Time) ([]string, error)
+	Anonymize(userID string, anonymizedAt time.Time) (string, error)
+	HardDelete(userID string) (string, error)
+	InsertSession(newData Session) error
+	GetSession(sessionID string) (*Session, error)
+	GetSessions(userID string, limit int) ([]Session, error)
+	UpdateSession(newData Session) error
+	RevokeSession(userID string, sessionID string, revokedAt time.Time) error
 }
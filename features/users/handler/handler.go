@@ -1,21 +1,27 @@
 package handler
 
 import (
+	"encoding/base64"
+	"errors"
 	"net/http"
 	"strings"
 	"test/features/users"
 	"test/helper"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
 type UserHandler struct {
-	s users.UserServiceInterface
+	s  users.UserServiceInterface
+	st helper.StorageInterface
 }
 
-func NewHandler(service users.UserServiceInterface) users.UserHandlerInterface {
+// NewHandler takes the storage avatars are kept in to link them in profiles.
+func NewHandler(service users.UserServiceInterface, storage helper.StorageInterface) users.UserHandlerInterface {
 	return &UserHandler{
-		s: service,
+		s:  service,
+		st: storage,
 	}
 }
 
@@ -33,16 +39,24 @@ func (uh *UserHandler) Register() echo.HandlerFunc {
 		serviceInput.HP = input.HP
 		serviceInput.Password = input.Password
 
-		result, err := uh.s.Register(*serviceInput)
+		result, err := uh.s.Register(c.Request().Context(), *serviceInput)
 
 		if err != nil {
 			c.Logger().Error("handler: input process error:", err.Error())
+			if strings.Contains(err.Error(), "registration closed") {
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("registration closed", nil))
+			}
+			var policyErr *helper.PasswordPolicyError
+			if errors.As(err, &policyErr) {
+				return c.JSON(http.StatusBadRequest, helper.FormatResponse("invalid password", policyErr.Violations))
+			}
 			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
 		}
 
 		var response = new(RegisterResponse)
 		response.Nama = result.Nama
 		response.HP = result.HP
+		response.Verified = result.HPVerifiedAt != nil
 
 		return c.JSON(http.StatusCreated, helper.FormatResponse("success", response))
 	}
@@ -57,20 +71,620 @@ func (uh *UserHandler) Login() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
 		}
 
-		result, err := uh.s.Login(input.HP, input.Password)
+		result, err := uh.s.Login(c.Request().Context(), input.HP, input.Password)
 
 		if err != nil {
 			c.Logger().Error("handler: login process error:", err.Error())
 			if strings.Contains(err.Error(), "not found") {
 				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
 			}
+			if strings.Contains(err.Error(), "not verified") {
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("account not verified", nil))
+			}
+			if strings.Contains(err.Error(), "deactivated") {
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("account deactivated", nil))
+			}
+			if strings.Contains(err.Error(), "account conflict") {
+				return c.JSON(http.StatusConflict, helper.FormatResponse("account conflict", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", loginResponse(c, result)))
+	}
+}
+
+func (uh *UserHandler) RequestLoginCode() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(LoginCodeInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if err := uh.s.RequestLoginCode(c.Request().Context(), input.HP); err != nil {
+			c.Logger().Error("handler: request login code process error:", err.Error())
+			return c.JSON(codeErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (uh *UserHandler) LoginWithCode() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(LoginWithCodeInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := uh.s.LoginWithCode(c.Request().Context(), input.HP, input.Code)
+
+		if err != nil {
+			c.Logger().Error("handler: login with code process error:", err.Error())
+			if strings.Contains(err.Error(), "invalid") {
+				return c.JSON(http.StatusUnauthorized, helper.FormatResponse("fail", nil))
+			}
+			if strings.Contains(err.Error(), "deactivated") {
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("account deactivated", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", loginResponse(c, result)))
+	}
+}
+
+func (uh *UserHandler) VerifyMFA() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(VerifyMFAInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := uh.s.VerifyMFA(c.Request().Context(), input.MFAToken, input.Code)
+
+		if err != nil {
+			c.Logger().Error("handler: verify mfa process error:", err.Error())
+			return c.JSON(mfaErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", loginResponse(c, result)))
+	}
+}
+
+func (uh *UserHandler) VerifyLogin() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(VerifyLoginInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := uh.s.VerifyLogin(c.Request().Context(), input.StepUpToken, input.Code)
+
+		if err != nil {
+			c.Logger().Error("handler: verify login process error:", err.Error())
+			if strings.Contains(err.Error(), "invalid step up token") {
+				return c.JSON(http.StatusUnauthorized, helper.FormatResponse("fail", nil))
+			}
+			if strings.Contains(err.Error(), "deactivated") {
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("account deactivated", nil))
+			}
+			return c.JSON(codeErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", loginResponse(c, result)))
+	}
+}
+
+func (uh *UserHandler) EnrollTOTP() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := uh.s.EnrollTOTP(c.Request().Context(), principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: enroll totp process error:", err.Error())
+			return c.JSON(mfaErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		if c.Request().Header.Get(echo.HeaderAccept) == "image/png" {
+			return c.Blob(http.StatusCreated, "image/png", result.QRCode)
+		}
+
+		var response = new(TOTPEnrollmentResponse)
+		response.Secret = result.Secret
+		response.URI = result.URI
+		response.QRCode = base64.StdEncoding.EncodeToString(result.QRCode)
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", response))
+	}
+}
+
+func (uh *UserHandler) ConfirmTOTP() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(MFACodeInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := uh.s.ConfirmTOTP(c.Request().Context(), principal.UserID, input.Code)
+
+		if err != nil {
+			c.Logger().Error("handler: confirm totp process error:", err.Error())
+			return c.JSON(mfaErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		var response = new(RecoveryCodesResponse)
+		response.RecoveryCodes = result
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (uh *UserHandler) DisableTOTP() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(MFACodeInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if err := uh.s.DisableTOTP(c.Request().Context(), principal.UserID, input.Code); err != nil {
+			c.Logger().Error("handler: disable totp process error:", err.Error())
+			return c.JSON(mfaErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (uh *UserHandler) RegenerateRecoveryCodes() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(MFACodeInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := uh.s.RegenerateRecoveryCodes(c.Request().Context(), principal.UserID, input.Code)
+
+		if err != nil {
+			c.Logger().Error("handler: regenerate recovery codes process error:", err.Error())
+			return c.JSON(mfaErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		var response = new(RecoveryCodesResponse)
+		response.RecoveryCodes = result
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+func (uh *UserHandler) ForgotPassword() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(ForgotPasswordInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if err := uh.s.ForgotPassword(c.Request().Context(), input.HP); err != nil {
+			c.Logger().Error("handler: forgot password process error:", err.Error())
+			return c.JSON(codeErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (uh *UserHandler) ResetPassword() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(ResetPasswordInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if err := uh.s.ResetPassword(c.Request().Context(), input.HP, input.Code, input.Password); err != nil {
+			c.Logger().Error("handler: reset password process error:", err.Error())
+			var policyErr *helper.PasswordPolicyError
+			if errors.As(err, &policyErr) {
+				return c.JSON(http.StatusBadRequest, helper.FormatResponse("invalid password", policyErr.Violations))
+			}
+			return c.JSON(codeErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (uh *UserHandler) VerifyPhone() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(VerifyPhoneInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if err := uh.s.VerifyPhone(c.Request().Context(), input.HP, input.Code); err != nil {
+			c.Logger().Error("handler: verify phone process error:", err.Error())
+			return c.JSON(codeErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (uh *UserHandler) ResendVerification() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(ResendVerificationInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if err := uh.s.ResendVerification(c.Request().Context(), input.HP); err != nil {
+			c.Logger().Error("handler: resend verification process error:", err.Error())
+			return c.JSON(codeErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (uh *UserHandler) ChangePassword() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(ChangePasswordInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		result, err := uh.s.ChangePassword(c.Request().Context(), principal.UserID, input.CurrentPassword, input.NewPassword)
+
+		if err != nil {
+			c.Logger().Error("handler: change password process error:", err.Error())
+			if strings.Contains(err.Error(), "not found") {
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			}
+			if strings.Contains(err.Error(), "invalid current password") {
+				return c.JSON(http.StatusUnauthorized, helper.FormatResponse("fail", nil))
+			}
+			if strings.Contains(err.Error(), "managed by directory") {
+				return c.JSON(http.StatusConflict, helper.FormatResponse("password managed by directory", nil))
+			}
+			var policyErr *helper.PasswordPolicyError
+			if errors.As(err, &policyErr) {
+				return c.JSON(http.StatusBadRequest, helper.FormatResponse("invalid password", policyErr.Violations))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", loginResponse(c, result)))
+	}
+}
+
+func (uh *UserHandler) DeleteAccount() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+		var input = new(DeleteAccountInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		if err := uh.s.DeleteAccount(c.Request().Context(), *principal, input.Password); err != nil {
+			c.Logger().Error("handler: delete account process error:", err.Error())
+			if strings.Contains(err.Error(), "not found") {
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			}
+			if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "reauthentication") {
+				return c.JSON(http.StatusUnauthorized, helper.FormatResponse("fail", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (uh *UserHandler) Deactivate() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := uh.s.Deactivate(c.Request().Context(), *principal, c.Param("id")); err != nil {
+			c.Logger().Error("handler: deactivate process error:", err.Error())
+			return c.JSON(adminErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (uh *UserHandler) Reactivate() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := uh.s.Reactivate(c.Request().Context(), *principal, c.Param("id")); err != nil {
+			c.Logger().Error("handler: reactivate process error:", err.Error())
+			return c.JSON(adminErrorStatus(err), helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+// Impersonate always returns the token in the body. Setting it as cookies
+// would replace the admin's own session in their browser.
+func (uh *UserHandler) Impersonate() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := uh.s.Impersonate(c.Request().Context(), principal.UserID, c.Param("id"))
+
+		if err != nil {
+			c.Logger().Error("handler: impersonate process error:", err.Error())
+			switch {
+			case strings.Contains(err.Error(), "not found"):
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			case strings.Contains(err.Error(), "cannot"), strings.Contains(err.Error(), "deactivated"):
+				return c.JSON(http.StatusBadRequest, helper.FormatResponse(err.Error(), nil))
+			}
 			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
 		}
 
-		var response = new(LoginResponse)
+		var response = new(ImpersonationResponse)
+		response.UserID = c.Param("id")
 		response.Nama = result.Nama
 		response.Token = result.Access
+		response.ImpersonatedBy = principal.UserID
+
+		return c.JSON(http.StatusCreated, helper.FormatResponse("success", response))
+	}
+}
+
+func (uh *UserHandler) StopImpersonation() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := uh.s.StopImpersonation(c.Request().Context(), *principal); err != nil {
+			c.Logger().Error("handler: stop impersonation process error:", err.Error())
+			switch {
+			case strings.Contains(err.Error(), "not impersonating"):
+				return c.JSON(http.StatusBadRequest, helper.FormatResponse("not impersonating", nil))
+			case strings.Contains(err.Error(), "not found"):
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (uh *UserHandler) RefreshSession() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input = new(RefreshSessionInput)
+
+		if err := c.Bind(input); err != nil {
+			c.Logger().Error("handler: bind input error:", err.Error())
+			return c.JSON(http.StatusBadRequest, helper.FormatResponse("fail", nil))
+		}
+
+		var refreshToken = input.RefreshToken
+		if refreshToken == "" {
+			refreshToken = helper.SessionCookie(c, helper.RefreshTokenCookie)
+			if refreshToken != "" && !helper.ValidCSRF(c) {
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("invalid csrf token", nil))
+			}
+		}
+
+		result, err := uh.s.RefreshSession(c.Request().Context(), refreshToken)
+
+		if err != nil {
+			c.Logger().Error("handler: refresh session process error:", err.Error())
+			if strings.Contains(err.Error(), "invalid") {
+				return c.JSON(http.StatusUnauthorized, helper.FormatResponse("fail", nil))
+			}
+			if strings.Contains(err.Error(), "deactivated") {
+				return c.JSON(http.StatusForbidden, helper.FormatResponse("account deactivated", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", loginResponse(c, result)))
+	}
+}
+
+func (uh *UserHandler) MyProfile() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := uh.s.GetUser(c.Request().Context(), principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: get profile process error:", err.Error())
+			if strings.Contains(err.Error(), "not found") {
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var response = new(ProfileResponse)
+		response.ID = result.ID
+		response.Nama = result.Nama
+		response.HP = result.HP
+		response.Role = result.Role
+		response.Verified = result.HPVerifiedAt != nil
+		response.TOTPEnabled = result.TOTPEnabled
+		response.Avatar = helper.AvatarURLs(uh.st, result.AvatarKey)
 
 		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
 	}
 }
+
+func (uh *UserHandler) ListSessions() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		result, err := uh.s.ListSessions(c.Request().Context(), principal.UserID)
+
+		if err != nil {
+			c.Logger().Error("handler: list sessions process error:", err.Error())
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		var now = time.Now()
+		var response = make([]SessionResponse, 0, len(result))
+		for _, session := range result {
+			response = append(response, SessionResponse{
+				ID:             session.ID,
+				IP:             session.IP,
+				UserAgent:      session.UserAgent,
+				Location:       sessionLocation(session),
+				CreatedAt:      session.CreatedAt,
+				LastSeenAt:     session.LastSeenAt,
+				ExpiresAt:      session.ExpiresAt,
+				RevokedAt:      session.RevokedAt,
+				Active:         session.RevokedAt == nil && now.Before(session.ExpiresAt),
+				Current:        session.ID == principal.SessionID,
+				ImpersonatedBy: session.ActorID,
+			})
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", response))
+	}
+}
+
+// Logout ends the session of the access token and clears the session
+// cookies of a browser client.
+func (uh *UserHandler) Logout() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		var err error
+		if principal.Impersonated() {
+			err = uh.s.StopImpersonation(c.Request().Context(), *principal)
+		} else {
+			err = uh.s.RevokeSession(c.Request().Context(), principal.UserID, principal.SessionID)
+		}
+
+		if err != nil {
+			c.Logger().Error("handler: logout process error:", err.Error())
+			if !strings.Contains(err.Error(), "not found") {
+				return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+			}
+		}
+
+		helper.ClearSessionCookies(c)
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+func (uh *UserHandler) RevokeSession() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var principal = helper.GetPrincipal(c)
+
+		if err := uh.s.RevokeSession(c.Request().Context(), principal.UserID, c.Param("id")); err != nil {
+			c.Logger().Error("handler: revoke session process error:", err.Error())
+			if strings.Contains(err.Error(), "not found") {
+				return c.JSON(http.StatusNotFound, helper.FormatResponse("fail", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.FormatResponse("fail", nil))
+		}
+
+		return c.JSON(http.StatusOK, helper.FormatResponse("success", nil))
+	}
+}
+
+// loginResponse sets the tokens as cookies instead when the client asked for
+// cookie mode.
+func loginResponse(c echo.Context, result *users.UserCredential) *LoginResponse {
+	var response = new(LoginResponse)
+	response.Nama = result.Nama
+	if tokens, csrfToken := helper.DeliverTokens(c, result.Access); tokens != nil {
+		response.Token = tokens
+	} else {
+		response.CSRFToken = csrfToken
+	}
+	response.MFARequired = result.MFARequired
+	response.MFAToken = result.MFAToken
+	response.StepUpRequired = result.StepUpRequired
+	response.StepUpToken = result.StepUpToken
+
+	return response
+}
+
+func sessionLocation(session users.Session) string {
+	if session.City == "" {
+		return session.Country
+	}
+
+	return session.City + ", " + session.Country
+}
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusUnauthorized
+	case strings.Contains(err.Error(), "already enabled"),
+		strings.Contains(err.Error(), "not enabled"),
+		strings.Contains(err.Error(), "not enrolled"):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func codeErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	case strings.Contains(err.Error(), "already verified"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "too many"):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func adminErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "forbidden"):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "cannot"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -10,3 +10,58 @@ type LoginInput struct {
 	HP       string `json:"hp"`
 	Password string `json:"password"`
 }
+
+type LoginCodeInput struct {
+	HP string `json:"hp"`
+}
+
+type LoginWithCodeInput struct {
+	HP   string `json:"hp"`
+	Code string `json:"code"`
+}
+
+type VerifyMFAInput struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type VerifyLoginInput struct {
+	StepUpToken string `json:"step_up_token"`
+	Code        string `json:"code"`
+}
+
+type MFACodeInput struct {
+	Code string `json:"code"`
+}
+
+type ForgotPasswordInput struct {
+	HP string `json:"hp"`
+}
+
+type ResetPasswordInput struct {
+	HP       string `json:"hp"`
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
+
+type VerifyPhoneInput struct {
+	HP   string `json:"hp"`
+	Code string `json:"code"`
+}
+
+type ResendVerificationInput struct {
+	HP string `json:"hp"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type DeleteAccountInput struct {
+	Password string `json:"password"`
+}
+
+type RefreshSessionInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
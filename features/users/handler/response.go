@@ -1,11 +1,62 @@
 package handler
 
+import "time"
+
 type RegisterResponse struct {
-	Nama string `json:"nama"`
-	HP   string `json:"hp"`
+	Nama     string `json:"nama"`
+	HP       string `json:"hp"`
+	Verified bool   `json:"verified"`
 }
 
 type LoginResponse struct {
-	Nama  string `json:"nama"`
-	Token any    `json:"token"`
+	Nama           string `json:"nama"`
+	Token          any    `json:"token,omitempty"`
+	CSRFToken      string `json:"csrf_token,omitempty"`
+	MFARequired    bool   `json:"mfa_required,omitempty"`
+	MFAToken       string `json:"mfa_token,omitempty"`
+	StepUpRequired bool   `json:"step_up_required,omitempty"`
+	StepUpToken    string `json:"step_up_token,omitempty"`
+}
+
+type ProfileResponse struct {
+	ID          string `json:"id"`
+	Nama        string `json:"nama"`
+	HP          string `json:"hp"`
+	Role        string `json:"role"`
+	Verified    bool   `json:"verified"`
+	TOTPEnabled bool   `json:"totp_enabled"`
+	// Avatar links the sizes of the avatar by name, see helper.AvatarSizes.
+	Avatar map[string]string `json:"avatar,omitempty"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QRCode string `json:"qr_code"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type SessionResponse struct {
+	ID         string     `json:"id"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	Location   string     `json:"location,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Active     bool       `json:"active"`
+	Current    bool       `json:"current"`
+	// ImpersonatedBy names the admin who opened the session as the user.
+	ImpersonatedBy string `json:"impersonated_by,omitempty"`
+}
+
+type ImpersonationResponse struct {
+	UserID         string `json:"user_id"`
+	Nama           string `json:"nama"`
+	Token          any    `json:"token"`
+	ImpersonatedBy string `json:"impersonated_by"`
 }
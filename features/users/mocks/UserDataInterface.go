@@ -3,7 +3,9 @@
 package mocks
 
 import (
+	context "context"
 	users "test/features/users"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -13,6 +15,300 @@ type UserDataInterface struct {
 	mock.Mock
 }
 
+// Anonymize provides a mock function with given fields: userID, anonymizedAt
+func (_m *UserDataInterface) Anonymize(userID string, anonymizedAt time.Time) (string, error) {
+	ret := _m.Called(userID, anonymizedAt)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) (string, error)); ok {
+		return rf(userID, anonymizedAt)
+	}
+	if rf, ok := ret.Get(0).(func(string, time.Time) string); ok {
+		r0 = rf(userID, anonymizedAt)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(userID, anonymizedAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountOneTimeCodes provides a mock function with given fields: userID, purpose, since
+func (_m *UserDataInterface) CountOneTimeCodes(userID string, purpose string, since time.Time) (int, error) {
+	ret := _m.Called(userID, purpose, since)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) (int, error)); ok {
+		return rf(userID, purpose, since)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) int); ok {
+		r0 = rf(userID, purpose, since)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, time.Time) error); ok {
+		r1 = rf(userID, purpose, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Deactivate provides a mock function with given fields: userID, deactivatedAt
+func (_m *UserDataInterface) Deactivate(userID string, deactivatedAt time.Time) error {
+	ret := _m.Called(userID, deactivatedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) error); ok {
+		r0 = rf(userID, deactivatedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByDirectory provides a mock function with given fields: directory, directoryID
+func (_m *UserDataInterface) GetByDirectory(directory string, directoryID string) (*users.User, error) {
+	ret := _m.Called(directory, directoryID)
+
+	var r0 *users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*users.User, error)); ok {
+		return rf(directory, directoryID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *users.User); ok {
+		r0 = rf(directory, directoryID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(directory, directoryID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByHP provides a mock function with given fields: hp
+func (_m *UserDataInterface) GetByHP(hp string) (*users.User, error) {
+	ret := _m.Called(hp)
+
+	var r0 *users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*users.User, error)); ok {
+		return rf(hp)
+	}
+	if rf, ok := ret.Get(0).(func(string) *users.User); ok {
+		r0 = rf(hp)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(hp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByID provides a mock function with given fields: id
+func (_m *UserDataInterface) GetByID(id string) (*users.User, error) {
+	ret := _m.Called(id)
+
+	var r0 *users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*users.User, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *users.User); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeletedBefore provides a mock function with given fields: before
+func (_m *UserDataInterface) GetDeletedBefore(before time.Time) ([]string, error) {
+	ret := _m.Called(before)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) ([]string, error)); ok {
+		return rf(before)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) []string); ok {
+		r0 = rf(before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOneTimeCode provides a mock function with given fields: userID, purpose
+func (_m *UserDataInterface) GetOneTimeCode(userID string, purpose string) (*users.OneTimeCode, error) {
+	ret := _m.Called(userID, purpose)
+
+	var r0 *users.OneTimeCode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*users.OneTimeCode, error)); ok {
+		return rf(userID, purpose)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *users.OneTimeCode); ok {
+		r0 = rf(userID, purpose)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.OneTimeCode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userID, purpose)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPasswordHistory provides a mock function with given fields: userID, limit
+func (_m *UserDataInterface) GetPasswordHistory(userID string, limit int) ([]string, error) {
+	ret := _m.Called(userID, limit)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int) ([]string, error)); ok {
+		return rf(userID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(string, int) []string); ok {
+		r0 = rf(userID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(userID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSession provides a mock function with given fields: sessionID
+func (_m *UserDataInterface) GetSession(sessionID string) (*users.Session, error) {
+	ret := _m.Called(sessionID)
+
+	var r0 *users.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*users.Session, error)); ok {
+		return rf(sessionID)
+	}
+	if rf, ok := ret.Get(0).(func(string) *users.Session); ok {
+		r0 = rf(sessionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(sessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSessions provides a mock function with given fields: userID, limit
+func (_m *UserDataInterface) GetSessions(userID string, limit int) ([]users.Session, error) {
+	ret := _m.Called(userID, limit)
+
+	var r0 []users.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int) ([]users.Session, error)); ok {
+		return rf(userID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(string, int) []users.Session); ok {
+		r0 = rf(userID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(userID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HardDelete provides a mock function with given fields: userID
+func (_m *UserDataInterface) HardDelete(userID string) (string, error) {
+	ret := _m.Called(userID)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: newData
 func (_m *UserDataInterface) Insert(newData users.User) (*users.User, error) {
 	ret := _m.Called(newData)
@@ -39,30 +335,277 @@ func (_m *UserDataInterface) Insert(newData users.User) (*users.User, error) {
 	return r0, r1
 }
 
-// Login provides a mock function with given fields: hp, password
-func (_m *UserDataInterface) Login(hp string, password string) (*users.User, error) {
-	ret := _m.Called(hp, password)
+// InsertOneTimeCode provides a mock function with given fields: newData
+func (_m *UserDataInterface) InsertOneTimeCode(newData users.OneTimeCode) error {
+	ret := _m.Called(newData)
 
-	var r0 *users.User
-	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (*users.User, error)); ok {
-		return rf(hp, password)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(users.OneTimeCode) error); ok {
+		r0 = rf(newData)
+	} else {
+		r0 = ret.Error(0)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) *users.User); ok {
-		r0 = rf(hp, password)
+
+	return r0
+}
+
+// InsertSession provides a mock function with given fields: newData
+func (_m *UserDataInterface) InsertSession(newData users.Session) error {
+	ret := _m.Called(newData)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(users.Session) error); ok {
+		r0 = rf(newData)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// List provides a mock function with given fields: query
+func (_m *UserDataInterface) List(query users.UserQuery) ([]users.User, int, error) {
+	ret := _m.Called(query)
+
+	var r0 []users.User
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(users.UserQuery) ([]users.User, int, error)); ok {
+		return rf(query)
+	}
+	if rf, ok := ret.Get(0).(func(users.UserQuery) []users.User); ok {
+		r0 = rf(query)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*users.User)
+			r0 = ret.Get(0).([]users.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(hp, password)
+	if rf, ok := ret.Get(1).(func(users.UserQuery) int); ok {
+		r1 = rf(query)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(int)
 	}
 
-	return r0, r1
+	if rf, ok := ret.Get(2).(func(users.UserQuery) error); ok {
+		r2 = rf(query)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Reactivate provides a mock function with given fields: userID
+func (_m *UserDataInterface) Reactivate(userID string) error {
+	ret := _m.Called(userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceRecoveryCodes provides a mock function with given fields: userID, codeHashes
+func (_m *UserDataInterface) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	ret := _m.Called(userID, codeHashes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string) error); ok {
+		r0 = rf(userID, codeHashes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeSession provides a mock function with given fields: userID, sessionID, revokedAt
+func (_m *UserDataInterface) RevokeSession(userID string, sessionID string, revokedAt time.Time) error {
+	ret := _m.Called(userID, sessionID, revokedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) error); ok {
+		r0 = rf(userID, sessionID, revokedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeTokens provides a mock function with given fields: userID, revokedAt
+func (_m *UserDataInterface) RevokeTokens(userID string, revokedAt time.Time) error {
+	ret := _m.Called(userID, revokedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) error); ok {
+		r0 = rf(userID, revokedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetHPVerified provides a mock function with given fields: userID, verifiedAt
+func (_m *UserDataInterface) SetHPVerified(userID string, verifiedAt time.Time) error {
+	ret := _m.Called(userID, verifiedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) error); ok {
+		r0 = rf(userID, verifiedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SoftDelete provides a mock function with given fields: userID
+func (_m *UserDataInterface) SoftDelete(userID string) error {
+	ret := _m.Called(userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateAvatar provides a mock function with given fields: userID, avatarKey
+func (_m *UserDataInterface) UpdateAvatar(userID string, avatarKey string) error {
+	ret := _m.Called(userID, avatarKey)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(userID, avatarKey)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateOneTimeCode provides a mock function with given fields: newData
+func (_m *UserDataInterface) UpdateOneTimeCode(newData users.OneTimeCode) error {
+	ret := _m.Called(newData)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(users.OneTimeCode) error); ok {
+		r0 = rf(newData)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePassword provides a mock function with given fields: userID, password
+func (_m *UserDataInterface) UpdatePassword(userID string, password string) error {
+	ret := _m.Called(userID, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(userID, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateProfile provides a mock function with given fields: newData
+func (_m *UserDataInterface) UpdateProfile(newData users.User) error {
+	ret := _m.Called(newData)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(users.User) error); ok {
+		r0 = rf(newData)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRole provides a mock function with given fields: userID, role
+func (_m *UserDataInterface) UpdateRole(userID string, role string) error {
+	ret := _m.Called(userID, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(userID, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateSession provides a mock function with given fields: newData
+func (_m *UserDataInterface) UpdateSession(newData users.Session) error {
+	ret := _m.Called(newData)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(users.Session) error); ok {
+		r0 = rf(newData)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateTOTP provides a mock function with given fields: newData
+func (_m *UserDataInterface) UpdateTOTP(newData users.User) error {
+	ret := _m.Called(newData)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(users.User) error); ok {
+		r0 = rf(newData)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseRecoveryCode provides a mock function with given fields: userID, codeHash
+func (_m *UserDataInterface) UseRecoveryCode(userID string, codeHash string) error {
+	ret := _m.Called(userID, codeHash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(userID, codeHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WithContext provides a mock function with given fields: ctx
+func (_m *UserDataInterface) WithContext(ctx context.Context) users.UserDataInterface {
+	ret := _m.Called(ctx)
+
+	var r0 users.UserDataInterface
+	if rf, ok := ret.Get(0).(func(context.Context) users.UserDataInterface); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(users.UserDataInterface)
+		}
+	}
+
+	return r0
 }
 
 // NewUserDataInterface creates a new instance of UserDataInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
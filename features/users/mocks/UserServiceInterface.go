@@ -3,7 +3,9 @@
 package mocks
 
 import (
+	context "context"
 	users "test/features/users"
+	helper "test/helper"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -13,25 +15,25 @@ type UserServiceInterface struct {
 	mock.Mock
 }
 
-// Login provides a mock function with given fields: hp, password
-func (_m *UserServiceInterface) Login(hp string, password string) (*users.UserCredential, error) {
-	ret := _m.Called(hp, password)
+// ChangePassword provides a mock function with given fields: ctx, userID, currentPassword, newPassword
+func (_m *UserServiceInterface) ChangePassword(ctx context.Context, userID string, currentPassword string, newPassword string) (*users.UserCredential, error) {
+	ret := _m.Called(ctx, userID, currentPassword, newPassword)
 
 	var r0 *users.UserCredential
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (*users.UserCredential, error)); ok {
-		return rf(hp, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*users.UserCredential, error)); ok {
+		return rf(ctx, userID, currentPassword, newPassword)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) *users.UserCredential); ok {
-		r0 = rf(hp, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *users.UserCredential); ok {
+		r0 = rf(ctx, userID, currentPassword, newPassword)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*users.UserCredential)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(hp, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, userID, currentPassword, newPassword)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -39,25 +41,270 @@ func (_m *UserServiceInterface) Login(hp string, password string) (*users.UserCr
 	return r0, r1
 }
 
-// Register provides a mock function with given fields: newData
-func (_m *UserServiceInterface) Register(newData users.User) (*users.User, error) {
-	ret := _m.Called(newData)
+// ConfirmTOTP provides a mock function with given fields: ctx, userID, code
+func (_m *UserServiceInterface) ConfirmTOTP(ctx context.Context, userID string, code string) ([]string, error) {
+	ret := _m.Called(ctx, userID, code)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]string, error)); ok {
+		return rf(ctx, userID, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []string); ok {
+		r0 = rf(ctx, userID, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Deactivate provides a mock function with given fields: ctx, actor, userID
+func (_m *UserServiceInterface) Deactivate(ctx context.Context, actor helper.Principal, userID string) error {
+	ret := _m.Called(ctx, actor, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, helper.Principal, string) error); ok {
+		r0 = rf(ctx, actor, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteAccount provides a mock function with given fields: ctx, principal, password
+func (_m *UserServiceInterface) DeleteAccount(ctx context.Context, principal helper.Principal, password string) error {
+	ret := _m.Called(ctx, principal, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, helper.Principal, string) error); ok {
+		r0 = rf(ctx, principal, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DisableTOTP provides a mock function with given fields: ctx, userID, code
+func (_m *UserServiceInterface) DisableTOTP(ctx context.Context, userID string, code string) error {
+	ret := _m.Called(ctx, userID, code)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userID, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnrollTOTP provides a mock function with given fields: ctx, userID
+func (_m *UserServiceInterface) EnrollTOTP(ctx context.Context, userID string) (*users.TOTPEnrollment, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *users.TOTPEnrollment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*users.TOTPEnrollment, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *users.TOTPEnrollment); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.TOTPEnrollment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ForgotPassword provides a mock function with given fields: ctx, hp
+func (_m *UserServiceInterface) ForgotPassword(ctx context.Context, hp string) error {
+	ret := _m.Called(ctx, hp)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, hp)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetUser provides a mock function with given fields: ctx, userID
+func (_m *UserServiceInterface) GetUser(ctx context.Context, userID string) (*users.User, error) {
+	ret := _m.Called(ctx, userID)
 
 	var r0 *users.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(users.User) (*users.User, error)); ok {
-		return rf(newData)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*users.User, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(users.User) *users.User); ok {
-		r0 = rf(newData)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *users.User); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*users.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(users.User) error); ok {
-		r1 = rf(newData)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Impersonate provides a mock function with given fields: ctx, actorID, userID
+func (_m *UserServiceInterface) Impersonate(ctx context.Context, actorID string, userID string) (*users.UserCredential, error) {
+	ret := _m.Called(ctx, actorID, userID)
+
+	var r0 *users.UserCredential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*users.UserCredential, error)); ok {
+		return rf(ctx, actorID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *users.UserCredential); ok {
+		r0 = rf(ctx, actorID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.UserCredential)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, actorID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSessions provides a mock function with given fields: ctx, userID
+func (_m *UserServiceInterface) ListSessions(ctx context.Context, userID string) ([]users.Session, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []users.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]users.Session, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []users.Session); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUsers provides a mock function with given fields: ctx, query
+func (_m *UserServiceInterface) ListUsers(ctx context.Context, query users.UserQuery) ([]users.User, int, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 []users.User
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, users.UserQuery) ([]users.User, int, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, users.UserQuery) []users.User); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, users.UserQuery) int); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, users.UserQuery) error); ok {
+		r2 = rf(ctx, query)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Login provides a mock function with given fields: ctx, hp, password
+func (_m *UserServiceInterface) Login(ctx context.Context, hp string, password string) (*users.UserCredential, error) {
+	ret := _m.Called(ctx, hp, password)
+
+	var r0 *users.UserCredential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*users.UserCredential, error)); ok {
+		return rf(ctx, hp, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *users.UserCredential); ok {
+		r0 = rf(ctx, hp, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.UserCredential)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, hp, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoginExternal provides a mock function with given fields: ctx, userID, provider
+func (_m *UserServiceInterface) LoginExternal(ctx context.Context, userID string, provider string) (*users.UserCredential, error) {
+	ret := _m.Called(ctx, userID, provider)
+
+	var r0 *users.UserCredential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*users.UserCredential, error)); ok {
+		return rf(ctx, userID, provider)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *users.UserCredential); ok {
+		r0 = rf(ctx, userID, provider)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.UserCredential)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, provider)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -65,6 +312,404 @@ func (_m *UserServiceInterface) Register(newData users.User) (*users.User, error
 	return r0, r1
 }
 
+// LoginPasskey provides a mock function with given fields: ctx, userID, userVerified
+func (_m *UserServiceInterface) LoginPasskey(ctx context.Context, userID string, userVerified bool) (*users.UserCredential, error) {
+	ret := _m.Called(ctx, userID, userVerified)
+
+	var r0 *users.UserCredential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (*users.UserCredential, error)); ok {
+		return rf(ctx, userID, userVerified)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *users.UserCredential); ok {
+		r0 = rf(ctx, userID, userVerified)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.UserCredential)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, userID, userVerified)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoginWithCode provides a mock function with given fields: ctx, hp, code
+func (_m *UserServiceInterface) LoginWithCode(ctx context.Context, hp string, code string) (*users.UserCredential, error) {
+	ret := _m.Called(ctx, hp, code)
+
+	var r0 *users.UserCredential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*users.UserCredential, error)); ok {
+		return rf(ctx, hp, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *users.UserCredential); ok {
+		r0 = rf(ctx, hp, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.UserCredential)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, hp, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Provision provides a mock function with given fields: ctx, actorID, newData
+func (_m *UserServiceInterface) Provision(ctx context.Context, actorID string, newData users.User) (*users.User, error) {
+	ret := _m.Called(ctx, actorID, newData)
+
+	var r0 *users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, users.User) (*users.User, error)); ok {
+		return rf(ctx, actorID, newData)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, users.User) *users.User); ok {
+		r0 = rf(ctx, actorID, newData)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, users.User) error); ok {
+		r1 = rf(ctx, actorID, newData)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeDeletedAccounts provides a mock function with given fields:
+func (_m *UserServiceInterface) PurgeDeletedAccounts() (int, error) {
+	ret := _m.Called()
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (int, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reactivate provides a mock function with given fields: ctx, actor, userID
+func (_m *UserServiceInterface) Reactivate(ctx context.Context, actor helper.Principal, userID string) error {
+	ret := _m.Called(ctx, actor, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, helper.Principal, string) error); ok {
+		r0 = rf(ctx, actor, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RefreshSession provides a mock function with given fields: ctx, refreshToken
+func (_m *UserServiceInterface) RefreshSession(ctx context.Context, refreshToken string) (*users.UserCredential, error) {
+	ret := _m.Called(ctx, refreshToken)
+
+	var r0 *users.UserCredential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*users.UserCredential, error)); ok {
+		return rf(ctx, refreshToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *users.UserCredential); ok {
+		r0 = rf(ctx, refreshToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.UserCredential)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, refreshToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RegenerateRecoveryCodes provides a mock function with given fields: ctx, userID, code
+func (_m *UserServiceInterface) RegenerateRecoveryCodes(ctx context.Context, userID string, code string) ([]string, error) {
+	ret := _m.Called(ctx, userID, code)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]string, error)); ok {
+		return rf(ctx, userID, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []string); ok {
+		r0 = rf(ctx, userID, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Register provides a mock function with given fields: ctx, newData
+func (_m *UserServiceInterface) Register(ctx context.Context, newData users.User) (*users.User, error) {
+	ret := _m.Called(ctx, newData)
+
+	var r0 *users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, users.User) (*users.User, error)); ok {
+		return rf(ctx, newData)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, users.User) *users.User); ok {
+		r0 = rf(ctx, newData)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, users.User) error); ok {
+		r1 = rf(ctx, newData)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveAccount provides a mock function with given fields: ctx, actorID, userID
+func (_m *UserServiceInterface) RemoveAccount(ctx context.Context, actorID string, userID string) error {
+	ret := _m.Called(ctx, actorID, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, actorID, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RequestLoginCode provides a mock function with given fields: ctx, hp
+func (_m *UserServiceInterface) RequestLoginCode(ctx context.Context, hp string) error {
+	ret := _m.Called(ctx, hp)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, hp)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResendVerification provides a mock function with given fields: ctx, hp
+func (_m *UserServiceInterface) ResendVerification(ctx context.Context, hp string) error {
+	ret := _m.Called(ctx, hp)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, hp)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: ctx, hp, code, newPassword
+func (_m *UserServiceInterface) ResetPassword(ctx context.Context, hp string, code string, newPassword string) error {
+	ret := _m.Called(ctx, hp, code, newPassword)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, hp, code, newPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeSession provides a mock function with given fields: ctx, userID, sessionID
+func (_m *UserServiceInterface) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	ret := _m.Called(ctx, userID, sessionID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userID, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPassword provides a mock function with given fields: ctx, actorID, userID, password
+func (_m *UserServiceInterface) SetPassword(ctx context.Context, actorID string, userID string, password string) error {
+	ret := _m.Called(ctx, actorID, userID, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, actorID, userID, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StopImpersonation provides a mock function with given fields: ctx, principal
+func (_m *UserServiceInterface) StopImpersonation(ctx context.Context, principal helper.Principal) error {
+	ret := _m.Called(ctx, principal)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, helper.Principal) error); ok {
+		r0 = rf(ctx, principal)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateProfile provides a mock function with given fields: ctx, actorID, newData
+func (_m *UserServiceInterface) UpdateProfile(ctx context.Context, actorID string, newData users.User) (*users.User, error) {
+	ret := _m.Called(ctx, actorID, newData)
+
+	var r0 *users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, users.User) (*users.User, error)); ok {
+		return rf(ctx, actorID, newData)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, users.User) *users.User); ok {
+		r0 = rf(ctx, actorID, newData)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, users.User) error); ok {
+		r1 = rf(ctx, actorID, newData)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ValidateSession provides a mock function with given fields: principal
+func (_m *UserServiceInterface) ValidateSession(principal helper.Principal) error {
+	ret := _m.Called(principal)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(helper.Principal) error); ok {
+		r0 = rf(principal)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyLogin provides a mock function with given fields: ctx, stepUpToken, code
+func (_m *UserServiceInterface) VerifyLogin(ctx context.Context, stepUpToken string, code string) (*users.UserCredential, error) {
+	ret := _m.Called(ctx, stepUpToken, code)
+
+	var r0 *users.UserCredential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*users.UserCredential, error)); ok {
+		return rf(ctx, stepUpToken, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *users.UserCredential); ok {
+		r0 = rf(ctx, stepUpToken, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.UserCredential)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, stepUpToken, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyMFA provides a mock function with given fields: ctx, mfaToken, code
+func (_m *UserServiceInterface) VerifyMFA(ctx context.Context, mfaToken string, code string) (*users.UserCredential, error) {
+	ret := _m.Called(ctx, mfaToken, code)
+
+	var r0 *users.UserCredential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*users.UserCredential, error)); ok {
+		return rf(ctx, mfaToken, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *users.UserCredential); ok {
+		r0 = rf(ctx, mfaToken, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.UserCredential)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, mfaToken, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyPhone provides a mock function with given fields: ctx, hp, code
+func (_m *UserServiceInterface) VerifyPhone(ctx context.Context, hp string, code string) error {
+	ret := _m.Called(ctx, hp, code)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, hp, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewUserServiceInterface creates a new instance of UserServiceInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUserServiceInterface(t interface {
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"test/features/audit"
+	"test/features/users"
+	"test/helper"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PasswordAuthenticator checks the password stored in the users table. Logins
+// are HPs. Accounts a directory owns are left to the directory.
+type PasswordAuthenticator struct {
+	d users.UserDataInterface
+}
+
+func NewPasswordAuthenticator(data users.UserDataInterface) users.AuthenticatorInterface {
+	return &PasswordAuthenticator{
+		d: data,
+	}
+}
+
+func (pa *PasswordAuthenticator) Authenticate(ctx context.Context, login string, password string) (*users.User, error) {
+	result, err := pa.d.WithContext(ctx).GetByHP(login)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("data not found")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	if result.Directory != "" {
+		return nil, errors.New("data not found")
+	}
+
+	if !helper.ComparePassword(result.Password, password) {
+		return result, errors.New("invalid password")
+	}
+
+	if !helper.IsPasswordHash(result.Password) {
+		pa.upgradePassword(result.ID, password)
+	}
+
+	return result, nil
+}
+
+// upgradePassword replaces a plain text password stored before hashing was
+// introduced. Failing here must not fail the login, the next one retries.
+func (pa *PasswordAuthenticator) upgradePassword(userID string, password string) {
+	passwordHash, err := helper.HashPassword(password)
+	if err != nil {
+		logrus.Error("service: password hashing error:", err.Error())
+		return
+	}
+
+	if err := pa.d.UpdatePassword(userID, passwordHash); err != nil {
+		logrus.Error("service: upgrade password error:", err.Error())
+	}
+}
+
+// DirectoryAuthenticator checks logins against a directory like LDAP. Logins
+// are whatever the directory's user filter matches on.
+type DirectoryAuthenticator struct {
+	dir helper.DirectoryInterface
+}
+
+func NewDirectoryAuthenticator(directory helper.DirectoryInterface) users.AuthenticatorInterface {
+	return &DirectoryAuthenticator{
+		dir: directory,
+	}
+}
+
+func (da *DirectoryAuthenticator) Authenticate(ctx context.Context, login string, password string) (*users.User, error) {
+	found, err := da.dir.Authenticate(login, password)
+	if found == nil {
+		return nil, err
+	}
+
+	var result = new(users.User)
+	result.Nama = found.Nama
+	result.HP = found.HP
+	result.Role = found.Role
+	result.Directory = da.dir.Name()
+	result.DirectoryID = found.ID
+
+	return result, err
+}
+
+// authenticate asks the authenticators in order until one knows the login.
+// An authenticator that fails ends the login, the next one could otherwise
+// accept a password the failing one would have refused.
+func (us *UserService) authenticate(ctx context.Context, login string, password string) (*users.User, error) {
+	for _, authenticator := range us.auth {
+		result, err := authenticator.Authenticate(ctx, login, password)
+		if err == nil {
+			if result.Directory != "" {
+				return us.directoryAccount(ctx, result)
+			}
+			return result, nil
+		}
+
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			continue
+		case strings.Contains(err.Error(), "invalid password"):
+			var userID string
+			if result != nil {
+				userID = result.ID
+			}
+			us.loginFailed(ctx, userID, "invalid password")
+			return nil, errors.New("data not found")
+		}
+
+		logrus.Error("service: authenticator error:", err.Error())
+		return nil, errors.New("process failed")
+	}
+
+	us.loginFailed(ctx, "", "unknown account")
+	return nil, errors.New("data not found")
+}
+
+// directoryAccount returns the account of a directory entry, created on its
+// first login. Nama, HP and role follow the directory on every login. An HP
+// that belongs to another account is refused rather than linked, the
+// directory entry does not prove its owner holds that account.
+func (us *UserService) directoryAccount(ctx context.Context, entry *users.User) (*users.User, error) {
+	result, err := us.d.GetByDirectory(entry.Directory, entry.DirectoryID)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("process failed")
+		}
+		return us.insertDirectoryAccount(ctx, entry)
+	}
+
+	if result.DeactivatedAt != nil {
+		return result, nil
+	}
+
+	var before = map[string]any{"nama": result.Nama, "hp": result.HP, "role": result.Role}
+	var changed = false
+
+	if entry.Nama != result.Nama || entry.HP != result.HP {
+		var hpChanged = entry.HP != result.HP
+		if hpChanged {
+			if err := us.checkDirectoryHP(ctx, result.ID, entry); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := us.d.UpdateProfile(users.User{ID: result.ID, Nama: entry.Nama, HP: entry.HP}); err != nil {
+			return nil, errors.New("update process failed")
+		}
+		result.Nama = entry.Nama
+		result.HP = entry.HP
+		changed = true
+
+		if hpChanged && entry.HP != "" {
+			var now = us.c.Now()
+			if err := us.d.SetHPVerified(result.ID, now); err != nil {
+				return nil, errors.New("update process failed")
+			}
+			result.HPVerifiedAt = &now
+		}
+	}
+
+	if entry.Role != result.Role {
+		if err := us.d.UpdateRole(result.ID, entry.Role); err != nil {
+			return nil, errors.New("update process failed")
+		}
+		result.Role = entry.Role
+		changed = true
+	}
+
+	if changed {
+		us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.updated", TargetID: result.ID, Before: before, After: map[string]any{"nama": result.Nama, "hp": result.HP, "role": result.Role}})
+	}
+
+	return result, nil
+}
+
+func (us *UserService) insertDirectoryAccount(ctx context.Context, entry *users.User) (*users.User, error) {
+	if err := us.checkDirectoryHP(ctx, "", entry); err != nil {
+		return nil, err
+	}
+
+	newID, err := us.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	var newData = *entry
+	newData.ID = newID
+	result, err := us.d.Insert(newData)
+	if err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	if result.HP != "" {
+		var now = us.c.Now()
+		if err := us.d.SetHPVerified(result.ID, now); err != nil {
+			return nil, errors.New("update process failed")
+		}
+		result.HPVerifiedAt = &now
+	}
+
+	us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.registered", TargetID: result.ID, After: map[string]any{"role": result.Role, "hp_verified": result.HP != "", "directory": result.Directory}})
+
+	return result, nil
+}
+
+// checkDirectoryHP allows any number of directory accounts without an HP.
+func (us *UserService) checkDirectoryHP(ctx context.Context, userID string, entry *users.User) error {
+	if entry.HP == "" {
+		return nil
+	}
+
+	if err := us.checkHPAvailable(userID, entry.HP); err != nil {
+		if strings.Contains(err.Error(), "hp already used") {
+			us.loginFailed(ctx, userID, "hp already used")
+			return errors.New("account conflict")
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"test/features/users"
+	"test/helper"
+	"time"
+)
+
+const (
+	codePurposeReset  = "password_reset"
+	codePurposeVerify = "hp_verification"
+	codePurposeLogin  = "login_verification"
+	codePurposeSignIn = "passwordless_login"
+	// codePurposeMFAFailures counts wrong second factors, it holds no code.
+	codePurposeMFAFailures = "mfa_failures"
+
+	codeLength      = 6
+	codeTTL         = time.Minute * 10
+	codeCooldown    = time.Minute
+	codeMaxAttempts = 5
+	// signInCodeHourlyLimit caps the sign-in codes sent to one account, every
+	// new code comes with a fresh set of attempts.
+	signInCodeHourlyLimit = 5
+
+	resetCodeMessage  = "Your password reset code is %s. It expires in %d minutes, do not share it with anyone."
+	verifyCodeMessage = "Your verification code is %s. It expires in %d minutes, do not share it with anyone."
+	signInCodeMessage = "Your sign-in code is %s. It expires in %d minutes, do not share it with anyone."
+	loginCodeMessage  = "We noticed a sign-in to your account from a new device or location. Your code to continue is %s, it expires in %d minutes. If this wasn't you, change your password."
+)
+
+// sendCode issues a new one-time code for the purpose and delivers it to the
+// user's HP. Requesting a code again inside the cooldown is refused, and a new
+// code replaces the pending one.
+func (us *UserService) sendCode(user *users.User, purpose string, message string) error {
+	var now = us.c.Now()
+
+	lastCode, err := us.d.GetOneTimeCode(user.ID, purpose)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return errors.New("process failed")
+	}
+	if err == nil && now.Sub(lastCode.CreatedAt) < codeCooldown {
+		return errors.New("too many requests")
+	}
+
+	code, err := us.g.GenerateOTP(codeLength)
+	if err != nil {
+		return errors.New("code generator failed")
+	}
+
+	// Six digits are easy to brute force from a leaked hash, so the codes
+	// get the same slow hash as passwords.
+	codeHash, err := helper.HashPassword(code)
+	if err != nil {
+		return errors.New("code hashing failed")
+	}
+
+	var newCode = new(users.OneTimeCode)
+	newCode.UserID = user.ID
+	newCode.Purpose = purpose
+	newCode.CodeHash = codeHash
+	newCode.ExpiresAt = now.Add(codeTTL)
+	newCode.CreatedAt = now
+
+	if err := us.d.InsertOneTimeCode(*newCode); err != nil {
+		return errors.New("insert process failed")
+	}
+
+	if err := us.n.Send(user.HP, fmt.Sprintf(message, code, int(codeTTL.Minutes()))); err != nil {
+		return errors.New("send process failed")
+	}
+
+	return nil
+}
+
+// redeemCode checks the pending code of the purpose and burns it on success,
+// returning the time it was redeemed at. Every wrong guess is counted and the
+// code stops working after codeMaxAttempts.
+func (us *UserService) redeemCode(userID string, purpose string, code string) (time.Time, error) {
+	pending, err := us.d.GetOneTimeCode(userID, purpose)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return time.Time{}, errors.New("invalid code")
+		}
+		return time.Time{}, errors.New("process failed")
+	}
+
+	var now = us.c.Now()
+	if pending.Attempts >= codeMaxAttempts || !now.Before(pending.ExpiresAt) {
+		return time.Time{}, errors.New("invalid code")
+	}
+
+	if !helper.ComparePassword(pending.CodeHash, code) {
+		pending.Attempts++
+		if err := us.d.UpdateOneTimeCode(*pending); err != nil {
+			return time.Time{}, errors.New("process failed")
+		}
+		return time.Time{}, errors.New("invalid code")
+	}
+
+	pending.UsedAt = &now
+	if err := us.d.UpdateOneTimeCode(*pending); err != nil {
+		return time.Time{}, errors.New("process failed")
+	}
+
+	return now, nil
+}
+
+// secondFactorFailures returns the wrong second factors of the user counted
+// since the first of them, nil when there were none within mfaChallengeTTL.
+// The count is kept per user, a new challenge does not reset it.
+func (us *UserService) secondFactorFailures(userID string) (*users.OneTimeCode, error) {
+	result, err := us.d.GetOneTimeCode(userID, codePurposeMFAFailures)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, nil
+		}
+		return nil, errors.New("process failed")
+	}
+
+	if !us.c.Now().Before(result.ExpiresAt) {
+		return nil, nil
+	}
+
+	return result, nil
+}
+
+func (us *UserService) countSecondFactorFailure(userID string, failures *users.OneTimeCode) error {
+	if failures != nil {
+		failures.Attempts++
+		if err := us.d.UpdateOneTimeCode(*failures); err != nil {
+			return errors.New("process failed")
+		}
+		return nil
+	}
+
+	var now = us.c.Now()
+	var newData = users.OneTimeCode{UserID: userID, Purpose: codePurposeMFAFailures, Attempts: 1, ExpiresAt: now.Add(mfaChallengeTTL), CreatedAt: now}
+	if err := us.d.InsertOneTimeCode(newData); err != nil {
+		return errors.New("process failed")
+	}
+
+	return nil
+}
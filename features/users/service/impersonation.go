@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"test/features/audit"
+	"test/features/users"
+	"test/helper"
+)
+
+// An impersonation ends with its access token, it can not be refreshed.
+const impersonationTTL = helper.AccessTokenTTL
+
+// Impersonate lets an admin act as another user for support. The token names
+// the admin in its act claim and lives on its own session, so the user sees
+// it in their sessions and it can be ended like any other.
+func (us *UserService) Impersonate(ctx context.Context, actorID string, userID string) (*users.UserCredential, error) {
+	if actorID == userID {
+		return nil, errors.New("cannot impersonate own account")
+	}
+
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.DeactivatedAt != nil {
+		return nil, errors.New("account deactivated")
+	}
+
+	// Acting as another admin would hand out their rights.
+	if result.Role == users.RoleAdmin {
+		return nil, errors.New("cannot impersonate admin")
+	}
+
+	sessionID, err := us.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	var now = us.c.Now()
+	var meta = helper.RequestMetaFromContext(ctx)
+	var session = new(users.Session)
+	session.ID = sessionID
+	session.UserID = result.ID
+	session.IP = meta.IP
+	session.UserAgent = meta.UserAgent
+	session.ActorID = actorID
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(impersonationTTL)
+
+	if err := us.d.InsertSession(*session); err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	var role = result.Role
+	if role == "" {
+		role = users.RoleUser
+	}
+
+	tokenData := us.j.GenerateJWT(helper.Principal{UserID: result.ID, Roles: []string{role}, SessionID: sessionID, ActorID: actorID})
+	if tokenData == nil {
+		return nil, errors.New("token process failed")
+	}
+
+	us.audit(ctx, audit.Event{ActorID: actorID, Action: "user.impersonation_started", TargetID: result.ID, After: map[string]any{"session_id": sessionID, "expires_at": session.ExpiresAt}})
+
+	response := new(users.UserCredential)
+	response.Nama = result.Nama
+	response.Access = tokenData
+
+	return response, nil
+}
+
+// StopImpersonation ends the session of an impersonation token before it
+// expires on its own.
+func (us *UserService) StopImpersonation(ctx context.Context, principal helper.Principal) error {
+	if !principal.Impersonated() {
+		return errors.New("not impersonating")
+	}
+
+	if err := us.d.RevokeSession(principal.UserID, principal.SessionID, us.c.Now()); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("update process failed")
+	}
+
+	us.audit(ctx, audit.Event{ActorID: principal.ActorID, Action: "user.impersonation_stopped", TargetID: principal.UserID, After: map[string]any{"session_id": principal.SessionID}})
+
+	return nil
+}
+
+// validateActor ends an impersonation as soon as the admin behind it lost
+// the right to start one.
+func (us *UserService) validateActor(principal helper.Principal) error {
+	if principal.SessionID == "" {
+		return errors.New("session revoked")
+	}
+
+	actor, err := us.getUser(context.Background(), principal.ActorID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("session revoked")
+		}
+		return err
+	}
+
+	if actor.DeactivatedAt != nil || actor.Role != users.RoleAdmin {
+		return errors.New("session revoked")
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"test/features/users"
+	"time"
+)
+
+// RequestLoginCode sends a sign-in code to the HP. Like ForgotPassword it
+// reports success for numbers without an account, for accounts that can not
+// sign in with a code: unverified HPs and accounts a directory owns, and for
+// requests over the limits.
+func (us *UserService) RequestLoginCode(ctx context.Context, hp string) error {
+	result, err := us.d.WithContext(ctx).GetByHP(hp)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return errors.New("process failed")
+	}
+
+	if result.HPVerifiedAt == nil || result.Directory != "" || result.DeactivatedAt != nil {
+		return nil
+	}
+
+	sent, err := us.d.CountOneTimeCodes(result.ID, codePurposeSignIn, us.c.Now().Add(-time.Hour))
+	if err != nil {
+		return errors.New("process failed")
+	}
+	if sent >= signInCodeHourlyLimit {
+		return nil
+	}
+
+	if err := us.sendCode(result, codePurposeSignIn, signInCodeMessage); err != nil && !strings.Contains(err.Error(), "too many requests") {
+		return err
+	}
+
+	return nil
+}
+
+// LoginWithCode is the passwordless first factor. A second factor is still
+// asked for, the step-up is not since the code already went to the HP.
+func (us *UserService) LoginWithCode(ctx context.Context, hp string, code string) (*users.UserCredential, error) {
+	result, err := us.d.WithContext(ctx).GetByHP(hp)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("invalid code")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	if _, err := us.redeemCode(result.ID, codePurposeSignIn, code); err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			us.loginFailed(ctx, result.ID, "invalid sign-in code")
+		}
+		return nil, err
+	}
+
+	if result.DeactivatedAt != nil {
+		us.loginFailed(ctx, result.ID, "account deactivated")
+		return nil, errors.New("account deactivated")
+	}
+
+	return us.completeLogin(ctx, result, map[string]any{"method": "code"}, proofHP)
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"test/features/audit"
+	"test/features/users"
+	"test/helper"
+)
+
+const maxListLimit = 100
+
+// The methods in this file manage accounts on behalf of someone else, an
+// administrator or a provisioning system like an HR directory. actorID is who
+// the audit log names as responsible. Inside an organization only its members
+// can be managed.
+
+// GetUser also returns deactivated accounts, deleted ones are not found.
+func (us *UserService) GetUser(ctx context.Context, userID string) (*users.User, error) {
+	return us.getUser(ctx, userID)
+}
+
+func (us *UserService) ListUsers(ctx context.Context, query users.UserQuery) ([]users.User, int, error) {
+	if query.Offset < 0 || query.Limit < 0 || query.Limit > maxListLimit {
+		return nil, 0, errors.New("invalid page")
+	}
+
+	result, total, err := us.d.WithContext(ctx).List(query)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid filter") {
+			return nil, 0, err
+		}
+		return nil, 0, errors.New("process failed")
+	}
+
+	return result, total, nil
+}
+
+// Provision creates an account without the verification code Register sends,
+// the provisioning system is trusted with the HP. The password is optional,
+// accounts without one can only log in through an external provider until a
+// password is set.
+func (us *UserService) Provision(ctx context.Context, actorID string, newData users.User) (*users.User, error) {
+	newData.Nama = strings.TrimSpace(newData.Nama)
+	newData.HP = strings.TrimSpace(newData.HP)
+	if newData.HP == "" {
+		return nil, errors.New("invalid hp")
+	}
+
+	if err := us.checkHPAvailable("", newData.HP); err != nil {
+		return nil, err
+	}
+
+	if newData.Password != "" {
+		if err := us.checkPassword("", newData.Password, []string{newData.Nama, newData.HP}); err != nil {
+			return nil, err
+		}
+
+		passwordHash, err := helper.HashPassword(newData.Password)
+		if err != nil {
+			return nil, errors.New("password hashing failed")
+		}
+		newData.Password = passwordHash
+	}
+
+	newID, err := us.g.GenerateUUID()
+	if err != nil {
+		return nil, errors.New("id generator failed")
+	}
+
+	newData.ID = newID
+	newData.Role = users.RoleUser
+	newData.HPVerifiedAt = nil
+	result, err := us.d.Insert(newData)
+	if err != nil {
+		return nil, errors.New("insert process failed")
+	}
+
+	var now = us.c.Now()
+	if err := us.d.SetHPVerified(result.ID, now); err != nil {
+		return nil, errors.New("update process failed")
+	}
+	result.HPVerifiedAt = &now
+
+	us.audit(ctx, audit.Event{ActorID: actorID, Action: "user.registered", TargetID: result.ID, After: map[string]any{"role": newData.Role, "hp_verified": true, "provisioned": true}})
+
+	return result, nil
+}
+
+// UpdateProfile changes Nama and HP. Like on Provision a new HP counts as
+// verified.
+func (us *UserService) UpdateProfile(ctx context.Context, actorID string, newData users.User) (*users.User, error) {
+	result, err := us.getUser(ctx, newData.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	newData.Nama = strings.TrimSpace(newData.Nama)
+	newData.HP = strings.TrimSpace(newData.HP)
+	if newData.HP == "" {
+		return nil, errors.New("invalid hp")
+	}
+
+	if newData.Nama == result.Nama && newData.HP == result.HP {
+		return result, nil
+	}
+
+	var hpChanged = newData.HP != result.HP
+	if hpChanged {
+		if err := us.checkHPAvailable(result.ID, newData.HP); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := us.d.UpdateProfile(newData); err != nil {
+		return nil, errors.New("update process failed")
+	}
+
+	var before = map[string]any{"nama": result.Nama, "hp": result.HP}
+	result.Nama = newData.Nama
+	result.HP = newData.HP
+
+	if hpChanged {
+		var now = us.c.Now()
+		if err := us.d.SetHPVerified(result.ID, now); err != nil {
+			return nil, errors.New("update process failed")
+		}
+		result.HPVerifiedAt = &now
+	}
+
+	us.audit(ctx, audit.Event{ActorID: actorID, Action: "user.updated", TargetID: result.ID, Before: before, After: map[string]any{"nama": result.Nama, "hp": result.HP}})
+
+	return result, nil
+}
+
+// SetPassword replaces the password without the current one, and ends every
+// session like a reset does.
+func (us *UserService) SetPassword(ctx context.Context, actorID string, userID string, password string) error {
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if result.Directory != "" {
+		return errors.New("password managed by directory")
+	}
+
+	if err := us.checkPassword(result.ID, password, []string{result.Nama, result.HP}); err != nil {
+		return err
+	}
+
+	passwordHash, err := helper.HashPassword(password)
+	if err != nil {
+		return errors.New("password hashing failed")
+	}
+
+	if err := us.d.UpdatePassword(result.ID, passwordHash); err != nil {
+		return errors.New("update process failed")
+	}
+
+	if err := us.revokeTokens(result.ID, us.c.Now()); err != nil {
+		return errors.New("process failed")
+	}
+
+	us.audit(ctx, audit.Event{ActorID: actorID, Action: "user.password_set", TargetID: result.ID})
+
+	return nil
+}
+
+// RemoveAccount deletes the account like DeleteAccount, without the password
+// confirmation. It can still be restored until the purge job runs.
+func (us *UserService) RemoveAccount(ctx context.Context, actorID string, userID string) error {
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return us.removeAccount(ctx, actorID, result)
+}
+
+func (us *UserService) checkHPAvailable(userID string, hp string) error {
+	existing, err := us.d.GetByHP(hp)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return errors.New("process failed")
+	}
+
+	if existing.ID != userID {
+		return errors.New("hp already used")
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"test/features/audit"
+	"test/features/users"
+	"test/helper"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	stepUpChallengePurpose = "step_up"
+	loginHistoryLimit      = 20
+
+	// Faster than a commercial flight. Short hops are ignored, GeoIP is not
+	// precise enough to tell nearby cities apart.
+	impossibleTravelSpeed       = 900.0
+	impossibleTravelMinDistance = 500.0
+
+	riskNewDevice        = "new_device"
+	riskNewIPRange       = "new_ip_range"
+	riskImpossibleTravel = "impossible_travel"
+
+	loginAlertMessage = "New sign-in to your account from %s. If this wasn't you, change your password right away."
+)
+
+type loginOrigin struct {
+	meta        helper.RequestMeta
+	fingerprint string
+	ipRange     string
+	location    *helper.GeoLocation
+}
+
+// VerifyLogin completes a login that was held back by the step-up check with
+// the code sent to the user's HP.
+func (us *UserService) VerifyLogin(ctx context.Context, stepUpToken string, code string) (*users.UserCredential, error) {
+	userID, err := us.j.ParseChallengeToken(stepUpToken, stepUpChallengePurpose)
+	if err != nil {
+		return nil, errors.New("invalid step up token")
+	}
+
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.DeactivatedAt != nil {
+		return nil, errors.New("account deactivated")
+	}
+
+	if _, err := us.redeemCode(result.ID, codePurposeLogin, code); err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			us.loginFailed(ctx, result.ID, "invalid step up code")
+		}
+		return nil, err
+	}
+
+	us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.login", TargetID: result.ID, After: map[string]any{"step_up": true}})
+
+	return us.credential(ctx, result)
+}
+
+// checkLoginRisk compares the login with the user's recent sessions and alerts
+// the user when it comes from somewhere new. It reports whether the login has
+// to be confirmed with a code first; accounts with TOTP already get a second
+// factor and are only notified, like logins that cannot be stepped up.
+func (us *UserService) checkLoginRisk(ctx context.Context, user *users.User, canStepUp bool) (bool, error) {
+	var origin = us.loginOrigin(ctx)
+
+	history, err := us.d.GetSessions(user.ID, loginHistoryLimit)
+	if err != nil {
+		return false, errors.New("process failed")
+	}
+
+	// Sessions an admin opened by impersonating the user came from the
+	// admin's device, they say nothing about where the user logs in.
+	var own = make([]users.Session, 0, len(history))
+	for _, session := range history {
+		if session.ActorID == "" {
+			own = append(own, session)
+		}
+	}
+
+	var reasons = assessLogin(own, origin, us.c.Now())
+	if len(reasons) == 0 {
+		return false, nil
+	}
+
+	us.audit(ctx, audit.Event{ActorID: user.ID, Action: "user.login_suspicious", TargetID: user.ID, After: map[string]any{"reasons": reasons}})
+
+	// Accounts created through an external provider may have no HP to alert
+	// or challenge, the audit event is all that is left for them.
+	if user.HP == "" {
+		return false, nil
+	}
+
+	if canStepUp && us.cfg.SuspiciousLoginPolicy == "step_up" && !user.TOTPEnabled {
+		return true, nil
+	}
+
+	if err := us.n.Send(user.HP, fmt.Sprintf(loginAlertMessage, describeOrigin(origin))); err != nil {
+		logrus.Error("service: send login alert error:", err.Error())
+	}
+
+	return false, nil
+}
+
+func (us *UserService) stepUpChallenge(user *users.User) (*users.UserCredential, error) {
+	if err := us.sendCode(user, codePurposeLogin, loginCodeMessage); err != nil {
+		return nil, err
+	}
+
+	stepUpToken := us.j.GenerateChallengeToken(user.ID, stepUpChallengePurpose, codeTTL)
+	if stepUpToken == "" {
+		return nil, errors.New("token process failed")
+	}
+
+	response := new(users.UserCredential)
+	response.Nama = user.Nama
+	response.StepUpRequired = true
+	response.StepUpToken = stepUpToken
+
+	return response, nil
+}
+
+func (us *UserService) loginOrigin(ctx context.Context) loginOrigin {
+	var meta = helper.RequestMetaFromContext(ctx)
+	var origin = loginOrigin{
+		meta:        meta,
+		fingerprint: helper.DeviceFingerprint(meta),
+		ipRange:     helper.IPRange(meta.IP),
+	}
+
+	if us.geo != nil && meta.IP != "" {
+		location, err := us.geo.Locate(meta.IP)
+		if err == nil {
+			origin.location = location
+		}
+	}
+
+	return origin
+}
+
+// assessLogin lists what is unusual about the login. A user without any
+// history has nothing to compare with, their first login is never flagged.
+func assessLogin(history []users.Session, origin loginOrigin, now time.Time) []string {
+	var reasons = []string{}
+	if len(history) == 0 {
+		return reasons
+	}
+
+	var knownDevice = false
+	var knownRange = false
+	for _, session := range history {
+		if session.Fingerprint == origin.fingerprint {
+			knownDevice = true
+		}
+		if origin.ipRange != "" && helper.IPRange(session.IP) == origin.ipRange {
+			knownRange = true
+		}
+	}
+
+	if !knownDevice {
+		reasons = append(reasons, riskNewDevice)
+	}
+	if origin.ipRange != "" && !knownRange {
+		reasons = append(reasons, riskNewIPRange)
+	}
+
+	// history is ordered newest first, the travel check only looks at the
+	// most recent login that could be located.
+	if origin.location != nil {
+		for _, session := range history {
+			if session.Latitude == nil || session.Longitude == nil {
+				continue
+			}
+
+			var distance = helper.DistanceKm(helper.GeoLocation{Latitude: *session.Latitude, Longitude: *session.Longitude}, *origin.location)
+			var hours = now.Sub(session.CreatedAt).Hours()
+			if distance >= impossibleTravelMinDistance && (hours <= 0 || distance/hours > impossibleTravelSpeed) {
+				reasons = append(reasons, riskImpossibleTravel)
+			}
+			break
+		}
+	}
+
+	return reasons
+}
+
+func describeOrigin(origin loginOrigin) string {
+	var device = origin.meta.UserAgent
+	if device == "" {
+		device = "an unknown device"
+	}
+
+	var place = origin.meta.IP
+	if origin.location != nil && origin.location.City != "" {
+		place = origin.location.City + ", " + origin.location.Country + " (" + origin.meta.IP + ")"
+	}
+	if place == "" {
+		return device
+	}
+
+	return device + " at " + place
+}
@@ -1,43 +1,770 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
 	"errors"
+	"fmt"
 	"strings"
+	"test/configs"
+	"test/features/audit"
 	"test/features/users"
 	"test/helper"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	totpIssuer          = "Restfull"
+	mfaChallengePurpose = "mfa"
+	mfaChallengeTTL     = time.Minute * 5
+	recoveryCodeCount   = 10
 )
 
 type UserService struct {
-	d users.UserDataInterface
-	g helper.GeneratorInterface
-	j helper.JWTInterface
+	d   users.UserDataInterface
+	g   helper.GeneratorInterface
+	j   helper.JWTInterface
+	c   helper.ClockInterface
+	n   helper.NotifierInterface
+	a   audit.AuditServiceInterface
+	p   *helper.PasswordPolicy
+	geo helper.GeoLocatorInterface
+	// storage holds the avatars, purged accounts lose theirs.
+	storage helper.StorageInterface
+	// auth is asked in order on every login.
+	auth []users.AuthenticatorInterface
+	// revokers and erasers let the other features clean up after a user.
+	revokers []users.RevocationHookInterface
+	erasers  []users.ErasureHookInterface
+	cfg      configs.ProgramConfig
 }
 
-func New(data users.UserDataInterface, generator helper.GeneratorInterface, jwt helper.JWTInterface) users.UserServiceInterface {
+// New checks logins against the password in the users table when no
+// authenticators are given.
+func New(data users.UserDataInterface, generator helper.GeneratorInterface, jwt helper.JWTInterface, clock helper.ClockInterface, notifier helper.NotifierInterface, auditor audit.AuditServiceInterface, policy *helper.PasswordPolicy, geo helper.GeoLocatorInterface, storage helper.StorageInterface, authenticators []users.AuthenticatorInterface, revokers []users.RevocationHookInterface, erasers []users.ErasureHookInterface, cfg configs.ProgramConfig) users.UserServiceInterface {
+	if len(authenticators) == 0 {
+		authenticators = []users.AuthenticatorInterface{NewPasswordAuthenticator(data)}
+	}
+
 	return &UserService{
-		d: data,
-		g: generator,
-		j: jwt,
+		d:        data,
+		g:        generator,
+		j:        jwt,
+		c:        clock,
+		n:        notifier,
+		a:        auditor,
+		p:        policy,
+		geo:      geo,
+		storage:  storage,
+		auth:     authenticators,
+		revokers: revokers,
+		erasers:  erasers,
+		cfg:      cfg,
 	}
 }
 
-func (us *UserService) Register(newData users.User) (*users.User, error) {
+func (us *UserService) Register(ctx context.Context, newData users.User) (*users.User, error) {
+	if us.cfg.InviteOnly {
+		return nil, errors.New("registration closed")
+	}
+
+	if err := us.checkPassword("", newData.Password, []string{newData.Nama, newData.HP}); err != nil {
+		return nil, err
+	}
+
+	if err := us.checkHPAvailable("", newData.HP); err != nil {
+		return nil, err
+	}
+
 	newID, err := us.g.GenerateUUID()
 	if err != nil {
 		return nil, errors.New("id generator failed")
 	}
 
 	newData.ID = newID
+	newData.Password, err = helper.HashPassword(newData.Password)
+	if err != nil {
+		return nil, errors.New("password hashing failed")
+	}
+
+	newData.Role = users.RoleUser
+	newData.HPVerifiedAt = nil
 	result, err := us.d.Insert(newData)
 	if err != nil {
 		return nil, errors.New("insert process failed")
 	}
 
+	us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.registered", TargetID: result.ID, After: map[string]any{"role": newData.Role, "hp_verified": false}})
+
+	// The account exists at this point, a failed delivery can be retried
+	// through ResendVerification.
+	if err := us.sendCode(result, codePurposeVerify, verifyCodeMessage); err != nil {
+		logrus.Error("service: send verification code error:", err.Error())
+	}
+
 	return result, nil
 }
 
-func (us *UserService) Login(hp string, password string) (*users.UserCredential, error) {
-	result, err := us.d.Login(hp, password)
+func (us *UserService) Login(ctx context.Context, hp string, password string) (*users.UserCredential, error) {
+	result, err := us.authenticate(ctx, hp, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.DeactivatedAt != nil {
+		us.loginFailed(ctx, result.ID, "account deactivated")
+		return nil, errors.New("account deactivated")
+	}
+
+	// The directory vouches for the HP of its accounts.
+	if result.Directory == "" && result.HPVerifiedAt == nil && us.cfg.UnverifiedPolicy != "allow" {
+		us.loginFailed(ctx, result.ID, "account not verified")
+		return nil, errors.New("account not verified")
+	}
+
+	var after map[string]any
+	if result.Directory != "" {
+		after = map[string]any{"directory": result.Directory}
+	}
+
+	return us.completeLogin(ctx, result, after, proofNone)
+}
+
+// LoginExternal logs in an account an external identity provider has
+// authenticated. The provider only replaces the password, a second factor and
+// the suspicious login checks still apply. The HP does not have to be verified
+// since it is not what the user logged in with.
+func (us *UserService) LoginExternal(ctx context.Context, userID string, provider string) (*users.UserCredential, error) {
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// An erased account is gone for its owner, whatever still points to it.
+	if result.AnonymizedAt != nil {
+		return nil, errors.New("data not found")
+	}
+
+	if result.DeactivatedAt != nil {
+		us.loginFailed(ctx, result.ID, "account deactivated")
+		return nil, errors.New("account deactivated")
+	}
+
+	return us.completeLogin(ctx, result, map[string]any{"provider": provider}, proofNone)
+}
+
+// LoginPasskey logs in the account a passkey was verified for. Without user
+// verification the passkey only proves possession of the authenticator, so
+// it is treated like a password.
+func (us *UserService) LoginPasskey(ctx context.Context, userID string, userVerified bool) (*users.UserCredential, error) {
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Erasure deletes the passkeys as well, this covers one read before that.
+	if result.AnonymizedAt != nil {
+		return nil, errors.New("data not found")
+	}
+
+	if result.DeactivatedAt != nil {
+		us.loginFailed(ctx, result.ID, "account deactivated")
+		return nil, errors.New("account deactivated")
+	}
+
+	var proof = proofNone
+	if userVerified {
+		proof = proofMultiFactor
+	}
+
+	return us.completeLogin(ctx, result, map[string]any{"method": "passkey", "user_verified": userVerified}, proof)
+}
+
+func (us *UserService) VerifyMFA(ctx context.Context, mfaToken string, code string) (*users.UserCredential, error) {
+	userID, err := us.j.ParseChallengeToken(mfaToken, mfaChallengePurpose)
+	if err != nil {
+		return nil, errors.New("invalid mfa token")
+	}
+
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.TOTPEnabled {
+		return nil, errors.New("mfa not enabled")
+	}
+
+	if result.DeactivatedAt != nil {
+		return nil, errors.New("account deactivated")
+	}
+
+	// Like a one-time code the second factor only gets codeMaxAttempts
+	// guesses, after that even the right one is refused until the window
+	// is over.
+	failures, err := us.secondFactorFailures(result.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if failures != nil && failures.Attempts >= codeMaxAttempts {
+		us.loginFailed(ctx, result.ID, "too many second factor attempts")
+		return nil, errors.New("invalid code")
+	}
+
+	if err := us.verifySecondFactor(result, code); err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			us.loginFailed(ctx, result.ID, "invalid second factor")
+			if err := us.countSecondFactorFailure(result.ID, failures); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	if failures != nil {
+		var now = us.c.Now()
+		failures.UsedAt = &now
+		if err := us.d.UpdateOneTimeCode(*failures); err != nil {
+			return nil, errors.New("process failed")
+		}
+	}
+
+	us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.login", TargetID: result.ID, After: map[string]any{"mfa": true}})
+
+	return us.credential(ctx, result)
+}
+
+func (us *UserService) EnrollTOTP(ctx context.Context, userID string) (*users.TOTPEnrollment, error) {
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.TOTPEnabled {
+		return nil, errors.New("mfa already enabled")
+	}
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		return nil, errors.New("secret generator failed")
+	}
+
+	result.TOTPSecret = secret
+	result.TOTPLastStep = 0
+	if err := us.d.UpdateTOTP(*result); err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	var enrollment = new(users.TOTPEnrollment)
+	enrollment.Secret = secret
+	enrollment.URI = helper.TOTPURI(totpIssuer, result.HP, secret)
+	enrollment.QRCode, err = helper.QRCodePNG(enrollment.URI)
+	if err != nil {
+		return nil, errors.New("qr code process failed")
+	}
+
+	return enrollment, nil
+}
+
+func (us *UserService) ConfirmTOTP(ctx context.Context, userID string, code string) ([]string, error) {
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.TOTPEnabled {
+		return nil, errors.New("mfa already enabled")
+	}
+
+	if result.TOTPSecret == "" {
+		return nil, errors.New("mfa not enrolled")
+	}
+
+	step := helper.ValidateTOTP(result.TOTPSecret, code, us.c.Now())
+	if step < 0 {
+		return nil, errors.New("invalid code")
+	}
+
+	result.TOTPEnabled = true
+	result.TOTPLastStep = step
+	if err := us.d.UpdateTOTP(*result); err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	codes, err := us.newRecoveryCodes(result.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.mfa_enabled", TargetID: result.ID, Before: map[string]any{"totp_enabled": false}, After: map[string]any{"totp_enabled": true}})
+
+	return codes, nil
+}
+
+func (us *UserService) DisableTOTP(ctx context.Context, userID string, code string) error {
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !result.TOTPEnabled {
+		return errors.New("mfa not enabled")
+	}
+
+	if err := us.verifySecondFactor(result, code); err != nil {
+		return err
+	}
+
+	result.TOTPSecret = ""
+	result.TOTPEnabled = false
+	result.TOTPLastStep = 0
+	if err := us.d.UpdateTOTP(*result); err != nil {
+		return errors.New("process failed")
+	}
+
+	if err := us.d.ReplaceRecoveryCodes(result.ID, nil); err != nil {
+		return errors.New("process failed")
+	}
+
+	us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.mfa_disabled", TargetID: result.ID, Before: map[string]any{"totp_enabled": true}, After: map[string]any{"totp_enabled": false}})
+
+	return nil
+}
+
+func (us *UserService) RegenerateRecoveryCodes(ctx context.Context, userID string, code string) ([]string, error) {
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.TOTPEnabled {
+		return nil, errors.New("mfa not enabled")
+	}
+
+	if err := us.verifySecondFactor(result, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := us.newRecoveryCodes(result.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.recovery_codes_regenerated", TargetID: result.ID})
+
+	return codes, nil
+}
+
+func (us *UserService) ForgotPassword(ctx context.Context, hp string) error {
+	result, err := us.d.WithContext(ctx).GetByHP(hp)
+	if err != nil {
+		// Unknown numbers are reported as success so the endpoint cannot be
+		// used to find out who has an account.
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return errors.New("process failed")
+	}
+
+	// The directory owns the password of its accounts.
+	if result.Directory != "" {
+		return nil
+	}
+
+	// A request inside the cooldown sends no new code, but refusing it would
+	// tell the caller that the number has an account.
+	if err := us.sendCode(result, codePurposeReset, resetCodeMessage); err != nil && !strings.Contains(err.Error(), "too many requests") {
+		return err
+	}
+
+	return nil
+}
+
+func (us *UserService) ResetPassword(ctx context.Context, hp string, code string, newPassword string) error {
+	result, err := us.d.WithContext(ctx).GetByHP(hp)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("invalid code")
+		}
+		return errors.New("process failed")
+	}
+
+	if err := us.checkPassword(result.ID, newPassword, []string{result.Nama, result.HP}); err != nil {
+		return err
+	}
+
+	passwordHash, err := helper.HashPassword(newPassword)
+	if err != nil {
+		return errors.New("password hashing failed")
+	}
+
+	now, err := us.redeemCode(result.ID, codePurposeReset, code)
+	if err != nil {
+		return err
+	}
+
+	if err := us.d.UpdatePassword(result.ID, passwordHash); err != nil {
+		return errors.New("update process failed")
+	}
+
+	if err := us.revokeTokens(result.ID, now); err != nil {
+		return errors.New("process failed")
+	}
+
+	us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.password_reset", TargetID: result.ID})
+
+	return nil
+}
+
+// ChangePassword revokes every token issued so far and hands a new one back,
+// so the client making the change stays logged in while other sessions end.
+func (us *UserService) ChangePassword(ctx context.Context, userID string, currentPassword string, newPassword string) (*users.UserCredential, error) {
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Directory != "" {
+		return nil, errors.New("password managed by directory")
+	}
+
+	if !helper.ComparePassword(result.Password, currentPassword) {
+		return nil, errors.New("invalid current password")
+	}
+
+	if newPassword == currentPassword {
+		return nil, &helper.PasswordPolicyError{Violations: []helper.PasswordViolation{reusedPasswordViolation}}
+	}
+
+	if err := us.checkPassword(result.ID, newPassword, []string{result.Nama, result.HP}); err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := helper.HashPassword(newPassword)
+	if err != nil {
+		return nil, errors.New("password hashing failed")
+	}
+
+	if err := us.d.UpdatePassword(result.ID, passwordHash); err != nil {
+		return nil, errors.New("update process failed")
+	}
+
+	if err := us.revokeTokens(result.ID, us.c.Now()); err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.password_changed", TargetID: result.ID})
+
+	return us.credential(ctx, result)
+}
+
+// VerifyPhone and ResendVerification look the HP up outside of any
+// organization, a new account verifies its HP before it joins one. Like
+// ForgotPassword they answer unknown and verified numbers the same as any
+// other, the endpoints cannot be used to find out who has an account.
+func (us *UserService) VerifyPhone(ctx context.Context, hp string, code string) error {
+	result, err := us.d.GetByHP(hp)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("invalid code")
+		}
+		return errors.New("process failed")
+	}
+
+	if result.HPVerifiedAt != nil {
+		return errors.New("invalid code")
+	}
+
+	now, err := us.redeemCode(result.ID, codePurposeVerify, code)
+	if err != nil {
+		return err
+	}
+
+	if err := us.d.SetHPVerified(result.ID, now); err != nil {
+		return errors.New("update process failed")
+	}
+
+	us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.hp_verified", TargetID: result.ID, Before: map[string]any{"hp_verified": false}, After: map[string]any{"hp_verified": true}})
+
+	return nil
+}
+
+func (us *UserService) ResendVerification(ctx context.Context, hp string) error {
+	result, err := us.d.GetByHP(hp)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return errors.New("process failed")
+	}
+
+	if result.HPVerifiedAt != nil {
+		return nil
+	}
+
+	if err := us.sendCode(result, codePurposeVerify, verifyCodeMessage); err != nil && !strings.Contains(err.Error(), "too many requests") {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateSession refuses access tokens issued before the user's sessions
+// were revoked. Token iat only has second precision, so a token issued in the
+// same second as the revocation is still accepted. The account is checked
+// wherever it lives, the auth middleware checks the organization claim.
+func (us *UserService) ValidateSession(principal helper.Principal) error {
+	result, err := us.getUser(context.Background(), principal.UserID)
+	if err != nil {
+		return err
+	}
+
+	if result.DeactivatedAt != nil {
+		return errors.New("account deactivated")
+	}
+
+	if result.TokensRevokedAt != nil && principal.IssuedAt.Before(result.TokensRevokedAt.Truncate(time.Second)) {
+		return errors.New("session revoked")
+	}
+
+	if principal.Impersonated() {
+		if err := us.validateActor(principal); err != nil {
+			return err
+		}
+	}
+
+	if principal.SessionID != "" {
+		return us.touchSession(principal)
+	}
+
+	return nil
+}
+
+// DeleteAccount only soft deletes, the account can still be restored by an
+// admin until PurgeDeletedAccounts picks it up after the grace period.
+func (us *UserService) DeleteAccount(ctx context.Context, principal helper.Principal, password string) error {
+	result, err := us.getUser(ctx, principal.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err := us.confirmAccount(principal, result, password); err != nil {
+		return err
+	}
+
+	return us.removeAccount(ctx, result.ID, result)
+}
+
+// confirmAccount checks that the owner really asked for the account to be
+// deleted. Accounts with a password type it again, accounts without one have
+// to have signed in within users.RecentAuthTTL.
+func (us *UserService) confirmAccount(principal helper.Principal, user *users.User, password string) error {
+	if user.Password != "" {
+		if !helper.ComparePassword(user.Password, password) {
+			return errors.New("invalid password confirmation")
+		}
+		return nil
+	}
+
+	if principal.SessionID == "" {
+		return errors.New("reauthentication required")
+	}
+
+	session, err := us.d.GetSession(principal.SessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("reauthentication required")
+		}
+		return errors.New("process failed")
+	}
+
+	if !session.RecentlyAuthenticated(principal, us.c.Now()) {
+		return errors.New("reauthentication required")
+	}
+
+	return nil
+}
+
+func (us *UserService) removeAccount(ctx context.Context, actorID string, user *users.User) error {
+	if err := us.d.SoftDelete(user.ID); err != nil {
+		return errors.New("delete process failed")
+	}
+
+	if err := us.revokeTokens(user.ID, us.c.Now()); err != nil {
+		return errors.New("process failed")
+	}
+
+	us.audit(ctx, audit.Event{ActorID: actorID, Action: "user.deleted", TargetID: user.ID, Before: map[string]any{"deleted": false}, After: map[string]any{"deleted": true}})
+
+	return nil
+}
+
+func (us *UserService) Deactivate(ctx context.Context, actor helper.Principal, userID string) error {
+	if actor.UserID == userID {
+		return errors.New("cannot deactivate own account")
+	}
+
+	if err := us.checkAdminTarget(ctx, actor, userID); err != nil {
+		return err
+	}
+
+	var now = us.c.Now()
+	if err := us.d.WithContext(ctx).Deactivate(userID, now); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("update process failed")
+	}
+
+	if err := us.revokeTokens(userID, now); err != nil {
+		return errors.New("process failed")
+	}
+
+	us.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "user.deactivated", TargetID: userID, Before: map[string]any{"deactivated": false}, After: map[string]any{"deactivated": true}})
+
+	return nil
+}
+
+func (us *UserService) Reactivate(ctx context.Context, actor helper.Principal, userID string) error {
+	if err := us.checkAdminTarget(ctx, actor, userID); err != nil {
+		return err
+	}
+
+	if err := us.d.WithContext(ctx).Reactivate(userID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("update process failed")
+	}
+
+	us.audit(ctx, audit.Event{ActorID: actor.UserID, Action: "user.reactivated", TargetID: userID, Before: map[string]any{"deactivated": true}, After: map[string]any{"deactivated": false}})
+
+	return nil
+}
+
+// checkAdminTarget keeps the accounts of admins to admins. users:manage can be
+// granted through a group, whoever holds it that way must not be able to lock
+// out the admins who granted it.
+func (us *UserService) checkAdminTarget(ctx context.Context, actor helper.Principal, userID string) error {
+	if actor.HasRole(users.RoleAdmin) {
+		return nil
+	}
+
+	result, err := us.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if result.Role == users.RoleAdmin {
+		return errors.New("forbidden")
+	}
+
+	return nil
+}
+
+// PurgeDeletedAccounts anonymizes, or with PurgeMode "delete" removes, the
+// accounts whose deletion grace period is over. It keeps going past single
+// failures so one broken row does not block the rest.
+func (us *UserService) PurgeDeletedAccounts() (int, error) {
+	var now = us.c.Now()
+	var cutoff = now.Add(-time.Hour * 24 * time.Duration(us.cfg.DeletionGraceDays))
+
+	userIDs, err := us.d.GetDeletedBefore(cutoff)
+	if err != nil {
+		return 0, errors.New("process failed")
+	}
+
+	var purged = 0
+	var failed = 0
+	for _, userID := range userIDs {
+		if err := us.eraseCredentials(userID); err != nil {
+			logrus.Error("service: purge account ", userID, " error:", err.Error())
+			failed++
+			continue
+		}
+
+		var avatarKey string
+		if us.cfg.PurgeMode == "delete" {
+			avatarKey, err = us.d.HardDelete(userID)
+		} else {
+			avatarKey, err = us.d.Anonymize(userID, now)
+		}
+
+		if err != nil {
+			logrus.Error("service: purge account ", userID, " error:", err.Error())
+			failed++
+			continue
+		}
+
+		// The account no longer links the avatar, a failure only leaves
+		// unreachable files behind.
+		if err := helper.DeleteAvatar(context.Background(), us.storage, avatarKey); err != nil {
+			logrus.Error("service: remove avatar of ", userID, " error:", err.Error())
+		}
+
+		us.audit(context.Background(), audit.Event{Action: "user.purged", TargetID: userID, After: map[string]any{"mode": us.cfg.PurgeMode}})
+		purged++
+	}
+
+	if failed > 0 {
+		return purged, fmt.Errorf("purge process failed for %d accounts", failed)
+	}
+
+	return purged, nil
+}
+
+// loginProof is what the first factor of a login proved besides the account.
+type loginProof int
+
+const (
+	proofNone loginProof = iota
+	// proofHP skips the step-up, a code sent to the HP the login was already
+	// proven with adds nothing.
+	proofHP
+	// proofMultiFactor skips the second factor and the step-up. A passkey the
+	// authenticator verified the user for is two factors already, and bound to
+	// our origin so it cannot be phished.
+	proofMultiFactor
+)
+
+// completeLogin is shared by every first factor: it checks the login risk and
+// asks for the second factor before the session is started.
+func (us *UserService) completeLogin(ctx context.Context, result *users.User, after map[string]any, proof loginProof) (*users.UserCredential, error) {
+	stepUp, err := us.checkLoginRisk(ctx, result, proof == proofNone)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.TOTPEnabled && proof != proofMultiFactor {
+		mfaToken := us.j.GenerateChallengeToken(result.ID, mfaChallengePurpose, mfaChallengeTTL)
+		if mfaToken == "" {
+			return nil, errors.New("token process failed")
+		}
+
+		response := new(users.UserCredential)
+		response.Nama = result.Nama
+		response.MFARequired = true
+		response.MFAToken = mfaToken
+
+		return response, nil
+	}
+
+	if stepUp {
+		return us.stepUpChallenge(result)
+	}
+
+	us.audit(ctx, audit.Event{ActorID: result.ID, Action: "user.login", TargetID: result.ID, After: after})
+
+	return us.credential(ctx, result)
+}
+
+// getUser looks the account up inside the organization of ctx, if any.
+func (us *UserService) getUser(ctx context.Context, userID string) (*users.User, error) {
+	result, err := us.d.WithContext(ctx).GetByID(userID)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return nil, errors.New("data not found")
@@ -45,15 +772,141 @@ func (us *UserService) Login(hp string, password string) (*users.UserCredential,
 		return nil, errors.New("process failed")
 	}
 
-	tokenData := us.j.GenerateJWT(result.ID)
+	return result, nil
+}
+
+// credential starts a new session for the user and issues its tokens.
+func (us *UserService) credential(ctx context.Context, user *users.User) (*users.UserCredential, error) {
+	session, refreshToken, err := us.newSession(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return us.issueTokens(user, session.ID, refreshToken)
+}
+
+func (us *UserService) issueTokens(user *users.User, sessionID string, refreshToken string) (*users.UserCredential, error) {
+	var role = user.Role
+	if role == "" {
+		role = users.RoleUser
+	}
+
+	tokenData := us.j.GenerateJWT(helper.Principal{UserID: user.ID, Roles: []string{role}, SessionID: sessionID})
 
 	if tokenData == nil {
 		return nil, errors.New("token process failed")
 	}
+	tokenData["refresh_token"] = refreshToken
 
 	response := new(users.UserCredential)
-	response.Nama = result.Nama
+	response.Nama = user.Nama
 	response.Access = tokenData
 
 	return response, nil
 }
+
+// verifySecondFactor accepts either a current TOTP code or one of the unused
+// recovery codes. TOTP codes are bound to their time step so the same code
+// cannot be replayed while it is still inside the accepted window.
+func (us *UserService) verifySecondFactor(user *users.User, code string) error {
+	if step := helper.ValidateTOTP(user.TOTPSecret, code, us.c.Now()); step >= 0 {
+		if step <= user.TOTPLastStep {
+			return errors.New("invalid code")
+		}
+
+		user.TOTPLastStep = step
+		if err := us.d.UpdateTOTP(*user); err != nil {
+			return errors.New("process failed")
+		}
+
+		return nil
+	}
+
+	if err := us.d.UseRecoveryCode(user.ID, helper.HashToken(normalizeRecoveryCode(code))); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("invalid code")
+		}
+		return errors.New("process failed")
+	}
+
+	return nil
+}
+
+func (us *UserService) newRecoveryCodes(userID string) ([]string, error) {
+	var codes = make([]string, 0, recoveryCodeCount)
+	var hashes = make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, errors.New("recovery code generator failed")
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, helper.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := us.d.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return codes, nil
+}
+
+// audit failures are logged instead of returned, the change they describe has
+// already been committed at this point.
+func (us *UserService) audit(ctx context.Context, event audit.Event) {
+	if err := us.a.Record(ctx, event); err != nil {
+		logrus.Error("service: audit record error:", err.Error())
+	}
+}
+
+// loginFailed keeps the reason out of the response, where it would tell an
+// attacker which accounts exist, but in the audit log for whoever investigates.
+func (us *UserService) loginFailed(ctx context.Context, userID string, reason string) {
+	us.audit(ctx, audit.Event{ActorID: userID, Action: "user.login_failed", TargetID: userID, After: map[string]any{"reason": reason}})
+}
+
+var reusedPasswordViolation = helper.PasswordViolation{
+	Rule:    "reused",
+	Message: "password was used recently",
+}
+
+// checkPassword applies the password policy, plus the reuse rule for existing
+// accounts since only the service can reach their password history.
+func (us *UserService) checkPassword(userID string, password string, personal []string) error {
+	var violations = us.p.Check(password, personal)
+
+	if userID != "" && us.p.History > 0 {
+		history, err := us.d.GetPasswordHistory(userID, us.p.History)
+		if err != nil {
+			return errors.New("process failed")
+		}
+
+		for _, passwordHash := range history {
+			if helper.ComparePassword(passwordHash, password) {
+				violations = append(violations, reusedPasswordViolation)
+				break
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &helper.PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+func generateRecoveryCode() (string, error) {
+	var raw = make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	var encoded = strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+	return encoded[0:4] + "-" + encoded[4:8] + "-" + encoded[8:12] + "-" + encoded[12:16], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
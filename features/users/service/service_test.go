@@ -1,10 +1,17 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"strings"
+	"test/configs"
+	"test/features/audit"
+	auditMocks "test/features/audit/mocks"
 	"test/features/users"
 	"test/features/users/mocks"
+	realHelper "test/helper"
 	helper "test/helper/mocks"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 
@@ -17,19 +24,36 @@ func TestRegister(t *testing.T) {
 	generator := helper.NewGeneratorInterface(t)
 	jwt := helper.NewJWTInterface(t)
 	data := mocks.NewUserDataInterface(t)
-	service := New(data, generator, jwt)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, jwt, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny"})
 	newUser := users.User{
 		Nama:     "dida",
 		HP:       "123",
-		Password: "didadejan123",
+		Password: "Rahasia#2023",
 	}
 
 	t.Run("Success insert", func(t *testing.T) {
+		data.On("GetByHP", newUser.HP).Return(nil, errors.New("record not found")).Once()
 		generator.On("GenerateUUID").Return("randomUUID", nil).Once()
 		newUser.ID = "randomUUID"
-		data.On("Insert", newUser).Return(&newUser, nil).Once()
+		data.On("Insert", mock.MatchedBy(func(u users.User) bool {
+			return u.ID == newUser.ID && u.Password != newUser.Password && realHelper.ComparePassword(u.Password, newUser.Password)
+		})).Return(&newUser, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: newUser.ID, Action: "user.registered", TargetID: newUser.ID, After: map[string]any{"role": "user", "hp_verified": false}}).Return(nil).Once()
+		clock.On("Now").Return(time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)).Once()
+		data.On("GetOneTimeCode", newUser.ID, "hp_verification").Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateOTP", 6).Return("123456", nil).Once()
+		data.On("InsertOneTimeCode", mock.MatchedBy(func(c users.OneTimeCode) bool {
+			return c.UserID == newUser.ID && c.Purpose == "hp_verification"
+		})).Return(nil).Once()
+		notifier.On("Send", newUser.HP, mock.MatchedBy(func(message string) bool {
+			return strings.Contains(message, "123456")
+		})).Return(nil).Once()
 
-		result, err := service.Register(newUser)
+		result, err := service.Register(context.Background(), newUser)
 		assert.Nil(t, err)
 		assert.Equal(t, newUser.ID, result.ID)
 		assert.Equal(t, newUser.Nama, result.Nama)
@@ -37,34 +61,82 @@ func TestRegister(t *testing.T) {
 		data.AssertExpectations(t)
 	})
 
+	t.Run("Password policy violation", func(t *testing.T) {
+		weakUser := newUser
+		weakUser.Password = "dida1"
+
+		result, err := service.Register(context.Background(), weakUser)
+		var policyErr *realHelper.PasswordPolicyError
+		assert.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, []string{"min_length", "character_classes", "personal_info"}, []string{
+			policyErr.Violations[0].Rule,
+			policyErr.Violations[1].Rule,
+			policyErr.Violations[2].Rule,
+		})
+		assert.Nil(t, result)
+	})
+
+	t.Run("HP already used", func(t *testing.T) {
+		data.On("GetByHP", newUser.HP).Return(&users.User{ID: "otherUserID", HP: newUser.HP}, nil).Once()
+
+		result, err := service.Register(context.Background(), newUser)
+		assert.EqualError(t, err, "hp already used")
+		assert.Nil(t, result)
+	})
+
 	t.Run("Generate failed", func(t *testing.T) {
+		data.On("GetByHP", newUser.HP).Return(nil, errors.New("record not found")).Once()
 		generator.On("GenerateUUID").Return("", errors.New("some error on generator")).Once()
 
-		result, err := service.Register(newUser)
+		result, err := service.Register(context.Background(), newUser)
 		assert.Error(t, err)
 		assert.EqualError(t, err, "id generator failed")
 		assert.Nil(t, result)
 		generator.AssertExpectations(t)
 	})
+
+	t.Run("Registration closed", func(t *testing.T) {
+		closedService := New(data, generator, jwt, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny", InviteOnly: true})
+
+		result, err := closedService.Register(context.Background(), newUser)
+		assert.EqualError(t, err, "registration closed")
+		assert.Nil(t, result)
+	})
 }
 
 func TestLogin(t *testing.T) {
 	generator := helper.NewGeneratorInterface(t)
 	j := helper.NewJWTInterface(t)
 	data := mocks.NewUserDataInterface(t)
-	service := New(data, generator, j)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+	passwordHash, _ := realHelper.HashPassword("Rahasia#2023")
+	verifiedAt := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
 	userData := users.User{
-		ID:       "randomUserID",
-		Nama:     "dida",
-		HP:       "123",
-		Password: "didadejan123",
+		ID:           "randomUserID",
+		Nama:         "dida",
+		HP:           "123",
+		Password:     passwordHash,
+		HPVerifiedAt: &verifiedAt,
 	}
 
 	t.Run("success login", func(t *testing.T) {
 		jwtResult := map[string]any{"access_token": "randomAccessToken"}
-		data.On("Login", userData.HP, userData.Password).Return(&userData, nil)
-		j.On("GenerateJWT", mock.Anything).Return(jwtResult)
-		result, err := service.Login(userData.HP, userData.Password)
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{}, nil).Once()
+		clock.On("Now").Return(verifiedAt).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(verifiedAt).Once()
+		data.On("InsertSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.ID == "randomSessionID" && s.UserID == userData.ID && s.RefreshTokenHash != "" && s.ExpiresAt.Equal(verifiedAt.Add(time.Hour*24*30))
+		})).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+		result, err := service.Login(context.Background(), userData.HP, "Rahasia#2023")
 
 		data.AssertExpectations(t)
 		j.AssertExpectations(t)
@@ -73,5 +145,1895 @@ func TestLogin(t *testing.T) {
 		assert.NotNil(t, result)
 		assert.Equal(t, "dida", result.Nama)
 		assert.Equal(t, jwtResult, result.Access)
+		assert.True(t, strings.HasPrefix(jwtResult["refresh_token"].(string), "randomSessionID."))
+	})
+
+	t.Run("mfa required", func(t *testing.T) {
+		mfaUser := userData
+		mfaUser.TOTPEnabled = true
+		data.On("GetByHP", userData.HP).Return(&mfaUser, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{}, nil).Once()
+		clock.On("Now").Return(verifiedAt).Once()
+		j.On("GenerateChallengeToken", userData.ID, "mfa", time.Minute*5).Return("randomMFAToken").Once()
+		result, err := service.Login(context.Background(), userData.HP, "Rahasia#2023")
+
+		assert.Nil(t, err)
+		assert.True(t, result.MFARequired)
+		assert.Equal(t, "randomMFAToken", result.MFAToken)
+		assert.Nil(t, result.Access)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_failed", TargetID: userData.ID, After: map[string]any{"reason": "invalid password"}}).Return(nil).Once()
+		result, err := service.Login(context.Background(), userData.HP, "wrongpassword")
+
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		data.On("GetByHP", "999").Return(nil, errors.New("record not found")).Once()
+		auditor.On("Record", mock.Anything, audit.Event{Action: "user.login_failed", After: map[string]any{"reason": "unknown account"}}).Return(nil).Once()
+		result, err := service.Login(context.Background(), "999", "Rahasia#2023")
+
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+	})
+
+	t.Run("unverified account", func(t *testing.T) {
+		unverifiedUser := userData
+		unverifiedUser.HPVerifiedAt = nil
+		data.On("GetByHP", userData.HP).Return(&unverifiedUser, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_failed", TargetID: userData.ID, After: map[string]any{"reason": "account not verified"}}).Return(nil).Once()
+		result, err := service.Login(context.Background(), userData.HP, "Rahasia#2023")
+
+		assert.EqualError(t, err, "account not verified")
+		assert.Nil(t, result)
+	})
+
+	t.Run("deactivated account", func(t *testing.T) {
+		deactivatedUser := userData
+		deactivatedUser.DeactivatedAt = &verifiedAt
+		data.On("GetByHP", userData.HP).Return(&deactivatedUser, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_failed", TargetID: userData.ID, After: map[string]any{"reason": "account deactivated"}}).Return(nil).Once()
+		result, err := service.Login(context.Background(), userData.HP, "Rahasia#2023")
+
+		assert.EqualError(t, err, "account deactivated")
+		assert.Nil(t, result)
+	})
+
+	t.Run("unverified account allowed by policy", func(t *testing.T) {
+		unverifiedUser := userData
+		unverifiedUser.HPVerifiedAt = nil
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		allowService := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "allow"})
+		data.On("GetByHP", userData.HP).Return(&unverifiedUser, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{}, nil).Once()
+		clock.On("Now").Return(verifiedAt).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(verifiedAt).Once()
+		data.On("InsertSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.ID == "randomSessionID" && s.UserID == userData.ID && s.RefreshTokenHash != "" && s.ExpiresAt.Equal(verifiedAt.Add(time.Hour*24*30))
+		})).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+		result, err := allowService.Login(context.Background(), userData.HP, "Rahasia#2023")
+
+		assert.Nil(t, err)
+		assert.Equal(t, jwtResult, result.Access)
+	})
+
+	t.Run("legacy plain text password is upgraded", func(t *testing.T) {
+		legacyUser := userData
+		legacyUser.Password = "Rahasia#2023"
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		data.On("GetByHP", userData.HP).Return(&legacyUser, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{}, nil).Once()
+		clock.On("Now").Return(verifiedAt).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID}).Return(nil).Once()
+		data.On("UpdatePassword", userData.ID, mock.MatchedBy(func(hash string) bool {
+			return realHelper.IsPasswordHash(hash) && realHelper.ComparePassword(hash, "Rahasia#2023")
+		})).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(verifiedAt).Once()
+		data.On("InsertSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.ID == "randomSessionID" && s.UserID == userData.ID && s.RefreshTokenHash != "" && s.ExpiresAt.Equal(verifiedAt.Add(time.Hour*24*30))
+		})).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+		result, err := service.Login(context.Background(), userData.HP, "Rahasia#2023")
+
+		assert.Nil(t, err)
+		assert.Equal(t, jwtResult, result.Access)
+	})
+}
+
+func TestVerifyMFA(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	secret, _ := realHelper.GenerateTOTPSecret()
+	userData := users.User{
+		ID:          "randomUserID",
+		Nama:        "dida",
+		HP:          "123",
+		TOTPSecret:  secret,
+		TOTPEnabled: true,
+	}
+
+	t.Run("success with totp code", func(t *testing.T) {
+		user := userData
+		code, _ := realHelper.TOTPCode(secret, realHelper.TOTPStep(now))
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		j.On("ParseChallengeToken", "randomMFAToken", "mfa").Return(userData.ID, nil).Once()
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "mfa_failures").Return(nil, errors.New("record not found")).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("UpdateTOTP", mock.MatchedBy(func(u users.User) bool {
+			return u.TOTPLastStep == realHelper.TOTPStep(now)
+		})).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID, After: map[string]any{"mfa": true}}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.ID == "randomSessionID" && s.UserID == userData.ID && s.RefreshTokenHash != "" && s.ExpiresAt.Equal(now.Add(time.Hour*24*30))
+		})).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.VerifyMFA(context.Background(), "randomMFAToken", code)
+		assert.Nil(t, err)
+		assert.Equal(t, jwtResult, result.Access)
+	})
+
+	t.Run("replayed totp code", func(t *testing.T) {
+		user := userData
+		user.TOTPLastStep = realHelper.TOTPStep(now)
+		code, _ := realHelper.TOTPCode(secret, realHelper.TOTPStep(now))
+		j.On("ParseChallengeToken", "randomMFAToken", "mfa").Return(userData.ID, nil).Once()
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "mfa_failures").Return(nil, errors.New("record not found")).Once()
+		clock.On("Now").Return(now.Add(time.Second * 10)).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_failed", TargetID: userData.ID, After: map[string]any{"reason": "invalid second factor"}}).Return(nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertOneTimeCode", users.OneTimeCode{UserID: userData.ID, Purpose: "mfa_failures", Attempts: 1, ExpiresAt: now.Add(time.Minute * 5), CreatedAt: now}).Return(nil).Once()
+
+		result, err := service.VerifyMFA(context.Background(), "randomMFAToken", code)
+		assert.EqualError(t, err, "invalid code")
+		assert.Nil(t, result)
+	})
+
+	t.Run("expired totp code falls back to recovery codes", func(t *testing.T) {
+		user := userData
+		code, _ := realHelper.TOTPCode(secret, realHelper.TOTPStep(now))
+		j.On("ParseChallengeToken", "randomMFAToken", "mfa").Return(userData.ID, nil).Once()
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "mfa_failures").Return(nil, errors.New("record not found")).Once()
+		clock.On("Now").Return(now.Add(time.Minute * 2)).Once()
+		data.On("UseRecoveryCode", userData.ID, realHelper.HashToken(code)).Return(errors.New("data not found")).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_failed", TargetID: userData.ID, After: map[string]any{"reason": "invalid second factor"}}).Return(nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertOneTimeCode", users.OneTimeCode{UserID: userData.ID, Purpose: "mfa_failures", Attempts: 1, ExpiresAt: now.Add(time.Minute * 5), CreatedAt: now}).Return(nil).Once()
+
+		result, err := service.VerifyMFA(context.Background(), "randomMFAToken", code)
+		assert.EqualError(t, err, "invalid code")
+		assert.Nil(t, result)
+	})
+
+	t.Run("success with recovery code", func(t *testing.T) {
+		user := userData
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		j.On("ParseChallengeToken", "randomMFAToken", "mfa").Return(userData.ID, nil).Once()
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "mfa_failures").Return(nil, errors.New("record not found")).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("UseRecoveryCode", userData.ID, realHelper.HashToken("abcdefghijklmnop")).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID, After: map[string]any{"mfa": true}}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.ID == "randomSessionID" && s.UserID == userData.ID && s.RefreshTokenHash != "" && s.ExpiresAt.Equal(now.Add(time.Hour*24*30))
+		})).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.VerifyMFA(context.Background(), "randomMFAToken", "ABCD-EFGH-IJKL-MNOP")
+		assert.Nil(t, err)
+		assert.Equal(t, jwtResult, result.Access)
+	})
+
+	t.Run("wrong code counted", func(t *testing.T) {
+		user := userData
+		failures := users.OneTimeCode{ID: 7, UserID: userData.ID, Purpose: "mfa_failures", Attempts: 2, ExpiresAt: now.Add(time.Minute * 4), CreatedAt: now.Add(-time.Minute)}
+		j.On("ParseChallengeToken", "randomMFAToken", "mfa").Return(userData.ID, nil).Once()
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "mfa_failures").Return(&failures, nil).Once()
+		clock.On("Now").Return(now).Twice()
+		data.On("UseRecoveryCode", userData.ID, realHelper.HashToken("000000")).Return(errors.New("data not found")).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_failed", TargetID: userData.ID, After: map[string]any{"reason": "invalid second factor"}}).Return(nil).Once()
+		data.On("UpdateOneTimeCode", mock.MatchedBy(func(c users.OneTimeCode) bool {
+			return c.ID == 7 && c.Attempts == 3 && c.UsedAt == nil
+		})).Return(nil).Once()
+
+		result, err := service.VerifyMFA(context.Background(), "randomMFAToken", "000000")
+		assert.EqualError(t, err, "invalid code")
+		assert.Nil(t, result)
+	})
+
+	t.Run("right code after too many wrong ones", func(t *testing.T) {
+		user := userData
+		code, _ := realHelper.TOTPCode(secret, realHelper.TOTPStep(now))
+		failures := users.OneTimeCode{ID: 7, UserID: userData.ID, Purpose: "mfa_failures", Attempts: 5, ExpiresAt: now.Add(time.Minute * 4), CreatedAt: now.Add(-time.Minute)}
+		j.On("ParseChallengeToken", "randomMFAToken", "mfa").Return(userData.ID, nil).Once()
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "mfa_failures").Return(&failures, nil).Once()
+		clock.On("Now").Return(now).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_failed", TargetID: userData.ID, After: map[string]any{"reason": "too many second factor attempts"}}).Return(nil).Once()
+
+		result, err := service.VerifyMFA(context.Background(), "randomMFAToken", code)
+		assert.EqualError(t, err, "invalid code")
+		assert.Nil(t, result)
+	})
+
+	t.Run("success clears the failures", func(t *testing.T) {
+		user := userData
+		code, _ := realHelper.TOTPCode(secret, realHelper.TOTPStep(now))
+		failures := users.OneTimeCode{ID: 7, UserID: userData.ID, Purpose: "mfa_failures", Attempts: 4, ExpiresAt: now.Add(time.Minute * 4), CreatedAt: now.Add(-time.Minute)}
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		j.On("ParseChallengeToken", "randomMFAToken", "mfa").Return(userData.ID, nil).Once()
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "mfa_failures").Return(&failures, nil).Once()
+		clock.On("Now").Return(now).Times(4)
+		data.On("UpdateTOTP", mock.Anything).Return(nil).Once()
+		data.On("UpdateOneTimeCode", mock.MatchedBy(func(c users.OneTimeCode) bool {
+			return c.ID == 7 && c.UsedAt != nil
+		})).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID, After: map[string]any{"mfa": true}}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		data.On("InsertSession", mock.Anything).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.VerifyMFA(context.Background(), "randomMFAToken", code)
+		assert.Nil(t, err)
+		assert.Equal(t, jwtResult, result.Access)
+	})
+
+	t.Run("invalid mfa token", func(t *testing.T) {
+		j.On("ParseChallengeToken", "expiredToken", "mfa").Return("", errors.New("token is expired")).Once()
+
+		result, err := service.VerifyMFA(context.Background(), "expiredToken", "123456")
+		assert.EqualError(t, err, "invalid mfa token")
+		assert.Nil(t, result)
+	})
+}
+
+func TestTOTPEnrollment(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	userData := users.User{
+		ID:   "randomUserID",
+		Nama: "dida",
+		HP:   "123",
+	}
+
+	var enrollment *users.TOTPEnrollment
+
+	t.Run("success enroll", func(t *testing.T) {
+		user := userData
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+		data.On("UpdateTOTP", mock.MatchedBy(func(u users.User) bool {
+			return u.TOTPSecret != "" && !u.TOTPEnabled
+		})).Return(nil).Once()
+
+		result, err := service.EnrollTOTP(context.Background(), userData.ID)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, result.Secret)
+		assert.Contains(t, result.URI, "otpauth://totp/Restfull:123?")
+		assert.Contains(t, result.URI, "secret="+result.Secret)
+		assert.Equal(t, []byte("\x89PNG"), result.QRCode[:4])
+		enrollment = result
+	})
+
+	t.Run("confirm with wrong code", func(t *testing.T) {
+		user := userData
+		user.TOTPSecret = enrollment.Secret
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		result, err := service.ConfirmTOTP(context.Background(), userData.ID, "000000x")
+		assert.EqualError(t, err, "invalid code")
+		assert.Nil(t, result)
+	})
+
+	t.Run("success confirm", func(t *testing.T) {
+		user := userData
+		user.TOTPSecret = enrollment.Secret
+		code, _ := realHelper.TOTPCode(enrollment.Secret, realHelper.TOTPStep(now)-1)
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("UpdateTOTP", mock.MatchedBy(func(u users.User) bool {
+			return u.TOTPEnabled && u.TOTPLastStep == realHelper.TOTPStep(now)-1
+		})).Return(nil).Once()
+		data.On("ReplaceRecoveryCodes", userData.ID, mock.MatchedBy(func(hashes []string) bool {
+			return len(hashes) == 10
+		})).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.mfa_enabled", TargetID: userData.ID, Before: map[string]any{"totp_enabled": false}, After: map[string]any{"totp_enabled": true}}).Return(nil).Once()
+
+		result, err := service.ConfirmTOTP(context.Background(), userData.ID, code)
+		assert.Nil(t, err)
+		assert.Len(t, result, 10)
+		assert.Len(t, result[0], 19)
+	})
+
+	t.Run("enroll when already enabled", func(t *testing.T) {
+		user := userData
+		user.TOTPEnabled = true
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+
+		result, err := service.EnrollTOTP(context.Background(), userData.ID)
+		assert.EqualError(t, err, "mfa already enabled")
+		assert.Nil(t, result)
+	})
+
+	t.Run("success disable", func(t *testing.T) {
+		user := userData
+		user.TOTPSecret = enrollment.Secret
+		user.TOTPEnabled = true
+		code, _ := realHelper.TOTPCode(enrollment.Secret, realHelper.TOTPStep(now))
+		data.On("GetByID", userData.ID).Return(&user, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("UpdateTOTP", mock.MatchedBy(func(u users.User) bool {
+			return u.TOTPEnabled
+		})).Return(nil).Once()
+		data.On("UpdateTOTP", mock.MatchedBy(func(u users.User) bool {
+			return !u.TOTPEnabled && u.TOTPSecret == ""
+		})).Return(nil).Once()
+		data.On("ReplaceRecoveryCodes", userData.ID, []string(nil)).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.mfa_disabled", TargetID: userData.ID, Before: map[string]any{"totp_enabled": true}, After: map[string]any{"totp_enabled": false}}).Return(nil).Once()
+
+		err := service.DisableTOTP(context.Background(), userData.ID, code)
+		assert.Nil(t, err)
+	})
+}
+
+func TestForgotPassword(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	userData := users.User{
+		ID:   "randomUserID",
+		Nama: "dida",
+		HP:   "123",
+	}
+
+	t.Run("success send code", func(t *testing.T) {
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("GetOneTimeCode", userData.ID, "password_reset").Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateOTP", 6).Return("123456", nil).Once()
+		data.On("InsertOneTimeCode", mock.MatchedBy(func(r users.OneTimeCode) bool {
+			return r.UserID == userData.ID && r.Purpose == "password_reset" && r.CodeHash != "123456" &&
+				realHelper.ComparePassword(r.CodeHash, "123456") && r.ExpiresAt.Equal(now.Add(time.Minute*10))
+		})).Return(nil).Once()
+		notifier.On("Send", userData.HP, mock.MatchedBy(func(message string) bool {
+			return strings.Contains(message, "123456")
+		})).Return(nil).Once()
+
+		err := service.ForgotPassword(context.Background(), userData.HP)
+		assert.Nil(t, err)
+	})
+
+	t.Run("unknown number", func(t *testing.T) {
+		data.On("GetByHP", "999").Return(nil, errors.New("record not found")).Once()
+
+		err := service.ForgotPassword(context.Background(), "999")
+		assert.Nil(t, err)
+	})
+
+	t.Run("requested again too soon looks like success", func(t *testing.T) {
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		clock.On("Now").Return(now.Add(time.Second * 30)).Once()
+		data.On("GetOneTimeCode", userData.ID, "password_reset").Return(&users.OneTimeCode{UserID: userData.ID, Purpose: "password_reset", CreatedAt: now}, nil).Once()
+
+		err := service.ForgotPassword(context.Background(), userData.HP)
+		assert.Nil(t, err)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	codeHash, _ := realHelper.HashPassword("123456")
+	userData := users.User{
+		ID:   "randomUserID",
+		Nama: "dida",
+		HP:   "123",
+	}
+	resetData := users.OneTimeCode{
+		ID:        1,
+		UserID:    userData.ID,
+		Purpose:   "password_reset",
+		CodeHash:  codeHash,
+		ExpiresAt: now.Add(time.Minute * 10),
+		CreatedAt: now,
+	}
+	data.On("GetPasswordHistory", userData.ID, 3).Return([]string{}, nil)
+
+	t.Run("success reset", func(t *testing.T) {
+		reset := resetData
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "password_reset").Return(&reset, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		data.On("UpdateOneTimeCode", mock.MatchedBy(func(r users.OneTimeCode) bool {
+			return r.UsedAt != nil
+		})).Return(nil).Once()
+		data.On("UpdatePassword", userData.ID, mock.MatchedBy(func(hash string) bool {
+			return realHelper.ComparePassword(hash, "Baru#Sekali9")
+		})).Return(nil).Once()
+		data.On("RevokeTokens", userData.ID, now.Add(time.Minute)).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.password_reset", TargetID: userData.ID}).Return(nil).Once()
+
+		err := service.ResetPassword(context.Background(), userData.HP, "123456", "Baru#Sekali9")
+		assert.Nil(t, err)
+	})
+
+	t.Run("wrong code counts attempt", func(t *testing.T) {
+		reset := resetData
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "password_reset").Return(&reset, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		data.On("UpdateOneTimeCode", mock.MatchedBy(func(r users.OneTimeCode) bool {
+			return r.Attempts == 1 && r.UsedAt == nil
+		})).Return(nil).Once()
+
+		err := service.ResetPassword(context.Background(), userData.HP, "654321", "Baru#Sekali9")
+		assert.EqualError(t, err, "invalid code")
+	})
+
+	t.Run("expired code", func(t *testing.T) {
+		reset := resetData
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "password_reset").Return(&reset, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute * 10)).Once()
+
+		err := service.ResetPassword(context.Background(), userData.HP, "123456", "Baru#Sekali9")
+		assert.EqualError(t, err, "invalid code")
+	})
+
+	t.Run("too many attempts", func(t *testing.T) {
+		reset := resetData
+		reset.Attempts = 5
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "password_reset").Return(&reset, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+
+		err := service.ResetPassword(context.Background(), userData.HP, "123456", "Baru#Sekali9")
+		assert.EqualError(t, err, "invalid code")
+	})
+}
+
+func TestValidateSession(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	revokedAt := time.Date(2023, 9, 1, 10, 0, 0, 500, time.UTC)
+	userData := users.User{
+		ID:              "randomUserID",
+		TokensRevokedAt: &revokedAt,
+	}
+
+	t.Run("token issued after revocation", func(t *testing.T) {
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+
+		err := service.ValidateSession(realHelper.Principal{UserID: userData.ID, IssuedAt: revokedAt.Truncate(time.Second)})
+		assert.Nil(t, err)
+	})
+
+	t.Run("token issued before revocation", func(t *testing.T) {
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+
+		err := service.ValidateSession(realHelper.Principal{UserID: userData.ID, IssuedAt: revokedAt.Add(-time.Hour)})
+		assert.EqualError(t, err, "session revoked")
+	})
+
+	t.Run("active session is touched", func(t *testing.T) {
+		now := revokedAt.Add(time.Hour)
+		session := users.Session{ID: "randomSessionID", UserID: userData.ID, LastSeenAt: now.Add(-time.Minute * 5), ExpiresAt: now.Add(time.Hour)}
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("GetSession", "randomSessionID").Return(&session, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("UpdateSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.ID == "randomSessionID" && s.LastSeenAt.Equal(now)
+		})).Return(nil).Once()
+
+		err := service.ValidateSession(realHelper.Principal{UserID: userData.ID, SessionID: "randomSessionID", IssuedAt: now})
+		assert.Nil(t, err)
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		now := revokedAt.Add(time.Hour)
+		session := users.Session{ID: "randomSessionID", UserID: userData.ID, LastSeenAt: now, ExpiresAt: now.Add(time.Hour), RevokedAt: &now}
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("GetSession", "randomSessionID").Return(&session, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		err := service.ValidateSession(realHelper.Principal{UserID: userData.ID, SessionID: "randomSessionID", IssuedAt: now})
+		assert.EqualError(t, err, "session revoked")
+	})
+}
+
+func TestSessions(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	userData := users.User{ID: "randomUserID", Nama: "dida", HP: "123"}
+	session := users.Session{
+		ID:               "randomSessionID",
+		UserID:           userData.ID,
+		RefreshTokenHash: realHelper.HashToken("randomSecret"),
+		CreatedAt:        now.Add(-time.Hour),
+		LastSeenAt:       now.Add(-time.Hour),
+		ExpiresAt:        now.Add(time.Hour),
+	}
+
+	t.Run("success refresh", func(t *testing.T) {
+		current := session
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		data.On("GetSession", session.ID).Return(&current, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("UpdateSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.ID == session.ID && s.RefreshTokenHash != session.RefreshTokenHash && s.ExpiresAt.Equal(now.Add(time.Hour*24*30))
+		})).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: session.ID}).Return(jwtResult).Once()
+
+		result, err := service.RefreshSession(context.Background(), "randomSessionID.randomSecret")
+		assert.Nil(t, err)
+		assert.Equal(t, jwtResult, result.Access)
+		assert.NotEqual(t, "randomSessionID.randomSecret", jwtResult["refresh_token"])
+	})
+
+	t.Run("reused refresh token revokes the session", func(t *testing.T) {
+		current := session
+		data.On("GetSession", session.ID).Return(&current, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("RevokeSession", userData.ID, session.ID, now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.session_reuse_detected", TargetID: userData.ID, After: map[string]any{"session_id": session.ID}}).Return(nil).Once()
+
+		result, err := service.RefreshSession(context.Background(), "randomSessionID.oldSecret")
+		assert.EqualError(t, err, "invalid refresh token")
+		assert.Nil(t, result)
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		current := session
+		data.On("GetSession", session.ID).Return(&current, nil).Once()
+		clock.On("Now").Return(now.Add(time.Hour * 2)).Once()
+
+		result, err := service.RefreshSession(context.Background(), "randomSessionID.randomSecret")
+		assert.EqualError(t, err, "invalid refresh token")
+		assert.Nil(t, result)
+	})
+
+	t.Run("malformed refresh token", func(t *testing.T) {
+		result, err := service.RefreshSession(context.Background(), "randomSecret")
+		assert.EqualError(t, err, "invalid refresh token")
+		assert.Nil(t, result)
+	})
+
+	t.Run("list sessions", func(t *testing.T) {
+		data.On("GetSessions", userData.ID, 50).Return([]users.Session{session}, nil).Once()
+
+		result, err := service.ListSessions(context.Background(), userData.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, []users.Session{session}, result)
+	})
+
+	t.Run("revoke session", func(t *testing.T) {
+		clock.On("Now").Return(now).Once()
+		data.On("RevokeSession", userData.ID, session.ID, now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.session_revoked", TargetID: userData.ID, After: map[string]any{"session_id": session.ID}}).Return(nil).Once()
+
+		err := service.RevokeSession(context.Background(), userData.ID, session.ID)
+		assert.Nil(t, err)
+	})
+
+	t.Run("revoke unknown session", func(t *testing.T) {
+		clock.On("Now").Return(now).Once()
+		data.On("RevokeSession", userData.ID, "otherSessionID", now).Return(errors.New("data not found")).Once()
+
+		err := service.RevokeSession(context.Background(), userData.ID, "otherSessionID")
+		assert.EqualError(t, err, "data not found")
+	})
+}
+
+func TestVerifyPhone(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny"})
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	codeHash, _ := realHelper.HashPassword("123456")
+	userData := users.User{
+		ID:   "randomUserID",
+		Nama: "dida",
+		HP:   "123",
+	}
+	codeData := users.OneTimeCode{
+		ID:        1,
+		UserID:    userData.ID,
+		Purpose:   "hp_verification",
+		CodeHash:  codeHash,
+		ExpiresAt: now.Add(time.Minute * 10),
+		CreatedAt: now,
+	}
+
+	t.Run("success verify", func(t *testing.T) {
+		code := codeData
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "hp_verification").Return(&code, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		data.On("UpdateOneTimeCode", mock.MatchedBy(func(c users.OneTimeCode) bool {
+			return c.UsedAt != nil
+		})).Return(nil).Once()
+		data.On("SetHPVerified", userData.ID, now.Add(time.Minute)).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.hp_verified", TargetID: userData.ID, Before: map[string]any{"hp_verified": false}, After: map[string]any{"hp_verified": true}}).Return(nil).Once()
+
+		err := service.VerifyPhone(context.Background(), userData.HP, "123456")
+		assert.Nil(t, err)
+	})
+
+	t.Run("already verified", func(t *testing.T) {
+		verifiedUser := userData
+		verifiedUser.HPVerifiedAt = &now
+		data.On("GetByHP", userData.HP).Return(&verifiedUser, nil).Once()
+
+		err := service.VerifyPhone(context.Background(), userData.HP, "123456")
+		assert.EqualError(t, err, "invalid code")
+	})
+
+	t.Run("unknown number", func(t *testing.T) {
+		data.On("GetByHP", "999").Return(nil, errors.New("record not found")).Once()
+
+		err := service.VerifyPhone(context.Background(), "999", "123456")
+		assert.EqualError(t, err, "invalid code")
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		code := codeData
+		code.Attempts = 4
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "hp_verification").Return(&code, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		data.On("UpdateOneTimeCode", mock.MatchedBy(func(c users.OneTimeCode) bool {
+			return c.Attempts == 5 && c.UsedAt == nil
+		})).Return(nil).Once()
+
+		err := service.VerifyPhone(context.Background(), userData.HP, "000000")
+		assert.EqualError(t, err, "invalid code")
+	})
+
+	t.Run("resend after cooldown", func(t *testing.T) {
+		code := codeData
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute * 2)).Once()
+		data.On("GetOneTimeCode", userData.ID, "hp_verification").Return(&code, nil).Once()
+		generator.On("GenerateOTP", 6).Return("654321", nil).Once()
+		data.On("InsertOneTimeCode", mock.MatchedBy(func(c users.OneTimeCode) bool {
+			return c.Purpose == "hp_verification" && realHelper.ComparePassword(c.CodeHash, "654321")
+		})).Return(nil).Once()
+		notifier.On("Send", userData.HP, mock.MatchedBy(func(message string) bool {
+			return strings.Contains(message, "654321")
+		})).Return(nil).Once()
+
+		err := service.ResendVerification(context.Background(), userData.HP)
+		assert.Nil(t, err)
+	})
+
+	t.Run("resend inside cooldown", func(t *testing.T) {
+		code := codeData
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		clock.On("Now").Return(now.Add(time.Second * 10)).Once()
+		data.On("GetOneTimeCode", userData.ID, "hp_verification").Return(&code, nil).Once()
+
+		err := service.ResendVerification(context.Background(), userData.HP)
+		assert.Nil(t, err)
+	})
+
+	t.Run("resend to a verified number", func(t *testing.T) {
+		verifiedUser := userData
+		verifiedUser.HPVerifiedAt = &now
+		data.On("GetByHP", userData.HP).Return(&verifiedUser, nil).Once()
+
+		err := service.ResendVerification(context.Background(), userData.HP)
+		assert.Nil(t, err)
+	})
+
+	t.Run("resend to an unknown number", func(t *testing.T) {
+		data.On("GetByHP", "999").Return(nil, errors.New("record not found")).Once()
+
+		err := service.ResendVerification(context.Background(), "999")
+		assert.Nil(t, err)
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	passwordHash, _ := realHelper.HashPassword("Rahasia#2023")
+	userData := users.User{
+		ID:       "randomUserID",
+		Nama:     "dida",
+		HP:       "123",
+		Password: passwordHash,
+	}
+	data.On("GetPasswordHistory", userData.ID, 3).Return([]string{passwordHash}, nil)
+
+	t.Run("success change", func(t *testing.T) {
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("UpdatePassword", userData.ID, mock.MatchedBy(func(hash string) bool {
+			return realHelper.ComparePassword(hash, "Baru#Sekali9")
+		})).Return(nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("RevokeTokens", userData.ID, now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.password_changed", TargetID: userData.ID}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.ID == "randomSessionID" && s.UserID == userData.ID && s.RefreshTokenHash != "" && s.ExpiresAt.Equal(now.Add(time.Hour*24*30))
+		})).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.ChangePassword(context.Background(), userData.ID, "Rahasia#2023", "Baru#Sekali9")
+		assert.Nil(t, err)
+		assert.Equal(t, jwtResult, result.Access)
+	})
+
+	t.Run("wrong current password", func(t *testing.T) {
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+
+		result, err := service.ChangePassword(context.Background(), userData.ID, "wrongpassword", "Baru#Sekali9")
+		assert.EqualError(t, err, "invalid current password")
+		assert.Nil(t, result)
+	})
+
+	t.Run("same password", func(t *testing.T) {
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+
+		result, err := service.ChangePassword(context.Background(), userData.ID, "Rahasia#2023", "Rahasia#2023")
+		assert.ErrorContains(t, err, "invalid password")
+		assert.Nil(t, result)
+	})
+
+	t.Run("recently used password", func(t *testing.T) {
+		oldHash, _ := realHelper.HashPassword("Lama#Sekali7")
+		historyData := mocks.NewUserDataInterface(t)
+		historyService := New(historyData, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{})
+		historyData.On("WithContext", mock.Anything).Return(historyData).Maybe()
+		historyData.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		historyData.On("GetPasswordHistory", userData.ID, 3).Return([]string{passwordHash, oldHash}, nil).Once()
+
+		result, err := historyService.ChangePassword(context.Background(), userData.ID, "Rahasia#2023", "Lama#Sekali7")
+		var policyErr *realHelper.PasswordPolicyError
+		assert.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, "reused", policyErr.Violations[0].Rule)
+		assert.Nil(t, result)
+	})
+
+	t.Run("audit failure does not fail the change", func(t *testing.T) {
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("UpdatePassword", userData.ID, mock.Anything).Return(nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("RevokeTokens", userData.ID, now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, mock.Anything).Return(errors.New("insert process failed")).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.ID == "randomSessionID" && s.UserID == userData.ID && s.RefreshTokenHash != "" && s.ExpiresAt.Equal(now.Add(time.Hour*24*30))
+		})).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.ChangePassword(context.Background(), userData.ID, "Rahasia#2023", "Baru#Sekali9")
+		assert.Nil(t, err)
+		assert.NotNil(t, result)
+	})
+}
+
+func TestDeleteAccount(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	passwordHash, _ := realHelper.HashPassword("Rahasia#2023")
+	userData := users.User{
+		ID:       "randomUserID",
+		Nama:     "dida",
+		HP:       "123",
+		Password: passwordHash,
+	}
+	owner := realHelper.Principal{UserID: userData.ID}
+	// linked signs in through a provider and has no password.
+	linked := users.User{ID: "linkedUserID", Nama: "dida"}
+	linkedOwner := realHelper.Principal{UserID: linked.ID, SessionID: "randomSessionID"}
+
+	t.Run("success delete", func(t *testing.T) {
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("SoftDelete", userData.ID).Return(nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("RevokeTokens", userData.ID, now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.deleted", TargetID: userData.ID, Before: map[string]any{"deleted": false}, After: map[string]any{"deleted": true}}).Return(nil).Once()
+
+		err := service.DeleteAccount(context.Background(), owner, "Rahasia#2023")
+		assert.Nil(t, err)
+	})
+
+	t.Run("wrong password confirmation", func(t *testing.T) {
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+
+		err := service.DeleteAccount(context.Background(), owner, "wrongpassword")
+		assert.EqualError(t, err, "invalid password confirmation")
+	})
+
+	t.Run("account without password signed in recently", func(t *testing.T) {
+		data.On("GetByID", linked.ID).Return(&linked, nil).Once()
+		data.On("GetSession", "randomSessionID").Return(&users.Session{ID: "randomSessionID", UserID: linked.ID, CreatedAt: now.Add(-time.Minute)}, nil).Once()
+		clock.On("Now").Return(now).Twice()
+		data.On("SoftDelete", linked.ID).Return(nil).Once()
+		data.On("RevokeTokens", linked.ID, now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: linked.ID, Action: "user.deleted", TargetID: linked.ID, Before: map[string]any{"deleted": false}, After: map[string]any{"deleted": true}}).Return(nil).Once()
+
+		err := service.DeleteAccount(context.Background(), linkedOwner, "")
+		assert.Nil(t, err)
+	})
+
+	t.Run("account without password signed in long ago", func(t *testing.T) {
+		data.On("GetByID", linked.ID).Return(&linked, nil).Once()
+		data.On("GetSession", "randomSessionID").Return(&users.Session{ID: "randomSessionID", UserID: linked.ID, CreatedAt: now.Add(-time.Hour)}, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		err := service.DeleteAccount(context.Background(), linkedOwner, "")
+		assert.EqualError(t, err, "reauthentication required")
+	})
+
+	t.Run("account without password using an API key", func(t *testing.T) {
+		data.On("GetByID", linked.ID).Return(&linked, nil).Once()
+
+		err := service.DeleteAccount(context.Background(), realHelper.Principal{UserID: linked.ID, APIKeyID: "randomKeyID"}, "")
+		assert.EqualError(t, err, "reauthentication required")
+	})
+}
+
+func TestDeactivate(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	// revoker stands for a feature handing out its own tokens.
+	revoker := mocks.NewRevocationHookInterface(t)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, []users.RevocationHookInterface{revoker}, nil, configs.ProgramConfig{})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	admin := realHelper.Principal{UserID: "adminID", Roles: []string{users.RoleAdmin}}
+	manager := realHelper.Principal{UserID: "managerID", Roles: []string{users.RoleUser}}
+
+	t.Run("success deactivate", func(t *testing.T) {
+		clock.On("Now").Return(now).Once()
+		data.On("Deactivate", "randomUserID", now).Return(nil).Once()
+		data.On("RevokeTokens", "randomUserID", now).Return(nil).Once()
+		revoker.On("RevokeUser", "randomUserID", now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "adminID", Action: "user.deactivated", TargetID: "randomUserID", Before: map[string]any{"deactivated": false}, After: map[string]any{"deactivated": true}}).Return(nil).Once()
+
+		err := service.Deactivate(context.Background(), admin, "randomUserID")
+		assert.Nil(t, err)
+	})
+
+	t.Run("deactivate own account", func(t *testing.T) {
+		err := service.Deactivate(context.Background(), admin, "adminID")
+		assert.EqualError(t, err, "cannot deactivate own account")
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		clock.On("Now").Return(now).Once()
+		data.On("Deactivate", "unknownID", now).Return(errors.New("data not found")).Once()
+
+		err := service.Deactivate(context.Background(), admin, "unknownID")
+		assert.EqualError(t, err, "data not found")
+	})
+
+	t.Run("success reactivate", func(t *testing.T) {
+		data.On("Reactivate", "randomUserID").Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "adminID", Action: "user.reactivated", TargetID: "randomUserID", Before: map[string]any{"deactivated": true}, After: map[string]any{"deactivated": false}}).Return(nil).Once()
+
+		err := service.Reactivate(context.Background(), admin, "randomUserID")
+		assert.Nil(t, err)
+	})
+
+	t.Run("manager deactivates a user", func(t *testing.T) {
+		data.On("GetByID", "randomUserID").Return(&users.User{ID: "randomUserID", Role: users.RoleUser}, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("Deactivate", "randomUserID", now).Return(nil).Once()
+		data.On("RevokeTokens", "randomUserID", now).Return(nil).Once()
+		revoker.On("RevokeUser", "randomUserID", now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "managerID", Action: "user.deactivated", TargetID: "randomUserID", Before: map[string]any{"deactivated": false}, After: map[string]any{"deactivated": true}}).Return(nil).Once()
+
+		err := service.Deactivate(context.Background(), manager, "randomUserID")
+		assert.Nil(t, err)
+	})
+
+	t.Run("manager deactivates an admin", func(t *testing.T) {
+		data.On("GetByID", "adminID").Return(&users.User{ID: "adminID", Role: users.RoleAdmin}, nil).Once()
+
+		err := service.Deactivate(context.Background(), manager, "adminID")
+		assert.EqualError(t, err, "forbidden")
+	})
+
+	t.Run("manager reactivates an admin", func(t *testing.T) {
+		data.On("GetByID", "adminID").Return(&users.User{ID: "adminID", Role: users.RoleAdmin}, nil).Once()
+
+		err := service.Reactivate(context.Background(), manager, "adminID")
+		assert.EqualError(t, err, "forbidden")
+	})
+
+	t.Run("deactivated account session", func(t *testing.T) {
+		data.On("GetByID", "randomUserID").Return(&users.User{ID: "randomUserID", DeactivatedAt: &now}, nil).Once()
+
+		err := service.ValidateSession(realHelper.Principal{UserID: "randomUserID", IssuedAt: now.Add(time.Hour)})
+		assert.EqualError(t, err, "account deactivated")
+	})
+}
+
+func TestImpersonation(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	admin := users.User{ID: "adminID", Role: users.RoleAdmin}
+	userData := users.User{ID: "randomUserID", Nama: "Random User", Role: users.RoleUser}
+	principal := realHelper.Principal{UserID: userData.ID, Roles: []string{users.RoleUser}, SessionID: "randomSessionID", ActorID: admin.ID, IssuedAt: now}
+
+	t.Run("success impersonate", func(t *testing.T) {
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.ID == "randomSessionID" && s.UserID == userData.ID && s.ActorID == admin.ID && s.RefreshTokenHash == "" && s.ExpiresAt.Equal(now.Add(realHelper.AccessTokenTTL))
+		})).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{users.RoleUser}, SessionID: "randomSessionID", ActorID: admin.ID}).Return(map[string]any{"access_token": "randomAccessToken"}).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: admin.ID, Action: "user.impersonation_started", TargetID: userData.ID, After: map[string]any{"session_id": "randomSessionID", "expires_at": now.Add(realHelper.AccessTokenTTL)}}).Return(nil).Once()
+
+		result, err := service.Impersonate(context.Background(), admin.ID, userData.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, "Random User", result.Nama)
+		assert.Equal(t, map[string]any{"access_token": "randomAccessToken"}, result.Access)
+	})
+
+	t.Run("impersonate own account", func(t *testing.T) {
+		result, err := service.Impersonate(context.Background(), admin.ID, admin.ID)
+		assert.EqualError(t, err, "cannot impersonate own account")
+		assert.Nil(t, result)
+	})
+
+	t.Run("impersonate admin", func(t *testing.T) {
+		data.On("GetByID", "otherAdminID").Return(&users.User{ID: "otherAdminID", Role: users.RoleAdmin}, nil).Once()
+
+		result, err := service.Impersonate(context.Background(), admin.ID, "otherAdminID")
+		assert.EqualError(t, err, "cannot impersonate admin")
+		assert.Nil(t, result)
+	})
+
+	t.Run("impersonate deactivated account", func(t *testing.T) {
+		data.On("GetByID", "deactivatedID").Return(&users.User{ID: "deactivatedID", DeactivatedAt: &now}, nil).Once()
+
+		result, err := service.Impersonate(context.Background(), admin.ID, "deactivatedID")
+		assert.EqualError(t, err, "account deactivated")
+		assert.Nil(t, result)
+	})
+
+	t.Run("impersonation session is valid", func(t *testing.T) {
+		session := users.Session{ID: "randomSessionID", UserID: userData.ID, ActorID: admin.ID, LastSeenAt: now, ExpiresAt: now.Add(realHelper.AccessTokenTTL)}
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("GetByID", admin.ID).Return(&admin, nil).Once()
+		data.On("GetSession", "randomSessionID").Return(&session, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		err := service.ValidateSession(principal)
+		assert.Nil(t, err)
+	})
+
+	t.Run("actor is no admin anymore", func(t *testing.T) {
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("GetByID", admin.ID).Return(&users.User{ID: admin.ID, Role: users.RoleUser}, nil).Once()
+
+		err := service.ValidateSession(principal)
+		assert.EqualError(t, err, "session revoked")
+	})
+
+	t.Run("session of another actor", func(t *testing.T) {
+		session := users.Session{ID: "randomSessionID", UserID: userData.ID, LastSeenAt: now, ExpiresAt: now.Add(sessionTTL)}
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("GetByID", admin.ID).Return(&admin, nil).Once()
+		data.On("GetSession", "randomSessionID").Return(&session, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		err := service.ValidateSession(principal)
+		assert.EqualError(t, err, "session revoked")
+	})
+
+	t.Run("impersonation session cannot be refreshed", func(t *testing.T) {
+		session := users.Session{ID: "randomSessionID", UserID: userData.ID, ActorID: admin.ID, ExpiresAt: now.Add(realHelper.AccessTokenTTL)}
+		data.On("GetSession", "randomSessionID").Return(&session, nil).Once()
+		clock.On("Now").Return(now).Once()
+
+		result, err := service.RefreshSession(context.Background(), "randomSessionID.randomSecret")
+		assert.EqualError(t, err, "invalid refresh token")
+		assert.Nil(t, result)
+	})
+
+	t.Run("success stop", func(t *testing.T) {
+		clock.On("Now").Return(now).Once()
+		data.On("RevokeSession", userData.ID, "randomSessionID", now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: admin.ID, Action: "user.impersonation_stopped", TargetID: userData.ID, After: map[string]any{"session_id": "randomSessionID"}}).Return(nil).Once()
+
+		err := service.StopImpersonation(context.Background(), principal)
+		assert.Nil(t, err)
+	})
+
+	t.Run("stop without impersonating", func(t *testing.T) {
+		err := service.StopImpersonation(context.Background(), realHelper.Principal{UserID: userData.ID, SessionID: "randomSessionID"})
+		assert.EqualError(t, err, "not impersonating")
+	})
+}
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	storage := helper.NewStorageInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	// eraser stands for a feature keeping credentials in its own tables.
+	eraser := mocks.NewErasureHookInterface(t)
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-time.Hour * 24 * 30)
+
+	t.Run("anonymize after grace period", func(t *testing.T) {
+		service := New(data, generator, j, clock, notifier, auditor, policy, nil, storage, nil, nil, []users.ErasureHookInterface{eraser}, configs.ProgramConfig{DeletionGraceDays: 30, PurgeMode: "anonymize"})
+		clock.On("Now").Return(now).Once()
+		data.On("GetDeletedBefore", cutoff).Return([]string{"firstID", "secondID"}, nil).Once()
+		eraser.On("EraseUser", "firstID").Return(nil).Once()
+		eraser.On("EraseUser", "secondID").Return(nil).Once()
+		data.On("Anonymize", "firstID", now).Return("avatars/firstID/abc", nil).Once()
+		data.On("Anonymize", "secondID", now).Return("", errors.New("some db error")).Once()
+		for _, size := range []string{"large", "medium", "small"} {
+			storage.On("Delete", mock.Anything, "avatars/firstID/abc-"+size+".jpg").Return(nil).Once()
+		}
+		auditor.On("Record", mock.Anything, audit.Event{Action: "user.purged", TargetID: "firstID", After: map[string]any{"mode": "anonymize"}}).Return(nil).Once()
+
+		purged, err := service.PurgeDeletedAccounts()
+		assert.Equal(t, 1, purged)
+		assert.EqualError(t, err, "purge process failed for 1 accounts")
+	})
+
+	t.Run("hard delete after grace period", func(t *testing.T) {
+		service := New(data, generator, j, clock, notifier, auditor, policy, nil, storage, nil, nil, []users.ErasureHookInterface{eraser}, configs.ProgramConfig{DeletionGraceDays: 30, PurgeMode: "delete"})
+		clock.On("Now").Return(now).Once()
+		data.On("GetDeletedBefore", cutoff).Return([]string{"firstID"}, nil).Once()
+		eraser.On("EraseUser", "firstID").Return(nil).Once()
+		data.On("HardDelete", "firstID").Return("avatars/firstID/abc", nil).Once()
+		storage.On("Delete", mock.Anything, "avatars/firstID/abc-large.jpg").Return(errors.New("storage unavailable")).Once()
+		storage.On("Delete", mock.Anything, "avatars/firstID/abc-medium.jpg").Return(nil).Once()
+		storage.On("Delete", mock.Anything, "avatars/firstID/abc-small.jpg").Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{Action: "user.purged", TargetID: "firstID", After: map[string]any{"mode": "delete"}}).Return(nil).Once()
+
+		purged, err := service.PurgeDeletedAccounts()
+		assert.Equal(t, 1, purged)
+		assert.Nil(t, err)
+	})
+
+	t.Run("credentials of another feature not erased", func(t *testing.T) {
+		service := New(data, generator, j, clock, notifier, auditor, policy, nil, storage, nil, nil, []users.ErasureHookInterface{eraser}, configs.ProgramConfig{DeletionGraceDays: 30, PurgeMode: "delete"})
+		clock.On("Now").Return(now).Once()
+		data.On("GetDeletedBefore", cutoff).Return([]string{"firstID"}, nil).Once()
+		eraser.On("EraseUser", "firstID").Return(errors.New("some db error")).Once()
+
+		purged, err := service.PurgeDeletedAccounts()
+		assert.Equal(t, 0, purged)
+		assert.EqualError(t, err, "purge process failed for 1 accounts")
+	})
+}
+
+func TestLoginRisk(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	geo := helper.NewGeoLocatorInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{SuspiciousLoginPolicy: "notify"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	passwordHash, _ := realHelper.HashPassword("Rahasia#2023")
+	userData := users.User{
+		ID:           "randomUserID",
+		Nama:         "dida",
+		HP:           "123",
+		Password:     passwordHash,
+		HPVerifiedAt: &now,
+	}
+	meta := realHelper.RequestMeta{IP: "103.10.20.7", UserAgent: "Mozilla/5.0 Firefox/118.0"}
+	ctx := realHelper.WithRequestMeta(context.Background(), meta)
+	jakartaLat, jakartaLon := -6.2088, 106.8456
+	knownSession := users.Session{
+		ID:          "oldSessionID",
+		UserID:      userData.ID,
+		IP:          "103.10.20.99",
+		Fingerprint: realHelper.DeviceFingerprint(meta),
+		Latitude:    &jakartaLat,
+		Longitude:   &jakartaLon,
+		CreatedAt:   now.Add(-time.Hour),
+	}
+	otherSession := users.Session{
+		ID:          "oldSessionID",
+		UserID:      userData.ID,
+		IP:          "36.80.1.1",
+		Fingerprint: realHelper.DeviceFingerprint(realHelper.RequestMeta{UserAgent: "curl/8.0"}),
+		CreatedAt:   now.Add(-time.Hour * 24),
+	}
+
+	t.Run("known device and network", func(t *testing.T) {
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{knownSession}, nil).Once()
+		clock.On("Now").Return(now).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.IP == meta.IP && s.Fingerprint == realHelper.DeviceFingerprint(meta)
+		})).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.Login(ctx, userData.HP, "Rahasia#2023")
+		assert.Nil(t, err)
+		assert.Equal(t, jwtResult, result.Access)
+	})
+
+	t.Run("new device is notified", func(t *testing.T) {
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{otherSession}, nil).Once()
+		clock.On("Now").Return(now).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_suspicious", TargetID: userData.ID, After: map[string]any{"reasons": []string{"new_device", "new_ip_range"}}}).Return(nil).Once()
+		notifier.On("Send", userData.HP, mock.MatchedBy(func(message string) bool {
+			return strings.Contains(message, "Firefox") && strings.Contains(message, meta.IP)
+		})).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertSession", mock.Anything).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.Login(ctx, userData.HP, "Rahasia#2023")
+		assert.Nil(t, err)
+		assert.Equal(t, jwtResult, result.Access)
+	})
+
+	t.Run("impossible travel", func(t *testing.T) {
+		geoService := New(data, generator, j, clock, notifier, auditor, policy, geo, nil, nil, nil, nil, configs.ProgramConfig{SuspiciousLoginPolicy: "notify"})
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		london := &realHelper.GeoLocation{Country: "GB", City: "London", Latitude: 51.5072, Longitude: -0.1276}
+		geo.On("Locate", meta.IP).Return(london, nil).Twice()
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{knownSession}, nil).Once()
+		clock.On("Now").Return(now).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_suspicious", TargetID: userData.ID, After: map[string]any{"reasons": []string{"impossible_travel"}}}).Return(nil).Once()
+		notifier.On("Send", userData.HP, mock.MatchedBy(func(message string) bool {
+			return strings.Contains(message, "London, GB")
+		})).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("InsertSession", mock.MatchedBy(func(s users.Session) bool {
+			return s.City == "London" && s.Latitude != nil && *s.Latitude == london.Latitude
+		})).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := geoService.Login(ctx, userData.HP, "Rahasia#2023")
+		assert.Nil(t, err)
+		assert.Equal(t, jwtResult, result.Access)
+	})
+
+	t.Run("step up required", func(t *testing.T) {
+		stepUpService := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{SuspiciousLoginPolicy: "step_up"})
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{otherSession}, nil).Once()
+		clock.On("Now").Return(now).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_suspicious", TargetID: userData.ID, After: map[string]any{"reasons": []string{"new_device", "new_ip_range"}}}).Return(nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("GetOneTimeCode", userData.ID, "login_verification").Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateOTP", 6).Return("123456", nil).Once()
+		data.On("InsertOneTimeCode", mock.MatchedBy(func(c users.OneTimeCode) bool {
+			return c.UserID == userData.ID && c.Purpose == "login_verification"
+		})).Return(nil).Once()
+		notifier.On("Send", userData.HP, mock.MatchedBy(func(message string) bool {
+			return strings.Contains(message, "123456")
+		})).Return(nil).Once()
+		j.On("GenerateChallengeToken", userData.ID, "step_up", time.Minute*10).Return("randomStepUpToken").Once()
+
+		result, err := stepUpService.Login(ctx, userData.HP, "Rahasia#2023")
+		assert.Nil(t, err)
+		assert.True(t, result.StepUpRequired)
+		assert.Equal(t, "randomStepUpToken", result.StepUpToken)
+		assert.Nil(t, result.Access)
+	})
+
+	t.Run("step up completed", func(t *testing.T) {
+		codeHash, _ := realHelper.HashPassword("123456")
+		code := users.OneTimeCode{ID: 1, UserID: userData.ID, Purpose: "login_verification", CodeHash: codeHash, ExpiresAt: now.Add(time.Minute * 10), CreatedAt: now}
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		j.On("ParseChallengeToken", "randomStepUpToken", "step_up").Return(userData.ID, nil).Once()
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "login_verification").Return(&code, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		data.On("UpdateOneTimeCode", mock.MatchedBy(func(c users.OneTimeCode) bool {
+			return c.UsedAt != nil
+		})).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID, After: map[string]any{"step_up": true}}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute)).Once()
+		data.On("InsertSession", mock.Anything).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.VerifyLogin(ctx, "randomStepUpToken", "123456")
+		assert.Nil(t, err)
+		assert.Equal(t, jwtResult, result.Access)
+	})
+
+	t.Run("invalid step up token", func(t *testing.T) {
+		j.On("ParseChallengeToken", "randomMFAToken", "step_up").Return("", errors.New("token purpose mismatch")).Once()
+
+		result, err := service.VerifyLogin(ctx, "randomMFAToken", "123456")
+		assert.EqualError(t, err, "invalid step up token")
+		assert.Nil(t, result)
+	})
+}
+
+func TestLoginExternal(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny", SuspiciousLoginPolicy: "step_up"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	// Created through the provider, without HP or password.
+	userData := users.User{ID: "randomUserID", Nama: "dida", Role: users.RoleUser}
+
+	t.Run("Success without verified hp", func(t *testing.T) {
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{}, nil).Once()
+		clock.On("Now").Return(now).Twice()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID, After: map[string]any{"provider": "google"}}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		data.On("InsertSession", mock.Anything).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.LoginExternal(context.Background(), userData.ID, "google")
+		assert.Nil(t, err)
+		assert.Equal(t, "randomAccessToken", result.Access["access_token"])
+	})
+
+	t.Run("New device without hp is not challenged", func(t *testing.T) {
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		ctx := realHelper.WithRequestMeta(context.Background(), realHelper.RequestMeta{IP: "36.80.1.1", UserAgent: "curl/8.0"})
+		history := []users.Session{{ID: "oldSessionID", UserID: userData.ID, IP: "103.10.20.99", Fingerprint: "otherFingerprint", CreatedAt: now.Add(-time.Hour)}}
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return(history, nil).Once()
+		clock.On("Now").Return(now).Twice()
+		auditor.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool { return e.Action == "user.login_suspicious" })).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID, After: map[string]any{"provider": "google"}}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		data.On("InsertSession", mock.Anything).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.LoginExternal(ctx, userData.ID, "google")
+		assert.Nil(t, err)
+		assert.False(t, result.StepUpRequired)
+	})
+
+	t.Run("Second factor still required", func(t *testing.T) {
+		withTOTP := userData
+		withTOTP.TOTPEnabled = true
+		data.On("GetByID", userData.ID).Return(&withTOTP, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{}, nil).Once()
+		clock.On("Now").Return(now).Once()
+		j.On("GenerateChallengeToken", userData.ID, "mfa", time.Minute*5).Return("randomMFAToken").Once()
+
+		result, err := service.LoginExternal(context.Background(), userData.ID, "google")
+		assert.Nil(t, err)
+		assert.True(t, result.MFARequired)
+		assert.Nil(t, result.Access)
+	})
+
+	t.Run("Deactivated account", func(t *testing.T) {
+		deactivated := userData
+		deactivated.DeactivatedAt = &now
+		data.On("GetByID", userData.ID).Return(&deactivated, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_failed", TargetID: userData.ID, After: map[string]any{"reason": "account deactivated"}}).Return(nil).Once()
+
+		result, err := service.LoginExternal(context.Background(), userData.ID, "google")
+		assert.EqualError(t, err, "account deactivated")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Erased account", func(t *testing.T) {
+		anonymized := userData
+		anonymized.AnonymizedAt = &now
+		data.On("GetByID", userData.ID).Return(&anonymized, nil).Once()
+
+		result, err := service.LoginExternal(context.Background(), userData.ID, "google")
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+	})
+}
+
+func TestProvision(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Success without password", func(t *testing.T) {
+		data.On("GetByHP", "0812").Return(nil, errors.New("data not found")).Once()
+		generator.On("GenerateUUID").Return("randomUUID", nil).Once()
+		data.On("Insert", mock.MatchedBy(func(u users.User) bool {
+			return u.ID == "randomUUID" && u.HP == "0812" && u.Nama == "dida" && u.Password == "" && u.Role == users.RoleUser
+		})).Return(&users.User{ID: "randomUUID", Nama: "dida", HP: "0812", Role: users.RoleUser}, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("SetHPVerified", "randomUUID", now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "scim", Action: "user.registered", TargetID: "randomUUID", After: map[string]any{"role": users.RoleUser, "hp_verified": true, "provisioned": true}}).Return(nil).Once()
+
+		result, err := service.Provision(context.Background(), "scim", users.User{Nama: " dida ", HP: "0812"})
+		assert.Nil(t, err)
+		assert.Equal(t, "randomUUID", result.ID)
+		assert.Equal(t, &now, result.HPVerifiedAt)
+	})
+
+	t.Run("HP already used", func(t *testing.T) {
+		data.On("GetByHP", "0812").Return(&users.User{ID: "otherID", HP: "0812"}, nil).Once()
+
+		result, err := service.Provision(context.Background(), "scim", users.User{Nama: "dida", HP: "0812"})
+		assert.EqualError(t, err, "hp already used")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Weak password", func(t *testing.T) {
+		data.On("GetByHP", "0812").Return(nil, errors.New("data not found")).Once()
+
+		result, err := service.Provision(context.Background(), "scim", users.User{Nama: "dida", HP: "0812", Password: "dida1"})
+		var policyErr *realHelper.PasswordPolicyError
+		assert.ErrorAs(t, err, &policyErr)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Missing HP", func(t *testing.T) {
+		result, err := service.Provision(context.Background(), "scim", users.User{Nama: "dida"})
+		assert.EqualError(t, err, "invalid hp")
+		assert.Nil(t, result)
+	})
+}
+
+func TestUpdateProfile(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Success new hp", func(t *testing.T) {
+		data.On("GetByID", "randomUserID").Return(&users.User{ID: "randomUserID", Nama: "dida", HP: "0812"}, nil).Once()
+		data.On("GetByHP", "0813").Return(nil, errors.New("data not found")).Once()
+		data.On("UpdateProfile", users.User{ID: "randomUserID", Nama: "dida", HP: "0813"}).Return(nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("SetHPVerified", "randomUserID", now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "scim", Action: "user.updated", TargetID: "randomUserID", Before: map[string]any{"nama": "dida", "hp": "0812"}, After: map[string]any{"nama": "dida", "hp": "0813"}}).Return(nil).Once()
+
+		result, err := service.UpdateProfile(context.Background(), "scim", users.User{ID: "randomUserID", Nama: "dida", HP: "0813"})
+		assert.Nil(t, err)
+		assert.Equal(t, "0813", result.HP)
+		assert.Equal(t, &now, result.HPVerifiedAt)
+	})
+
+	t.Run("Unchanged", func(t *testing.T) {
+		data.On("GetByID", "randomUserID").Return(&users.User{ID: "randomUserID", Nama: "dida", HP: "0812"}, nil).Once()
+
+		result, err := service.UpdateProfile(context.Background(), "scim", users.User{ID: "randomUserID", Nama: "dida", HP: "0812"})
+		assert.Nil(t, err)
+		assert.Equal(t, "0812", result.HP)
+	})
+
+	t.Run("HP of another account", func(t *testing.T) {
+		data.On("GetByID", "randomUserID").Return(&users.User{ID: "randomUserID", Nama: "dida", HP: "0812"}, nil).Once()
+		data.On("GetByHP", "0813").Return(&users.User{ID: "otherID", HP: "0813"}, nil).Once()
+
+		result, err := service.UpdateProfile(context.Background(), "scim", users.User{ID: "randomUserID", Nama: "dida", HP: "0813"})
+		assert.EqualError(t, err, "hp already used")
+		assert.Nil(t, result)
+	})
+}
+
+func TestListUsers(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	t.Run("Success", func(t *testing.T) {
+		var query = users.UserQuery{Filters: []users.UserFilter{{Field: users.FieldHP, Operator: users.OperatorEqual, Value: "0812"}}, Limit: 10}
+		data.On("List", query).Return([]users.User{{ID: "randomUserID", HP: "0812"}}, 1, nil).Once()
+
+		result, total, err := service.ListUsers(context.Background(), query)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, total)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("Page too large", func(t *testing.T) {
+		result, total, err := service.ListUsers(context.Background(), users.UserQuery{Limit: 101})
+		assert.EqualError(t, err, "invalid page")
+		assert.Equal(t, 0, total)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Invalid filter", func(t *testing.T) {
+		var query = users.UserQuery{Filters: []users.UserFilter{{Field: "password", Operator: users.OperatorEqual, Value: "x"}}, Limit: 10}
+		data.On("List", query).Return(nil, 0, errors.New("invalid filter")).Once()
+
+		_, _, err := service.ListUsers(context.Background(), query)
+		assert.EqualError(t, err, "invalid filter")
+	})
+}
+
+func TestLoginDirectory(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	directory := helper.NewDirectoryInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	authenticators := []users.AuthenticatorInterface{NewPasswordAuthenticator(data), NewDirectoryAuthenticator(directory)}
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, authenticators, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	entry := realHelper.DirectoryUser{ID: "entryUUID", DN: "uid=dida,dc=example,dc=com", Nama: "Dida Putra", HP: "0812", Role: users.RoleAdmin}
+	directory.On("Name").Return("corp")
+
+	t.Run("First login creates account", func(t *testing.T) {
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		data.On("GetByHP", "dida").Return(nil, errors.New("record not found")).Once()
+		directory.On("Authenticate", "dida", "Rahasia#2023").Return(&entry, nil).Once()
+		data.On("GetByDirectory", "corp", "entryUUID").Return(nil, errors.New("record not found")).Once()
+		data.On("GetByHP", "0812").Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateUUID").Return("randomUserID", nil).Once()
+		created := users.User{ID: "randomUserID", Nama: "Dida Putra", HP: "0812", Role: users.RoleAdmin, Directory: "corp", DirectoryID: "entryUUID"}
+		data.On("Insert", created).Return(&created, nil).Once()
+		clock.On("Now").Return(now)
+		data.On("SetHPVerified", "randomUserID", now).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "randomUserID", Action: "user.registered", TargetID: "randomUserID", After: map[string]any{"role": users.RoleAdmin, "hp_verified": true, "directory": "corp"}}).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "randomUserID", Action: "user.login", TargetID: "randomUserID", After: map[string]any{"directory": "corp"}}).Return(nil).Once()
+		data.On("GetSessions", "randomUserID", 20).Return([]users.Session{}, nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		data.On("InsertSession", mock.Anything).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: "randomUserID", Roles: []string{users.RoleAdmin}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.Login(context.Background(), "dida", "Rahasia#2023")
+		assert.Nil(t, err)
+		assert.Equal(t, "randomAccessToken", result.Access["access_token"])
+	})
+
+	t.Run("Changes follow the directory", func(t *testing.T) {
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		demoted := entry
+		demoted.Role = users.RoleUser
+		demoted.Nama = "Dida"
+		existing := users.User{ID: "randomUserID", Nama: "Dida Putra", HP: "0812", Role: users.RoleAdmin, HPVerifiedAt: &now, Directory: "corp", DirectoryID: "entryUUID"}
+		data.On("GetByHP", "dida").Return(nil, errors.New("record not found")).Once()
+		directory.On("Authenticate", "dida", "Rahasia#2023").Return(&demoted, nil).Once()
+		data.On("GetByDirectory", "corp", "entryUUID").Return(&existing, nil).Once()
+		data.On("UpdateProfile", users.User{ID: "randomUserID", Nama: "Dida", HP: "0812"}).Return(nil).Once()
+		data.On("UpdateRole", "randomUserID", users.RoleUser).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "randomUserID", Action: "user.updated", TargetID: "randomUserID", Before: map[string]any{"nama": "Dida Putra", "hp": "0812", "role": users.RoleAdmin}, After: map[string]any{"nama": "Dida", "hp": "0812", "role": users.RoleUser}}).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "randomUserID", Action: "user.login", TargetID: "randomUserID", After: map[string]any{"directory": "corp"}}).Return(nil).Once()
+		data.On("GetSessions", "randomUserID", 20).Return([]users.Session{}, nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		data.On("InsertSession", mock.Anything).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: "randomUserID", Roles: []string{users.RoleUser}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.Login(context.Background(), "dida", "Rahasia#2023")
+		assert.Nil(t, err)
+		assert.Equal(t, "Dida", result.Nama)
+	})
+
+	t.Run("HP of a local account", func(t *testing.T) {
+		data.On("GetByHP", "dida").Return(nil, errors.New("record not found")).Once()
+		directory.On("Authenticate", "dida", "Rahasia#2023").Return(&entry, nil).Once()
+		data.On("GetByDirectory", "corp", "entryUUID").Return(nil, errors.New("record not found")).Once()
+		data.On("GetByHP", "0812").Return(&users.User{ID: "localUserID", HP: "0812"}, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "", Action: "user.login_failed", TargetID: "", After: map[string]any{"reason": "hp already used"}}).Return(nil).Once()
+
+		result, err := service.Login(context.Background(), "dida", "Rahasia#2023")
+		assert.EqualError(t, err, "account conflict")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Wrong directory password", func(t *testing.T) {
+		data.On("GetByHP", "dida").Return(nil, errors.New("record not found")).Once()
+		directory.On("Authenticate", "dida", "wrong").Return(&entry, errors.New("invalid password")).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "", Action: "user.login_failed", TargetID: "", After: map[string]any{"reason": "invalid password"}}).Return(nil).Once()
+
+		result, err := service.Login(context.Background(), "dida", "wrong")
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Directory unavailable", func(t *testing.T) {
+		data.On("GetByHP", "dida").Return(nil, errors.New("record not found")).Once()
+		directory.On("Authenticate", "dida", "Rahasia#2023").Return(nil, errors.New("directory unavailable")).Once()
+
+		result, err := service.Login(context.Background(), "dida", "Rahasia#2023")
+		assert.EqualError(t, err, "process failed")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Directory account skips the password check", func(t *testing.T) {
+		data.On("GetByHP", "0812").Return(&users.User{ID: "randomUserID", HP: "0812", Password: "", Directory: "corp"}, nil).Once()
+		directory.On("Authenticate", "0812", "").Return(nil, errors.New("data not found")).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: "", Action: "user.login_failed", TargetID: "", After: map[string]any{"reason": "unknown account"}}).Return(nil).Once()
+
+		result, err := service.Login(context.Background(), "0812", "")
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Directory owns the password", func(t *testing.T) {
+		data.On("GetByID", "randomUserID").Return(&users.User{ID: "randomUserID", Directory: "corp"}, nil).Once()
+
+		result, err := service.ChangePassword(context.Background(), "randomUserID", "old", "Rahasia#2024")
+		assert.EqualError(t, err, "password managed by directory")
+		assert.Nil(t, result)
+	})
+}
+
+func TestRequestLoginCode(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	userData := users.User{ID: "randomUserID", Nama: "dida", HP: "123", HPVerifiedAt: &now}
+
+	t.Run("success send code", func(t *testing.T) {
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		clock.On("Now").Return(now).Twice()
+		data.On("CountOneTimeCodes", userData.ID, "passwordless_login", now.Add(-time.Hour)).Return(1, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "passwordless_login").Return(nil, errors.New("record not found")).Once()
+		generator.On("GenerateOTP", 6).Return("123456", nil).Once()
+		data.On("InsertOneTimeCode", mock.MatchedBy(func(r users.OneTimeCode) bool {
+			return r.Purpose == "passwordless_login" && realHelper.ComparePassword(r.CodeHash, "123456") && r.ExpiresAt.Equal(now.Add(time.Minute*10))
+		})).Return(nil).Once()
+		notifier.On("Send", userData.HP, mock.MatchedBy(func(message string) bool {
+			return strings.Contains(message, "sign-in code is 123456")
+		})).Return(nil).Once()
+
+		err := service.RequestLoginCode(context.Background(), userData.HP)
+		assert.Nil(t, err)
+	})
+
+	t.Run("hourly limit reached looks like success", func(t *testing.T) {
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		clock.On("Now").Return(now).Once()
+		data.On("CountOneTimeCodes", userData.ID, "passwordless_login", now.Add(-time.Hour)).Return(5, nil).Once()
+
+		err := service.RequestLoginCode(context.Background(), userData.HP)
+		assert.Nil(t, err)
+	})
+
+	t.Run("requested again too soon looks like success", func(t *testing.T) {
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		clock.On("Now").Return(now.Add(time.Second * 30)).Twice()
+		data.On("CountOneTimeCodes", userData.ID, "passwordless_login", now.Add(time.Second*30-time.Hour)).Return(1, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "passwordless_login").Return(&users.OneTimeCode{UserID: userData.ID, Purpose: "passwordless_login", CreatedAt: now}, nil).Once()
+
+		err := service.RequestLoginCode(context.Background(), userData.HP)
+		assert.Nil(t, err)
+	})
+
+	t.Run("unverified hp gets nothing", func(t *testing.T) {
+		data.On("GetByHP", "456").Return(&users.User{ID: "otherID", HP: "456"}, nil).Once()
+
+		err := service.RequestLoginCode(context.Background(), "456")
+		assert.Nil(t, err)
+	})
+
+	t.Run("unknown number", func(t *testing.T) {
+		data.On("GetByHP", "999").Return(nil, errors.New("record not found")).Once()
+
+		err := service.RequestLoginCode(context.Background(), "999")
+		assert.Nil(t, err)
+	})
+}
+
+func TestLoginWithCode(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny", SuspiciousLoginPolicy: "step_up"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	codeHash, _ := realHelper.HashPassword("123456")
+	userData := users.User{ID: "randomUserID", Nama: "dida", HP: "123", Role: users.RoleUser, HPVerifiedAt: &now}
+	codeData := users.OneTimeCode{ID: 1, UserID: userData.ID, Purpose: "passwordless_login", CodeHash: codeHash, ExpiresAt: now.Add(time.Minute * 10), CreatedAt: now}
+
+	t.Run("success from a new device is not stepped up", func(t *testing.T) {
+		pending := codeData
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		ctx := realHelper.WithRequestMeta(context.Background(), realHelper.RequestMeta{IP: "36.80.1.1", UserAgent: "curl/8.0"})
+		history := []users.Session{{ID: "oldSessionID", UserID: userData.ID, IP: "103.10.20.99", Fingerprint: "otherFingerprint", CreatedAt: now.Add(-time.Hour)}}
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "passwordless_login").Return(&pending, nil).Once()
+		clock.On("Now").Return(now.Add(time.Minute))
+		data.On("UpdateOneTimeCode", mock.MatchedBy(func(r users.OneTimeCode) bool { return r.UsedAt != nil })).Return(nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return(history, nil).Once()
+		auditor.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool { return e.Action == "user.login_suspicious" })).Return(nil).Once()
+		notifier.On("Send", userData.HP, mock.MatchedBy(func(m string) bool { return strings.Contains(m, "New sign-in") })).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID, After: map[string]any{"method": "code"}}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		data.On("InsertSession", mock.Anything).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{users.RoleUser}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.LoginWithCode(ctx, userData.HP, "123456")
+		assert.Nil(t, err)
+		assert.False(t, result.StepUpRequired)
+		assert.Equal(t, "randomAccessToken", result.Access["access_token"])
+	})
+
+	t.Run("wrong code is counted", func(t *testing.T) {
+		pending := codeData
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "passwordless_login").Return(&pending, nil).Once()
+		data.On("UpdateOneTimeCode", mock.MatchedBy(func(r users.OneTimeCode) bool { return r.Attempts == 1 && r.UsedAt == nil })).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_failed", TargetID: userData.ID, After: map[string]any{"reason": "invalid sign-in code"}}).Return(nil).Once()
+
+		result, err := service.LoginWithCode(context.Background(), userData.HP, "654321")
+		assert.EqualError(t, err, "invalid code")
+		assert.Nil(t, result)
+	})
+
+	t.Run("attempts used up", func(t *testing.T) {
+		pending := codeData
+		pending.Attempts = 5
+		data.On("GetByHP", userData.HP).Return(&userData, nil).Once()
+		data.On("GetOneTimeCode", userData.ID, "passwordless_login").Return(&pending, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_failed", TargetID: userData.ID, After: map[string]any{"reason": "invalid sign-in code"}}).Return(nil).Once()
+
+		result, err := service.LoginWithCode(context.Background(), userData.HP, "123456")
+		assert.EqualError(t, err, "invalid code")
+		assert.Nil(t, result)
+	})
+
+	t.Run("unknown number", func(t *testing.T) {
+		data.On("GetByHP", "999").Return(nil, errors.New("record not found")).Once()
+
+		result, err := service.LoginWithCode(context.Background(), "999", "123456")
+		assert.EqualError(t, err, "invalid code")
+		assert.Nil(t, result)
+	})
+}
+
+func TestLoginPasskey(t *testing.T) {
+	generator := helper.NewGeneratorInterface(t)
+	j := helper.NewJWTInterface(t)
+	data := mocks.NewUserDataInterface(t)
+	clock := helper.NewClockInterface(t)
+	notifier := helper.NewNotifierInterface(t)
+	auditor := auditMocks.NewAuditServiceInterface(t)
+	policy := realHelper.NewPasswordPolicy(8, 3, 3, nil)
+	service := New(data, generator, j, clock, notifier, auditor, policy, nil, nil, nil, nil, nil, configs.ProgramConfig{UnverifiedPolicy: "deny", SuspiciousLoginPolicy: "step_up"})
+	data.On("WithContext", mock.Anything).Return(data).Maybe()
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	userData := users.User{ID: "randomUserID", Nama: "dida", HP: "123", HPVerifiedAt: &now, Role: users.RoleUser, TOTPEnabled: true}
+
+	t.Run("User verified skips second factor", func(t *testing.T) {
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{}, nil).Once()
+		clock.On("Now").Return(now).Twice()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID, After: map[string]any{"method": "passkey", "user_verified": true}}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		data.On("InsertSession", mock.Anything).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.LoginPasskey(context.Background(), userData.ID, true)
+		assert.Nil(t, err)
+		assert.False(t, result.MFARequired)
+		assert.Equal(t, "randomAccessToken", result.Access["access_token"])
+	})
+
+	t.Run("User verified from a new device is not stepped up", func(t *testing.T) {
+		withoutTOTP := userData
+		withoutTOTP.TOTPEnabled = false
+		jwtResult := map[string]any{"access_token": "randomAccessToken"}
+		ctx := realHelper.WithRequestMeta(context.Background(), realHelper.RequestMeta{IP: "36.80.1.1", UserAgent: "curl/8.0"})
+		history := []users.Session{{ID: "oldSessionID", UserID: userData.ID, IP: "103.10.20.99", Fingerprint: "otherFingerprint", CreatedAt: now.Add(-time.Hour)}}
+		data.On("GetByID", userData.ID).Return(&withoutTOTP, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return(history, nil).Once()
+		clock.On("Now").Return(now).Twice()
+		auditor.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool { return e.Action == "user.login_suspicious" })).Return(nil).Once()
+		notifier.On("Send", userData.HP, mock.MatchedBy(func(m string) bool { return strings.Contains(m, "New sign-in") })).Return(nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login", TargetID: userData.ID, After: map[string]any{"method": "passkey", "user_verified": true}}).Return(nil).Once()
+		generator.On("GenerateUUID").Return("randomSessionID", nil).Once()
+		data.On("InsertSession", mock.Anything).Return(nil).Once()
+		j.On("GenerateJWT", realHelper.Principal{UserID: userData.ID, Roles: []string{"user"}, SessionID: "randomSessionID"}).Return(jwtResult).Once()
+
+		result, err := service.LoginPasskey(ctx, userData.ID, true)
+		assert.Nil(t, err)
+		assert.False(t, result.StepUpRequired)
+		assert.Equal(t, "randomAccessToken", result.Access["access_token"])
+	})
+
+	t.Run("Without user verification second factor required", func(t *testing.T) {
+		data.On("GetByID", userData.ID).Return(&userData, nil).Once()
+		data.On("GetSessions", userData.ID, 20).Return([]users.Session{}, nil).Once()
+		clock.On("Now").Return(now).Once()
+		j.On("GenerateChallengeToken", userData.ID, "mfa", time.Minute*5).Return("randomMFAToken").Once()
+
+		result, err := service.LoginPasskey(context.Background(), userData.ID, false)
+		assert.Nil(t, err)
+		assert.True(t, result.MFARequired)
+		assert.Nil(t, result.Access)
+	})
+
+	t.Run("Deactivated account", func(t *testing.T) {
+		deactivated := userData
+		deactivated.DeactivatedAt = &now
+		data.On("GetByID", userData.ID).Return(&deactivated, nil).Once()
+		auditor.On("Record", mock.Anything, audit.Event{ActorID: userData.ID, Action: "user.login_failed", TargetID: userData.ID, After: map[string]any{"reason": "account deactivated"}}).Return(nil).Once()
+
+		result, err := service.LoginPasskey(context.Background(), userData.ID, true)
+		assert.EqualError(t, err, "account deactivated")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Erased account", func(t *testing.T) {
+		erased := userData
+		erased.AnonymizedAt = &now
+		data.On("GetByID", userData.ID).Return(&erased, nil).Once()
+
+		result, err := service.LoginPasskey(context.Background(), userData.ID, true)
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
 	})
 }
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"test/features/audit"
+	"test/features/users"
+	"test/helper"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	sessionTTL           = time.Hour * 24 * 30
+	sessionTouchInterval = time.Minute
+	sessionListLimit     = 50
+)
+
+// RefreshSession trades a refresh token for a new access token on the same
+// session. Refresh tokens are single use: every refresh rotates it, and an old
+// one coming back means it was copied, so the session is ended for both.
+func (us *UserService) RefreshSession(ctx context.Context, refreshToken string) (*users.UserCredential, error) {
+	sessionID, secret, found := strings.Cut(refreshToken, ".")
+	if !found || sessionID == "" || secret == "" {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	session, err := us.d.GetSession(sessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("invalid refresh token")
+		}
+		return nil, errors.New("process failed")
+	}
+
+	var now = us.c.Now()
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) || session.ActorID != "" {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(helper.HashToken(secret)), []byte(session.RefreshTokenHash)) != 1 {
+		if err := us.d.RevokeSession(session.UserID, session.ID, now); err != nil {
+			logrus.Error("service: revoke reused session error:", err.Error())
+		}
+		us.audit(ctx, audit.Event{ActorID: session.UserID, Action: "user.session_reuse_detected", TargetID: session.UserID, After: map[string]any{"session_id": session.ID}})
+		return nil, errors.New("invalid refresh token")
+	}
+
+	result, err := us.getUser(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.DeactivatedAt != nil {
+		return nil, errors.New("account deactivated")
+	}
+
+	newSecret, err := generateSessionSecret()
+	if err != nil {
+		return nil, errors.New("token process failed")
+	}
+
+	session.RefreshTokenHash = helper.HashToken(newSecret)
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(sessionTTL)
+	if err := us.d.UpdateSession(*session); err != nil {
+		return nil, errors.New("update process failed")
+	}
+
+	return us.issueTokens(result, session.ID, session.ID+"."+newSecret)
+}
+
+// ListSessions returns the most recent sessions, ended ones included, so the
+// user also sees where they logged in before.
+func (us *UserService) ListSessions(ctx context.Context, userID string) ([]users.Session, error) {
+	result, err := us.d.GetSessions(userID, sessionListLimit)
+	if err != nil {
+		return nil, errors.New("process failed")
+	}
+
+	return result, nil
+}
+
+func (us *UserService) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	if err := us.d.RevokeSession(userID, sessionID, us.c.Now()); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data not found")
+		}
+		return errors.New("update process failed")
+	}
+
+	us.audit(ctx, audit.Event{ActorID: userID, Action: "user.session_revoked", TargetID: userID, After: map[string]any{"session_id": sessionID}})
+
+	return nil
+}
+
+func (us *UserService) newSession(ctx context.Context, userID string) (*users.Session, string, error) {
+	sessionID, err := us.g.GenerateUUID()
+	if err != nil {
+		return nil, "", errors.New("id generator failed")
+	}
+
+	secret, err := generateSessionSecret()
+	if err != nil {
+		return nil, "", errors.New("token process failed")
+	}
+
+	var now = us.c.Now()
+	var origin = us.loginOrigin(ctx)
+	var session = new(users.Session)
+	session.ID = sessionID
+	session.UserID = userID
+	session.IP = origin.meta.IP
+	session.UserAgent = origin.meta.UserAgent
+	session.Fingerprint = origin.fingerprint
+	if origin.location != nil {
+		session.Country = origin.location.Country
+		session.City = origin.location.City
+		session.Latitude = &origin.location.Latitude
+		session.Longitude = &origin.location.Longitude
+	}
+	session.RefreshTokenHash = helper.HashToken(secret)
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(sessionTTL)
+
+	if err := us.d.InsertSession(*session); err != nil {
+		return nil, "", errors.New("insert process failed")
+	}
+
+	return session, sessionID + "." + secret, nil
+}
+
+// touchSession refuses tokens of an ended session and keeps its last seen
+// time roughly current without writing on every request.
+func (us *UserService) touchSession(principal helper.Principal) error {
+	session, err := us.d.GetSession(principal.SessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("session revoked")
+		}
+		return errors.New("process failed")
+	}
+
+	var now = us.c.Now()
+	if session.UserID != principal.UserID || session.ActorID != principal.ActorID || session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		return errors.New("session revoked")
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		session.LastSeenAt = now
+		if err := us.d.UpdateSession(*session); err != nil {
+			logrus.Error("service: touch session error:", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// revokeTokens ends the sessions and access tokens of the user, along with
+// what the other features handed out for them.
+func (us *UserService) revokeTokens(userID string, revokedAt time.Time) error {
+	if err := us.d.RevokeTokens(userID, revokedAt); err != nil {
+		return err
+	}
+
+	for _, revoker := range us.revokers {
+		if err := revoker.RevokeUser(userID, revokedAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eraseCredentials has the other features delete what could still log in as
+// the user, before the account itself goes.
+func (us *UserService) eraseCredentials(userID string) error {
+	for _, eraser := range us.erasers {
+		if err := eraser.EraseUser(userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func generateSessionSecret() (string, error) {
+	var raw = make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,262 @@
+package helper
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImpersonatedByHeader marks every response to an impersonation token with
+// the ID of the admin behind it, so clients can show it.
+const ImpersonatedByHeader = "X-Impersonated-By"
+
+type Principal struct {
+	UserID    string
+	Roles     []string
+	Scopes    []string
+	SessionID string
+	IssuedAt  time.Time
+	// APIKeyID is set when the request was authenticated with an API key
+	// instead of an access token.
+	APIKeyID string
+	// ClientID and GrantID are set on access tokens issued to an OAuth client.
+	ClientID string
+	GrantID  string
+	// ActorID is the admin acting as UserID while impersonating them.
+	ActorID string
+	// OrganizationID is set on tokens scoped to one organization.
+	OrganizationID string
+	// Groups are the groups of the user when the token was issued, if they
+	// were few enough to be embedded.
+	Groups []string
+}
+
+// SessionValidatorInterface lets the middleware refuse tokens that are still
+// cryptographically valid but were revoked server side.
+type SessionValidatorInterface interface {
+	ValidateSession(principal Principal) error
+}
+
+// SessionValidators runs every validator, each one only looks at the tokens it
+// issued.
+type SessionValidators []SessionValidatorInterface
+
+func (v SessionValidators) ValidateSession(principal Principal) error {
+	for _, validator := range v {
+		if err := validator.ValidateSession(principal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type APIKeyAuthenticatorInterface interface {
+	Authenticate(key string) (*Principal, error)
+}
+
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Impersonated reports whether an admin acts as the user.
+func (p Principal) Impersonated() bool {
+	return p.ActorID != ""
+}
+
+// Limited reports whether the principal may only use the routes its scopes
+// allow. Access tokens from an interactive login are not limited.
+func (p Principal) Limited() bool {
+	return p.APIKeyID != "" || p.ClientID != "" || len(p.Scopes) > 0
+}
+
+type principalKey struct{}
+
+const principalContextKey = "principal"
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// GetPrincipal returns the principal stored by AuthMiddleware, or nil when the
+// route is not protected.
+func GetPrincipal(c echo.Context) *Principal {
+	principal, _ := c.Get(principalContextKey).(*Principal)
+	return principal
+}
+
+// AuthMiddleware only accepts access tokens from an interactive login, it
+// guards the routes that manage the account itself.
+func AuthMiddleware(j JWTInterface, sessions SessionValidatorInterface) echo.MiddlewareFunc {
+	return authMiddleware(j, sessions, nil, false)
+}
+
+// ScopedAuthMiddleware also accepts limited principals: OAuth access tokens
+// and, when keys is set, "ApiKey" keys. Routes using it have to name the
+// scope they need with RequireScope.
+func ScopedAuthMiddleware(j JWTInterface, sessions SessionValidatorInterface, keys APIKeyAuthenticatorInterface) echo.MiddlewareFunc {
+	return authMiddleware(j, sessions, keys, true)
+}
+
+func authMiddleware(j JWTInterface, sessions SessionValidatorInterface, keys APIKeyAuthenticatorInterface, allowLimited bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var header = c.Request().Header.Get(echo.HeaderAuthorization)
+			scheme, token, found := strings.Cut(header, " ")
+			token = strings.TrimSpace(token)
+
+			// Browsers send the cookie on their own, also on requests another
+			// site triggered, so those have to carry the CSRF token.
+			var fromCookie = false
+			if header == "" {
+				if cookie := SessionCookie(c, AccessTokenCookie); cookie != "" {
+					scheme, token, found = "Bearer", cookie, true
+					fromCookie = true
+				}
+			}
+
+			if !found || token == "" {
+				return c.JSON(http.StatusUnauthorized, FormatResponse("unauthorized", nil))
+			}
+
+			if fromCookie && !ValidCSRF(c) {
+				return c.JSON(http.StatusForbidden, FormatResponse("invalid csrf token", nil))
+			}
+
+			var principal *Principal
+			switch {
+			case strings.EqualFold(scheme, "Bearer"):
+				result, err := j.ParseToken(token)
+				if err != nil {
+					c.Logger().Error("middleware: parse token error:", err.Error())
+					return c.JSON(http.StatusUnauthorized, FormatResponse("unauthorized", nil))
+				}
+
+				if sessions != nil {
+					if err := sessions.ValidateSession(*result); err != nil {
+						c.Logger().Error("middleware: validate session error:", err.Error())
+						return c.JSON(http.StatusUnauthorized, FormatResponse("unauthorized", nil))
+					}
+				}
+				principal = result
+			case strings.EqualFold(scheme, "ApiKey") && keys != nil:
+				result, err := keys.Authenticate(token)
+				if err != nil {
+					c.Logger().Error("middleware: authenticate api key error:", err.Error())
+					return c.JSON(http.StatusUnauthorized, FormatResponse("unauthorized", nil))
+				}
+				principal = result
+			default:
+				return c.JSON(http.StatusUnauthorized, FormatResponse("unauthorized", nil))
+			}
+
+			if principal.Limited() && !allowLimited {
+				return c.JSON(http.StatusForbidden, FormatResponse("forbidden", nil))
+			}
+
+			// A token scoped to an organization is only good for its own one,
+			// and names it when the request did not.
+			var ctx = c.Request().Context()
+			if principal.OrganizationID != "" {
+				if tenantID, ok := TenantFromContext(ctx); ok && tenantID != principal.OrganizationID {
+					return c.JSON(http.StatusForbidden, FormatResponse("organization mismatch", nil))
+				}
+				ctx = WithTenant(ctx, principal.OrganizationID)
+			}
+
+			if principal.Impersonated() {
+				c.Response().Header().Set(ImpersonatedByHeader, principal.ActorID)
+			}
+
+			c.Set(principalContextKey, principal)
+			c.SetRequest(c.Request().WithContext(WithPrincipal(ctx, principal)))
+
+			return next(c)
+		}
+	}
+}
+
+func RequireRole(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var principal = GetPrincipal(c)
+			if principal == nil || !principal.HasRole(role) {
+				return c.JSON(http.StatusForbidden, FormatResponse("forbidden", nil))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// DenyImpersonation guards the routes an admin must not use while acting as
+// someone else, like changing their credentials or deleting their account.
+func DenyImpersonation() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if principal := GetPrincipal(c); principal != nil && principal.Impersonated() {
+				return c.JSON(http.StatusForbidden, FormatResponse("not allowed while impersonating", nil))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireScope lets limited principals through only when they carry the
+// scope, unlimited ones always pass.
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var principal = GetPrincipal(c)
+			if principal == nil || (principal.Limited() && !principal.HasScope(scope)) {
+				return c.JSON(http.StatusForbidden, FormatResponse("forbidden", nil))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// ProvisioningAuthMiddleware guards the provisioning API with the one bearer
+// token the directory is configured with. Without a token every request is
+// refused, the API is off.
+func ProvisioningAuthMiddleware(token string) echo.MiddlewareFunc {
+	var expected = HashToken(token)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var header = c.Request().Header.Get(echo.HeaderAuthorization)
+			scheme, value, found := strings.Cut(header, " ")
+			value = strings.TrimSpace(value)
+			if token == "" || !found || !strings.EqualFold(scheme, "Bearer") || value == "" ||
+				subtle.ConstantTimeCompare([]byte(HashToken(value)), []byte(expected)) != 1 {
+				return c.JSON(http.StatusUnauthorized, FormatResponse("unauthorized", nil))
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	AvatarContentType = "image/jpeg"
+	// AvatarMaxPixels bounds the decoded size of uploads, a small file can
+	// still declare a huge image.
+	AvatarMaxPixels = 4096 * 4096
+	avatarQuality   = 85
+)
+
+type AvatarSize struct {
+	Name   string
+	Pixels int
+}
+
+// AvatarSizes are the square images kept of every avatar, largest first. The
+// original upload is not kept.
+var AvatarSizes = []AvatarSize{
+	{Name: "large", Pixels: 512},
+	{Name: "medium", Pixels: 128},
+	{Name: "small", Pixels: 48},
+}
+
+// avatarFormats maps the sniffed content types to the name the image package
+// registers the decoder under.
+var avatarFormats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// ProcessAvatar turns an uploaded image into the AvatarSizes, encoded as JPEG
+// and keyed by size name. The type is sniffed from the content, whatever the
+// client claims it to be. The image is cropped to the centered square,
+// transparent parts end up white, only the first frame of a GIF is used.
+func ProcessAvatar(body []byte) (map[string][]byte, error) {
+	format, found := avatarFormats[http.DetectContentType(body)]
+	if !found {
+		return nil, errors.New("unsupported image type")
+	}
+
+	config, decoded, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil || decoded != format || config.Width < 1 || config.Height < 1 {
+		return nil, errors.New("invalid image")
+	}
+	if config.Width*config.Height > AvatarMaxPixels {
+		return nil, errors.New("image too large")
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.New("invalid image")
+	}
+
+	var bounds = src.Bounds()
+	var side = bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	var origin = image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2)
+
+	var square = image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(square, square.Bounds(), src, origin, draw.Over)
+
+	var result = map[string][]byte{}
+	var current = square
+	for _, size := range AvatarSizes {
+		// Every size is scaled from the one before, which keeps the work on
+		// large uploads to a single pass over the original.
+		current = resizeSquare(current, size.Pixels)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, current, &jpeg.Options{Quality: avatarQuality}); err != nil {
+			return nil, err
+		}
+		result[size.Name] = buf.Bytes()
+	}
+
+	return result, nil
+}
+
+// resizeSquare scales a square image by averaging the source area under every
+// target pixel. Images smaller than the target are scaled up.
+func resizeSquare(src *image.RGBA, size int) *image.RGBA {
+	var side = src.Bounds().Dx()
+	var dst = image.NewRGBA(image.Rect(0, 0, size, size))
+	if side == size {
+		copy(dst.Pix, src.Pix)
+		return dst
+	}
+
+	var scale = float64(side) / float64(size)
+	for dy := 0; dy < size; dy++ {
+		var y0, y1 = float64(dy) * scale, float64(dy+1) * scale
+		for dx := 0; dx < size; dx++ {
+			var x0, x1 = float64(dx) * scale, float64(dx+1) * scale
+			var sum [4]float64
+			var total float64
+			for sy := int(y0); sy < side && float64(sy) < y1; sy++ {
+				var wy = overlap(y0, y1, sy)
+				for sx := int(x0); sx < side && float64(sx) < x1; sx++ {
+					var weight = wy * overlap(x0, x1, sx)
+					var offset = src.PixOffset(sx, sy)
+					for i := 0; i < 4; i++ {
+						sum[i] += weight * float64(src.Pix[offset+i])
+					}
+					total += weight
+				}
+			}
+
+			var offset = dst.PixOffset(dx, dy)
+			for i := 0; i < 4; i++ {
+				dst.Pix[offset+i] = uint8(sum[i]/total + 0.5)
+			}
+		}
+	}
+
+	return dst
+}
+
+// overlap is how much of the source pixel at position p lies in [from, to).
+func overlap(from float64, to float64, p int) float64 {
+	var start, end = float64(p), float64(p + 1)
+	if from > start {
+		start = from
+	}
+	if to < end {
+		end = to
+	}
+
+	return end - start
+}
+
+// AvatarKeys are the storage keys of the sizes of an avatar, keyed by size
+// name.
+func AvatarKeys(avatarKey string) map[string]string {
+	var result = map[string]string{}
+	for _, size := range AvatarSizes {
+		result[size.Name] = avatarKey + "-" + size.Name + ".jpg"
+	}
+
+	return result
+}
+
+// AvatarURLs links the sizes of an avatar, nil for accounts without one.
+func AvatarURLs(storage StorageInterface, avatarKey string) map[string]string {
+	if avatarKey == "" || storage == nil {
+		return nil
+	}
+
+	var result = map[string]string{}
+	for name, key := range AvatarKeys(avatarKey) {
+		result[name] = storage.URL(key)
+	}
+
+	return result
+}
+
+// DeleteAvatar removes every stored size of an avatar. It keeps going past a
+// failed size and returns the first error, accounts without an avatar are a
+// no-op.
+func DeleteAvatar(ctx context.Context, storage StorageInterface, avatarKey string) error {
+	if avatarKey == "" || storage == nil {
+		return nil
+	}
+
+	var result error
+	var keys = AvatarKeys(avatarKey)
+	for _, size := range AvatarSizes {
+		if err := storage.Delete(ctx, keys[size.Name]); err != nil && result == nil {
+			result = err
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,114 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestProcessAvatar(t *testing.T) {
+	t.Run("Resized into every size", func(t *testing.T) {
+		// A wide image, red on the left and right edge and blue in the
+		// middle, only the middle is kept.
+		var src = image.NewNRGBA(image.Rect(0, 0, 900, 600))
+		for y := 0; y < 600; y++ {
+			for x := 0; x < 900; x++ {
+				var c = color.NRGBA{R: 255, A: 255}
+				if x >= 150 && x < 750 {
+					c = color.NRGBA{B: 255, A: 255}
+				}
+				src.Set(x, y, c)
+			}
+		}
+
+		result, err := ProcessAvatar(encodePNG(t, src))
+		assert.Nil(t, err)
+		assert.Len(t, result, len(AvatarSizes))
+		for _, size := range AvatarSizes {
+			img, err := jpeg.Decode(bytes.NewReader(result[size.Name]))
+			assert.Nil(t, err)
+			assert.Equal(t, image.Rect(0, 0, size.Pixels, size.Pixels), img.Bounds())
+
+			r, _, b, _ := img.At(0, 0).RGBA()
+			assert.Less(t, r>>8, uint32(40), size.Name)
+			assert.Greater(t, b>>8, uint32(200), size.Name)
+		}
+	})
+
+	t.Run("Transparent parts are white", func(t *testing.T) {
+		result, err := ProcessAvatar(encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 20, 20))))
+		assert.Nil(t, err)
+
+		img, err := jpeg.Decode(bytes.NewReader(result["small"]))
+		assert.Nil(t, err)
+		r, g, b, _ := img.At(24, 24).RGBA()
+		assert.Greater(t, r>>8, uint32(240))
+		assert.Greater(t, g>>8, uint32(240))
+		assert.Greater(t, b>>8, uint32(240))
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		result, err := ProcessAvatar([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+		assert.EqualError(t, err, "unsupported image type")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Broken image", func(t *testing.T) {
+		var body = encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 20, 20)))
+
+		result, err := ProcessAvatar(body[:40])
+		assert.EqualError(t, err, "invalid image")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Too many pixels", func(t *testing.T) {
+		// Only the header is read, the declared size is enough to refuse it.
+		var body = encodePNG(t, image.NewGray(image.Rect(0, 0, 5000, 4000)))
+
+		result, err := ProcessAvatar(body)
+		assert.EqualError(t, err, "image too large")
+		assert.Nil(t, result)
+	})
+}
+
+func TestAvatarURLs(t *testing.T) {
+	var storage = NewLocalStorage(t.TempDir(), "https://auth.example.com/media")
+
+	assert.Nil(t, AvatarURLs(storage, ""))
+	assert.Equal(t, map[string]string{
+		"large":  "https://auth.example.com/media/avatars/userID/abc-large.jpg",
+		"medium": "https://auth.example.com/media/avatars/userID/abc-medium.jpg",
+		"small":  "https://auth.example.com/media/avatars/userID/abc-small.jpg",
+	}, AvatarURLs(storage, "avatars/userID/abc"))
+}
+
+func TestDeleteAvatar(t *testing.T) {
+	var dir = t.TempDir()
+	var storage = NewLocalStorage(dir, "https://auth.example.com/media")
+	for _, key := range AvatarKeys("avatars/userID/abc") {
+		assert.Nil(t, storage.Put(context.Background(), key, AvatarContentType, []byte("image")))
+	}
+
+	assert.Nil(t, DeleteAvatar(context.Background(), storage, "avatars/userID/abc"))
+	for _, size := range AvatarSizes {
+		_, err := os.Stat(filepath.Join(dir, "avatars", "userID", "abc-"+size.Name+".jpg"))
+		assert.True(t, os.IsNotExist(err), size.Name)
+	}
+
+	assert.Nil(t, DeleteAvatar(context.Background(), storage, ""))
+	assert.Nil(t, DeleteAvatar(context.Background(), nil, "avatars/userID/abc"))
+}
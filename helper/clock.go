@@ -0,0 +1,17 @@
+package helper
+
+import "time"
+
+type ClockInterface interface {
+	Now() time.Time
+}
+
+type Clock struct{}
+
+func NewClock() ClockInterface {
+	return &Clock{}
+}
+
+func (c Clock) Now() time.Time {
+	return time.Now()
+}
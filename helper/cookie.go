@@ -0,0 +1,150 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// SessionModeHeader is how a browser client asks for its tokens as
+	// cookies, with the value "cookie". Other clients keep getting them in
+	// the response body.
+	SessionModeHeader = "X-Session-Mode"
+	// CSRFHeader has to repeat the CSRF cookie on every unsafe request
+	// authenticated with the session cookies.
+	CSRFHeader = "X-CSRF-Token"
+
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFTokenCookie    = "csrf_token"
+
+	// The refresh cookie is only sent to the refresh endpoint, and lives as
+	// long as a session can. The server refuses it once the session ended.
+	refreshCookiePath   = "/login/refresh"
+	refreshCookieMaxAge = time.Hour * 24 * 30
+
+	cookieConfigKey = "session_cookie_config"
+)
+
+// SessionCookieConfig is the optional cookie mode for browser clients. The
+// cookies are always HttpOnly and Secure, except the CSRF cookie the
+// frontend has to read.
+type SessionCookieConfig struct {
+	Enabled  bool
+	Domain   string
+	SameSite http.SameSite
+}
+
+// SessionCookieMiddleware makes the config known to the handlers issuing
+// tokens and to the auth middleware. It has to run before both.
+func SessionCookieMiddleware(cfg SessionCookieConfig) echo.MiddlewareFunc {
+	if cfg.SameSite == 0 {
+		cfg.SameSite = http.SameSiteStrictMode
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if cfg.Enabled {
+				c.Set(cookieConfigKey, cfg)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func cookieConfig(c echo.Context) (SessionCookieConfig, bool) {
+	cfg, ok := c.Get(cookieConfigKey).(SessionCookieConfig)
+	return cfg, ok
+}
+
+// DeliverTokens sets the tokens of a login as cookies when the client asked
+// for cookie mode. It returns what goes into the response body: the tokens
+// themselves, or nil and the CSRF token in cookie mode.
+func DeliverTokens(c echo.Context, tokens map[string]any) (map[string]any, string) {
+	cfg, enabled := cookieConfig(c)
+	if !enabled || tokens == nil || c.Request().Header.Get(SessionModeHeader) != "cookie" {
+		return tokens, ""
+	}
+
+	var raw = make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		c.Logger().Error("cookie: csrf token error:", err.Error())
+		return tokens, ""
+	}
+	var csrfToken = base64.RawURLEncoding.EncodeToString(raw)
+
+	accessToken, _ := tokens["access_token"].(string)
+	refreshToken, _ := tokens["refresh_token"].(string)
+
+	c.SetCookie(cfg.cookie(AccessTokenCookie, accessToken, "/", AccessTokenTTL, true))
+	if refreshToken != "" {
+		c.SetCookie(cfg.cookie(RefreshTokenCookie, refreshToken, refreshCookiePath, refreshCookieMaxAge, true))
+	}
+	c.SetCookie(cfg.cookie(CSRFTokenCookie, csrfToken, "/", refreshCookieMaxAge, false))
+
+	return nil, csrfToken
+}
+
+// ClearSessionCookies removes the cookies of DeliverTokens, if any were set.
+func ClearSessionCookies(c echo.Context) {
+	cfg, enabled := cookieConfig(c)
+	if !enabled {
+		return
+	}
+
+	c.SetCookie(cfg.cookie(AccessTokenCookie, "", "/", -1, true))
+	c.SetCookie(cfg.cookie(RefreshTokenCookie, "", refreshCookiePath, -1, true))
+	c.SetCookie(cfg.cookie(CSRFTokenCookie, "", "/", -1, false))
+}
+
+// SessionCookie returns the value of one of the session cookies, or "" when
+// cookie mode is off.
+func SessionCookie(c echo.Context, name string) string {
+	if _, enabled := cookieConfig(c); !enabled {
+		return ""
+	}
+
+	cookie, err := c.Cookie(name)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
+// ValidCSRF checks the double-submit token of a request authenticated with
+// cookies. Safe methods change nothing and pass without it.
+func ValidCSRF(c echo.Context) bool {
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	var expected = SessionCookie(c, CSRFTokenCookie)
+	var sent = c.Request().Header.Get(CSRFHeader)
+
+	return expected != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(expected)) == 1
+}
+
+func (cfg SessionCookieConfig) cookie(name string, value string, path string, maxAge time.Duration, httpOnly bool) *http.Cookie {
+	var cookie = new(http.Cookie)
+	cookie.Name = name
+	cookie.Value = value
+	cookie.Path = path
+	cookie.Domain = cfg.Domain
+	cookie.MaxAge = int(maxAge.Seconds())
+	if maxAge < 0 {
+		cookie.MaxAge = -1
+	}
+	cookie.Secure = true
+	cookie.HttpOnly = httpOnly
+	cookie.SameSite = cfg.SameSite
+
+	return cookie
+}
@@ -0,0 +1,168 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func withCookieMode(cfg SessionCookieConfig, next echo.HandlerFunc) echo.HandlerFunc {
+	return SessionCookieMiddleware(cfg)(next)
+}
+
+func findCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestDeliverTokens(t *testing.T) {
+	e := echo.New()
+	tokens := map[string]any{"access_token": "randomAccessToken", "refresh_token": "randomRefreshToken"}
+	var body map[string]any
+	var csrfToken string
+	handler := func(c echo.Context) error {
+		body, csrfToken = DeliverTokens(c, tokens)
+		return c.NoContent(http.StatusOK)
+	}
+
+	t.Run("Cookie mode requested", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.Header.Set(SessionModeHeader, "cookie")
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, withCookieMode(SessionCookieConfig{Enabled: true, Domain: "example.com"}, handler)(e.NewContext(req, rec)))
+		assert.Nil(t, body)
+		assert.NotEmpty(t, csrfToken)
+
+		access := findCookie(rec, AccessTokenCookie)
+		assert.Equal(t, "randomAccessToken", access.Value)
+		assert.True(t, access.HttpOnly)
+		assert.True(t, access.Secure)
+		assert.Equal(t, http.SameSiteStrictMode, access.SameSite)
+		assert.Equal(t, int(AccessTokenTTL/time.Second), access.MaxAge)
+		assert.Equal(t, "example.com", access.Domain)
+
+		refresh := findCookie(rec, RefreshTokenCookie)
+		assert.Equal(t, "randomRefreshToken", refresh.Value)
+		assert.Equal(t, "/login/refresh", refresh.Path)
+		assert.True(t, refresh.HttpOnly)
+
+		csrf := findCookie(rec, CSRFTokenCookie)
+		assert.Equal(t, csrfToken, csrf.Value)
+		assert.False(t, csrf.HttpOnly)
+	})
+
+	t.Run("Not requested", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, withCookieMode(SessionCookieConfig{Enabled: true}, handler)(e.NewContext(req, rec)))
+		assert.Equal(t, tokens, body)
+		assert.Empty(t, csrfToken)
+		assert.Empty(t, rec.Result().Cookies())
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.Header.Set(SessionModeHeader, "cookie")
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, withCookieMode(SessionCookieConfig{}, handler)(e.NewContext(req, rec)))
+		assert.Equal(t, tokens, body)
+		assert.Empty(t, rec.Result().Cookies())
+	})
+
+	t.Run("Cleared", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, withCookieMode(SessionCookieConfig{Enabled: true}, func(c echo.Context) error {
+			ClearSessionCookies(c)
+			return c.NoContent(http.StatusOK)
+		})(e.NewContext(req, rec)))
+		assert.Equal(t, -1, findCookie(rec, AccessTokenCookie).MaxAge)
+		assert.Equal(t, -1, findCookie(rec, RefreshTokenCookie).MaxAge)
+		assert.Equal(t, -1, findCookie(rec, CSRFTokenCookie).MaxAge)
+	})
+}
+
+func TestCookieAuthMiddleware(t *testing.T) {
+	j := newTestJWT(time.Now())
+	e := echo.New()
+	var handler = withCookieMode(SessionCookieConfig{Enabled: true}, AuthMiddleware(j, nil)(func(c echo.Context) error {
+		return c.String(http.StatusOK, GetPrincipal(c).UserID)
+	}))
+	var accessCookie = &http.Cookie{Name: AccessTokenCookie, Value: j.GenerateToken(Principal{UserID: "randomUserID"})}
+	var csrfCookie = &http.Cookie{Name: CSRFTokenCookie, Value: "randomCSRFToken"}
+
+	t.Run("Safe method without csrf token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(accessCookie)
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "randomUserID", rec.Body.String())
+	})
+
+	t.Run("Unsafe method with csrf token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/", nil)
+		req.AddCookie(accessCookie)
+		req.AddCookie(csrfCookie)
+		req.Header.Set(CSRFHeader, "randomCSRFToken")
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Unsafe method without csrf token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.AddCookie(accessCookie)
+		req.AddCookie(csrfCookie)
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Wrong csrf token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/", nil)
+		req.AddCookie(accessCookie)
+		req.AddCookie(csrfCookie)
+		req.Header.Set(CSRFHeader, "otherCSRFToken")
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Bearer token needs no csrf token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+accessCookie.Value)
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Cookie ignored when disabled", func(t *testing.T) {
+		var disabled = withCookieMode(SessionCookieConfig{}, AuthMiddleware(j, nil)(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(accessCookie)
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, disabled(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
@@ -0,0 +1,32 @@
+package helper
+
+import (
+	"net"
+	"strings"
+)
+
+// DeviceFingerprint identifies the client a login came from. A device id sent
+// by the client wins over the user agent, which changes on every update.
+func DeviceFingerprint(meta RequestMeta) string {
+	if meta.DeviceID != "" {
+		return HashToken("device:" + meta.DeviceID)
+	}
+
+	return HashToken("ua:" + strings.TrimSpace(meta.UserAgent))
+}
+
+// IPRange groups addresses that usually belong to the same network: the /24
+// for IPv4 and the /48 for IPv6. An empty string is returned for anything
+// that does not parse.
+func IPRange(ip string) string {
+	var addr = net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+
+	if v4 := addr.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+
+	return (&net.IPNet{IP: addr.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
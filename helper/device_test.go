@@ -0,0 +1,33 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPRange(t *testing.T) {
+	assert.Equal(t, "103.10.20.0/24", IPRange("103.10.20.7"))
+	assert.Equal(t, IPRange("103.10.20.7"), IPRange("103.10.20.250"))
+	assert.NotEqual(t, IPRange("103.10.20.7"), IPRange("103.10.21.7"))
+	assert.Equal(t, "2001:db8:1::/48", IPRange("2001:db8:1:2::1"))
+	assert.Equal(t, "", IPRange("not an ip"))
+}
+
+func TestDeviceFingerprint(t *testing.T) {
+	var browser = RequestMeta{UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/118.0"}
+
+	assert.Equal(t, DeviceFingerprint(browser), DeviceFingerprint(RequestMeta{UserAgent: browser.UserAgent + " "}))
+	assert.NotEqual(t, DeviceFingerprint(browser), DeviceFingerprint(RequestMeta{UserAgent: "curl/8.0"}))
+	assert.Equal(t,
+		DeviceFingerprint(RequestMeta{UserAgent: "app/1.0", DeviceID: "randomDeviceID"}),
+		DeviceFingerprint(RequestMeta{UserAgent: "app/1.1", DeviceID: "randomDeviceID"}))
+}
+
+func TestDistanceKm(t *testing.T) {
+	var jakarta = GeoLocation{Latitude: -6.2088, Longitude: 106.8456}
+	var surabaya = GeoLocation{Latitude: -7.2575, Longitude: 112.7521}
+
+	assert.InDelta(t, 663, DistanceKm(jakarta, surabaya), 10)
+	assert.Equal(t, 0.0, DistanceKm(jakarta, jakarta))
+}
@@ -1,9 +1,16 @@
 package helper
 
-import "github.com/google/uuid"
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+
+	"github.com/google/uuid"
+)
 
 type GeneratorInterface interface {
 	GenerateUUID() (string, error)
+	GenerateOTP(length int) (string, error)
 }
 
 type Generator struct{}
@@ -20,3 +27,16 @@ func (g Generator) GenerateUUID() (string, error) {
 
 	return result.String(), nil
 }
+
+func (g Generator) GenerateOTP(length int) (string, error) {
+	var result strings.Builder
+	for i := 0; i < length; i++ {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(digit.String())
+	}
+
+	return result.String(), nil
+}
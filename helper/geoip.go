@@ -0,0 +1,72 @@
+package helper
+
+import (
+	"errors"
+	"math"
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+type GeoLocation struct {
+	Country   string
+	City      string
+	Latitude  float64
+	Longitude float64
+}
+
+type GeoLocatorInterface interface {
+	Locate(ip string) (*GeoLocation, error)
+}
+
+// GeoIPLocator looks addresses up in a local MaxMind City database, so no
+// request leaves the server.
+type GeoIPLocator struct {
+	db *geoip2.Reader
+}
+
+func NewGeoIPLocator(path string) (*GeoIPLocator, error) {
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeoIPLocator{db: db}, nil
+}
+
+func (gl *GeoIPLocator) Locate(ip string) (*GeoLocation, error) {
+	var addr = net.ParseIP(ip)
+	if addr == nil {
+		return nil, errors.New("invalid ip address")
+	}
+
+	record, err := gl.db.City(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.Location.Latitude == 0 && record.Location.Longitude == 0 {
+		return nil, errors.New("location not found")
+	}
+
+	var result = new(GeoLocation)
+	result.Country = record.Country.IsoCode
+	result.City = record.City.Names["en"]
+	result.Latitude = record.Location.Latitude
+	result.Longitude = record.Location.Longitude
+
+	return result, nil
+}
+
+// DistanceKm is the great-circle distance between two locations.
+func DistanceKm(from GeoLocation, to GeoLocation) float64 {
+	const earthRadiusKm = 6371.0
+
+	var lat1 = from.Latitude * math.Pi / 180
+	var lat2 = to.Latitude * math.Pi / 180
+	var dLat = lat2 - lat1
+	var dLon = (to.Longitude - from.Longitude) * math.Pi / 180
+
+	var a = math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,13 @@
+package helper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken is meant for high-entropy, server generated secrets (recovery
+// codes, one-time codes with short expiry); use bcrypt for passwords.
+func HashToken(value string) string {
+	var sum = sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
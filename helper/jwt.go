@@ -1,7 +1,7 @@
 package helper
 
 import (
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -9,27 +9,79 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// AccessTokenTTL is how long access tokens from GenerateToken are valid.
+const AccessTokenTTL = time.Minute * 10
+
+// MaxTokenGroups caps the groups embedded in an access token. Tokens of users
+// in more groups carry none, they have to be looked up at /users/me/groups,
+// so the token still fits in a header or cookie.
+const MaxTokenGroups = 16
+
 type JWTInterface interface {
-	GenerateJWT(userID string) map[string]any
-	GenerateToken(id string) string
-	ExtractToken(token *jwt.Token) any
+	GenerateJWT(principal Principal) map[string]any
+	GenerateToken(principal Principal) string
+	ExtractToken(token *jwt.Token) (*Principal, error)
+	ParseToken(tokenString string) (*Principal, error)
+	GenerateChallengeToken(userID string, purpose string, ttl time.Duration) string
+	ParseChallengeToken(tokenString string, purpose string) (string, error)
+}
+
+// GroupSourceInterface lists the names of the groups a user is in, including
+// the ones inherited through nested groups.
+type GroupSourceInterface interface {
+	GroupsOf(userID string) ([]string, error)
 }
 
 type JWT struct {
 	signKey    string
 	refreshKey string
+	issuer     string
+	audience   string
+	groups     GroupSourceInterface
+	now        func() time.Time
+}
+
+type Claims struct {
+	UserID    string   `json:"id"`
+	Roles     []string `json:"roles,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	// OrganizationID scopes the token to one organization the user is a
+	// member of.
+	OrganizationID string `json:"org,omitempty"`
+	// Groups is only informational, permissions are checked against the
+	// current groups on every request.
+	Groups []string `json:"groups,omitempty"`
+	// Purpose is empty for access tokens. Short-lived tokens that only prove
+	// one step of a flow (e.g. a passed password check) carry their purpose
+	// and are refused by ParseToken.
+	Purpose string `json:"purpose,omitempty"`
+	// Actor is the RFC 8693 act claim of an impersonation token: the admin
+	// acting as the user in sub.
+	Actor *ActorClaim `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type ActorClaim struct {
+	Subject string `json:"sub"`
 }
 
-func New(signKey string, refreshKey string) JWTInterface {
+// New embeds the groups of the user in access tokens when groups is set.
+func New(signKey string, refreshKey string, issuer string, audience string, groups GroupSourceInterface) JWTInterface {
 	return &JWT{
 		signKey:    signKey,
 		refreshKey: refreshKey,
+		issuer:     issuer,
+		audience:   audience,
+		groups:     groups,
+		now:        time.Now,
 	}
 }
 
-func (j *JWT) GenerateJWT(userID string) map[string]any {
+func (j *JWT) GenerateJWT(principal Principal) map[string]any {
 	var result = map[string]any{}
-	var accessToken = j.GenerateToken(userID)
+	var accessToken = j.GenerateToken(principal)
 	if accessToken == "" {
 		return nil
 	}
@@ -37,12 +89,92 @@ func (j *JWT) GenerateJWT(userID string) map[string]any {
 	return result
 }
 
-func (j *JWT) GenerateToken(id string) string {
-	var claims = jwt.MapClaims{}
-	claims["id"] = id
-	claims["iat"] = time.Now().Unix()
-	claims["exp"] = time.Now().Add(time.Minute * 10).Unix()
+func (j *JWT) GenerateToken(principal Principal) string {
+	var claims = j.newClaims(principal.UserID, AccessTokenTTL)
+	claims.Roles = principal.Roles
+	claims.Scopes = principal.Scopes
+	claims.SessionID = principal.SessionID
+	claims.ClientID = principal.ClientID
+	claims.OrganizationID = principal.OrganizationID
+	claims.ID = principal.GrantID
+	if principal.ActorID != "" {
+		claims.Actor = &ActorClaim{Subject: principal.ActorID}
+	}
+	claims.Groups = j.tokenGroups(principal)
+
+	return j.sign(claims)
+}
+
+// tokenGroups leaves the groups out of tokens issued to OAuth clients, which
+// have no business knowing them, and out of tokens they would make too big.
+func (j *JWT) tokenGroups(principal Principal) []string {
+	if j.groups == nil || principal.ClientID != "" {
+		return nil
+	}
+
+	groups, err := j.groups.GroupsOf(principal.UserID)
+	if err != nil {
+		logrus.Error("get token groups error", err.Error())
+		return nil
+	}
 
+	if len(groups) == 0 || len(groups) > MaxTokenGroups {
+		return nil
+	}
+
+	return groups
+}
+
+func (j *JWT) ParseToken(tokenString string) (*Principal, error) {
+	token, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	return j.ExtractToken(token)
+}
+
+func (j *JWT) GenerateChallengeToken(userID string, purpose string, ttl time.Duration) string {
+	var claims = j.newClaims(userID, ttl)
+	claims.Purpose = purpose
+
+	return j.sign(claims)
+}
+
+func (j *JWT) ParseChallengeToken(tokenString string, purpose string) (string, error) {
+	token, err := j.parse(tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	claims := token.Claims.(*Claims)
+	if claims.ExpiresAt == nil {
+		return "", jwt.ErrTokenRequiredClaimMissing
+	}
+	if purpose == "" || claims.Purpose != purpose {
+		return "", errors.New("token purpose mismatch")
+	}
+
+	return claims.UserID, nil
+}
+
+func (j *JWT) newClaims(userID string, ttl time.Duration) *Claims {
+	var now = j.now()
+	var claims = new(Claims)
+	claims.UserID = userID
+	claims.Subject = userID
+	claims.Issuer = j.issuer
+	if j.audience != "" {
+		claims.Audience = jwt.ClaimStrings{j.audience}
+	}
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+
+	return claims
+}
+
+func (j *JWT) sign(claims *Claims) string {
 	var sign = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	validToken, err := sign.SignedString([]byte(j.signKey))
 
@@ -53,6 +185,66 @@ func (j *JWT) GenerateToken(id string) string {
 	return validToken
 }
 
+func (j *JWT) parse(tokenString string) (*jwt.Token, error) {
+	var options = []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithTimeFunc(j.now),
+	}
+	if j.issuer != "" {
+		options = append(options, jwt.WithIssuer(j.issuer))
+	}
+	if j.audience != "" {
+		options = append(options, jwt.WithAudience(j.audience))
+	}
+
+	return jwt.ParseWithClaims(tokenString, new(Claims), func(t *jwt.Token) (interface{}, error) {
+		return []byte(j.signKey), nil
+	}, options...)
+}
+
+func (j *JWT) ExtractToken(token *jwt.Token) (*Principal, error) {
+	if token == nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok {
+		return nil, errors.New("unsupported token claims")
+	}
+
+	// jwt/v5 only checks exp when it is present, an access token without one
+	// would otherwise never expire.
+	if claims.ExpiresAt == nil {
+		return nil, jwt.ErrTokenRequiredClaimMissing
+	}
+
+	if claims.Purpose != "" {
+		return nil, errors.New("token is not an access token")
+	}
+
+	if claims.UserID == "" {
+		return nil, errors.New("token has no user id")
+	}
+
+	var result = new(Principal)
+	result.UserID = claims.UserID
+	result.Roles = claims.Roles
+	result.Scopes = claims.Scopes
+	result.SessionID = claims.SessionID
+	result.ClientID = claims.ClientID
+	result.OrganizationID = claims.OrganizationID
+	result.Groups = claims.Groups
+	result.GrantID = claims.ID
+	if claims.Actor != nil {
+		result.ActorID = claims.Actor.Subject
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = claims.IssuedAt.Time
+	}
+
+	return result, nil
+}
+
 func (j JWT) RefereshJWT(accessToken string, refreshToken *jwt.Token) map[string]any {
 	var result = map[string]any{}
 	expTime, err := refreshToken.Claims.GetExpirationTime()
@@ -103,20 +295,3 @@ func (j *JWT) generateRefreshToken(accessToken string) string {
 
 	return refreshToken
 }
-
-func (j JWT) ExtractToken(token *jwt.Token) any {
-	if token.Valid {
-		var claims = token.Claims
-		expTime, _ := claims.GetExpirationTime()
-		fmt.Println(expTime.Time.After(time.Now()))
-		if expTime.Time.After(time.Now()) {
-			var mapClaim = claims.(jwt.MapClaims)
-			return mapClaim["id"]
-		}
-
-		logrus.Error("Token expired")
-		return nil
-
-	}
-	return nil
-}
@@ -0,0 +1,440 @@
+package helper
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestJWT(now time.Time) *JWT {
+	return &JWT{
+		signKey:    "secret",
+		refreshKey: "refresh",
+		issuer:     "restfull",
+		audience:   "restfull-api",
+		now:        func() time.Time { return now },
+	}
+}
+
+func TestParseToken(t *testing.T) {
+	issuedAt := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	principal := Principal{
+		UserID:    "randomUserID",
+		Roles:     []string{"admin"},
+		Scopes:    []string{"users:read"},
+		SessionID: "randomSessionID",
+		ClientID:  "randomClientID",
+		GrantID:   "randomGrantID",
+	}
+
+	t.Run("Success parse", func(t *testing.T) {
+		j := newTestJWT(issuedAt)
+		token := j.GenerateToken(principal)
+
+		result, err := j.ParseToken(token)
+		assert.Nil(t, err)
+		assert.Equal(t, principal.UserID, result.UserID)
+		assert.Equal(t, principal.Roles, result.Roles)
+		assert.Equal(t, principal.Scopes, result.Scopes)
+		assert.Equal(t, principal.SessionID, result.SessionID)
+		assert.Equal(t, principal.ClientID, result.ClientID)
+		assert.Equal(t, principal.GrantID, result.GrantID)
+		assert.True(t, issuedAt.Equal(result.IssuedAt))
+		assert.True(t, result.HasRole("admin"))
+		assert.True(t, result.HasScope("users:read"))
+		assert.False(t, result.Impersonated())
+	})
+
+	t.Run("Impersonation token names the actor", func(t *testing.T) {
+		j := newTestJWT(issuedAt)
+		token := j.GenerateToken(Principal{UserID: "randomUserID", SessionID: "randomSessionID", ActorID: "randomAdminID"})
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]any{"sub": "randomAdminID"}, parsed.Claims.(jwt.MapClaims)["act"])
+
+		result, err := j.ParseToken(token)
+		assert.Nil(t, err)
+		assert.Equal(t, "randomAdminID", result.ActorID)
+		assert.True(t, result.Impersonated())
+	})
+
+	t.Run("Invalid signature", func(t *testing.T) {
+		token := newTestJWT(issuedAt).GenerateToken(principal)
+		other := newTestJWT(issuedAt)
+		other.signKey = "another secret"
+
+		result, err := other.ParseToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		token := newTestJWT(issuedAt).GenerateToken(principal)
+
+		result, err := newTestJWT(issuedAt.Add(time.Minute * 11)).ParseToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Token not valid yet", func(t *testing.T) {
+		token := newTestJWT(issuedAt).GenerateToken(principal)
+
+		result, err := newTestJWT(issuedAt.Add(-time.Minute)).ParseToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenNotValidYet)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Wrong issuer", func(t *testing.T) {
+		token := newTestJWT(issuedAt).GenerateToken(principal)
+		other := newTestJWT(issuedAt)
+		other.issuer = "someone-else"
+
+		result, err := other.ParseToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Wrong audience", func(t *testing.T) {
+		token := newTestJWT(issuedAt).GenerateToken(principal)
+		other := newTestJWT(issuedAt)
+		other.audience = "another-api"
+
+		result, err := other.ParseToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Missing expiration", func(t *testing.T) {
+		j := newTestJWT(issuedAt)
+		claims := jwt.MapClaims{"id": principal.UserID, "iss": j.issuer, "aud": j.audience}
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(j.signKey))
+
+		result, err := j.ParseToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenRequiredClaimMissing)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Unexpected signing method", func(t *testing.T) {
+		j := newTestJWT(issuedAt)
+		claims := jwt.MapClaims{"id": principal.UserID, "exp": issuedAt.Add(time.Minute).Unix()}
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+		result, err := j.ParseToken(token)
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
+
+// groupSource lists the groups for the user ID.
+type groupSource map[string][]string
+
+func (s groupSource) GroupsOf(userID string) ([]string, error) {
+	return s[userID], nil
+}
+
+func TestTokenGroups(t *testing.T) {
+	issuedAt := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	many := make([]string, MaxTokenGroups+1)
+	for i := range many {
+		many[i] = "group"
+	}
+	j := newTestJWT(issuedAt)
+	j.groups = groupSource{"randomUserID": {"finance", "support"}, "busyUserID": many}
+
+	t.Run("Groups embedded", func(t *testing.T) {
+		result, err := j.ParseToken(j.GenerateToken(Principal{UserID: "randomUserID"}))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"finance", "support"}, result.Groups)
+	})
+
+	t.Run("Too many groups left out", func(t *testing.T) {
+		result, err := j.ParseToken(j.GenerateToken(Principal{UserID: "busyUserID"}))
+		assert.Nil(t, err)
+		assert.Nil(t, result.Groups)
+	})
+
+	t.Run("Left out of client tokens", func(t *testing.T) {
+		result, err := j.ParseToken(j.GenerateToken(Principal{UserID: "randomUserID", ClientID: "randomClientID", Scopes: []string{"openid"}}))
+		assert.Nil(t, err)
+		assert.Nil(t, result.Groups)
+	})
+}
+
+func TestChallengeToken(t *testing.T) {
+	j := newTestJWT(time.Now())
+
+	t.Run("Success parse", func(t *testing.T) {
+		token := j.GenerateChallengeToken("randomUserID", "mfa", time.Minute)
+
+		userID, err := j.ParseChallengeToken(token, "mfa")
+		assert.Nil(t, err)
+		assert.Equal(t, "randomUserID", userID)
+	})
+
+	t.Run("Wrong purpose", func(t *testing.T) {
+		token := j.GenerateChallengeToken("randomUserID", "mfa", time.Minute)
+
+		userID, err := j.ParseChallengeToken(token, "reset")
+		assert.Error(t, err)
+		assert.Empty(t, userID)
+	})
+
+	t.Run("Not accepted as access token", func(t *testing.T) {
+		token := j.GenerateChallengeToken("randomUserID", "mfa", time.Minute)
+
+		result, err := j.ParseToken(token)
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Access token is not a challenge", func(t *testing.T) {
+		token := j.GenerateToken(Principal{UserID: "randomUserID"})
+
+		userID, err := j.ParseChallengeToken(token, "mfa")
+		assert.Error(t, err)
+		assert.Empty(t, userID)
+	})
+}
+
+type sessionValidatorFunc func(principal Principal) error
+
+func (f sessionValidatorFunc) ValidateSession(principal Principal) error {
+	return f(principal)
+}
+
+type apiKeyAuthenticatorFunc func(key string) (*Principal, error)
+
+func (f apiKeyAuthenticatorFunc) Authenticate(key string) (*Principal, error) {
+	return f(key)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	j := newTestJWT(time.Now())
+	e := echo.New()
+	var handler = AuthMiddleware(j, nil)(func(c echo.Context) error {
+		principal := GetPrincipal(c)
+		fromContext, ok := PrincipalFromContext(c.Request().Context())
+		assert.True(t, ok)
+		assert.Equal(t, principal, fromContext)
+		return c.String(http.StatusOK, principal.UserID)
+	})
+
+	t.Run("Valid bearer token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+j.GenerateToken(Principal{UserID: "randomUserID"}))
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "randomUserID", rec.Body.String())
+	})
+
+	t.Run("Missing header", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer not-a-token")
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Revoked session", func(t *testing.T) {
+		revoked := AuthMiddleware(j, sessionValidatorFunc(func(principal Principal) error {
+			return errors.New("session revoked")
+		}))(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+j.GenerateToken(Principal{UserID: "randomUserID"}))
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, revoked(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	keys := apiKeyAuthenticatorFunc(func(key string) (*Principal, error) {
+		if key != "ak_prefix_secret" {
+			return nil, errors.New("invalid api key")
+		}
+		return &Principal{UserID: "randomUserID", Scopes: []string{"audit:read"}, APIKeyID: "randomKeyID"}, nil
+	})
+	var keyHandler = ScopedAuthMiddleware(j, nil, keys)(func(c echo.Context) error {
+		return c.String(http.StatusOK, GetPrincipal(c).APIKeyID)
+	})
+
+	t.Run("Valid api key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "ApiKey ak_prefix_secret")
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, keyHandler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "randomKeyID", rec.Body.String())
+	})
+
+	t.Run("Invalid api key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "ApiKey ak_prefix_wrong")
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, keyHandler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Api key on token only route", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "ApiKey ak_prefix_secret")
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Client token on token only route", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+j.GenerateToken(Principal{UserID: "randomUserID", Scopes: []string{"audit:read"}, ClientID: "randomClientID", GrantID: "randomGrantID"}))
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Client token on scoped route", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+j.GenerateToken(Principal{UserID: "randomUserID", Scopes: []string{"audit:read"}, ClientID: "randomClientID", GrantID: "randomGrantID"}))
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, keyHandler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestRequireScope(t *testing.T) {
+	e := echo.New()
+	var handler = RequireScope("audit:read")(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	t.Run("Unlimited principal", func(t *testing.T) {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		c.Set("principal", &Principal{UserID: "randomUserID"})
+
+		assert.Nil(t, handler(c))
+		assert.Equal(t, http.StatusOK, c.Response().Status)
+	})
+
+	t.Run("Has scope", func(t *testing.T) {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		c.Set("principal", &Principal{UserID: "randomUserID", Scopes: []string{"audit:read"}, APIKeyID: "randomKeyID"})
+
+		assert.Nil(t, handler(c))
+		assert.Equal(t, http.StatusOK, c.Response().Status)
+	})
+
+	t.Run("Missing scope", func(t *testing.T) {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		c.Set("principal", &Principal{UserID: "randomUserID", Scopes: []string{"privacy:export"}, APIKeyID: "randomKeyID"})
+
+		assert.Nil(t, handler(c))
+		assert.Equal(t, http.StatusForbidden, c.Response().Status)
+	})
+}
+
+func TestRequireRole(t *testing.T) {
+	e := echo.New()
+	var handler = RequireRole("admin")(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	t.Run("Has role", func(t *testing.T) {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		c.Set("principal", &Principal{UserID: "randomUserID", Roles: []string{"admin"}})
+
+		assert.Nil(t, handler(c))
+		assert.Equal(t, http.StatusOK, c.Response().Status)
+	})
+
+	t.Run("Missing role", func(t *testing.T) {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		c.Set("principal", &Principal{UserID: "randomUserID", Roles: []string{"user"}})
+
+		assert.Nil(t, handler(c))
+		assert.Equal(t, http.StatusForbidden, c.Response().Status)
+	})
+}
+
+func TestImpersonation(t *testing.T) {
+	j := newTestJWT(time.Now())
+	e := echo.New()
+	var handler = AuthMiddleware(j, nil)(DenyImpersonation()(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}))
+
+	t.Run("Own token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+j.GenerateToken(Principal{UserID: "randomUserID"}))
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get(ImpersonatedByHeader))
+	})
+
+	t.Run("Impersonation token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+j.GenerateToken(Principal{UserID: "randomUserID", ActorID: "randomAdminID"}))
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Equal(t, "randomAdminID", rec.Header().Get(ImpersonatedByHeader))
+	})
+}
+
+func TestProvisioningAuthMiddleware(t *testing.T) {
+	e := echo.New()
+	var next = func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	var cases = []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{"Valid token", "provisioning-token", "Bearer provisioning-token", http.StatusNoContent},
+		{"Wrong token", "provisioning-token", "Bearer other-token", http.StatusUnauthorized},
+		{"Wrong scheme", "provisioning-token", "ApiKey provisioning-token", http.StatusUnauthorized},
+		{"Missing header", "provisioning-token", "", http.StatusUnauthorized},
+		{"No token configured", "", "Bearer ", http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tc.header)
+			}
+			rec := httptest.NewRecorder()
+
+			assert.Nil(t, ProvisioningAuthMiddleware(tc.token)(next)(e.NewContext(req, rec)))
+			assert.Equal(t, tc.status, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package helper
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPConfig is the corporate directory staff log in with, as configured in
+// the LDAP config file.
+type LDAPConfig struct {
+	// Name is what the account is linked to, changing it unlinks every
+	// account the directory created.
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	StartTLS bool   `json:"start_tls"`
+	// BindDN and BindPassword are the service account the user is looked up
+	// with. Without them the search runs anonymously.
+	BindDN       string `json:"bind_dn"`
+	BindPassword string `json:"bind_password"`
+	BaseDN       string `json:"base_dn"`
+	// UserFilter finds the entry of a login, every {login} in it is replaced
+	// with the escaped login. It has to match one entry at most.
+	UserFilter string `json:"user_filter"`
+	// IDAttribute identifies the entry for good, like entryUUID or objectGUID.
+	// The DN is used without it, so a renamed entry becomes a new account.
+	IDAttribute    string `json:"id_attribute"`
+	NameAttribute  string `json:"name_attribute"`
+	PhoneAttribute string `json:"phone_attribute"`
+	GroupAttribute string `json:"group_attribute"`
+	// GroupRoles is checked in order, the first group the user is a member of
+	// decides the role. Users in none of them get DefaultRole.
+	GroupRoles  []LDAPGroupRole `json:"group_roles"`
+	DefaultRole string          `json:"default_role"`
+	Timeout     time.Duration   `json:"-"`
+}
+
+type LDAPGroupRole struct {
+	Group string `json:"group"`
+	Role  string `json:"role"`
+}
+
+// DirectoryUser is the entry of a user the directory authenticated.
+type DirectoryUser struct {
+	ID     string
+	DN     string
+	Nama   string
+	HP     string
+	Groups []string
+	Role   string
+}
+
+type DirectoryInterface interface {
+	Name() string
+	Authenticate(login string, password string) (*DirectoryUser, error)
+}
+
+// LDAPDirectory authenticates with a search and bind: the entry of the login
+// is looked up with the service account, then bound to with the password. A
+// new connection is opened for every login.
+type LDAPDirectory struct {
+	cfg LDAPConfig
+}
+
+func NewLDAPDirectory(cfg LDAPConfig) *LDAPDirectory {
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(uid={login})"
+	}
+	if cfg.NameAttribute == "" {
+		cfg.NameAttribute = "cn"
+	}
+	if cfg.PhoneAttribute == "" {
+		cfg.PhoneAttribute = "mobile"
+	}
+	if cfg.GroupAttribute == "" {
+		cfg.GroupAttribute = "memberOf"
+	}
+	if cfg.DefaultRole == "" {
+		cfg.DefaultRole = "user"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Second * 10
+	}
+
+	return &LDAPDirectory{
+		cfg: cfg,
+	}
+}
+
+// LoadLDAPConfig reads the LDAP config file.
+func LoadLDAPConfig(path string) (*LDAPConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg = new(LDAPConfig)
+	if err := json.Unmarshal(raw, cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Name == "" || cfg.URL == "" || cfg.BaseDN == "" {
+		return nil, errors.New("incomplete ldap config")
+	}
+
+	return cfg, nil
+}
+
+func (d *LDAPDirectory) Name() string {
+	return d.cfg.Name
+}
+
+// Authenticate returns "data not found" when the directory has no entry for
+// the login, and "invalid password" with the entry when the bind is refused.
+func (d *LDAPDirectory) Authenticate(login string, password string) (*DirectoryUser, error) {
+	// An empty password would be an unauthenticated bind, which servers
+	// accept for any DN.
+	if login == "" || password == "" {
+		return nil, errors.New("data not found")
+	}
+
+	conn, err := ldap.DialURL(d.cfg.URL, ldap.DialWithDialer(&net.Dialer{Timeout: d.cfg.Timeout}))
+	if err != nil {
+		return nil, errors.New("directory unavailable")
+	}
+	defer conn.Close()
+	conn.SetTimeout(d.cfg.Timeout)
+
+	if d.cfg.StartTLS {
+		if err := conn.StartTLS(&tls.Config{ServerName: hostname(d.cfg.URL)}); err != nil {
+			return nil, errors.New("directory unavailable")
+		}
+	}
+
+	if d.cfg.BindDN != "" {
+		if err := conn.Bind(d.cfg.BindDN, d.cfg.BindPassword); err != nil {
+			return nil, errors.New("directory bind failed")
+		}
+	}
+
+	var attributes = []string{d.cfg.NameAttribute, d.cfg.PhoneAttribute, d.cfg.GroupAttribute}
+	if d.cfg.IDAttribute != "" {
+		attributes = append(attributes, d.cfg.IDAttribute)
+	}
+
+	var filter = strings.ReplaceAll(d.cfg.UserFilter, "{login}", ldap.EscapeFilter(login))
+	var request = ldap.NewSearchRequest(d.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(d.cfg.Timeout.Seconds()), false, filter, attributes, nil)
+
+	found, err := conn.Search(request)
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, errors.New("directory search failed")
+	}
+	if found == nil || len(found.Entries) == 0 {
+		return nil, errors.New("data not found")
+	}
+	if len(found.Entries) > 1 {
+		return nil, errors.New("ambiguous login")
+	}
+
+	var result = d.directoryUser(found.Entries[0])
+
+	if err := conn.Bind(result.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return result, errors.New("invalid password")
+		}
+		return nil, errors.New("directory bind failed")
+	}
+
+	return result, nil
+}
+
+func (d *LDAPDirectory) directoryUser(entry *ldap.Entry) *DirectoryUser {
+	var result = new(DirectoryUser)
+	result.DN = entry.DN
+	result.ID = entry.DN
+	if d.cfg.IDAttribute != "" {
+		// Binary IDs like objectGUID are stored hex encoded.
+		if raw := entry.GetEqualFoldRawAttributeValue(d.cfg.IDAttribute); len(raw) > 0 {
+			if utf8.Valid(raw) {
+				result.ID = string(raw)
+			} else {
+				result.ID = hex.EncodeToString(raw)
+			}
+		}
+	}
+	result.Nama = entry.GetEqualFoldAttributeValue(d.cfg.NameAttribute)
+	result.HP = entry.GetEqualFoldAttributeValue(d.cfg.PhoneAttribute)
+	result.Groups = entry.GetEqualFoldAttributeValues(d.cfg.GroupAttribute)
+
+	result.Role = d.cfg.DefaultRole
+	for _, mapping := range d.cfg.GroupRoles {
+		if hasGroup(result.Groups, mapping.Group) {
+			result.Role = mapping.Role
+			break
+		}
+	}
+
+	return result
+}
+
+// hasGroup compares DNs without regard to case or the spaces around commas.
+func hasGroup(groups []string, group string) bool {
+	var want, err = ldap.ParseDN(group)
+	if err != nil {
+		return false
+	}
+
+	for _, g := range groups {
+		if have, err := ldap.ParseDN(g); err == nil && want.EqualFold(have) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hostname(rawURL string) string {
+	var host = rawURL
+	if _, rest, found := strings.Cut(host, "://"); found {
+		host = rest
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+
+	return host
+}
@@ -0,0 +1,227 @@
+package helper
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+// stubLDAPServer answers simple binds and searches from a fixed list of
+// entries. Searches match on the decompiled filter string.
+type stubLDAPServer struct {
+	listener net.Listener
+	entries  map[string]stubEntry
+	binds    []string
+}
+
+func newStubLDAPServer(t *testing.T, entries map[string]stubEntry) *stubLDAPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	var server = &stubLDAPServer{listener: listener, entries: entries}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+
+	return server
+}
+
+func (s *stubLDAPServer) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *stubLDAPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *stubLDAPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		var messageID = packet.Children[0].Value.(int64)
+		var request = packet.Children[1]
+
+		switch request.Tag {
+		case ldap.ApplicationBindRequest:
+			var dn = request.Children[1].Value.(string)
+			var password = request.Children[2].Data.String()
+			s.binds = append(s.binds, dn)
+
+			var code = uint16(ldap.LDAPResultInvalidCredentials)
+			if dn == "cn=service,dc=example,dc=com" && password == "service-secret" {
+				code = ldap.LDAPResultSuccess
+			}
+			for _, entry := range s.entries {
+				if entry.dn == dn && entry.password == password {
+					code = ldap.LDAPResultSuccess
+				}
+			}
+			conn.Write(stubResponse(messageID, ldap.ApplicationBindResponse, code).Bytes())
+		case ldap.ApplicationSearchRequest:
+			filter, _ := ldap.DecompileFilter(request.Children[6])
+			if entry, found := s.entries[filter]; found {
+				conn.Write(stubEntryPacket(messageID, entry).Bytes())
+			}
+			conn.Write(stubResponse(messageID, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess).Bytes())
+		default:
+			return
+		}
+	}
+}
+
+func stubEnvelope(messageID int64) *ber.Packet {
+	var envelope = ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	return envelope
+}
+
+func stubResponse(messageID int64, tag ber.Tag, code uint16) *ber.Packet {
+	var envelope = stubEnvelope(messageID)
+	var response = ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	envelope.AppendChild(response)
+	return envelope
+}
+
+func stubEntryPacket(messageID int64, entry stubEntry) *ber.Packet {
+	var envelope = stubEnvelope(messageID)
+	var response = ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "objectName"))
+
+	var attributes = ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range entry.attributes {
+		var attribute = ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		var set = ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+	response.AppendChild(attributes)
+	envelope.AppendChild(response)
+	return envelope
+}
+
+func TestLDAPDirectory(t *testing.T) {
+	server := newStubLDAPServer(t, map[string]stubEntry{
+		"(&(objectClass=person)(uid=dida))": {
+			dn:       "uid=dida,ou=people,dc=example,dc=com",
+			password: "Rahasia#2023",
+			attributes: map[string][]string{
+				"cn":        {"Dida Putra"},
+				"mobile":    {"0812"},
+				"entryUUID": {"4f1c2a9e-0000-4000-8000-000000000001"},
+				"memberOf":  {"cn=staff,ou=groups,dc=example,dc=com", "CN=Admins, OU=groups, DC=example, DC=com"},
+			},
+		},
+		"(&(objectClass=person)(uid=budi))": {
+			dn:       "uid=budi,ou=people,dc=example,dc=com",
+			password: "Rahasia#2024",
+			attributes: map[string][]string{
+				"cn":       {"Budi"},
+				"memberOf": {"cn=staff,ou=groups,dc=example,dc=com"},
+			},
+		},
+	})
+
+	directory := NewLDAPDirectory(LDAPConfig{
+		Name:         "corp",
+		URL:          server.URL(),
+		BindDN:       "cn=service,dc=example,dc=com",
+		BindPassword: "service-secret",
+		BaseDN:       "ou=people,dc=example,dc=com",
+		UserFilter:   "(&(objectClass=person)(uid={login}))",
+		IDAttribute:  "entryUUID",
+		GroupRoles: []LDAPGroupRole{
+			{Group: "cn=admins,ou=groups,dc=example,dc=com", Role: "admin"},
+			{Group: "cn=staff,ou=groups,dc=example,dc=com", Role: "user"},
+		},
+		DefaultRole: "guest",
+	})
+
+	t.Run("Success with group role", func(t *testing.T) {
+		result, err := directory.Authenticate("dida", "Rahasia#2023")
+		assert.Nil(t, err)
+		assert.Equal(t, "4f1c2a9e-0000-4000-8000-000000000001", result.ID)
+		assert.Equal(t, "uid=dida,ou=people,dc=example,dc=com", result.DN)
+		assert.Equal(t, "Dida Putra", result.Nama)
+		assert.Equal(t, "0812", result.HP)
+		assert.Equal(t, "admin", result.Role)
+		assert.Equal(t, "uid=dida,ou=people,dc=example,dc=com", server.binds[len(server.binds)-1])
+	})
+
+	t.Run("DN as id", func(t *testing.T) {
+		result, err := directory.Authenticate("budi", "Rahasia#2024")
+		assert.Nil(t, err)
+		assert.Equal(t, "uid=budi,ou=people,dc=example,dc=com", result.ID)
+		assert.Equal(t, "user", result.Role)
+		assert.Equal(t, "", result.HP)
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		result, err := directory.Authenticate("dida", "wrong")
+		assert.EqualError(t, err, "invalid password")
+		assert.Equal(t, "Dida Putra", result.Nama)
+	})
+
+	t.Run("Unknown login", func(t *testing.T) {
+		result, err := directory.Authenticate("nobody", "Rahasia#2023")
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Filter injection escaped", func(t *testing.T) {
+		result, err := directory.Authenticate("dida)(uid=*", "Rahasia#2023")
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Empty password", func(t *testing.T) {
+		var before = len(server.binds)
+		result, err := directory.Authenticate("dida", "")
+		assert.EqualError(t, err, "data not found")
+		assert.Nil(t, result)
+		assert.Len(t, server.binds, before)
+	})
+
+	t.Run("Wrong service account", func(t *testing.T) {
+		misconfigured := NewLDAPDirectory(LDAPConfig{Name: "corp", URL: server.URL(), BindDN: "cn=service,dc=example,dc=com", BindPassword: "wrong", BaseDN: "dc=example,dc=com"})
+
+		result, err := misconfigured.Authenticate("dida", "Rahasia#2023")
+		assert.EqualError(t, err, "directory bind failed")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Directory down", func(t *testing.T) {
+		listener, _ := net.Listen("tcp", "127.0.0.1:0")
+		var url = "ldap://" + listener.Addr().String()
+		listener.Close()
+
+		result, err := NewLDAPDirectory(LDAPConfig{Name: "corp", URL: url, BaseDN: "dc=example,dc=com"}).Authenticate("dida", "Rahasia#2023")
+		assert.True(t, strings.Contains(err.Error(), "unavailable"))
+		assert.Nil(t, result)
+	})
+}
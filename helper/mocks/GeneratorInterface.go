@@ -9,6 +9,30 @@ type GeneratorInterface struct {
 	mock.Mock
 }
 
+// GenerateOTP provides a mock function with given fields: length
+func (_m *GeneratorInterface) GenerateOTP(length int) (string, error) {
+	ret := _m.Called(length)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (string, error)); ok {
+		return rf(length)
+	}
+	if rf, ok := ret.Get(0).(func(int) string); ok {
+		r0 = rf(length)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(length)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GenerateUUID provides a mock function with given fields:
 func (_m *GeneratorInterface) GenerateUUID() (string, error) {
 	ret := _m.Called()
@@ -3,6 +3,9 @@
 package mocks
 
 import (
+	helper "test/helper"
+	time "time"
+
 	jwt "github.com/golang-jwt/jwt/v5"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -13,28 +16,52 @@ type JWTInterface struct {
 }
 
 // ExtractToken provides a mock function with given fields: token
-func (_m *JWTInterface) ExtractToken(token *jwt.Token) interface{} {
+func (_m *JWTInterface) ExtractToken(token *jwt.Token) (*helper.Principal, error) {
 	ret := _m.Called(token)
 
-	var r0 interface{}
-	if rf, ok := ret.Get(0).(func(*jwt.Token) interface{}); ok {
+	var r0 *helper.Principal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*jwt.Token) (*helper.Principal, error)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(*jwt.Token) *helper.Principal); ok {
 		r0 = rf(token)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(interface{})
+			r0 = ret.Get(0).(*helper.Principal)
 		}
 	}
 
+	if rf, ok := ret.Get(1).(func(*jwt.Token) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateChallengeToken provides a mock function with given fields: userID, purpose, ttl
+func (_m *JWTInterface) GenerateChallengeToken(userID string, purpose string, ttl time.Duration) string {
+	ret := _m.Called(userID, purpose, ttl)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, time.Duration) string); ok {
+		r0 = rf(userID, purpose, ttl)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
 	return r0
 }
 
-// GenerateJWT provides a mock function with given fields: userID
-func (_m *JWTInterface) GenerateJWT(userID string) map[string]interface{} {
-	ret := _m.Called(userID)
+// GenerateJWT provides a mock function with given fields: principal
+func (_m *JWTInterface) GenerateJWT(principal helper.Principal) map[string]interface{} {
+	ret := _m.Called(principal)
 
 	var r0 map[string]interface{}
-	if rf, ok := ret.Get(0).(func(string) map[string]interface{}); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(helper.Principal) map[string]interface{}); ok {
+		r0 = rf(principal)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(map[string]interface{})
@@ -44,13 +71,13 @@ func (_m *JWTInterface) GenerateJWT(userID string) map[string]interface{} {
 	return r0
 }
 
-// GenerateToken provides a mock function with given fields: id
-func (_m *JWTInterface) GenerateToken(id string) string {
-	ret := _m.Called(id)
+// GenerateToken provides a mock function with given fields: principal
+func (_m *JWTInterface) GenerateToken(principal helper.Principal) string {
+	ret := _m.Called(principal)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(helper.Principal) string); ok {
+		r0 = rf(principal)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
@@ -58,6 +85,56 @@ func (_m *JWTInterface) GenerateToken(id string) string {
 	return r0
 }
 
+// ParseChallengeToken provides a mock function with given fields: tokenString, purpose
+func (_m *JWTInterface) ParseChallengeToken(tokenString string, purpose string) (string, error) {
+	ret := _m.Called(tokenString, purpose)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (string, error)); ok {
+		return rf(tokenString, purpose)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = rf(tokenString, purpose)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(tokenString, purpose)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ParseToken provides a mock function with given fields: tokenString
+func (_m *JWTInterface) ParseToken(tokenString string) (*helper.Principal, error) {
+	ret := _m.Called(tokenString)
+
+	var r0 *helper.Principal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*helper.Principal, error)); ok {
+		return rf(tokenString)
+	}
+	if rf, ok := ret.Get(0).(func(string) *helper.Principal); ok {
+		r0 = rf(tokenString)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*helper.Principal)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tokenString)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewJWTInterface creates a new instance of JWTInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewJWTInterface(t interface {
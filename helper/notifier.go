@@ -0,0 +1,57 @@
+package helper
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type NotifierInterface interface {
+	Send(to string, message string) error
+}
+
+// LogNotifier stands in for a real SMS gateway during development, messages
+// only end up in the application log.
+type LogNotifier struct{}
+
+func NewLogNotifier() NotifierInterface {
+	return &LogNotifier{}
+}
+
+func (n LogNotifier) Send(to string, message string) error {
+	logrus.Infof("notifier: to=%s message=%q", to, message)
+	return nil
+}
+
+// FileNotifier appends every message as a JSON line to a file, which keeps the
+// codes easy to pick up from scripts and manual tests.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) NotifierInterface {
+	return &FileNotifier{
+		path: path,
+	}
+}
+
+func (n *FileNotifier) Send(to string, message string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(map[string]any{
+		"time":    time.Now().Format(time.RFC3339),
+		"to":      to,
+		"message": message,
+	})
+}
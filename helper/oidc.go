@@ -0,0 +1,118 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// IDTokenClaims are the OpenID Connect claims of an ID token. The profile
+// claims are only filled when the matching scope was granted.
+type IDTokenClaims struct {
+	Nonce               string `json:"nonce,omitempty"`
+	Name                string `json:"name,omitempty"`
+	Picture             string `json:"picture,omitempty"`
+	PhoneNumber         string `json:"phone_number,omitempty"`
+	PhoneNumberVerified *bool  `json:"phone_number_verified,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type IDTokenSignerInterface interface {
+	SignIDToken(claims IDTokenClaims) (string, error)
+	JWKS() JWKSet
+}
+
+// IDTokenSigner signs ID tokens with RS256. Unlike access tokens they are
+// verified by other applications, which only get the public key from JWKS.
+type IDTokenSigner struct {
+	key   *rsa.PrivateKey
+	keyID string
+}
+
+// NewIDTokenSigner loads a PEM encoded RSA key (PKCS #1 or #8), or generates
+// one when path is empty.
+func NewIDTokenSigner(path string) (*IDTokenSigner, error) {
+	if path == "" {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		return newIDTokenSigner(key), nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return newIDTokenSigner(key), nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an rsa key")
+	}
+
+	return newIDTokenSigner(key), nil
+}
+
+func newIDTokenSigner(key *rsa.PrivateKey) *IDTokenSigner {
+	var sum = sha256.Sum256(key.PublicKey.N.Bytes())
+
+	return &IDTokenSigner{
+		key:   key,
+		keyID: base64.RawURLEncoding.EncodeToString(sum[:12]),
+	}
+}
+
+func (s *IDTokenSigner) SignIDToken(claims IDTokenClaims) (string, error) {
+	var token = jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.keyID
+
+	return token.SignedString(s.key)
+}
+
+func (s *IDTokenSigner) JWKS() JWKSet {
+	var key = JWK{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: jwt.SigningMethodRS256.Alg(),
+		KeyID:     s.keyID,
+		Modulus:   base64.RawURLEncoding.EncodeToString(s.key.PublicKey.N.Bytes()),
+		Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.PublicKey.E)).Bytes()),
+	}
+
+	return JWKSet{Keys: []JWK{key}}
+}
@@ -0,0 +1,301 @@
+package helper
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ExternalProvider is an OpenID Connect provider users can log in with, as
+// configured in the providers file.
+type ExternalProvider struct {
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"`
+	Scopes       []string `json:"scopes"`
+	// DisableSignup refuses identities that are not linked to an account yet,
+	// instead of creating a new account for them.
+	DisableSignup bool `json:"disable_signup"`
+	// LinkVerifiedPhone links an unknown identity to the account with the same
+	// HP, when both the provider and this server have verified the number.
+	LinkVerifiedPhone bool `json:"link_verified_phone"`
+}
+
+// ExternalIdentity holds the claims of a verified ID token from an external
+// provider.
+type ExternalIdentity struct {
+	Subject             string
+	Email               string
+	EmailVerified       bool
+	Name                string
+	PhoneNumber         string
+	PhoneNumberVerified bool
+}
+
+type OIDCProviderInterface interface {
+	Config() ExternalProvider
+	AuthCodeURL(state string, nonce string, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*ExternalIdentity, error)
+}
+
+// OIDCProvider is the relying party side of the authorization code flow. The
+// discovery document and signing keys are fetched on first use, so a provider
+// that is down does not keep the server from starting.
+type OIDCProvider struct {
+	cfg    ExternalProvider
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *oidcMetadata
+	keys     map[string]*rsa.PublicKey
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type externalIDTokenClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	IDTokenClaims
+}
+
+func NewOIDCProvider(cfg ExternalProvider, client *http.Client) *OIDCProvider {
+	if client == nil {
+		client = &http.Client{Timeout: time.Second * 10}
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+
+	return &OIDCProvider{
+		cfg:    cfg,
+		client: client,
+	}
+}
+
+// LoadExternalProviders reads a JSON array of providers, keyed by their name
+// in the result.
+func LoadExternalProviders(path string) (map[string]OIDCProviderInterface, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []ExternalProvider
+	if err := json.Unmarshal(raw, &configs); err != nil {
+		return nil, err
+	}
+
+	var result = make(map[string]OIDCProviderInterface, len(configs))
+	for _, cfg := range configs {
+		if cfg.Name == "" || cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			return nil, errors.New("incomplete provider config")
+		}
+		if _, found := result[cfg.Name]; found {
+			return nil, fmt.Errorf("duplicate provider %s", cfg.Name)
+		}
+		result[cfg.Name] = NewOIDCProvider(cfg, nil)
+	}
+
+	return result, nil
+}
+
+func (p *OIDCProvider) Config() ExternalProvider {
+	return p.cfg
+}
+
+func (p *OIDCProvider) AuthCodeURL(state string, nonce string, codeChallenge string) (string, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	var query = url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	var separator = "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems the code at the token endpoint and verifies the ID token
+// that comes back: signature, issuer, audience, expiry and nonce.
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*ExternalIdentity, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	var form = url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return nil, errors.New("invalid token response")
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed: %s %s", token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("invalid token response")
+	}
+
+	return p.verify(token.IDToken, nonce)
+}
+
+func (p *OIDCProvider) verify(idToken string, nonce string) (*ExternalIdentity, error) {
+	var claims = new(externalIDTokenClaims)
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		return p.key(keyID)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithIssuer(p.cfg.Issuer), jwt.WithAudience(p.cfg.ClientID))
+	if err != nil {
+		return nil, errors.New("invalid id token")
+	}
+
+	if claims.ExpiresAt == nil || claims.Subject == "" || claims.Nonce != nonce {
+		return nil, errors.New("invalid id token")
+	}
+
+	var result = new(ExternalIdentity)
+	result.Subject = claims.Subject
+	result.Email = claims.Email
+	result.EmailVerified = claims.EmailVerified
+	result.Name = claims.Name
+	result.PhoneNumber = claims.PhoneNumber
+	result.PhoneNumberVerified = claims.PhoneNumberVerified != nil && *claims.PhoneNumberVerified
+
+	return result, nil
+}
+
+func (p *OIDCProvider) discover() (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var metadata = new(oidcMetadata)
+	if err := p.getJSON(strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", metadata); err != nil {
+		return nil, err
+	}
+
+	if metadata.Issuer != p.cfg.Issuer || metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("invalid provider metadata")
+	}
+
+	p.metadata = metadata
+	return metadata, nil
+}
+
+// key looks the signing key up by its id. An unknown id refetches the key set
+// once, providers rotate their keys without notice.
+func (p *OIDCProvider) key(keyID string) (*rsa.PublicKey, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, found := p.keys[keyID]; found {
+		return key, nil
+	}
+
+	var set JWKSet
+	if err := p.getJSON(metadata.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+
+	var keys = make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.Modulus)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.Exponent)
+		if errN != nil || errE != nil {
+			continue
+		}
+
+		keys[jwk.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.keys = keys
+
+	if key, found := keys[keyID]; found {
+		return key, nil
+	}
+
+	return nil, errors.New("signing key not found")
+}
+
+func (p *OIDCProvider) getJSON(endpoint string, result any) error {
+	res, err := p.client.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed with status %d", endpoint, res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(result)
+}
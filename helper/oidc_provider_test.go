@@ -0,0 +1,170 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockIssuer is a local OIDC provider, it answers every code with an ID token
+// built from claims.
+type mockIssuer struct {
+	server *httptest.Server
+	signer *IDTokenSigner
+	claims IDTokenClaims
+	form   url.Values
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	signer, err := NewIDTokenSigner("")
+	assert.Nil(t, err)
+
+	var issuer = &mockIssuer{signer: signer}
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.server.URL,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"jwks_uri":               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(issuer.signer.JWKS())
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		issuer.form = r.PostForm
+		if r.PostForm.Get("code") != "randomCode" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		idToken, err := issuer.signer.SignIDToken(issuer.claims)
+		assert.Nil(t, err)
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "randomAccessToken", "id_token": idToken})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+func (mi *mockIssuer) provider() *OIDCProvider {
+	return NewOIDCProvider(ExternalProvider{Name: "mock", Issuer: mi.server.URL, ClientID: "randomClientID", ClientSecret: "randomSecret", RedirectURL: "https://app.example.com/callback"}, mi.server.Client())
+}
+
+func (mi *mockIssuer) validClaims() IDTokenClaims {
+	var verified = true
+	var claims = IDTokenClaims{Nonce: "randomNonce", Name: "dida", PhoneNumber: "123", PhoneNumberVerified: &verified}
+	claims.Issuer = mi.server.URL
+	claims.Subject = "externalSubject"
+	claims.Audience = jwt.ClaimStrings{"randomClientID"}
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+
+	return claims
+}
+
+func TestOIDCProvider(t *testing.T) {
+	issuer := newMockIssuer(t)
+
+	t.Run("Auth code url", func(t *testing.T) {
+		result, err := issuer.provider().AuthCodeURL("randomState", "randomNonce", "randomChallenge")
+		assert.Nil(t, err)
+
+		parsed, _ := url.Parse(result)
+		assert.Equal(t, issuer.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+		assert.Equal(t, "randomState", parsed.Query().Get("state"))
+		assert.Equal(t, "randomNonce", parsed.Query().Get("nonce"))
+		assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+		assert.Equal(t, "openid profile email", parsed.Query().Get("scope"))
+	})
+
+	t.Run("Exchange", func(t *testing.T) {
+		issuer.claims = issuer.validClaims()
+
+		result, err := issuer.provider().Exchange(context.Background(), "randomCode", "randomVerifier", "randomNonce")
+		assert.Nil(t, err)
+		assert.Equal(t, &ExternalIdentity{Subject: "externalSubject", Name: "dida", PhoneNumber: "123", PhoneNumberVerified: true}, result)
+		assert.Equal(t, "randomVerifier", issuer.form.Get("code_verifier"))
+	})
+
+	t.Run("Wrong nonce", func(t *testing.T) {
+		issuer.claims = issuer.validClaims()
+
+		result, err := issuer.provider().Exchange(context.Background(), "randomCode", "randomVerifier", "otherNonce")
+		assert.EqualError(t, err, "invalid id token")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Wrong audience", func(t *testing.T) {
+		issuer.claims = issuer.validClaims()
+		issuer.claims.Audience = jwt.ClaimStrings{"otherClientID"}
+
+		result, err := issuer.provider().Exchange(context.Background(), "randomCode", "randomVerifier", "randomNonce")
+		assert.EqualError(t, err, "invalid id token")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Expired id token", func(t *testing.T) {
+		issuer.claims = issuer.validClaims()
+		issuer.claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+		result, err := issuer.provider().Exchange(context.Background(), "randomCode", "randomVerifier", "randomNonce")
+		assert.EqualError(t, err, "invalid id token")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Rotated signing key", func(t *testing.T) {
+		var provider = issuer.provider()
+		issuer.claims = issuer.validClaims()
+		_, err := provider.Exchange(context.Background(), "randomCode", "randomVerifier", "randomNonce")
+		assert.Nil(t, err)
+
+		original := issuer.signer
+		issuer.signer, err = NewIDTokenSigner("")
+		assert.Nil(t, err)
+		defer func() { issuer.signer = original }()
+
+		result, err := provider.Exchange(context.Background(), "randomCode", "randomVerifier", "randomNonce")
+		assert.Nil(t, err)
+		assert.Equal(t, "externalSubject", result.Subject)
+	})
+
+	t.Run("Rejected code", func(t *testing.T) {
+		result, err := issuer.provider().Exchange(context.Background(), "otherCode", "randomVerifier", "randomNonce")
+		assert.ErrorContains(t, err, "invalid_grant")
+		assert.Nil(t, result)
+	})
+}
+
+func TestLoadExternalProviders(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var path = filepath.Join(t.TempDir(), "providers.json")
+		assert.Nil(t, os.WriteFile(path, []byte(`[{"name":"google","issuer":"https://accounts.google.com","client_id":"id","client_secret":"secret","redirect_url":"https://app.example.com/callback"}]`), 0600))
+
+		result, err := LoadExternalProviders(path)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://accounts.google.com", result["google"].Config().Issuer)
+		assert.Equal(t, []string{"openid", "profile", "email"}, result["google"].Config().Scopes)
+	})
+
+	t.Run("Missing issuer", func(t *testing.T) {
+		var path = filepath.Join(t.TempDir(), "providers.json")
+		assert.Nil(t, os.WriteFile(path, []byte(`[{"name":"google","client_id":"id","redirect_url":"https://app.example.com/callback"}]`), 0600))
+
+		result, err := LoadExternalProviders(path)
+		assert.EqualError(t, err, "incomplete provider config")
+		assert.Nil(t, result)
+	})
+}
@@ -0,0 +1,73 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDTokenSigner(t *testing.T) {
+	t.Run("Verify with published key", func(t *testing.T) {
+		signer, err := NewIDTokenSigner("")
+		assert.Nil(t, err)
+
+		var claims = IDTokenClaims{Nonce: "randomNonce"}
+		claims.Subject = "randomUserID"
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+
+		token, err := signer.SignIDToken(claims)
+		assert.Nil(t, err)
+
+		var set = signer.JWKS()
+		assert.Len(t, set.Keys, 1)
+
+		parsed, err := jwt.ParseWithClaims(token, &IDTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+			assert.Equal(t, set.Keys[0].KeyID, token.Header["kid"])
+			return publicKey(t, set.Keys[0]), nil
+		}, jwt.WithValidMethods([]string{"RS256"}))
+		assert.Nil(t, err)
+		assert.Equal(t, "randomNonce", parsed.Claims.(*IDTokenClaims).Nonce)
+	})
+
+	t.Run("Load pkcs8 key", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.Nil(t, err)
+		raw, err := x509.MarshalPKCS8PrivateKey(key)
+		assert.Nil(t, err)
+
+		var path = filepath.Join(t.TempDir(), "oidc.pem")
+		assert.Nil(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: raw}), 0600))
+
+		signer, err := NewIDTokenSigner(path)
+		assert.Nil(t, err)
+		assert.Equal(t, key.PublicKey.N, publicKey(t, signer.JWKS().Keys[0]).N)
+	})
+
+	t.Run("Not a pem file", func(t *testing.T) {
+		var path = filepath.Join(t.TempDir(), "oidc.pem")
+		assert.Nil(t, os.WriteFile(path, []byte("not a key"), 0600))
+
+		signer, err := NewIDTokenSigner(path)
+		assert.EqualError(t, err, "no pem block found")
+		assert.Nil(t, signer)
+	})
+}
+
+func publicKey(t *testing.T, key JWK) *rsa.PublicKey {
+	n, err := base64.RawURLEncoding.DecodeString(key.Modulus)
+	assert.Nil(t, err)
+	e, err := base64.RawURLEncoding.DecodeString(key.Exponent)
+	assert.Nil(t, err)
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+}
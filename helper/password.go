@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func HashPassword(password string) (string, error) {
+	result, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// IsPasswordHash tells bcrypt hashes apart from the plain text passwords that
+// were stored before hashing was introduced.
+func IsPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+func ComparePassword(stored string, password string) bool {
+	if !IsPasswordHash(stored) {
+		return stored != "" && subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+}
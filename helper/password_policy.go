@@ -0,0 +1,183 @@
+package helper
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	var messages = make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return "invalid password: " + strings.Join(messages, ", ")
+}
+
+type PasswordPolicy struct {
+	MinLength int
+	// MinClasses is how many of lower case, upper case, digit and symbol
+	// have to appear in the password.
+	MinClasses int
+	// History is how many previous passwords, the current one included, may
+	// not be chosen again. Enforced by the caller that owns the history.
+	History  int
+	Breached BreachedPasswordInterface
+}
+
+func NewPasswordPolicy(minLength int, minClasses int, history int, breached BreachedPasswordInterface) *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:  minLength,
+		MinClasses: minClasses,
+		History:    history,
+		Breached:   breached,
+	}
+}
+
+// Check returns every rule the password breaks. personal holds account data
+// such as nama and hp that must not appear inside the password.
+func (p *PasswordPolicy) Check(password string, personal []string) []PasswordViolation {
+	var violations = []PasswordViolation{}
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Rule:    "min_length",
+			Message: "password is too short",
+		})
+	}
+
+	if passwordClasses(password) < p.MinClasses {
+		violations = append(violations, PasswordViolation{
+			Rule:    "character_classes",
+			Message: "password needs more variety of lower case, upper case, digits and symbols",
+		})
+	}
+
+	if containsPersonalInfo(password, personal) {
+		violations = append(violations, PasswordViolation{
+			Rule:    "personal_info",
+			Message: "password must not contain your name or phone number",
+		})
+	}
+
+	if p.Breached != nil && password != "" {
+		breached, err := p.Breached.IsBreached(password)
+		if err != nil {
+			// Screening is best effort, an unavailable list must not lock
+			// everyone out of registering or resetting.
+			logrus.Error("password policy: breached password lookup error:", err.Error())
+		} else if breached {
+			violations = append(violations, PasswordViolation{
+				Rule:    "breached",
+				Message: "password appeared in a data breach",
+			})
+		}
+	}
+
+	return violations
+}
+
+func passwordClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+func containsPersonalInfo(password string, personal []string) bool {
+	var lowered = strings.ToLower(password)
+	for _, value := range personal {
+		// Names are checked word by word, "Dida Dejan" should also keep
+		// "dejan2023!" out. Very short parts would forbid too much.
+		for _, part := range strings.Fields(strings.ToLower(value)) {
+			if len([]rune(part)) >= 3 && strings.Contains(lowered, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type BreachedPasswordInterface interface {
+	IsBreached(password string) (bool, error)
+}
+
+// LocalBreachedList screens passwords against a file in the Pwned Passwords
+// format: one upper case SHA-1 hash per line, optionally followed by
+// ":count". Lookups go through Range with the five character hash prefix,
+// the same k-anonymity split the online range API uses, so a remote
+// implementation can replace it without touching the callers.
+type LocalBreachedList struct {
+	ranges map[string][]string
+}
+
+func NewLocalBreachedList(path string) (*LocalBreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var list = &LocalBreachedList{ranges: map[string][]string{}}
+	var scanner = bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			continue
+		}
+		list.ranges[hash[:5]] = append(list.ranges[hash[:5]], hash[5:])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(list.ranges) == 0 {
+		return nil, errors.New("breached password list is empty")
+	}
+
+	return list, nil
+}
+
+func (l *LocalBreachedList) Range(prefix string) []string {
+	return l.ranges[strings.ToUpper(prefix)]
+}
+
+func (l *LocalBreachedList) IsBreached(password string) (bool, error) {
+	var sum = sha1.Sum([]byte(password))
+	var hash = strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	for _, suffix := range l.Range(hash[:5]) {
+		if suffix == hash[5:] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
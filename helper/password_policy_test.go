@@ -0,0 +1,78 @@
+package helper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rules(violations []PasswordViolation) []string {
+	var result = []string{}
+	for _, violation := range violations {
+		result = append(result, violation.Rule)
+	}
+	return result
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := NewPasswordPolicy(8, 3, 5, nil)
+
+	t.Run("Strong password", func(t *testing.T) {
+		assert.Empty(t, policy.Check("Rahasia#2023", []string{"dida", "08123"}))
+	})
+
+	t.Run("Single character password", func(t *testing.T) {
+		assert.Equal(t, []string{"min_length", "character_classes"}, rules(policy.Check("1", nil)))
+	})
+
+	t.Run("Contains name", func(t *testing.T) {
+		assert.Equal(t, []string{"personal_info"}, rules(policy.Check("Dejan#2023", []string{"Dida Dejan", "08123"})))
+	})
+
+	t.Run("Contains phone number", func(t *testing.T) {
+		assert.Equal(t, []string{"personal_info"}, rules(policy.Check("Pass#081234", []string{"dida", "081234"})))
+	})
+
+	t.Run("Short name parts are ignored", func(t *testing.T) {
+		assert.Empty(t, policy.Check("Aloha#2023", []string{"Al"}))
+	})
+}
+
+func TestLocalBreachedList(t *testing.T) {
+	// SHA-1 of "password" and "P@ssw0rd".
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\n21bd12dc183f740ee76f27b78eb39c8ad972a757:52000\nnot a hash\n"
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0600))
+
+	list, err := NewLocalBreachedList(path)
+	assert.Nil(t, err)
+
+	t.Run("Range lookup", func(t *testing.T) {
+		assert.Equal(t, []string{"1E4C9B93F3F0682250B6CF8331B7EE68FD8"}, list.Range("5baa6"))
+	})
+
+	t.Run("Breached password", func(t *testing.T) {
+		breached, err := list.IsBreached("P@ssw0rd")
+		assert.Nil(t, err)
+		assert.True(t, breached)
+	})
+
+	t.Run("Unknown password", func(t *testing.T) {
+		breached, err := list.IsBreached("Rahasia#2023")
+		assert.Nil(t, err)
+		assert.False(t, breached)
+	})
+
+	t.Run("Reported by policy", func(t *testing.T) {
+		policy := NewPasswordPolicy(8, 3, 5, list)
+		assert.Equal(t, []string{"breached"}, rules(policy.Check("P@ssw0rd", nil)))
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		result, err := NewLocalBreachedList(filepath.Join(t.TempDir(), "missing.txt"))
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
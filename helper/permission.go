@@ -0,0 +1,71 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Permissions are granted through groups. Admins hold every permission, other
+// users the ones of the groups they are in, directly or through a nested
+// group.
+const (
+	PermissionUsersManage       = "users:manage"
+	PermissionAuditRead         = "audit:read"
+	PermissionInvitationsManage = "invitations:manage"
+	PermissionGroupsManage      = "groups:manage"
+)
+
+var Permissions = []string{PermissionUsersManage, PermissionAuditRead, PermissionInvitationsManage, PermissionGroupsManage}
+
+// PermissionResolverInterface computes the effective permissions of a
+// principal.
+type PermissionResolverInterface interface {
+	Permissions(ctx context.Context, principal Principal) ([]string, error)
+}
+
+// NormalizePermissions sorts permissions and drops duplicates, unknown ones
+// are refused. An empty list is valid, a group may only be used to collect
+// users.
+func NormalizePermissions(permissions []string) ([]string, error) {
+	var result = []string{}
+	for _, permission := range permissions {
+		if !containsString(Permissions, permission) {
+			return nil, errors.New("invalid permission")
+		}
+		if !containsString(result, permission) {
+			result = append(result, permission)
+		}
+	}
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// RequirePermission asks resolver on every request, so a change to a group
+// applies right away and not only to tokens issued after it.
+func RequirePermission(resolver PermissionResolverInterface, permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var principal = GetPrincipal(c)
+			if principal == nil {
+				return c.JSON(http.StatusForbidden, FormatResponse("forbidden", nil))
+			}
+
+			permissions, err := resolver.Permissions(c.Request().Context(), *principal)
+			if err != nil {
+				c.Logger().Error("middleware: resolve permissions error:", err.Error())
+				return c.JSON(http.StatusInternalServerError, FormatResponse("fail", nil))
+			}
+
+			if !containsString(permissions, permission) {
+				return c.JSON(http.StatusForbidden, FormatResponse("forbidden", nil))
+			}
+
+			return next(c)
+		}
+	}
+}
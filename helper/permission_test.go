@@ -0,0 +1,70 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// permissionResolver grants the permissions listed for the user ID.
+type permissionResolver map[string][]string
+
+func (r permissionResolver) Permissions(ctx context.Context, principal Principal) ([]string, error) {
+	if principal.UserID == "brokenID" {
+		return nil, errors.New("process failed")
+	}
+	return r[principal.UserID], nil
+}
+
+func TestNormalizePermissions(t *testing.T) {
+	t.Run("Sorted without duplicates", func(t *testing.T) {
+		result, err := NormalizePermissions([]string{PermissionUsersManage, PermissionAuditRead, PermissionUsersManage})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{PermissionAuditRead, PermissionUsersManage}, result)
+	})
+
+	t.Run("No permission", func(t *testing.T) {
+		result, err := NormalizePermissions(nil)
+		assert.Nil(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("Unknown permission", func(t *testing.T) {
+		result, err := NormalizePermissions([]string{"users:delete"})
+		assert.EqualError(t, err, "invalid permission")
+		assert.Nil(t, result)
+	})
+}
+
+func TestRequirePermission(t *testing.T) {
+	e := echo.New()
+	var handler = RequirePermission(permissionResolver{"managerID": {PermissionUsersManage}}, PermissionUsersManage)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for name, tc := range map[string]struct {
+		principal *Principal
+		code      int
+	}{
+		"Granted":         {principal: &Principal{UserID: "managerID"}, code: http.StatusOK},
+		"Not granted":     {principal: &Principal{UserID: "userID"}, code: http.StatusForbidden},
+		"No principal":    {code: http.StatusForbidden},
+		"Resolver failed": {principal: &Principal{UserID: "brokenID"}, code: http.StatusInternalServerError},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+			if tc.principal != nil {
+				c.Set(principalContextKey, tc.principal)
+			}
+
+			assert.Nil(t, handler(c))
+			assert.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
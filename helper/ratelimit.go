@@ -0,0 +1,35 @@
+package helper
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitMiddleware allows every client IP perMinute requests a minute,
+// with bursts of the same size. Routes sharing the returned middleware share
+// the allowance. The IP is the one IPExtractor trusts, rotating a forged
+// X-Forwarded-For does not get a client a new allowance.
+func RateLimitMiddleware(perMinute int) echo.MiddlewareFunc {
+	var store = middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+		Rate:      rate.Limit(float64(perMinute) / time.Minute.Seconds()),
+		Burst:     perMinute,
+		ExpiresIn: time.Minute * 3,
+	})
+
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: store,
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			return clientIP(c), nil
+		},
+		ErrorHandler: func(c echo.Context, err error) error {
+			return c.JSON(http.StatusForbidden, FormatResponse("forbidden", nil))
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			return c.JSON(http.StatusTooManyRequests, FormatResponse("too many requests", nil))
+		},
+	})
+}
@@ -0,0 +1,95 @@
+package helper
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestMeta describes where a request came from, services pick it up from
+// the context to attach it to what they record.
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+	RequestID string
+	// DeviceID is an optional identifier the client keeps across logins, it
+	// makes device fingerprints stable when the user agent changes.
+	DeviceID string
+}
+
+const HeaderDeviceID = "X-Device-ID"
+
+type requestMetaKey struct{}
+
+func WithRequestMeta(ctx context.Context, meta RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, meta)
+}
+
+func RequestMetaFromContext(ctx context.Context) RequestMeta {
+	meta, _ := ctx.Value(requestMetaKey{}).(RequestMeta)
+	return meta
+}
+
+// IPExtractor decides the address c.RealIP reports. Without trusted proxies
+// it is the peer of the connection, X-Forwarded-For is ignored since clients
+// can put anything there. Behind proxies the header is only followed through
+// the addresses and CIDR ranges listed in trustedProxies.
+func IPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	var options = []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if strings.Contains(proxy, ":") {
+				proxy += "/128"
+			} else {
+				proxy += "/32"
+			}
+		}
+
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// clientIP is c.RealIP behind the IPExtractor of the server. Echo without an
+// extractor believes X-Forwarded-For, so the peer address is used then.
+func clientIP(c echo.Context) string {
+	if c.Echo().IPExtractor == nil {
+		return echo.ExtractIPDirect()(c.Request())
+	}
+
+	return c.RealIP()
+}
+
+// RequestMetaMiddleware has to run after middleware.RequestID so the
+// generated id is already on the response.
+func RequestMetaMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var requestID = c.Response().Header().Get(echo.HeaderXRequestID)
+			if requestID == "" {
+				requestID = c.Request().Header.Get(echo.HeaderXRequestID)
+			}
+
+			var meta = RequestMeta{
+				IP:        clientIP(c),
+				UserAgent: c.Request().UserAgent(),
+				RequestID: requestID,
+				DeviceID:  c.Request().Header.Get(HeaderDeviceID),
+			}
+			c.SetRequest(c.Request().WithContext(WithRequestMeta(c.Request().Context(), meta)))
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestMetaMiddleware(t *testing.T) {
+	e := echo.New()
+	var meta RequestMeta
+	e.Use(middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		Generator: func() string { return "randomRequestID" },
+	}))
+	e.Use(RequestMetaMiddleware())
+	e.GET("/", func(c echo.Context) error {
+		meta = RequestMetaFromContext(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:52000"
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Set(echo.HeaderXForwardedFor, "103.10.20.1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, RequestMeta{IP: "10.0.0.1", UserAgent: "curl/8.0", RequestID: "randomRequestID"}, meta)
+}
+
+func TestIPExtractor(t *testing.T) {
+	var realIP = func(extractor echo.IPExtractor, remoteAddr string, forwardedFor string) string {
+		e := echo.New()
+		e.IPExtractor = extractor
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+
+		return e.NewContext(req, httptest.NewRecorder()).RealIP()
+	}
+
+	t.Run("Forged header without proxies", func(t *testing.T) {
+		extractor, err := IPExtractor(nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, "36.80.1.1", realIP(extractor, "36.80.1.1:40000", "103.10.20.1"))
+	})
+
+	t.Run("Behind a trusted proxy", func(t *testing.T) {
+		extractor, err := IPExtractor([]string{"10.0.0.0/8", "192.168.1.5"})
+		assert.Nil(t, err)
+
+		assert.Equal(t, "36.80.1.1", realIP(extractor, "10.0.0.2:40000", "36.80.1.1"))
+		// A client can only prepend to the header, the proxy appends the
+		// address it saw.
+		assert.Equal(t, "36.80.1.1", realIP(extractor, "192.168.1.5:40000", "103.10.20.1, 36.80.1.1"))
+	})
+
+	t.Run("Forged header past the proxy", func(t *testing.T) {
+		extractor, err := IPExtractor([]string{"10.0.0.0/8"})
+		assert.Nil(t, err)
+
+		assert.Equal(t, "36.80.1.1", realIP(extractor, "36.80.1.1:40000", "103.10.20.1"))
+	})
+
+	t.Run("Invalid range", func(t *testing.T) {
+		_, err := IPExtractor([]string{"10.0.0.0/99"})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	e := echo.New()
+	var handler = RateLimitMiddleware(2)(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	var codes = []int{}
+	for _, ip := range []string{"36.80.1.1", "36.80.1.1", "36.80.1.1", "103.10.20.1"} {
+		req := httptest.NewRequest(http.MethodPost, "/login/code", nil)
+		req.RemoteAddr = ip + ":40000"
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		codes = append(codes, rec.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests, http.StatusNoContent}, codes)
+
+	t.Run("Forged forwarded for", func(t *testing.T) {
+		var handler = RateLimitMiddleware(2)(func(c echo.Context) error {
+			return c.NoContent(http.StatusNoContent)
+		})
+
+		var codes = []int{}
+		for _, forged := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+			req := httptest.NewRequest(http.MethodPost, "/login/code", nil)
+			req.RemoteAddr = "36.80.1.1:40000"
+			req.Header.Set(echo.HeaderXForwardedFor, forged)
+			rec := httptest.NewRecorder()
+
+			assert.Nil(t, handler(e.NewContext(req, rec)))
+			codes = append(codes, rec.Code)
+		}
+
+		assert.Equal(t, []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests}, codes)
+	})
+}
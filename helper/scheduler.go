@@ -0,0 +1,22 @@
+package helper
+
+import (
+	"context"
+	"time"
+)
+
+// RunPeriodically calls job every interval until ctx is done. It blocks, so
+// start it in its own goroutine.
+func RunPeriodically(ctx context.Context, interval time.Duration, job func()) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			job()
+		}
+	}
+}
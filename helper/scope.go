@@ -0,0 +1,69 @@
+package helper
+
+import "errors"
+
+// Scopes limit what an API key or an OAuth access token may be used for.
+// Routes that do not ask for one of them with RequireScope are only reachable
+// with an access token from an interactive login.
+const (
+	ScopeAuditRead     = "audit:read"
+	ScopeUsersManage   = "users:manage"
+	ScopePrivacyExport = "privacy:export"
+)
+
+// OpenID Connect scopes, profile and phone decide which claims end up in the
+// ID token and the userinfo response.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopePhone   = "phone"
+)
+
+var Scopes = []string{ScopeAuditRead, ScopeUsersManage, ScopePrivacyExport, ScopeOpenID, ScopeProfile, ScopePhone}
+
+// AdminScopes only make sense on routes that also require the admin role, they
+// are not granted to anyone else.
+var AdminScopes = []string{ScopeAuditRead, ScopeUsersManage}
+
+// NormalizeScopes drops duplicates and refuses unknown or missing scopes.
+func NormalizeScopes(scopes []string) ([]string, error) {
+	var result = []string{}
+	for _, scope := range scopes {
+		if !containsString(Scopes, scope) {
+			return nil, errors.New("invalid scope")
+		}
+		if !containsString(result, scope) {
+			result = append(result, scope)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New("invalid scope")
+	}
+
+	return result, nil
+}
+
+// ScopesAllowed reports whether scopes may be granted to a user, admin tells
+// whether the user has the admin role.
+func ScopesAllowed(scopes []string, admin bool) bool {
+	if admin {
+		return true
+	}
+
+	for _, scope := range scopes {
+		if containsString(AdminScopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
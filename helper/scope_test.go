@@ -0,0 +1,33 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeScopes(t *testing.T) {
+	t.Run("Duplicates dropped", func(t *testing.T) {
+		result, err := NormalizeScopes([]string{ScopePrivacyExport, ScopeAuditRead, ScopePrivacyExport})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{ScopePrivacyExport, ScopeAuditRead}, result)
+	})
+
+	t.Run("Unknown scope", func(t *testing.T) {
+		result, err := NormalizeScopes([]string{ScopeAuditRead, "users:delete"})
+		assert.EqualError(t, err, "invalid scope")
+		assert.Nil(t, result)
+	})
+
+	t.Run("No scope", func(t *testing.T) {
+		result, err := NormalizeScopes(nil)
+		assert.EqualError(t, err, "invalid scope")
+		assert.Nil(t, result)
+	})
+}
+
+func TestScopesAllowed(t *testing.T) {
+	assert.True(t, ScopesAllowed([]string{ScopePrivacyExport}, false))
+	assert.False(t, ScopesAllowed([]string{ScopePrivacyExport, ScopeUsersManage}, false))
+	assert.True(t, ScopesAllowed([]string{ScopeUsersManage}, true))
+}
@@ -0,0 +1,303 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StorageInterface keeps public files like avatars. Keys are slash separated
+// paths, URL returns where clients can fetch the file without credentials.
+type StorageInterface interface {
+	Put(ctx context.Context, key string, contentType string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// LocalStorage writes the files below a directory, which the server itself
+// hands out under baseURL.
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStorage(dir string, baseURL string) *LocalStorage {
+	return &LocalStorage{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (ls *LocalStorage) Put(ctx context.Context, key string, contentType string, body []byte) error {
+	file, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	// Written next to the target first, so a file is never served half way.
+	var tmp = file + ".tmp"
+	if err := os.WriteFile(tmp, body, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
+
+func (ls *LocalStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	file, err := ls.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(file)
+}
+
+// Delete succeeds for files that are already gone.
+func (ls *LocalStorage) Delete(ctx context.Context, key string) error {
+	file, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (ls *LocalStorage) URL(key string) string {
+	return ls.baseURL + "/" + key
+}
+
+func (ls *LocalStorage) path(key string) (string, error) {
+	if !validStorageKey(key) {
+		return "", errors.New("invalid storage key")
+	}
+
+	return filepath.Join(ls.dir, filepath.FromSlash(key)), nil
+}
+
+type S3Config struct {
+	// Endpoint is the base URL of the service, e.g. https://s3.eu-west-1.amazonaws.com
+	// or the address of a MinIO server. Buckets are addressed path style.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL is where the bucket is readable by clients, e.g. a CDN in
+	// front of it. Without it the files are linked on Endpoint.
+	PublicURL string
+}
+
+// S3Storage keeps the files in a bucket of an S3 compatible service. Requests
+// are signed with AWS Signature Version 4, the objects have to be made
+// readable by a bucket policy.
+type S3Storage struct {
+	cfg    S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3Storage(cfg S3Config, client *http.Client) *S3Storage {
+	if client == nil {
+		client = &http.Client{Timeout: time.Second * 30}
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	cfg.PublicURL = strings.TrimSuffix(cfg.PublicURL, "/")
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	return &S3Storage{
+		cfg:    cfg,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, contentType string, body []byte) error {
+	request, err := s.request(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentType)
+
+	_, err = s.do(request, body)
+	return err
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	request, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.do(request, nil)
+}
+
+// Delete succeeds for objects that are already gone, S3 answers 204 for them
+// as well.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	request, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.do(request, nil)
+	return err
+}
+
+func (s *S3Storage) URL(key string) string {
+	if s.cfg.PublicURL != "" {
+		return s.cfg.PublicURL + "/" + key
+	}
+
+	return s.cfg.Endpoint + "/" + s.cfg.Bucket + "/" + key
+}
+
+func (s *S3Storage) request(ctx context.Context, method string, key string, body []byte) (*http.Request, error) {
+	if !validStorageKey(key) {
+		return nil, errors.New("invalid storage key")
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, s.cfg.Endpoint+"/"+s.cfg.Bucket+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// do signs and sends the request, returning the body of a successful answer.
+func (s *S3Storage) do(request *http.Request, body []byte) ([]byte, error) {
+	var payloadHash = sha256Hex(body)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signV4(request, payloadHash, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, "s3", s.now())
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return nil, fmt.Errorf("storage: %s %s answered %d: %s", request.Method, request.URL.Path, response.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	return io.ReadAll(response.Body)
+}
+
+// signV4 sets X-Amz-Date and the Authorization header. The host and every
+// X-Amz header are signed.
+func signV4(request *http.Request, payloadHash string, accessKey string, secretKey string, region string, service string, now time.Time) {
+	now = now.UTC()
+	var amzDate = now.Format("20060102T150405Z")
+	var date = now.Format("20060102")
+	request.Header.Set("X-Amz-Date", amzDate)
+
+	var headers = map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	var names = make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	var signedHeaders = strings.Join(names, ";")
+
+	var canonicalPath = request.URL.EscapedPath()
+	if canonicalPath == "" {
+		canonicalPath = "/"
+	}
+
+	var canonicalRequest = strings.Join([]string{
+		request.Method,
+		canonicalPath,
+		canonicalQuery(request.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	var scope = date + "/" + region + "/" + service + "/aws4_request"
+	var stringToSign = strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	var key = hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	var signature = hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalQuery(query url.Values) string {
+	var pairs = []string{}
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(name)+"="+awsEscape(value))
+		}
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "&")
+}
+
+func awsEscape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	var mac = hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	var sum = sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// validStorageKey refuses keys that could leave the storage root.
+func validStorageKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+
+	return path.Clean(key) == key && !strings.HasPrefix(key, "../") && key != ".."
+}
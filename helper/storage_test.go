@@ -0,0 +1,149 @@
+package helper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockS3 is a local stand-in for an S3 compatible service. It checks the
+// signature of every request and keeps the objects in memory.
+type mockS3 struct {
+	server  *httptest.Server
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newMockS3(t *testing.T, accessKey string, secretKey string) *mockS3 {
+	var s3 = &mockS3{objects: map[string][]byte{}, types: map[string]string{}}
+	s3.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// The request is signed again with the known secret, the signature
+		// has to match the one the client sent.
+		signedAt, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+		if err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var check = r.Clone(context.Background())
+		check.URL.Host = r.Host
+		check.Header.Del("Authorization")
+		signV4(check, sha256Hex(body), accessKey, secretKey, "us-east-1", "s3", signedAt)
+		if check.Header.Get("Authorization") != r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		s3.mu.Lock()
+		defer s3.mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			object, found := s3.objects[r.URL.Path]
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(object)
+		case http.MethodPut:
+			s3.objects[r.URL.Path] = body
+			s3.types[r.URL.Path] = r.Header.Get("Content-Type")
+		case http.MethodDelete:
+			delete(s3.objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(s3.server.Close)
+
+	return s3
+}
+
+func TestSignV4(t *testing.T) {
+	// get-vanilla from the AWS Signature Version 4 test suite.
+	request, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	assert.Nil(t, err)
+
+	signV4(request, sha256Hex(nil), "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	assert.Equal(t, "20150830T123600Z", request.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31", request.Header.Get("Authorization"))
+}
+
+func TestS3Storage(t *testing.T) {
+	var s3 = newMockS3(t, "accessKey", "secretKey")
+
+	t.Run("Put and delete", func(t *testing.T) {
+		var storage = NewS3Storage(S3Config{Endpoint: s3.server.URL, Bucket: "media", AccessKey: "accessKey", SecretKey: "secretKey"}, nil)
+
+		assert.Nil(t, storage.Put(context.Background(), "avatars/userID/abc-small.jpg", "image/jpeg", []byte("image")))
+		assert.Equal(t, []byte("image"), s3.objects["/media/avatars/userID/abc-small.jpg"])
+		assert.Equal(t, "image/jpeg", s3.types["/media/avatars/userID/abc-small.jpg"])
+		assert.Equal(t, s3.server.URL+"/media/avatars/userID/abc-small.jpg", storage.URL("avatars/userID/abc-small.jpg"))
+		content, err := storage.Get(context.Background(), "avatars/userID/abc-small.jpg")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("image"), content)
+
+		assert.Nil(t, storage.Delete(context.Background(), "avatars/userID/abc-small.jpg"))
+		assert.NotContains(t, s3.objects, "/media/avatars/userID/abc-small.jpg")
+		_, err = storage.Get(context.Background(), "avatars/userID/abc-small.jpg")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Wrong secret", func(t *testing.T) {
+		var storage = NewS3Storage(S3Config{Endpoint: s3.server.URL, Bucket: "media", AccessKey: "accessKey", SecretKey: "otherSecret"}, nil)
+
+		var err = storage.Put(context.Background(), "avatars/userID/abc-small.jpg", "image/jpeg", []byte("image"))
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("Public URL", func(t *testing.T) {
+		var storage = NewS3Storage(S3Config{Endpoint: s3.server.URL, Bucket: "media", PublicURL: "https://cdn.example.com/"}, nil)
+
+		assert.Equal(t, "https://cdn.example.com/avatars/a.jpg", storage.URL("avatars/a.jpg"))
+	})
+}
+
+func TestLocalStorage(t *testing.T) {
+	var dir = t.TempDir()
+	var storage = NewLocalStorage(dir, "https://auth.example.com/media/")
+
+	t.Run("Put and delete", func(t *testing.T) {
+		assert.Nil(t, storage.Put(context.Background(), "avatars/userID/abc-small.jpg", "image/jpeg", []byte("image")))
+		content, err := os.ReadFile(filepath.Join(dir, "avatars", "userID", "abc-small.jpg"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("image"), content)
+		assert.Equal(t, "https://auth.example.com/media/avatars/userID/abc-small.jpg", storage.URL("avatars/userID/abc-small.jpg"))
+		content, err = storage.Get(context.Background(), "avatars/userID/abc-small.jpg")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("image"), content)
+
+		assert.Nil(t, storage.Delete(context.Background(), "avatars/userID/abc-small.jpg"))
+		assert.Nil(t, storage.Delete(context.Background(), "avatars/userID/abc-small.jpg"))
+		_, err = os.Stat(filepath.Join(dir, "avatars", "userID", "abc-small.jpg"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Key outside the directory", func(t *testing.T) {
+		for _, key := range []string{"../secret", "avatars/../../secret", "/etc/passwd", ""} {
+			var err = storage.Put(context.Background(), key, "image/jpeg", []byte("image"))
+			assert.NotNil(t, err, key)
+			assert.True(t, strings.Contains(err.Error(), "invalid storage key"), key)
+		}
+	})
+}
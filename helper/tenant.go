@@ -0,0 +1,139 @@
+package helper
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TenantHeader names the organization a request is for by its slug, for
+// clients that do not use the organization's subdomain.
+const TenantHeader = "X-Organization"
+
+// TenantResolverInterface finds the organization ID for a slug, it returns a
+// "not found" error for unknown slugs.
+type TenantResolverInterface interface {
+	ResolveTenant(slug string) (string, error)
+}
+
+type tenantKey struct{}
+
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// TenantMiddleware resolves the organization from the header, or else from
+// the subdomain of baseDomain the request was sent to. Requests naming no
+// organization are not scoped. The org claim of an access token is checked
+// later by the auth middleware.
+func TenantMiddleware(resolver TenantResolverInterface, baseDomain string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var slug = strings.TrimSpace(c.Request().Header.Get(TenantHeader))
+			if slug == "" {
+				slug = tenantSubdomain(c.Request().Host, baseDomain)
+			}
+
+			if slug == "" {
+				return next(c)
+			}
+
+			tenantID, err := resolver.ResolveTenant(strings.ToLower(slug))
+			if err != nil {
+				c.Logger().Error("middleware: resolve tenant error:", err.Error())
+				if strings.Contains(err.Error(), "not found") {
+					return c.JSON(http.StatusNotFound, FormatResponse("organization not found", nil))
+				}
+				return c.JSON(http.StatusInternalServerError, FormatResponse("fail", nil))
+			}
+
+			c.SetRequest(c.Request().WithContext(WithTenant(c.Request().Context(), tenantID)))
+
+			return next(c)
+		}
+	}
+}
+
+// tenantSubdomain returns "acme" for acme.example.com with the base domain
+// example.com. Deeper subdomains and the base domain itself name no tenant.
+func tenantSubdomain(host string, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	label, found := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(baseDomain))
+	if !found || label == "" || strings.Contains(label, ".") {
+		return ""
+	}
+
+	return label
+}
+
+// TenantScoped models are only visible inside the tenant of the statement
+// context once the TenantPlugin is in use.
+type TenantScoped interface {
+	TenantCondition(tenantID string) clause.Expression
+}
+
+const tenantScopedSetting = "tenant:scoped"
+
+// TenantPlugin adds the condition of TenantScoped models to every query,
+// update and delete run with a tenant in its context, e.g. through
+// db.WithContext(ctx). Creates are left alone.
+type TenantPlugin struct{}
+
+func (TenantPlugin) Name() string {
+	return "tenant"
+}
+
+func (TenantPlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("tenant:query", scopeTenant); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("tenant:row", scopeTenant); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("tenant:update", scopeTenant); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", scopeTenant)
+}
+
+func scopeTenant(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Context == nil {
+		return
+	}
+
+	tenantID, ok := TenantFromContext(db.Statement.Context)
+	if !ok {
+		return
+	}
+
+	// A statement reused for Count and Find runs the callbacks twice.
+	if _, done := db.Statement.Settings.Load(tenantScopedSetting); done {
+		return
+	}
+
+	scoped, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(TenantScoped)
+	if !ok {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{scoped.TenantCondition(tenantID)}})
+	db.Statement.Settings.Store(tenantScopedSetting, true)
+}
@@ -0,0 +1,154 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tenantResolver map[string]string
+
+func (r tenantResolver) ResolveTenant(slug string) (string, error) {
+	if tenantID, ok := r[slug]; ok {
+		return tenantID, nil
+	}
+	return "", errors.New("data not found")
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	e := echo.New()
+	var tenantID string
+	var handler = TenantMiddleware(tenantResolver{"acme": "acmeID"}, "example.com")(func(c echo.Context) error {
+		tenantID, _ = TenantFromContext(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+
+	for name, tc := range map[string]struct {
+		host     string
+		header   string
+		code     int
+		expected string
+	}{
+		"Header":                {host: "example.com", header: "Acme", code: http.StatusOK, expected: "acmeID"},
+		"Subdomain":             {host: "acme.example.com:8080", code: http.StatusOK, expected: "acmeID"},
+		"Header over subdomain": {host: "other.example.com", header: "acme", code: http.StatusOK, expected: "acmeID"},
+		"Base domain":           {host: "example.com", code: http.StatusOK},
+		"Deeper subdomain":      {host: "api.acme.example.com", code: http.StatusOK},
+		"Other domain":          {host: "acme.example.org", code: http.StatusOK},
+		"Unknown organization":  {host: "other.example.com", code: http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tenantID = ""
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tc.host
+			if tc.header != "" {
+				req.Header.Set(TenantHeader, tc.header)
+			}
+			rec := httptest.NewRecorder()
+
+			assert.Nil(t, handler(e.NewContext(req, rec)))
+			assert.Equal(t, tc.code, rec.Code)
+			assert.Equal(t, tc.expected, tenantID)
+		})
+	}
+}
+
+func TestTenantTokenClaim(t *testing.T) {
+	j := newTestJWT(time.Now())
+	e := echo.New()
+	var tenantID string
+	var handler = TenantMiddleware(tenantResolver{"acme": "acmeID", "other": "otherID"}, "")(AuthMiddleware(j, nil)(func(c echo.Context) error {
+		tenantID, _ = TenantFromContext(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	}))
+	var token = j.GenerateToken(Principal{UserID: "randomUserID", OrganizationID: "acmeID"})
+
+	t.Run("Claim names the organization", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "acmeID", tenantID)
+	})
+
+	t.Run("Claim for another organization", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(TenantHeader, "other")
+		rec := httptest.NewRecorder()
+
+		assert.Nil(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
+
+type tenantMember struct {
+	ID   string
+	Nama string
+}
+
+func (tenantMember) TenantCondition(tenantID string) clause.Expression {
+	return clause.Expr{SQL: "tenant_members.id IN (SELECT user_id FROM memberships WHERE organization_id = ?)", Vars: []any{tenantID}}
+}
+
+type tenantNote struct {
+	ID string
+}
+
+func TestTenantPlugin(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(TenantPlugin{}))
+	var ctx = WithTenant(context.Background(), "acmeID")
+
+	t.Run("Query in a tenant", func(t *testing.T) {
+		stmt := db.WithContext(ctx).Where("nama = ?", "dida").Find(&[]tenantMember{}).Statement
+
+		assert.Equal(t, "SELECT * FROM `tenant_members` WHERE nama = ? AND tenant_members.id IN (SELECT user_id FROM memberships WHERE organization_id = ?)", stmt.SQL.String())
+		assert.Equal(t, []any{"dida", "acmeID"}, stmt.Vars)
+	})
+
+	t.Run("Count and find on one statement", func(t *testing.T) {
+		var total int64
+		qry := db.WithContext(ctx).Model(&tenantMember{})
+		qry.Count(&total)
+		stmt := qry.Find(&[]tenantMember{}).Statement
+
+		assert.Equal(t, 1, len(stmt.Clauses["WHERE"].Expression.(clause.Where).Exprs))
+	})
+
+	t.Run("Update in a tenant", func(t *testing.T) {
+		stmt := db.WithContext(ctx).Model(&tenantMember{}).Where("id = ?", "randomUserID").Update("nama", "dida").Statement
+
+		assert.Contains(t, stmt.SQL.String(), "WHERE id = ? AND tenant_members.id IN")
+	})
+
+	t.Run("Delete in a tenant", func(t *testing.T) {
+		stmt := db.WithContext(ctx).Where("id = ?", "randomUserID").Delete(&tenantMember{}).Statement
+
+		assert.Contains(t, stmt.SQL.String(), "WHERE id = ? AND tenant_members.id IN")
+	})
+
+	t.Run("Without tenant", func(t *testing.T) {
+		stmt := db.WithContext(context.Background()).Find(&[]tenantMember{}).Statement
+
+		assert.Equal(t, "SELECT * FROM `tenant_members`", stmt.SQL.String())
+	})
+
+	t.Run("Model without tenant", func(t *testing.T) {
+		stmt := db.WithContext(ctx).Find(&[]tenantNote{}).Statement
+
+		assert.Equal(t, "SELECT * FROM `tenant_notes`", stmt.SQL.String())
+	})
+}
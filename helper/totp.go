@@ -0,0 +1,101 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30
+	// TOTPSkew is how many periods before and after the current one are
+	// still accepted, to tolerate clock drift on the user's device.
+	TOTPSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func GenerateTOTPSecret() (string, error) {
+	var secret = make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / TOTPPeriod
+}
+
+// TOTPCode computes the RFC 6238 code (HMAC-SHA1) of the given time step.
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+
+	var counter = make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	var mac = hmac.New(sha1.New, key)
+	mac.Write(counter)
+	var sum = mac.Sum(nil)
+
+	var offset = sum[len(sum)-1] & 0x0f
+	var value = binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	var mod uint32 = 1
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod), nil
+}
+
+// ValidateTOTP returns the matched time step so callers can refuse codes that
+// were already used, or -1 when the code does not match.
+func ValidateTOTP(secret string, code string, t time.Time) int64 {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return -1
+	}
+
+	var current = TOTPStep(t)
+	for step := current - TOTPSkew; step <= current+TOTPSkew; step++ {
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return -1
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step
+		}
+	}
+
+	return -1
+}
+
+func TOTPURI(issuer string, account string, secret string) string {
+	var query = url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(TOTPPeriod))
+
+	var label = url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+func QRCodePNG(content string) ([]byte, error) {
+	return qrcode.Encode(content, qrcode.Medium, 256)
+}
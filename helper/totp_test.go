@@ -0,0 +1,51 @@
+package helper
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, SHA1 seed, truncated to six digits.
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range vectors {
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, code)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.Nil(t, err)
+	now := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	code, _ := TOTPCode(secret, TOTPStep(now))
+
+	t.Run("Current period", func(t *testing.T) {
+		assert.Equal(t, TOTPStep(now), ValidateTOTP(secret, code, now))
+	})
+
+	t.Run("Within allowed skew", func(t *testing.T) {
+		assert.Equal(t, TOTPStep(now), ValidateTOTP(secret, code, now.Add(time.Second*TOTPPeriod)))
+	})
+
+	t.Run("Outside allowed skew", func(t *testing.T) {
+		assert.Equal(t, int64(-1), ValidateTOTP(secret, code, now.Add(time.Second*TOTPPeriod*2)))
+	})
+
+	t.Run("Malformed code", func(t *testing.T) {
+		assert.Equal(t, int64(-1), ValidateTOTP(secret, "12345", now))
+	})
+}
@@ -0,0 +1,306 @@
+package helper
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// COSE algorithms passkeys may be registered with, in order of preference.
+const (
+	COSEAlgES256 = -7
+	COSEAlgEdDSA = -8
+	COSEAlgRS256 = -257
+)
+
+var WebAuthnAlgorithms = []int{COSEAlgES256, COSEAlgEdDSA, COSEAlgRS256}
+
+const (
+	authDataUserPresent  = 0x01
+	authDataUserVerified = 0x04
+	authDataAttested     = 0x40
+)
+
+// WebAuthnConfig is the relying party passkeys are registered with. A
+// passkey only works on RPID and its subdomains, changing it makes every
+// registered passkey unusable.
+type WebAuthnConfig struct {
+	RPID   string
+	RPName string
+	// Origins are the pages allowed to run the ceremonies, like
+	// https://app.example.com.
+	Origins []string
+}
+
+// WebAuthnCredential is the passkey a registration created. PublicKey is
+// kept COSE encoded, the way the authenticator sent it.
+type WebAuthnCredential struct {
+	ID           []byte
+	PublicKey    []byte
+	SignCount    uint32
+	UserVerified bool
+}
+
+// WebAuthnAssertion is what a verified login tells about the authenticator.
+type WebAuthnAssertion struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+type WebAuthnInterface interface {
+	Config() WebAuthnConfig
+	VerifyRegistration(challenge string, clientDataJSON []byte, attestationObject []byte) (*WebAuthnCredential, error)
+	VerifyAssertion(challenge string, publicKey []byte, clientDataJSON []byte, authenticatorData []byte, signature []byte) (*WebAuthnAssertion, error)
+}
+
+// WebAuthn verifies the ceremonies of the WebAuthn Level 2 spec. Attestation
+// statements are not checked, passkeys are registered with attestation
+// "none" and trusted like a password the user chose.
+type WebAuthn struct {
+	cfg WebAuthnConfig
+}
+
+func NewWebAuthn(cfg WebAuthnConfig) WebAuthnInterface {
+	if cfg.RPName == "" {
+		cfg.RPName = cfg.RPID
+	}
+
+	return &WebAuthn{
+		cfg: cfg,
+	}
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type attestationObject struct {
+	Format   string          `cbor:"fmt"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+type authenticatorData struct {
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+}
+
+func (w *WebAuthn) Config() WebAuthnConfig {
+	return w.cfg
+}
+
+// VerifyRegistration checks the response to navigator.credentials.create for
+// the challenge the registration was started with.
+func (w *WebAuthn) VerifyRegistration(challenge string, clientDataJSON []byte, rawAttestation []byte) (*WebAuthnCredential, error) {
+	if err := w.verifyClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	var attestation attestationObject
+	if err := cbor.Unmarshal(rawAttestation, &attestation); err != nil {
+		return nil, errors.New("invalid attestation")
+	}
+
+	authData, err := w.parseAuthenticatorData(attestation.AuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if authData.flags&authDataAttested == 0 || len(authData.credentialID) == 0 {
+		return nil, errors.New("invalid authenticator data")
+	}
+
+	if _, err := parseCOSEKey(authData.publicKey); err != nil {
+		return nil, err
+	}
+
+	var result = new(WebAuthnCredential)
+	result.ID = authData.credentialID
+	result.PublicKey = authData.publicKey
+	result.SignCount = authData.signCount
+	result.UserVerified = authData.flags&authDataUserVerified != 0
+
+	return result, nil
+}
+
+// VerifyAssertion checks the response to navigator.credentials.get against
+// the public key stored at registration. Sign counts are left to the caller,
+// it knows the last one.
+func (w *WebAuthn) VerifyAssertion(challenge string, publicKey []byte, clientDataJSON []byte, rawAuthData []byte, signature []byte) (*WebAuthnAssertion, error) {
+	if err := w.verifyClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := w.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parseCOSEKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var clientDataHash = sha256.Sum256(clientDataJSON)
+	var signed = append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+
+	if !verifySignature(key, signed, signature) {
+		return nil, errors.New("invalid signature")
+	}
+
+	var result = new(WebAuthnAssertion)
+	result.SignCount = authData.signCount
+	result.UserVerified = authData.flags&authDataUserVerified != 0
+
+	return result, nil
+}
+
+func (w *WebAuthn) verifyClientData(raw []byte, ceremony string, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return errors.New("invalid client data")
+	}
+
+	if data.Type != ceremony {
+		return errors.New("invalid client data")
+	}
+
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(challenge)) != 1 {
+		return errors.New("invalid challenge")
+	}
+
+	if data.CrossOrigin {
+		return errors.New("origin not allowed")
+	}
+
+	for _, origin := range w.cfg.Origins {
+		if data.Origin == origin {
+			return nil
+		}
+	}
+
+	return errors.New("origin not allowed")
+}
+
+// parseAuthenticatorData reads the layout of section 6.1: the RP ID hash,
+// flags and sign count, then the attested credential data when present.
+func (w *WebAuthn) parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, errors.New("invalid authenticator data")
+	}
+
+	var rpIDHash = sha256.Sum256([]byte(w.cfg.RPID))
+	if !bytes.Equal(raw[:32], rpIDHash[:]) {
+		return nil, errors.New("rp id mismatch")
+	}
+
+	var result = new(authenticatorData)
+	result.flags = raw[32]
+	result.signCount = binary.BigEndian.Uint32(raw[33:37])
+
+	if result.flags&authDataUserPresent == 0 {
+		return nil, errors.New("user not present")
+	}
+
+	if result.flags&authDataAttested != 0 {
+		// AAGUID, then the credential ID prefixed with its length.
+		if len(raw) < 55 {
+			return nil, errors.New("invalid authenticator data")
+		}
+		var idLength = int(binary.BigEndian.Uint16(raw[53:55]))
+		if idLength > 1023 || len(raw) < 55+idLength {
+			return nil, errors.New("invalid authenticator data")
+		}
+		result.credentialID = raw[55 : 55+idLength]
+
+		var key cbor.RawMessage
+		if _, err := cbor.UnmarshalFirst(raw[55+idLength:], &key); err != nil {
+			return nil, errors.New("invalid authenticator data")
+		}
+		result.publicKey = key
+	}
+
+	return result, nil
+}
+
+// parseCOSEKey accepts the keys of WebAuthnAlgorithms. RSA keys shorter than
+// 2048 bits are refused.
+func parseCOSEKey(raw []byte) (crypto.PublicKey, error) {
+	var key map[int]cbor.RawMessage
+	if err := cbor.Unmarshal(raw, &key); err != nil {
+		return nil, errors.New("unsupported public key")
+	}
+
+	var kty, alg, crv int
+	var x, y, n, e []byte
+	if cbor.Unmarshal(key[1], &kty) != nil || cbor.Unmarshal(key[3], &alg) != nil {
+		return nil, errors.New("unsupported public key")
+	}
+
+	switch {
+	case kty == 2 && alg == COSEAlgES256:
+		if cbor.Unmarshal(key[-1], &crv) != nil || cbor.Unmarshal(key[-2], &x) != nil || cbor.Unmarshal(key[-3], &y) != nil {
+			return nil, errors.New("unsupported public key")
+		}
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("unsupported public key")
+		}
+		// Unmarshal refuses points that are not on the curve.
+		px, py := elliptic.Unmarshal(elliptic.P256(), append(append([]byte{4}, x...), y...))
+		if px == nil {
+			return nil, errors.New("unsupported public key")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: px, Y: py}, nil
+	case kty == 1 && alg == COSEAlgEdDSA:
+		if cbor.Unmarshal(key[-1], &crv) != nil || cbor.Unmarshal(key[-2], &x) != nil {
+			return nil, errors.New("unsupported public key")
+		}
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("unsupported public key")
+		}
+		return ed25519.PublicKey(x), nil
+	case kty == 3 && alg == COSEAlgRS256:
+		if cbor.Unmarshal(key[-1], &n) != nil || cbor.Unmarshal(key[-2], &e) != nil {
+			return nil, errors.New("unsupported public key")
+		}
+		var modulus = new(big.Int).SetBytes(n)
+		var exponent = new(big.Int).SetBytes(e)
+		if modulus.BitLen() < 2048 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("unsupported public key")
+		}
+		return &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}, nil
+	}
+
+	return nil, errors.New("unsupported public key")
+}
+
+func verifySignature(key crypto.PublicKey, signed []byte, signature []byte) bool {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		var digest = sha256.Sum256(signed)
+		return ecdsa.VerifyASN1(k, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, signed, signature)
+	case *rsa.PublicKey:
+		var digest = sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	}
+
+	return false
+}
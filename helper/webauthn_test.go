@@ -0,0 +1,227 @@
+package helper
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// softAuthenticator plays a platform authenticator: it creates one
+// credential and signs assertions with it like a browser would pass them on.
+type softAuthenticator struct {
+	rpID         string
+	origin       string
+	key          crypto.Signer
+	credentialID []byte
+	signCount    uint32
+	flags        byte
+}
+
+func newSoftAuthenticator(t *testing.T, rpID string, origin string, key crypto.Signer) *softAuthenticator {
+	var credentialID = make([]byte, 16)
+	_, err := rand.Read(credentialID)
+	assert.Nil(t, err)
+
+	return &softAuthenticator{rpID: rpID, origin: origin, key: key, credentialID: credentialID, flags: authDataUserPresent | authDataUserVerified}
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremony string, challenge string) []byte {
+	raw, err := json.Marshal(map[string]any{"type": ceremony, "challenge": challenge, "origin": a.origin, "crossOrigin": false})
+	assert.Nil(t, err)
+	return raw
+}
+
+func (a *softAuthenticator) coseKey(t *testing.T) []byte {
+	var key map[int]any
+	switch k := a.key.Public().(type) {
+	case *ecdsa.PublicKey:
+		key = map[int]any{1: 2, 3: COSEAlgES256, -1: 1, -2: k.X.FillBytes(make([]byte, 32)), -3: k.Y.FillBytes(make([]byte, 32))}
+	case ed25519.PublicKey:
+		key = map[int]any{1: 1, 3: COSEAlgEdDSA, -1: 6, -2: []byte(k)}
+	}
+
+	raw, err := cbor.Marshal(key)
+	assert.Nil(t, err)
+	return raw
+}
+
+func (a *softAuthenticator) authData(t *testing.T, attested bool) []byte {
+	var rpIDHash = sha256.Sum256([]byte(a.rpID))
+	var result = append([]byte{}, rpIDHash[:]...)
+
+	var flags = a.flags
+	if attested {
+		flags |= authDataAttested
+	}
+	result = append(result, flags)
+	result = binary.BigEndian.AppendUint32(result, a.signCount)
+
+	if attested {
+		result = append(result, make([]byte, 16)...)
+		result = binary.BigEndian.AppendUint16(result, uint16(len(a.credentialID)))
+		result = append(result, a.credentialID...)
+		result = append(result, a.coseKey(t)...)
+	}
+
+	return result
+}
+
+func (a *softAuthenticator) create(t *testing.T, challenge string) ([]byte, []byte) {
+	attestation, err := cbor.Marshal(map[string]any{"fmt": "none", "attStmt": map[string]any{}, "authData": a.authData(t, true)})
+	assert.Nil(t, err)
+
+	return a.clientData(t, "webauthn.create", challenge), attestation
+}
+
+func (a *softAuthenticator) get(t *testing.T, challenge string) ([]byte, []byte, []byte) {
+	a.signCount++
+	var clientDataJSON = a.clientData(t, "webauthn.get", challenge)
+	var authData = a.authData(t, false)
+
+	var clientDataHash = sha256.Sum256(clientDataJSON)
+	var signed = append(append([]byte{}, authData...), clientDataHash[:]...)
+
+	var signature []byte
+	var err error
+	if _, ok := a.key.(ed25519.PrivateKey); ok {
+		signature, err = a.key.Sign(rand.Reader, signed, crypto.Hash(0))
+	} else {
+		var digest = sha256.Sum256(signed)
+		signature, err = a.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	assert.Nil(t, err)
+
+	return clientDataJSON, authData, signature
+}
+
+func TestWebAuthn(t *testing.T) {
+	webAuthn := NewWebAuthn(WebAuthnConfig{RPID: "example.com", RPName: "Example", Origins: []string{"https://app.example.com"}})
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	for name, key := range map[string]crypto.Signer{"ES256": ecKey, "EdDSA": edKey} {
+		t.Run("Register and login with "+name, func(t *testing.T) {
+			authenticator := newSoftAuthenticator(t, "example.com", "https://app.example.com", key)
+
+			clientDataJSON, attestation := authenticator.create(t, "registerChallenge")
+			credential, err := webAuthn.VerifyRegistration("registerChallenge", clientDataJSON, attestation)
+			assert.Nil(t, err)
+			assert.Equal(t, authenticator.credentialID, credential.ID)
+			assert.Equal(t, uint32(0), credential.SignCount)
+			assert.True(t, credential.UserVerified)
+
+			clientDataJSON, authData, signature := authenticator.get(t, "loginChallenge")
+			assertion, err := webAuthn.VerifyAssertion("loginChallenge", credential.PublicKey, clientDataJSON, authData, signature)
+			assert.Nil(t, err)
+			assert.Equal(t, uint32(1), assertion.SignCount)
+			assert.True(t, assertion.UserVerified)
+		})
+	}
+
+	authenticator := newSoftAuthenticator(t, "example.com", "https://app.example.com", ecKey)
+	clientDataJSON, attestation := authenticator.create(t, "registerChallenge")
+	credential, err := webAuthn.VerifyRegistration("registerChallenge", clientDataJSON, attestation)
+	assert.Nil(t, err)
+
+	t.Run("Registration for another challenge", func(t *testing.T) {
+		result, err := webAuthn.VerifyRegistration("otherChallenge", clientDataJSON, attestation)
+		assert.EqualError(t, err, "invalid challenge")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Assertion used for registration", func(t *testing.T) {
+		clientDataJSON, _, _ := authenticator.get(t, "registerChallenge")
+
+		result, err := webAuthn.VerifyRegistration("registerChallenge", clientDataJSON, attestation)
+		assert.EqualError(t, err, "invalid client data")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Phishing origin", func(t *testing.T) {
+		phished := newSoftAuthenticator(t, "example.com", "https://app.example.com.evil.test", ecKey)
+		clientDataJSON, authData, signature := phished.get(t, "loginChallenge")
+
+		result, err := webAuthn.VerifyAssertion("loginChallenge", credential.PublicKey, clientDataJSON, authData, signature)
+		assert.EqualError(t, err, "origin not allowed")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Credential of another relying party", func(t *testing.T) {
+		other := newSoftAuthenticator(t, "evil.test", "https://app.example.com", ecKey)
+		clientDataJSON, authData, signature := other.get(t, "loginChallenge")
+
+		result, err := webAuthn.VerifyAssertion("loginChallenge", credential.PublicKey, clientDataJSON, authData, signature)
+		assert.EqualError(t, err, "rp id mismatch")
+		assert.Nil(t, result)
+	})
+
+	t.Run("User not present", func(t *testing.T) {
+		authenticator.flags = 0
+		defer func() { authenticator.flags = authDataUserPresent | authDataUserVerified }()
+		clientDataJSON, authData, signature := authenticator.get(t, "loginChallenge")
+
+		result, err := webAuthn.VerifyAssertion("loginChallenge", credential.PublicKey, clientDataJSON, authData, signature)
+		assert.EqualError(t, err, "user not present")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Without user verification", func(t *testing.T) {
+		authenticator.flags = authDataUserPresent
+		defer func() { authenticator.flags = authDataUserPresent | authDataUserVerified }()
+		clientDataJSON, authData, signature := authenticator.get(t, "loginChallenge")
+
+		result, err := webAuthn.VerifyAssertion("loginChallenge", credential.PublicKey, clientDataJSON, authData, signature)
+		assert.Nil(t, err)
+		assert.False(t, result.UserVerified)
+	})
+
+	t.Run("Signed by another key", func(t *testing.T) {
+		otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		other := newSoftAuthenticator(t, "example.com", "https://app.example.com", otherKey)
+		clientDataJSON, authData, signature := other.get(t, "loginChallenge")
+
+		result, err := webAuthn.VerifyAssertion("loginChallenge", credential.PublicKey, clientDataJSON, authData, signature)
+		assert.EqualError(t, err, "invalid signature")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Tampered sign count", func(t *testing.T) {
+		clientDataJSON, authData, signature := authenticator.get(t, "loginChallenge")
+		authData[36]++
+
+		result, err := webAuthn.VerifyAssertion("loginChallenge", credential.PublicKey, clientDataJSON, authData, signature)
+		assert.EqualError(t, err, "invalid signature")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Truncated authenticator data", func(t *testing.T) {
+		clientDataJSON, authData, signature := authenticator.get(t, "loginChallenge")
+
+		result, err := webAuthn.VerifyAssertion("loginChallenge", credential.PublicKey, clientDataJSON, authData[:36], signature)
+		assert.EqualError(t, err, "invalid authenticator data")
+		assert.Nil(t, result)
+	})
+
+	t.Run("Unsupported key", func(t *testing.T) {
+		key, _ := cbor.Marshal(map[int]any{1: 2, 3: -35, -1: 2, -2: make([]byte, 48), -3: make([]byte, 48)})
+
+		_, err := parseCOSEKey(key)
+		assert.EqualError(t, err, "unsupported public key")
+	})
+
+	t.Run("Point not on curve", func(t *testing.T) {
+		key, _ := cbor.Marshal(map[int]any{1: 2, 3: COSEAlgES256, -1: 1, -2: make([]byte, 32), -3: make([]byte, 32)})
+
+		_, err := parseCOSEKey(key)
+		assert.EqualError(t, err, "unsupported public key")
+	})
+}
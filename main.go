@@ -1,14 +1,49 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"test/configs"
+	apiKeyData "test/features/apikeys/data"
+	apiKeyHandler "test/features/apikeys/handler"
+	apiKeyService "test/features/apikeys/service"
+	auditData "test/features/audit/data"
+	auditHandler "test/features/audit/handler"
+	auditService "test/features/audit/service"
+	avatarHandler "test/features/avatars/handler"
+	avatarService "test/features/avatars/service"
+	groupData "test/features/groups/data"
+	groupHandler "test/features/groups/handler"
+	groupService "test/features/groups/service"
+	identityData "test/features/identities/data"
+	identityHandler "test/features/identities/handler"
+	identityService "test/features/identities/service"
+	invitationData "test/features/invitations/data"
+	invitationHandler "test/features/invitations/handler"
+	invitationService "test/features/invitations/service"
+	oauthData "test/features/oauth/data"
+	oauthHandler "test/features/oauth/handler"
+	oauthService "test/features/oauth/service"
+	organizationData "test/features/organizations/data"
+	organizationHandler "test/features/organizations/handler"
+	organizationService "test/features/organizations/service"
+	passkeyData "test/features/passkeys/data"
+	passkeyHandler "test/features/passkeys/handler"
+	passkeyService "test/features/passkeys/service"
+	privacyData "test/features/privacy/data"
+	privacyHandler "test/features/privacy/handler"
+	privacyService "test/features/privacy/service"
+	scimHandler "test/features/scim/handler"
+	scimService "test/features/scim/service"
+	"test/features/users"
 	"test/features/users/data"
 	"test/features/users/handler"
 	"test/features/users/service"
 	"test/helper"
 	"test/routes"
 	"test/utils/database"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -23,21 +58,168 @@ func main() {
 
 	userModel := data.New(db)
 	generator := helper.NewGenerator()
-	jwtInterface := helper.New(config.Secret, config.RefreshSecret)
-	userServices := service.New(userModel, generator, jwtInterface)
+	clock := helper.NewClock()
+	auditModel := auditData.New(db)
+	auditServices := auditService.New(auditModel, generator, clock)
+	groupModel := groupData.New(db)
+	groupServices := groupService.New(groupModel, userModel, auditServices, generator, clock)
+	groupControll := groupHandler.NewHandler(groupServices)
+	jwtInterface := helper.New(config.Secret, config.RefreshSecret, config.TokenIssuer, config.TokenAudience, groupServices)
+	notifier := helper.NewLogNotifier()
+	if config.Notifier == "file" {
+		notifier = helper.NewFileNotifier(config.NotifierFile)
+	}
+	passwordPolicy := helper.NewPasswordPolicy(config.PasswordMinLength, config.PasswordMinClasses, config.PasswordHistory, nil)
+	if config.BreachedPasswordFile != "" {
+		breachedList, err := helper.NewLocalBreachedList(config.BreachedPasswordFile)
+		if err != nil {
+			e.Logger.Fatal("cannot load breached password list, ", err.Error())
+		}
+		passwordPolicy.Breached = breachedList
+	}
+	var geoLocator helper.GeoLocatorInterface
+	if config.GeoIPDatabase != "" {
+		locator, err := helper.NewGeoIPLocator(config.GeoIPDatabase)
+		if err != nil {
+			e.Logger.Fatal("cannot open geoip database, ", err.Error())
+		}
+		geoLocator = locator
+	}
+	var authenticators = []users.AuthenticatorInterface{service.NewPasswordAuthenticator(userModel)}
+	if config.LDAPConfigFile != "" {
+		ldapConfig, err := helper.LoadLDAPConfig(config.LDAPConfigFile)
+		if err != nil {
+			e.Logger.Fatal("cannot load ldap config, ", err.Error())
+		}
+		authenticators = append(authenticators, service.NewDirectoryAuthenticator(helper.NewLDAPDirectory(*ldapConfig)))
+	}
+	var avatarStorage helper.StorageInterface = helper.NewLocalStorage(config.AvatarDir, config.AvatarBaseURL)
+	if config.AvatarStorage == "s3" {
+		avatarStorage = helper.NewS3Storage(helper.S3Config{Endpoint: config.S3Endpoint, Region: config.S3Region, Bucket: config.S3Bucket, AccessKey: config.S3AccessKey, SecretKey: config.S3SecretKey, PublicURL: config.S3PublicURL}, nil)
+	}
+	apiKeyModel := apiKeyData.New(db)
+	oauthModel := oauthData.New(db)
+	identityModel := identityData.New(db)
+	passkeyModel := passkeyData.New(db)
+	// The features keeping tokens or credentials of a user in their own
+	// tables clean them up when the users service revokes or erases them.
+	var revocationHooks = []users.RevocationHookInterface{oauthModel}
+	var erasureHooks = []users.ErasureHookInterface{apiKeyModel, oauthModel, identityModel, passkeyModel}
+	userServices := service.New(userModel, generator, jwtInterface, clock, notifier, auditServices, passwordPolicy, geoLocator, avatarStorage, authenticators, revocationHooks, erasureHooks, *config)
 
-	userControll := handler.NewHandler(userServices)
+	userControll := handler.NewHandler(userServices, avatarStorage)
+	avatarControll := avatarHandler.NewHandler(avatarService.New(userModel, avatarStorage, auditServices, generator, *config))
 
+	auditControll := auditHandler.NewHandler(auditServices)
+
+	apiKeyServices := apiKeyService.New(apiKeyModel, userModel, auditServices, generator, clock)
+	apiKeyControll := apiKeyHandler.NewHandler(apiKeyServices)
+
+	if config.OIDCSigningKeyFile == "" {
+		e.Logger.Warn("no oidc signing key configured, id tokens will not survive a restart")
+	}
+	idTokenSigner, err := helper.NewIDTokenSigner(config.OIDCSigningKeyFile)
+	if err != nil {
+		e.Logger.Fatal("cannot load oidc signing key, ", err.Error())
+	}
+	oauthServices := oauthService.New(oauthModel, userModel, jwtInterface, idTokenSigner, auditServices, generator, clock, avatarStorage, *config)
+	oauthControll := oauthHandler.NewHandler(oauthServices)
+
+	var externalProviders = map[string]helper.OIDCProviderInterface{}
+	if config.ExternalProvidersFile != "" {
+		externalProviders, err = helper.LoadExternalProviders(config.ExternalProvidersFile)
+		if err != nil {
+			e.Logger.Fatal("cannot load external providers, ", err.Error())
+		}
+	}
+	identityServices := identityService.New(identityModel, userModel, userServices, externalProviders, auditServices, generator, clock)
+	identityControll := identityHandler.NewHandler(identityServices)
+	if config.WebAuthnRPID == "" || len(config.WebAuthnOrigins) == 0 {
+		e.Logger.Warn("no webauthn relying party configured, passkeys cannot be used")
+	}
+	webAuthn := helper.NewWebAuthn(helper.WebAuthnConfig{RPID: config.WebAuthnRPID, RPName: config.WebAuthnRPName, Origins: config.WebAuthnOrigins})
+	passkeyServices := passkeyService.New(passkeyModel, userModel, userServices, webAuthn, auditServices, generator, clock)
+	passkeyControll := passkeyHandler.NewHandler(passkeyServices)
+	privacyModel := privacyData.New(db)
+	privacyServices := privacyService.New(privacyModel, userModel, groupModel, identityModel, passkeyModel, apiKeyModel, oauthModel, auditServices, generator, clock, avatarStorage)
+	privacyControll := privacyHandler.NewHandler(privacyServices)
+	scimControll := scimHandler.NewHandler(scimService.New(userServices))
+	organizationModel := organizationData.New(db)
+	organizationServices := organizationService.New(organizationModel, userModel, jwtInterface, auditServices, generator, clock)
+	organizationControll := organizationHandler.NewHandler(organizationServices)
+	invitationModel := invitationData.New(db)
+	invitationServices := invitationService.New(invitationModel, userModel, userServices, jwtInterface, notifier, auditServices, generator, clock)
+	invitationControll := invitationHandler.NewHandler(invitationServices)
+
+	go helper.RunPeriodically(context.Background(), time.Hour, func() {
+		purged, err := userServices.PurgeDeletedAccounts()
+		if err != nil {
+			e.Logger.Error("purge deleted accounts error: ", err.Error())
+		}
+		if purged > 0 {
+			e.Logger.Info("purged deleted accounts: ", purged)
+		}
+	})
+
+	go helper.RunPeriodically(context.Background(), time.Second*30, func() {
+		processed, err := privacyServices.ProcessJobs()
+		if err != nil {
+			e.Logger.Error("process privacy jobs error: ", err.Error())
+		}
+		if processed > 0 {
+			e.Logger.Info("processed privacy jobs: ", processed)
+		}
+	})
+
+
+	ipExtractor, err := helper.IPExtractor(config.TrustedProxies)
+	if err != nil {
+		e.Logger.Fatal("cannot parse trusted proxies, ", err.Error())
+	}
+	e.IPExtractor = ipExtractor
 
 	e.Pre(middleware.RemoveTrailingSlash())
 
-	e.Use(middleware.CORS())
+	if len(config.CORSOrigins) > 0 {
+		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{AllowOrigins: config.CORSOrigins, AllowCredentials: true, ExposeHeaders: []string{helper.ImpersonatedByHeader}}))
+	} else {
+		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{ExposeHeaders: []string{helper.ImpersonatedByHeader}}))
+	}
+	e.Use(middleware.RequestID())
+	e.Use(helper.RequestMetaMiddleware())
+	e.Use(helper.SessionCookieMiddleware(helper.SessionCookieConfig{Enabled: config.SessionCookies, Domain: config.SessionCookieDomain, SameSite: sameSiteMode(config.SessionCookieSameSite)}))
+	e.Use(helper.TenantMiddleware(organizationServices, config.TenantDomain))
 	e.Use(middleware.LoggerWithConfig(
 		middleware.LoggerConfig{
 			Format: "method=${method}, uri=${uri}, status=${status}, time=${time_rfc3339}\n",
 		}))
 
-	routes.RouteUser(e, userControll, *config)
+	sessions := helper.SessionValidators{userServices, oauthServices}
+	auth := helper.AuthMiddleware(jwtInterface, sessions)
+	scopedAuth := helper.ScopedAuthMiddleware(jwtInterface, sessions, apiKeyServices)
+	routes.RouteUser(e, userControll, auth, scopedAuth, groupServices, *config)
+	routes.RoutePrivacy(e, privacyControll, auth, scopedAuth)
+	routes.RouteAudit(e, auditControll, scopedAuth, groupServices)
+	routes.RouteAPIKey(e, apiKeyControll, auth)
+	routes.RouteOAuth(e, oauthControll, auth, scopedAuth)
+	routes.RouteIdentity(e, identityControll, auth)
+	routes.RoutePasskey(e, passkeyControll, auth)
+	routes.RouteOrganization(e, organizationControll, auth)
+	routes.RouteInvitation(e, invitationControll, auth, groupServices)
+	routes.RouteGroup(e, groupControll, auth, groupServices)
+	routes.RouteAvatar(e, avatarControll, auth, *config)
+	routes.RouteScim(e, scimControll, helper.ProvisioningAuthMiddleware(config.SCIMToken))
 
 	e.Logger.Fatal(e.Start(fmt.Sprintf(":%d", config.ServerPort)).Error())
 }
+
+func sameSiteMode(value string) http.SameSite {
+	switch value {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	}
+
+	return http.SameSiteStrictMode
+}
@@ -1,16 +1,196 @@
 package routes
 
 import (
+	"path/filepath"
+	"strconv"
 	"test/configs"
+	"test/features/apikeys"
+	"test/features/audit"
+	"test/features/avatars"
+	"test/features/groups"
+	"test/features/identities"
+	"test/features/invitations"
+	"test/features/oauth"
+	"test/features/organizations"
+	"test/features/passkeys"
+	"test/features/privacy"
+	"test/features/scim"
 	"test/features/users"
+	"test/helper"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 )
 
-func RouteUser(e *echo.Echo, uc users.UserHandlerInterface, cfg configs.ProgramConfig) {
+// noImpersonation closes the routes that change how an account is secured or
+// give its data away to admins impersonating the user.
+var noImpersonation = helper.DenyImpersonation()
+
+// Routes are only reachable with an API key or an OAuth access token when they
+// are registered with scopedAuth and name the scope they need. Management
+// routes ask permissions for the permission they need, admins hold all of
+// them.
+func RouteUser(e *echo.Echo, uc users.UserHandlerInterface, auth echo.MiddlewareFunc, scopedAuth echo.MiddlewareFunc, permissions helper.PermissionResolverInterface, cfg configs.ProgramConfig) {
+	var admin = helper.RequireRole(users.RoleAdmin)
+	var manage = helper.RequirePermission(permissions, helper.PermissionUsersManage)
+	var codeLimit = helper.RateLimitMiddleware(10)
+
 	e.POST("/users", uc.Register())
+	e.POST("/users/verify", uc.VerifyPhone(), codeLimit)
+	e.POST("/users/verify/resend", uc.ResendVerification(), codeLimit)
 	e.POST("/login", uc.Login())
-	// e.GET("/users", uc.MyProfile(), echojwt.JWT([]byte(cfg.Secret)))
+	e.POST("/login/code", uc.RequestLoginCode(), codeLimit)
+	e.POST("/login/code/verify", uc.LoginWithCode(), codeLimit)
+	e.POST("/login/mfa", uc.VerifyMFA(), codeLimit)
+	e.POST("/login/verify", uc.VerifyLogin())
+	e.POST("/login/refresh", uc.RefreshSession())
+	e.POST("/logout", uc.Logout(), auth)
+	e.POST("/password/forgot", uc.ForgotPassword())
+	e.POST("/password/reset", uc.ResetPassword())
+	e.GET("/users/me", uc.MyProfile(), auth)
+	e.DELETE("/users/me", uc.DeleteAccount(), auth, noImpersonation)
+	e.PUT("/users/me/password", uc.ChangePassword(), auth, noImpersonation)
+	e.GET("/users/me/sessions", uc.ListSessions(), auth)
+	e.DELETE("/users/me/sessions/:id", uc.RevokeSession(), auth, noImpersonation)
+	e.POST("/users/me/mfa/totp", uc.EnrollTOTP(), auth, noImpersonation)
+	e.POST("/users/me/mfa/totp/confirm", uc.ConfirmTOTP(), auth, noImpersonation)
+	e.DELETE("/users/me/mfa/totp", uc.DisableTOTP(), auth, noImpersonation)
+	e.POST("/users/me/mfa/recovery-codes", uc.RegenerateRecoveryCodes(), auth, noImpersonation)
+	e.POST("/users/:id/deactivate", uc.Deactivate(), scopedAuth, manage, helper.RequireScope(helper.ScopeUsersManage))
+	e.POST("/users/:id/reactivate", uc.Reactivate(), scopedAuth, manage, helper.RequireScope(helper.ScopeUsersManage))
+	e.POST("/users/:id/impersonate", uc.Impersonate(), auth, admin, noImpersonation)
+	e.POST("/impersonation/stop", uc.StopImpersonation(), auth)
 	// // e.GET("/users/:id",)
 	// e.POST("/refresh", uc.RefreshToken(), echojwt.JWT([]byte(cfg.RefreshSecret)))
 }
+
+func RoutePrivacy(e *echo.Echo, pc privacy.PrivacyHandlerInterface, auth echo.MiddlewareFunc, scopedAuth echo.MiddlewareFunc) {
+	var scope = helper.RequireScope(helper.ScopePrivacyExport)
+
+	e.POST("/users/me/export", pc.RequestExport(), scopedAuth, scope, noImpersonation)
+	e.GET("/users/me/exports/:id", pc.DownloadExport(), scopedAuth, scope, noImpersonation)
+	e.POST("/users/me/erasure", pc.RequestErasure(), auth, noImpersonation)
+	e.GET("/privacy-jobs/:id", pc.GetJob())
+}
+
+func RouteAudit(e *echo.Echo, ac audit.AuditHandlerInterface, scopedAuth echo.MiddlewareFunc, permissions helper.PermissionResolverInterface) {
+	var read = helper.RequirePermission(permissions, helper.PermissionAuditRead)
+	var scope = helper.RequireScope(helper.ScopeAuditRead)
+
+	e.GET("/audit-events", ac.List(), scopedAuth, read, scope)
+	e.GET("/audit-events/verify", ac.Verify(), scopedAuth, read, scope)
+}
+
+func RouteAPIKey(e *echo.Echo, kc apikeys.APIKeyHandlerInterface, auth echo.MiddlewareFunc) {
+	e.POST("/users/me/api-keys", kc.Create(), auth, noImpersonation)
+	e.GET("/users/me/api-keys", kc.List(), auth)
+	e.DELETE("/users/me/api-keys/:id", kc.Revoke(), auth, noImpersonation)
+}
+
+func RouteOAuth(e *echo.Echo, oc oauth.OAuthHandlerInterface, auth echo.MiddlewareFunc, scopedAuth echo.MiddlewareFunc) {
+	var openid = helper.RequireScope(helper.ScopeOpenID)
+
+	e.POST("/oauth/clients", oc.RegisterClient(), auth, noImpersonation)
+	e.GET("/oauth/clients", oc.ListClients(), auth)
+	e.DELETE("/oauth/clients/:id", oc.DeleteClient(), auth, noImpersonation)
+	e.GET("/oauth/authorize", oc.Authorize(), auth, noImpersonation)
+	e.POST("/oauth/authorize", oc.Consent(), auth, noImpersonation)
+	e.POST("/oauth/token", oc.Token())
+	e.POST("/oauth/introspect", oc.Introspect())
+	e.POST("/oauth/revoke", oc.Revoke())
+	e.GET("/userinfo", oc.UserInfo(), scopedAuth, openid)
+	e.POST("/userinfo", oc.UserInfo(), scopedAuth, openid)
+	e.GET("/.well-known/openid-configuration", oc.Discovery())
+	e.GET("/.well-known/jwks.json", oc.JWKS())
+}
+
+func RouteIdentity(e *echo.Echo, ic identities.IdentityHandlerInterface, auth echo.MiddlewareFunc) {
+	e.GET("/auth/external", ic.Providers())
+	e.GET("/auth/external/:provider", ic.Start())
+	e.POST("/auth/external/:provider/callback", ic.Callback())
+	e.GET("/users/me/identities", ic.List(), auth)
+	e.POST("/users/me/identities", ic.Link(), auth, noImpersonation)
+	e.DELETE("/users/me/identities/:id", ic.Unlink(), auth, noImpersonation)
+}
+
+func RoutePasskey(e *echo.Echo, pc passkeys.PasskeyHandlerInterface, auth echo.MiddlewareFunc) {
+	var loginLimit = helper.RateLimitMiddleware(10)
+
+	e.POST("/login/passkey", pc.BeginLogin(), loginLimit)
+	e.POST("/login/passkey/verify", pc.FinishLogin(), loginLimit)
+	e.GET("/users/me/passkeys", pc.List(), auth)
+	e.POST("/users/me/passkeys/options", pc.BeginRegistration(), auth, noImpersonation)
+	e.POST("/users/me/passkeys", pc.FinishRegistration(), auth, noImpersonation)
+	e.DELETE("/users/me/passkeys/:id", pc.Delete(), auth, noImpersonation)
+}
+
+// Organization routes check the role of the member in the service, only
+// creating and deleting organizations is left to admins of the whole service.
+func RouteOrganization(e *echo.Echo, oc organizations.OrganizationHandlerInterface, auth echo.MiddlewareFunc) {
+	var admin = helper.RequireRole(users.RoleAdmin)
+
+	e.POST("/organizations", oc.Create(), auth, admin, noImpersonation)
+	e.GET("/organizations", oc.List(), auth, admin)
+	e.GET("/organizations/:id", oc.Get(), auth)
+	e.PUT("/organizations/:id", oc.Update(), auth, noImpersonation)
+	e.DELETE("/organizations/:id", oc.Delete(), auth, admin, noImpersonation)
+	e.GET("/organizations/:id/members", oc.ListMembers(), auth)
+	e.POST("/organizations/:id/members", oc.AddMember(), auth, noImpersonation)
+	e.PUT("/organizations/:id/members/:user_id", oc.UpdateMember(), auth, noImpersonation)
+	e.DELETE("/organizations/:id/members/:user_id", oc.RemoveMember(), auth, noImpersonation)
+	e.POST("/organizations/:id/switch", oc.Switch(), auth)
+	e.GET("/users/me/organizations", oc.ListMine(), auth)
+}
+
+// Invitations are accepted without a token, the invitation token is the
+// proof. They stay open while self-registration is closed.
+func RouteInvitation(e *echo.Echo, ic invitations.InvitationHandlerInterface, auth echo.MiddlewareFunc, permissions helper.PermissionResolverInterface) {
+	var manage = helper.RequirePermission(permissions, helper.PermissionInvitationsManage)
+
+	e.POST("/invitations", ic.Create(), auth, manage, noImpersonation)
+	e.GET("/invitations", ic.List(), auth, manage)
+	e.DELETE("/invitations/:id", ic.Revoke(), auth, manage, noImpersonation)
+	e.POST("/invitations/accept", ic.Accept(), helper.RateLimitMiddleware(10))
+}
+
+func RouteGroup(e *echo.Echo, gc groups.GroupHandlerInterface, auth echo.MiddlewareFunc, permissions helper.PermissionResolverInterface) {
+	var manage = helper.RequirePermission(permissions, helper.PermissionGroupsManage)
+
+	e.POST("/groups", gc.Create(), auth, manage, noImpersonation)
+	e.GET("/groups", gc.List(), auth, manage)
+	e.GET("/groups/:id", gc.Get(), auth, manage)
+	e.PUT("/groups/:id", gc.Update(), auth, manage, noImpersonation)
+	e.DELETE("/groups/:id", gc.Delete(), auth, manage, noImpersonation)
+	e.GET("/groups/:id/members", gc.ListMembers(), auth, manage)
+	e.POST("/groups/:id/members", gc.AddMember(), auth, manage, noImpersonation)
+	e.DELETE("/groups/:id/members/:user_id", gc.RemoveMember(), auth, manage, noImpersonation)
+	e.GET("/groups/:id/groups", gc.ListSubgroups(), auth, manage)
+	e.POST("/groups/:id/groups", gc.AddSubgroup(), auth, manage, noImpersonation)
+	e.DELETE("/groups/:id/groups/:child_id", gc.RemoveSubgroup(), auth, manage, noImpersonation)
+	e.GET("/users/me/groups", gc.ListMine(), auth)
+}
+
+// RouteAvatar refuses bodies well past AvatarMaxBytes before they are read,
+// the service checks the exact size of the image. Only authenticated callers
+// get that far. Locally stored avatars are served under /media.
+func RouteAvatar(e *echo.Echo, ac avatars.AvatarHandlerInterface, auth echo.MiddlewareFunc, cfg configs.ProgramConfig) {
+	var bodyLimit = middleware.BodyLimit(strconv.Itoa(cfg.AvatarMaxBytes + 64<<10))
+
+	e.PUT("/users/me/avatar", ac.Upload(), auth, bodyLimit)
+	e.DELETE("/users/me/avatar", ac.Delete(), auth)
+	if cfg.AvatarStorage == "local" {
+		e.Static("/media/avatars", filepath.Join(cfg.AvatarDir, "avatars"))
+	}
+}
+
+func RouteScim(e *echo.Echo, sc scim.ScimHandlerInterface, provisioning echo.MiddlewareFunc) {
+	var group = e.Group("/scim/v2", provisioning)
+
+	group.GET("/ServiceProviderConfig", sc.ServiceProviderConfig())
+	group.GET("/Users", sc.List())
+	group.POST("/Users", sc.Create())
+	group.GET("/Users/:id", sc.Get())
+	group.PUT("/Users/:id", sc.Replace())
+	group.PATCH("/Users/:id", sc.Patch())
+	group.DELETE("/Users/:id", sc.Delete())
+}
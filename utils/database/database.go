@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"test/configs"
+	"test/helper"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -21,5 +22,9 @@ func InitDB(c configs.ProgramConfig) *gorm.DB {
 		log.Fatal("cannot connect database, ", err.Error())
 	}
 
+	if err := db.Use(helper.TenantPlugin{}); err != nil {
+		log.Fatal("cannot register tenant plugin, ", err.Error())
+	}
+
 	return db
 }
\ No newline at end of file
@@ -1,11 +1,37 @@
 package database
 
 import (
+	apiKeyData "test/features/apikeys/data"
+	auditData "test/features/audit/data"
+	groupData "test/features/groups/data"
+	identityData "test/features/identities/data"
+	invitationData "test/features/invitations/data"
+	oauthData "test/features/oauth/data"
+	organizationData "test/features/organizations/data"
+	passkeyData "test/features/passkeys/data"
+	privacyData "test/features/privacy/data"
 	"test/features/users/data"
 
 	"gorm.io/gorm"
 )
 
 func Migrate(db *gorm.DB) {
-	db.AutoMigrate(data.User{})
-}
\ No newline at end of file
+	// Accounts created before phone verification existed are trusted as
+	// verified, only new registrations have to confirm their number.
+	var backfillVerified = db.Migrator().HasTable(&data.User{}) && !db.Migrator().HasColumn(&data.User{}, "HPVerifiedAt")
+
+	db.AutoMigrate(data.User{}, data.RecoveryCode{}, data.OneTimeCode{}, data.PasswordHistory{}, data.Session{})
+	db.AutoMigrate(auditData.AuditEvent{})
+	db.AutoMigrate(privacyData.PrivacyJob{})
+	db.AutoMigrate(apiKeyData.APIKey{})
+	db.AutoMigrate(oauthData.OAuthClient{}, oauthData.OAuthConsent{}, oauthData.OAuthCode{}, oauthData.OAuthGrant{})
+	db.AutoMigrate(identityData.ExternalIdentity{}, identityData.ExternalLoginState{})
+	db.AutoMigrate(passkeyData.Passkey{}, passkeyData.PasskeyCeremony{})
+	db.AutoMigrate(organizationData.Organization{}, organizationData.Membership{})
+	db.AutoMigrate(invitationData.Invitation{})
+	db.AutoMigrate(groupData.Group{}, groupData.GroupMember{}, groupData.GroupNesting{})
+
+	if backfillVerified {
+		db.Model(&data.User{}).Where("hp_verified_at IS NULL").Update("hp_verified_at", gorm.Expr("CURRENT_TIMESTAMP"))
+	}
+}